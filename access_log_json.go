@@ -0,0 +1,196 @@
+package timeline
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// traefikFieldNames maps Traefik access log fields to the column names of the CLF parser
+var traefikFieldNames = map[string]string{
+	"ClientHost":              "remote_host",
+	"ClientUsername":          "remote_user",
+	"RequestMethod":           "method",
+	"RequestProtocol":         "protocol",
+	"RequestHost":             "host",
+	"RequestScheme":           "scheme",
+	"RequestContentSize":      "request_size",
+	"DownstreamStatus":        "status",
+	"DownstreamContentSize":   "response_size",
+	"OriginStatus":            "origin_status",
+	"RetryAttempts":           "retry_attempts",
+	"RouterName":              "router",
+	"ServiceName":             "service",
+	"entryPointName":          "entry_point",
+	"TLSVersion":              "tls_version",
+	"request_User-Agent":      "user_agent",
+	"request_Referer":         "referer",
+	"request_X-Forwarded-For": "forwarded_for",
+	"level":                   "level",
+	"msg":                     "message",
+	"time":                    "timestamp",
+}
+
+// normalizeJSONAccessLog recognizes Caddy and Traefik JSON access logs and maps their fields
+// to the column names used by the CLF parser (method, path, status, response_size, ...).
+// Returns nil when the row is not a known JSON access log.
+func normalizeJSONAccessLog(data Row) (Row, Format) {
+	if request, ok := data["request"].(map[string]any); ok && request["method"] != nil && request["uri"] != nil {
+		return normalizeCaddyAccessLog(data, request), FormatCaddy
+	}
+	if data["RequestMethod"] != nil && data["DownstreamStatus"] != nil {
+		return normalizeTraefikAccessLog(data), FormatTraefik
+	}
+	return nil, ""
+}
+
+// normalizeCaddyAccessLog maps a Caddy access log line.
+// Example: {"level":"info","ts":1646861401.52,"logger":"http.log.access","msg":"handled request","request":{"remote_ip":"127.0.0.1","proto":"HTTP/2.0","method":"GET","host":"localhost","uri":"/?q=1","headers":{"User-Agent":["curl/7.82.0"]}},"bytes_read":0,"duration":0.0009,"size":10900,"status":200}
+// Fields: timestamp, level, logger, message, method, path, query, protocol, host, remote_host, remote_port,
+// client_ip, user_agent, referer, forwarded_for, status, response_size, request_size, duration_ms, user_id
+func normalizeCaddyAccessLog(data Row, request map[string]any) Row {
+	result := make(Row)
+	for k, v := range data {
+		switch k {
+		case "ts":
+			if seconds, ok := toFloat64(v); ok {
+				result["timestamp"] = unixFloatToTime(seconds)
+			}
+		case "msg":
+			result["message"] = v
+		case "size":
+			result["response_size"] = v
+		case "bytes_read":
+			result["request_size"] = v
+		case "duration":
+			// Caddy logs the duration in seconds
+			if seconds, ok := toFloat64(v); ok {
+				result["duration_ms"] = seconds * 1000
+			}
+		case "user_id":
+			if v != "" {
+				result[k] = v
+			}
+		case "request", "resp_headers":
+			// Handled below or not stored: header names are not valid column names
+		default:
+			result[k] = v
+		}
+	}
+
+	result["method"] = request["method"]
+	setPathAndQuery(result, request["uri"])
+	setIfPresent(result, "protocol", request["proto"])
+	setIfPresent(result, "host", request["host"])
+	setIfPresent(result, "remote_host", request["remote_ip"])
+	setIfPresent(result, "remote_port", request["remote_port"])
+	setIfPresent(result, "client_ip", request["client_ip"])
+
+	if headers, ok := request["headers"].(map[string]any); ok {
+		setIfPresent(result, "user_agent", firstHeaderValue(headers, "User-Agent"))
+		setIfPresent(result, "referer", firstHeaderValue(headers, "Referer"))
+		setIfPresent(result, "forwarded_for", firstHeaderValue(headers, "X-Forwarded-For"))
+	}
+
+	return result
+}
+
+// normalizeTraefikAccessLog maps a Traefik JSON access log line.
+// Example: {"ClientHost":"10.0.0.1","DownstreamContentSize":1234,"DownstreamStatus":200,"Duration":1234567,"RequestHost":"example.com","RequestMethod":"GET","RequestPath":"/api?q=1","RequestProtocol":"HTTP/1.1","RouterName":"api@docker","level":"info","msg":"","time":"2025-09-21T10:00:00Z"}
+// Fields: timestamp, level, message, method, path, query, protocol, host, scheme, remote_host, remote_user,
+// status, origin_status, response_size, request_size, duration_ms, origin_duration_ms, router, service,
+// entry_point, retry_attempts, tls_version, user_agent, referer, forwarded_for, and the other fields
+// as is (e.g. ClientAddr, StartUTC), without the headers
+func normalizeTraefikAccessLog(data Row) Row {
+	result := make(Row)
+	for k, v := range data {
+		switch k {
+		case "RequestPath":
+			setPathAndQuery(result, v)
+		case "Duration":
+			// Traefik logs durations in nanoseconds
+			if nanoseconds, ok := toFloat64(v); ok {
+				result["duration_ms"] = nanoseconds / 1e6
+			}
+		case "OriginDuration":
+			if nanoseconds, ok := toFloat64(v); ok {
+				result["origin_duration_ms"] = nanoseconds / 1e6
+			}
+		default:
+			if v == "" || v == "-" || isTraefikHeaderField(k) {
+				continue
+			}
+			if name, known := traefikFieldNames[k]; known {
+				result[name] = v
+			} else {
+				result[k] = v
+			}
+		}
+	}
+	return result
+}
+
+// isTraefikHeaderField reports whether the field is a header that isn't mapped to a column, e.g.
+// request_Accept or downstream_Content-Type; header names are not valid column names
+func isTraefikHeaderField(field string) bool {
+	if _, known := traefikFieldNames[field]; known {
+		return false
+	}
+	for _, prefix := range []string{"request_", "downstream_", "origin_"} {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setPathAndQuery splits a request uri into the path and query columns
+func setPathAndQuery(result Row, uri any) {
+	uriString, ok := uri.(string)
+	if !ok {
+		return
+	}
+	path, query, hasQuery := strings.Cut(uriString, "?")
+	result["path"] = path
+	if hasQuery && query != "" {
+		result["query"] = query
+	}
+}
+
+// firstHeaderValue returns the first value of a header; Caddy logs headers as lists of strings
+func firstHeaderValue(headers map[string]any, name string) any {
+	if values, ok := headers[name].([]any); ok && len(values) > 0 {
+		return values[0]
+	}
+	return headers[name]
+}
+
+// setIfPresent sets the column only for non-empty values
+func setIfPresent(result Row, column string, value any) {
+	if value == nil || value == "" {
+		return
+	}
+	result[column] = value
+}
+
+// toFloat64 converts the numeric values produced by the JSON parser
+func toFloat64(v any) (float64, bool) {
+	switch number := v.(type) {
+	case int:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
+
+// unixFloatToTime converts fractional unix seconds (e.g. 1646861401.5241024) to a UTC time
+func unixFloatToTime(seconds float64) time.Time {
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*1e9)).UTC()
+}
+
+// unixMillisToTime converts unix milliseconds to a UTC time
+func unixMillisToTime(millis int64) time.Time {
+	return time.UnixMilli(millis).UTC()
+}
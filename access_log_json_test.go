@@ -0,0 +1,75 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_caddy_access_log(t *testing.T) {
+	is := is.New(t)
+	line := `{"level":"info","ts":1646861401.5,"logger":"http.log.access.log0","msg":"handled request","request":{"remote_ip":"127.0.0.1","remote_port":"41342","proto":"HTTP/2.0","method":"GET","host":"localhost","uri":"/search?q=go","headers":{"User-Agent":["curl/7.82.0"],"Accept":["*/*"]}},"bytes_read":0,"user_id":"","duration":0.25,"size":10900,"status":200,"resp_headers":{"Server":["Caddy"]}}`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["timestamp"], time.Date(2022, 3, 9, 21, 30, 1, 500000000, time.UTC))
+	is.Equal(data["level"], "info")
+	is.Equal(data["message"], "handled request")
+	is.Equal(data["method"], "GET")
+	is.Equal(data["path"], "/search")
+	is.Equal(data["query"], "q=go")
+	is.Equal(data["protocol"], "HTTP/2.0")
+	is.Equal(data["host"], "localhost")
+	is.Equal(data["remote_host"], "127.0.0.1")
+	is.Equal(data["user_agent"], "curl/7.82.0")
+	is.Equal(data["status"], 200)
+	is.Equal(data["response_size"], 10900)
+	is.Equal(data["request_size"], 0)
+	is.Equal(data["duration_ms"], 250.0)
+	// Nested objects with header names are not stored
+	_, hasRequest := data["request"]
+	is.True(!hasRequest)
+	_, hasRespHeaders := data["resp_headers"]
+	is.True(!hasRespHeaders)
+	_, hasUserID := data["user_id"]
+	is.True(!hasUserID)
+}
+
+func Test_parse_traefik_access_log(t *testing.T) {
+	is := is.New(t)
+	line := `{"ClientAddr":"10.0.0.1:54321","ClientHost":"10.0.0.1","ClientUsername":"-","DownstreamContentSize":1234,"DownstreamStatus":404,"Duration":1500000,"OriginDuration":1000000,"OriginStatus":404,"RequestHost":"example.com","RequestMethod":"POST","RequestPath":"/api/users","RequestProtocol":"HTTP/1.1","RouterName":"api@docker","ServiceName":"api@docker","entryPointName":"web","level":"info","msg":"","request_User-Agent":"curl/8.0","downstream_Content-Type":"application/json","StartUTC":"2025-09-21T10:00:00.0015Z","time":"2025-09-21T10:00:00Z"}`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["timestamp"], "2025-09-21T10:00:00Z")
+	is.Equal(data["method"], "POST")
+	is.Equal(data["path"], "/api/users")
+	is.Equal(data["protocol"], "HTTP/1.1")
+	is.Equal(data["host"], "example.com")
+	is.Equal(data["remote_host"], "10.0.0.1")
+	is.Equal(data["status"], 404)
+	is.Equal(data["origin_status"], 404)
+	is.Equal(data["response_size"], 1234)
+	is.Equal(data["duration_ms"], 1.5)
+	is.Equal(data["origin_duration_ms"], 1.0)
+	is.Equal(data["router"], "api@docker")
+	is.Equal(data["entry_point"], "web")
+	is.Equal(data["user_agent"], "curl/8.0")
+	_, hasUser := data["remote_user"]
+	is.True(!hasUser)
+	// The fields without a column name are kept, the headers are not
+	is.Equal(data["ClientAddr"], "10.0.0.1:54321")
+	is.Equal(data["StartUTC"], "2025-09-21T10:00:00.0015Z")
+	_, hasHeader := data["downstream_Content-Type"]
+	is.True(!hasHeader)
+}
+
+func Test_parse_json_with_request_field_that_is_not_an_access_log(t *testing.T) {
+	is := is.New(t)
+	line := `{"request":{"id":5},"message":"hi"}`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["request"], map[string]any{"id": 5})
+}
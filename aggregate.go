@@ -0,0 +1,150 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Metric is an aggregation of Aggregate, see Count, Sum, Avg, Min and Max
+type Metric struct {
+	function string
+	column   string
+	name     string
+}
+
+// Count counts the rows, the column of the result is count
+func Count() Metric {
+	return Metric{function: "count", name: "count"}
+}
+
+// Sum sums a column, the column of the result is sum_<column>
+func Sum(column string) Metric {
+	return Metric{function: "sum", column: column, name: "sum_" + column}
+}
+
+// Avg averages a column, the column of the result is avg_<column>
+func Avg(column string) Metric {
+	return Metric{function: "avg", column: column, name: "avg_" + column}
+}
+
+// Min returns the minimum of a column, the column of the result is min_<column>
+func Min(column string) Metric {
+	return Metric{function: "min", column: column, name: "min_" + column}
+}
+
+// Max returns the maximum of a column, the column of the result is max_<column>
+func Max(column string) Metric {
+	return Metric{function: "max", column: column, name: "max_" + column}
+}
+
+// As renames the column of the result
+func (m Metric) As(name string) Metric {
+	m.name = name
+	return m
+}
+
+// expression returns the SQL of the metric
+func (m Metric) expression() string {
+	argument := "*"
+	if m.column != "" {
+		argument = quoteIdentifier(m.column)
+	}
+	return fmt.Sprintf("%s(%s) AS %s", m.function, argument, quoteIdentifier(m.name))
+}
+
+// Aggregate calculates the metrics per time bucket (e.g. 5 minutes) and per combination of values
+// of the groupBy columns, for the rows that match the filters. The rows of the result have a bucket
+// column with the start time of the bucket, the groupBy columns and a column per metric, ordered by
+// bucket. Buckets without rows are not returned.
+func (w *Writer) Aggregate(ctx context.Context, table string, bucket time.Duration, groupBy []string, metrics []Metric, filters ...Filter) ([]Row, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	if bucket <= 0 {
+		return nil, errors.New("the bucket must be positive")
+	}
+	if len(metrics) == 0 {
+		metrics = []Metric{Count()}
+	}
+
+	source, _, err := w.tableSource(table, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []string{"bucket"}
+	for _, column := range groupBy {
+		groups = append(groups, quoteIdentifier(column))
+	}
+	selected := append([]string{"time_bucket(to_microseconds(?::BIGINT), timestamp) AS bucket"}, groups[1:]...)
+	for _, metric := range metrics {
+		selected = append(selected, metric.expression())
+	}
+
+	args := []any{bucket.Microseconds()}
+	var query strings.Builder
+	fmt.Fprintf(&query, "SELECT %s FROM %s", strings.Join(selected, ", "), source)
+	if where, filterArgs := filtersSQL(filters); where != "" {
+		fmt.Fprintf(&query, " WHERE %s", where)
+		args = append(args, filterArgs...)
+	}
+	fmt.Fprintf(&query, " GROUP BY %s ORDER BY %s", strings.Join(groups, ", "), strings.Join(groups, ", "))
+
+	rows, err := w.DB.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate %s: %w", table, err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// maxBuckets is the maximum number of buckets of Buckets
+const maxBuckets = 100_000
+
+// Buckets calculates a metric per time bucket (e.g. 1 minute) for the rows from (inclusive) to
+// (exclusive) that match the filters, like Aggregate, but returns every bucket of the range, so
+// the series can be charted without gaps. The rows have a bucket column with the start time of
+// the bucket and a column with the metric, ordered by bucket. A Count or Sum of an empty bucket is
+// 0, the other metrics are missing (NULL).
+func (w *Writer) Buckets(ctx context.Context, table string, metric Metric, bucket time.Duration, from, to time.Time, filters ...Filter) ([]Row, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	if bucket <= 0 {
+		return nil, errors.New("the bucket must be positive")
+	}
+	if !from.Before(to) {
+		return nil, errors.New("from must be before to")
+	}
+	if to.Sub(from)/bucket > maxBuckets {
+		return nil, fmt.Errorf("more than %d buckets of %s from %s to %s", maxBuckets, bucket, from, to)
+	}
+	source, _, err := w.tableSource(table, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	value := quoteIdentifier(metric.name)
+	if metric.function == "count" || metric.function == "sum" {
+		value = fmt.Sprintf("coalesce(%s, 0) AS %s", value, value)
+	}
+	args := []any{bucket.Microseconds(), from.UTC(), to.UTC(), bucket.Microseconds(), bucket.Microseconds(), from.UTC(), to.UTC()}
+	var query strings.Builder
+	fmt.Fprintf(&query, "WITH series AS (SELECT unnest(generate_series(time_bucket(to_microseconds(?::BIGINT), ?::TIMESTAMP), ?::TIMESTAMP - INTERVAL 1 MICROSECOND, to_microseconds(?::BIGINT))) AS bucket), ")
+	fmt.Fprintf(&query, "metrics AS (SELECT time_bucket(to_microseconds(?::BIGINT), timestamp) AS bucket, %s FROM %s WHERE timestamp >= ? AND timestamp < ?", metric.expression(), source)
+	if where, filterArgs := filtersSQL(filters); where != "" {
+		fmt.Fprintf(&query, " AND %s", where)
+		args = append(args, filterArgs...)
+	}
+	fmt.Fprintf(&query, " GROUP BY bucket) SELECT bucket, %s FROM series LEFT JOIN metrics USING (bucket) ORDER BY bucket", value)
+
+	rows, err := w.DB.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the buckets of %s: %w", table, err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
@@ -0,0 +1,72 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_aggregate_per_time_bucket_and_group(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		level := "info"
+		if i%4 == 0 {
+			level = "error"
+		}
+		is.NoErr(writer.Write("requests", NewRow(start.Add(time.Duration(i)*time.Minute), Row{"level": level, "duration": i, "path": "/"})))
+	}
+
+	rows, err := writer.Aggregate(context.Background(), "requests", 5*time.Minute, []string{"level"},
+		[]Metric{Count(), Sum("duration"), Max("duration").As("slowest")}, Eq("path", "/"))
+
+	is.NoErr(err)
+	is.Equal(len(rows), 5)
+	is.Equal(rows[0], Row{"bucket": start, "level": "error", "count": 2, "sum_duration": 4, "slowest": 4})
+	is.Equal(rows[1], Row{"bucket": start, "level": "info", "count": 3, "sum_duration": 6, "slowest": 3})
+	is.Equal(rows[4]["bucket"], start.Add(10*time.Minute))
+	is.Equal(rows[4]["count"], 2)
+}
+
+func Test_buckets_fill_the_gaps_of_the_series(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	for _, minute := range []int{0, 1, 4} {
+		is.NoErr(writer.Write("requests", NewRow(start.Add(time.Duration(minute)*time.Minute+time.Second), Row{"duration": minute + 1})))
+	}
+
+	// When the buckets are requested for a range with empty minutes
+	rows, err := writer.Buckets(context.Background(), "requests", Count(), time.Minute, start, start.Add(6*time.Minute))
+	is.NoErr(err)
+
+	// Then every minute has a bucket, the empty minutes count 0
+	is.Equal(len(rows), 6)
+	counts := []int{}
+	for i, row := range rows {
+		is.Equal(row["bucket"], start.Add(time.Duration(i)*time.Minute))
+		counts = append(counts, row.Int("count"))
+	}
+	is.Equal(counts, []int{1, 1, 0, 0, 1, 0})
+
+	// And an average of an empty bucket is missing
+	rows, err = writer.Buckets(context.Background(), "requests", Avg("duration"), time.Minute, start, start.Add(3*time.Minute), Gt("duration", 1))
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"bucket": start}, {"bucket": start.Add(time.Minute), "avg_duration": 2.0}, {"bucket": start.Add(2 * time.Minute)}})
+}
+
+func Test_aggregate_of_a_partitioned_table(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("requests")
+	start := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("requests", NewRow(start, Row{"duration": 1})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(time.Hour), Row{"duration": 2})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(24*time.Hour), Row{"duration": 4})))
+
+	rows, err := writer.Aggregate(context.Background(), "requests", 24*time.Hour, nil, []Metric{Count(), Sum("duration")})
+
+	is.NoErr(err)
+	is.Equal(rows, []Row{
+		{"bucket": start, "count": 2, "sum_duration": 3},
+		{"bucket": start.Add(24 * time.Hour), "count": 1, "sum_duration": 4},
+	})
+}
@@ -0,0 +1,98 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TopN returns the n values of a column with the highest metric for the rows from (inclusive) to
+// (exclusive), e.g. the paths with the most requests or the highest average duration. The rows
+// have the column and the metric, the highest metric first. Rows without a value are skipped.
+func (w *Writer) TopN(ctx context.Context, table string, column string, metric Metric, n int, from, to time.Time) ([]Row, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	if n <= 0 {
+		return nil, nil
+	}
+	source, _, err := w.tableSource(table, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	quoted := quoteIdentifier(column)
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE timestamp >= ? AND timestamp < ? AND %s IS NOT NULL GROUP BY %s ORDER BY %s DESC NULLS LAST, %s LIMIT %d",
+		quoted, metric.expression(), source, quoted, quoted, quoteIdentifier(metric.name), quoted, n)
+	rows, err := w.DB.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the top %d of %s.%s: %w", n, table, column, err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// Session is a group of rows with the same key without idle gaps, see Sessions
+type Session struct {
+	Key    any
+	Start  time.Time
+	End    time.Time
+	Events int
+}
+
+// Duration is the time between the first and the last row of the session
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Sessions groups the rows that match all filters by the value of keyColumn (e.g. user_id) into
+// sessions: a new session starts when a row is more than gap after the previous row of its key.
+// The sessions are ordered by start. Rows without a key are skipped.
+func (w *Writer) Sessions(ctx context.Context, table string, keyColumn string, gap time.Duration, filters ...Filter) ([]Session, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	if gap <= 0 {
+		return nil, errors.New("the gap must be positive")
+	}
+	source, _, err := w.tableSource(table, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	key := quoteIdentifier(keyColumn)
+	where := key + " IS NOT NULL"
+	args := []any{gap.Microseconds()}
+	if condition, filterArgs := filtersSQL(filters); condition != "" {
+		where += " AND " + condition
+		args = append(args, filterArgs...)
+	}
+	query := fmt.Sprintf(`WITH starts AS (
+	SELECT %[1]s AS key, timestamp, CASE WHEN timestamp - lag(timestamp) OVER (PARTITION BY %[1]s ORDER BY timestamp) <= to_microseconds(?::BIGINT) THEN 0 ELSE 1 END AS new_session
+	FROM %[2]s WHERE %[3]s
+), numbered AS (
+	SELECT key, timestamp, sum(new_session) OVER (PARTITION BY key ORDER BY timestamp ROWS UNBOUNDED PRECEDING) AS session FROM starts
+)
+SELECT key, min(timestamp) AS start, max(timestamp) AS end, count(*) AS events FROM numbered GROUP BY key, session ORDER BY start, key`,
+		key, source, where)
+
+	rows, err := w.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the sessions of %s: %w", table, err)
+	}
+	defer rows.Close()
+	result, err := scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, len(result))
+	for i, row := range result {
+		start, _ := row["start"].(time.Time)
+		end, _ := row["end"].(time.Time)
+		events, _ := row["events"].(int)
+		sessions[i] = Session{Key: row["key"], Start: start, End: end, Events: events}
+	}
+	return sessions, nil
+}
@@ -0,0 +1,83 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_top_n(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	requests := []Row{
+		{"path": "/", "duration": 0.1},
+		{"path": "/login", "duration": 0.9},
+		{"path": "/", "duration": 0.3},
+		{"path": "/search", "duration": 2.0},
+		{"path": "/", "duration": 0.2},
+		{"path": "/login", "duration": 1.1},
+	}
+	for i, request := range requests {
+		is.NoErr(writer.Write("requests", NewRow(start.Add(time.Duration(i)*time.Second), request)))
+	}
+	is.NoErr(writer.Write("requests", NewRow(start.Add(time.Hour), Row{"path": "/later", "duration": 9.0})))
+
+	busiest, err := writer.TopN(context.Background(), "requests", "path", Count(), 2, start, start.Add(time.Hour))
+	is.NoErr(err)
+	is.Equal(busiest, []Row{{"path": "/", "count": 3}, {"path": "/login", "count": 2}})
+
+	slowest, err := writer.TopN(context.Background(), "requests", "path", Max("duration").As("slowest"), 1, start, start.Add(time.Hour))
+	is.NoErr(err)
+	is.Equal(slowest, []Row{{"path": "/search", "slowest": 2.0}})
+}
+
+func Test_sessions_are_separated_by_idle_gaps(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	events := []struct {
+		offset time.Duration
+		user   string
+	}{
+		{0, "ann"},
+		{time.Minute, "bob"},
+		{5 * time.Minute, "ann"},
+		{20 * time.Minute, "ann"},
+		{21 * time.Minute, "ann"},
+	}
+	for _, event := range events {
+		is.NoErr(writer.Write("clicks", NewRow(start.Add(event.offset), Row{"user": event.user})))
+	}
+	is.NoErr(writer.Write("clicks", NewRow(start, Row{"anonymous": true})))
+
+	sessions, err := writer.Sessions(context.Background(), "clicks", "user", 10*time.Minute)
+
+	is.NoErr(err)
+	is.Equal(sessions, []Session{
+		{Key: "ann", Start: start, End: start.Add(5 * time.Minute), Events: 2},
+		{Key: "bob", Start: start.Add(time.Minute), End: start.Add(time.Minute), Events: 1},
+		{Key: "ann", Start: start.Add(20 * time.Minute), End: start.Add(21 * time.Minute), Events: 2},
+	})
+	is.Equal(sessions[0].Duration(), 5*time.Minute)
+
+	sessions, err = writer.Sessions(context.Background(), "clicks", "user", 10*time.Minute, Eq("user", "bob"))
+	is.NoErr(err)
+	is.Equal(len(sessions), 1)
+}
+
+func Test_top_n_and_sessions_of_a_partitioned_table(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("requests")
+	start := time.Date(2024, 9, 1, 23, 50, 0, 0, time.UTC)
+	is.NoErr(writer.Write("requests", NewRow(start, Row{"path": "/", "user": "ann"})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(5*time.Minute), Row{"path": "/login", "user": "ann"})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(15*time.Minute), Row{"path": "/", "user": "ann"})))
+
+	busiest, err := writer.TopN(context.Background(), "requests", "path", Count(), 1, start, start.Add(time.Hour))
+	is.NoErr(err)
+	is.Equal(busiest, []Row{{"path": "/", "count": 2}})
+
+	// The session continues in the partition of the next day
+	sessions, err := writer.Sessions(context.Background(), "requests", "user", 10*time.Minute)
+	is.NoErr(err)
+	is.Equal(sessions, []Session{{Key: "ann", Start: start, End: start.Add(15 * time.Minute), Events: 3}})
+}
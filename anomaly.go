@@ -0,0 +1,82 @@
+package timeline
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// AnomalyConfig configures DetectAnomalies
+type AnomalyConfig struct {
+	// Columns are the checked numeric columns, all numeric columns without columns
+	Columns []string
+	// Alpha is the weight of a new value in the moving averages, defaults to 0.05. A larger alpha
+	// adapts faster to a new normal.
+	Alpha float64
+	// Threshold is the number of standard deviations from the moving average of an anomaly,
+	// defaults to 3
+	Threshold float64
+	// WarmUp is the number of values of a column before its anomalies are flagged, defaults to 30
+	WarmUp int
+}
+
+// columnStats are the exponentially weighted moving average and variance of a column
+type columnStats struct {
+	count    int
+	mean     float64
+	variance float64
+}
+
+// DetectAnomalies keeps an exponentially weighted moving average and standard deviation per
+// numeric column and flags the rows with a value that deviates more than the threshold: the
+// _anomaly column has the names of the deviating columns, e.g. duration_ms or
+// duration_ms,response_size, so spikes are found with WHERE _anomaly IS NOT NULL. The statistics
+// are kept in memory and start again when the process starts.
+func DetectAnomalies(config AnomalyConfig) Transformer {
+	if config.Alpha <= 0 || config.Alpha > 1 {
+		config.Alpha = 0.05
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = 3
+	}
+	if config.WarmUp <= 0 {
+		config.WarmUp = 30
+	}
+
+	var mu sync.Mutex
+	stats := map[string]*columnStats{}
+	return func(row Row) Row {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var anomalies []string
+		for column, value := range row {
+			number, ok := toFloat64(value)
+			if !ok || (len(config.Columns) > 0 && !slices.Contains(config.Columns, column)) {
+				continue
+			}
+			s := stats[column]
+			if s == nil {
+				s = &columnStats{mean: number}
+				stats[column] = s
+			}
+			if s.count >= config.WarmUp && math.Abs(number-s.mean) > config.Threshold*math.Sqrt(s.variance) {
+				anomalies = append(anomalies, column)
+			}
+
+			// The incremental EWMA of the mean and the variance
+			diff := number - s.mean
+			increment := config.Alpha * diff
+			s.mean += increment
+			s.variance = (1 - config.Alpha) * (s.variance + diff*increment)
+			s.count++
+		}
+
+		if len(anomalies) > 0 {
+			slices.Sort(anomalies)
+			row["_anomaly"] = strings.Join(anomalies, ",")
+		}
+		return row
+	}
+}
@@ -0,0 +1,50 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_detect_anomalies_flags_the_spikes(t *testing.T) {
+	is := is.New(t)
+	detect := DetectAnomalies(AnomalyConfig{WarmUp: 10})
+
+	// Given normal durations and sizes
+	for i := 0; i < 50; i++ {
+		row := detect(Row{"duration_ms": 100 + i%5, "response_size": 1000.0 + float64(i%3), "path": "/"})
+		_, flagged := row["_anomaly"]
+		is.True(!flagged)
+	}
+
+	// When a row has spikes
+	row := detect(Row{"duration_ms": 5000, "response_size": 9000.0, "path": "/"})
+
+	// Then the deviating columns are flagged
+	is.Equal(row["_anomaly"], "duration_ms,response_size")
+
+	// And a normal row isn't
+	row = detect(Row{"duration_ms": 102, "response_size": 1001.0})
+	_, flagged := row["_anomaly"]
+	is.True(!flagged)
+}
+
+func Test_detect_anomalies_only_checks_the_columns_after_the_warm_up(t *testing.T) {
+	is := is.New(t)
+	detect := DetectAnomalies(AnomalyConfig{Columns: []string{"duration_ms"}, WarmUp: 5})
+
+	// During the warm up nothing is flagged
+	for i := 0; i < 5; i++ {
+		row := detect(Row{"duration_ms": 100 * (i + 1), "status": 200})
+		_, flagged := row["_anomaly"]
+		is.True(!flagged)
+	}
+	for i := 0; i < 20; i++ {
+		detect(Row{"duration_ms": 100, "status": 200})
+	}
+
+	// Columns that aren't checked are never flagged
+	row := detect(Row{"duration_ms": 100, "status": 500})
+	_, flagged := row["_anomaly"]
+	is.True(!flagged)
+}
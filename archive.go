@@ -0,0 +1,143 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveTo keeps the archived rows of the tables (see ArchiveAfter) in Parquet files in a
+// directory per table in dir. The directory is a local path or an S3 URL like s3://bucket/timeline,
+// which requires the httpfs extension and credentials in DuckDB (see ConfigureS3). Query, Between and
+// Export read the archived rows of a table together with its rows in the database. QueryPage
+// doesn't support archived tables. The archives aren't stored in the database: call ArchiveTo again
+// after the database is opened.
+func (w *Writer) ArchiveTo(dir string, tables ...string) {
+	w.partitionMu.Lock()
+	defer w.partitionMu.Unlock()
+	if w.archives == nil {
+		w.archives = make(map[string]string)
+	}
+	for _, table := range tables {
+		w.archives[table] = dir
+	}
+}
+
+// ArchiveAfter moves the rows that are older than age from the tables to their archive (see
+// ArchiveTo), or from all tables with an archive without tables. Every run writes a Parquet file
+// per table (or daily partition) with the moved rows.
+func ArchiveAfter(age time.Duration, tables ...string) RetentionPolicy {
+	return RetentionPolicy{maxAge: age, tables: tables, archive: true}
+}
+
+// archiveDir returns the archive directory of the table
+func (w *Writer) archiveDir(table string) (string, bool) {
+	w.partitionMu.RLock()
+	defer w.partitionMu.RUnlock()
+	dir, ok := w.archives[table]
+	return dir, ok
+}
+
+// archivedTables returns the tables with an archive
+func (w *Writer) archivedTables() []string {
+	w.partitionMu.RLock()
+	defer w.partitionMu.RUnlock()
+	tables := make([]string, 0, len(w.archives))
+	for table := range w.archives {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// archivePath returns the directory of the archived files of a table
+func archivePath(dir string, table string) string {
+	if strings.Contains(dir, "://") {
+		return strings.TrimSuffix(dir, "/") + "/" + table
+	}
+	return filepath.Join(dir, table)
+}
+
+// archiveSource returns the FROM clause of the archived rows of a table, or an empty string when
+// the table has no archived rows
+func (w *Writer) archiveSource(table string) (string, error) {
+	dir, ok := w.archiveDir(table)
+	if !ok {
+		return "", nil
+	}
+	pattern := quoteString(archivePath(dir, table) + "/*.parquet")
+	var files int
+	if err := w.DB.QueryRow("SELECT count(*) FROM glob(" + pattern + ")").Scan(&files); err != nil {
+		return "", fmt.Errorf("failed to list the archive of %s: %w", table, err)
+	}
+	if files == 0 {
+		return "", nil
+	}
+	return "read_parquet(" + pattern + ", union_by_name = true)", nil
+}
+
+// archiveRows moves the rows of a table (or daily partition) before the cutoff to a Parquet file
+// in the archive of the table and returns the number of moved rows
+func (w *Writer) archiveRows(ctx context.Context, table string, cutoff time.Time) (int64, error) {
+	archived := table
+	base, end, partition := w.partitionOf(table)
+	if partition {
+		archived = base
+	}
+	dir, ok := w.archiveDir(archived)
+	if !ok {
+		return 0, fmt.Errorf("table %s has no archive", archived)
+	}
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin archival of %s: %w", table, err)
+	}
+	defer tx.Rollback()
+
+	var count int64
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s WHERE timestamp < ?", quoteTableName(table)), cutoff).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to archive %s: %w", table, err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	path := archivePath(dir, archived)
+	local := !strings.Contains(dir, "://")
+	if local {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return 0, fmt.Errorf("failed to create directory %s: %w", path, err)
+		}
+	}
+	_, name := splitTableName(table)
+	file := path + "/" + name + "-" + cutoff.Format("20060102T150405.000000000Z") + ".parquet"
+
+	// The rows are exported and deleted in one transaction, so rows that are written meanwhile
+	// are not deleted without being exported
+	statement := fmt.Sprintf("COPY (SELECT * FROM %s WHERE timestamp < ? ORDER BY timestamp) TO %s (FORMAT parquet, COMPRESSION zstd)",
+		quoteTableName(table), quoteString(file))
+	if _, err := tx.ExecContext(ctx, statement, cutoff); err != nil {
+		return 0, fmt.Errorf("failed to archive %s: %w", table, err)
+	}
+	if partition && !end.After(cutoff) {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s", quoteTableName(table)))
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE timestamp < ?", quoteTableName(table)), cutoff)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		// Don't read the rows twice
+		if local {
+			os.Remove(file)
+		}
+		return 0, fmt.Errorf("failed to archive %s: %w", table, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,87 @@
+package timeline
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_archive_after_moves_the_old_rows_to_parquet(t *testing.T) {
+	is, writer := setup(t)
+	dir := t.TempDir()
+	writer.ArchiveTo(dir, "logs")
+	now := time.Now().UTC()
+
+	// Given an old and a new row
+	is.NoErr(writer.Write("logs", NewRow(now.Add(-48*time.Hour), Row{"message": "old"})))
+	is.NoErr(writer.Write("logs", NewRow(now, Row{"message": "new"})))
+
+	// When the rows older than a day are archived
+	archived, err := writer.ApplyRetention(context.Background(), ArchiveAfter(24*time.Hour))
+
+	// Then the old row is moved to a Parquet file
+	is.NoErr(err)
+	is.Equal(archived, int64(1))
+	waitForCount(t, writer, "SELECT count(*) FROM logs", 1)
+	files, err := filepath.Glob(filepath.Join(dir, "logs", "*.parquet"))
+	is.NoErr(err)
+	is.Equal(len(files), 1)
+
+	// And the queries read the archived and the new row
+	rows, err := writer.Between(context.Background(), "logs", now.Add(-72*time.Hour), now.Add(time.Hour), 0)
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.Equal(rows[0]["message"], "old")
+	is.Equal(rows[1]["message"], "new")
+}
+
+func Test_archive_after_without_old_rows_writes_no_file(t *testing.T) {
+	is, writer := setup(t)
+	dir := t.TempDir()
+	writer.ArchiveTo(dir, "logs")
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "new"})))
+
+	archived, err := writer.ApplyRetention(context.Background(), ArchiveAfter(24*time.Hour))
+
+	is.NoErr(err)
+	is.Equal(archived, int64(0))
+	files, err := filepath.Glob(filepath.Join(dir, "logs", "*.parquet"))
+	is.NoErr(err)
+	is.Equal(len(files), 0)
+	rows, err := writer.Query(context.Background(), "logs", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(len(rows), 1)
+}
+
+func Test_archive_after_drops_old_partitions(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("logs")
+	writer.ArchiveTo(t.TempDir(), "logs")
+	now := time.Now().UTC()
+	for days := 0; days < 5; days++ {
+		is.NoErr(writer.Write("logs", NewRow(now.AddDate(0, 0, -days), Row{"days": days})))
+	}
+
+	archived, err := writer.ApplyRetention(context.Background(), ArchiveAfter(60*time.Hour, "logs"))
+
+	is.NoErr(err)
+	is.Equal(archived, int64(2))
+	tables, err := writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.True(tables[0] > partitionName("logs", now.AddDate(0, 0, -4)))
+	rows, err := writer.Query(context.Background(), "logs", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(len(rows), 5)
+	is.Equal(rows[0]["days"], 4)
+}
+
+func Test_archive_after_a_table_without_archive_fails(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("logs", NewRow(time.Now().Add(-48*time.Hour), Row{"message": "old"})))
+
+	_, err := writer.ApplyRetention(context.Background(), ArchiveAfter(24*time.Hour, "logs"))
+
+	is.True(err != nil)
+	waitForCount(t, writer, "SELECT count(*) FROM logs", 1)
+}
@@ -0,0 +1,58 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Attach attaches another timeline database file to the connection of the writer, so it can be
+// queried with the alias as prefix of its tables, e.g. to query a database per service:
+//
+//	writer.Attach(ctx, "data/billing.db", "billing", true)
+//	rows, err := writer.Query(ctx, "billing.app", timeline.QueryOptions{Limit: 100})
+//	rows, err = writer.SQL(ctx, "SELECT * FROM app UNION ALL BY NAME SELECT * FROM billing.app", nil)
+//
+// The alias defaults to the normalized file name without extension. A database that is attached
+// read-only can be attached by multiple processes; otherwise Write can write to its tables too.
+func (w *Writer) Attach(ctx context.Context, path string, alias string, readOnly bool) error {
+	if alias == "" {
+		alias = normalizeColumnName(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	}
+	statement := fmt.Sprintf("ATTACH %s AS %s", quoteString(path), quoteIdentifier(alias))
+	if readOnly {
+		statement += " (READ_ONLY)"
+	}
+	if _, err := w.DB.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to attach %s as %s: %w", path, alias, err)
+	}
+	return nil
+}
+
+// Detach detaches a database that is attached with Attach
+func (w *Writer) Detach(ctx context.Context, alias string) error {
+	if _, err := w.DB.ExecContext(ctx, "DETACH "+quoteIdentifier(alias)); err != nil {
+		return fmt.Errorf("failed to detach %s: %w", alias, err)
+	}
+	return nil
+}
+
+// AttachedDatabases returns the aliases of the attached databases
+func (w *Writer) AttachedDatabases(ctx context.Context) ([]string, error) {
+	rows, err := w.DB.QueryContext(ctx, "SELECT database_name FROM duckdb_databases() WHERE NOT internal AND database_name <> current_database() ORDER BY database_name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attached databases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("failed to scan database name: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
@@ -0,0 +1,52 @@
+package timeline
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_attach_databases_to_query_across_them(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i, service := range []string{"billing", "shop-api"} {
+		file, err := NewStorageClient(filepath.Join(dir, service+".db"))
+		is.NoErr(err)
+		is.NoErr(file.Write("app", NewRow(now.Add(time.Duration(i)*time.Second), Row{"message": "hello from " + service})))
+		is.NoErr(file.Close())
+	}
+	is.NoErr(writer.Write("app", NewRow(now.Add(-time.Second), Row{"level": "info"})))
+
+	// When the databases are attached
+	is.NoErr(writer.Attach(ctx, filepath.Join(dir, "billing.db"), "", true))
+	is.NoErr(writer.Attach(ctx, filepath.Join(dir, "shop-api.db"), "shop", false))
+
+	// Then their tables can be queried with the alias
+	aliases, err := writer.AttachedDatabases(ctx)
+	is.NoErr(err)
+	is.Equal(aliases, []string{"billing", "shop"})
+	rows, err := writer.Query(ctx, "billing.app", QueryOptions{Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "hello from billing"}})
+	rows, err = writer.SQL(ctx, "SELECT message FROM billing.app UNION ALL SELECT message FROM shop.app ORDER BY message", nil)
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "hello from billing"}, {"message": "hello from shop-api"}})
+
+	// And the tables of the writer are not mixed up with the attached tables
+	is.NoErr(writer.Write("app", NewRow(now, Row{"level": "error"})))
+	rows, err = writer.Query(ctx, "app", QueryOptions{Columns: []string{"level"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"level": "info"}, {"level": "error"}})
+
+	// And read-write attached databases can be written
+	is.NoErr(writer.Write("shop.app", NewRow(now, Row{"message": "written", "status": 200})))
+	is.True(writer.Write("billing.app", NewRow(now, Row{"message": "read-only"})) != nil)
+
+	is.NoErr(writer.Detach(ctx, "billing"))
+	aliases, err = writer.AttachedDatabases(ctx)
+	is.NoErr(err)
+	is.Equal(aliases, []string{"shop"})
+}
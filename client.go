@@ -3,29 +3,32 @@ package timeline
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/marcboeker/go-duckdb"
+	"github.com/marcboeker/go-duckdb"
 )
 
 type NullString sql.NullString
 
 func NewMemoryClient() (*Writer, error) {
-	db, err := sql.Open("duckdb", ":memory:")
+	connector, err := duckdb.NewConnector("", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	writer := &Writer{
-		DB:     db,
 		ctx:    ctx,
 		cancel: cancel,
 		ticker: time.NewTicker(200 * time.Millisecond),
 	}
+	writer.open(connector)
 
 	// Start periodic checkpointing goroutine
 	go writer.periodicCheckpoint()
@@ -34,126 +37,263 @@ func NewMemoryClient() (*Writer, error) {
 }
 
 func NewStorageClient(dbPath string) (*Writer, error) {
-	db, err := sql.Open("duckdb", dbPath)
+	return NewStorageClientWithConfig(dbPath, ClientConfig{})
+}
+
+// NewStorageClientWithConfig is NewStorageClient with a configuration of the resources of DuckDB
+func NewStorageClientWithConfig(dbPath string, config ClientConfig) (*Writer, error) {
+	connector, err := duckdb.NewConnector(config.dsn(dbPath, nil), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database %s: %w", dbPath, err)
+		return nil, openError(dbPath, err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	writer := &Writer{
-		DB:     db,
 		ctx:    ctx,
 		cancel: cancel,
-		ticker: time.NewTicker(200 * time.Millisecond),
 	}
+	writer.open(connector)
 
 	// Start periodic checkpointing goroutine
-	go writer.periodicCheckpoint()
+	if interval := config.checkpointInterval(); interval > 0 {
+		writer.ticker = time.NewTicker(interval)
+		go writer.periodicCheckpoint()
+	}
 
 	return writer, nil
 }
 
+// open opens the database of the connector, with connections that log their statements in debug
+// mode (see SetDebug)
+func (w *Writer) open(connector driver.Connector) {
+	w.connector = debugConnector{Connector: connector, debug: &w.debug}
+	w.DB = sql.OpenDB(w.connector)
+}
+
 type Row map[string]any
 
+// TimestampFormats are the layouts of the strings in the timestamp field of NewRow, a layout
+// without a time zone is UTC. Append a layout for another format.
+var TimestampFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	// Common Log Format
+	"02/Jan/2006:15:04:05 -0700",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// NewRow returns a row with the timestamp, unless the data has a timestamp field with a time: a
+// time.Time or a string in one of the TimestampFormats
 func NewRow(timestamp time.Time, data map[string]any) Row {
 	// The user can override the timestamp column value
-	ts, exists := data["timestamp"]
-	if !exists || duckDbTypeFromInput(ts) != Timestamp {
-		data["timestamp"] = timestamp
+	switch ts := data["timestamp"].(type) {
+	case time.Time:
+		return data
+	case string:
+		if t, ok := parseTimestamp(ts, TimestampFormats); ok {
+			data["timestamp"] = t
+			return data
+		}
 	}
+	data["timestamp"] = timestamp
 	return data
 }
 
+// parseTimestamp parses a string with the first matching layout, e.g. of TimestampFormats
+func parseTimestamp(value string, layouts []string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
 type Writer struct {
 	DB           *sql.DB
 	ctx          context.Context
 	cancel       context.CancelFunc
 	checkpointMu sync.Mutex
+	// schemaMu serializes the schema changes of the writes, see WriteContext
+	schemaMu     sync.Mutex
 	ticker       *time.Ticker
+	followers    followers
+	replicators  replicators
+	readOnly     bool
+	queryTimeout atomic.Int64
+	// lastWrite is the time (in Unix nanoseconds) of the last written row
+	lastWrite   atomic.Int64
+	rowsWritten atomic.Int64
+	writeErrors atomic.Int64
+	// connector opens connections to the database of DB, see readPool
+	connector  driver.Connector
+	readPoolMu sync.Mutex
+	reader     *Writer
+	// partitioned are the tables of PartitionDaily, templates the table templates that were used
+	// and archives the directories of ArchiveTo
+	partitionMu sync.RWMutex
+	partitioned map[string]bool
+	templates   map[string]tableTemplate
+	archives    map[string]string
+	closed      atomic.Bool
+	// quota is the disk quota of SetQuota, nil without quota
+	quota      atomic.Pointer[quota]
+	metricsRef atomic.Pointer[metricsRef]
+	tracerRef  atomic.Pointer[tracerRef]
+	rulesRef   atomic.Pointer[ruleSet]
+	aliases    atomic.Pointer[map[string]string]
+	// columnConflict is the ColumnConflict of SetColumnConflict
+	columnConflict atomic.Int32
+	writeStats     writeStats
+	// schemaSubscribers are the subscriptions of SchemaEvents
+	schemaSubscribers schemaSubscribers
+	ddlAudit          atomic.Bool
+	debug             debugState
 }
 
+// Close stops the periodic checkpoints, checkpoints the database, so all written rows are in the
+// database file, and closes the database. Closing a closed writer does nothing.
 func (w *Writer) Close() error {
+	if !w.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
 	// Stop the periodic checkpointing goroutine
 	w.cancel()
-	w.ticker.Stop()
-	return w.DB.Close()
+	if w.ticker != nil {
+		w.ticker.Stop()
+	}
+	w.closeReadPool()
+	w.rulesRef.Swap(nil).stop()
+
+	var err error
+	if !w.readOnly {
+		err = w.Checkpoint()
+	}
+	return errors.Join(err, w.DB.Close())
 }
 
 // with datetime object (not string)
 func (w *Writer) Write(table string, row Row) error {
+	return w.WriteContext(context.Background(), table, row)
+}
+
+// WriteContext is Write with a context, which cancels the write and carries the trace of the
+// write, see SetTracer
+func (w *Writer) WriteContext(ctx context.Context, table string, row Row) (err error) {
+	start := time.Now()
+	// Followers follow the partitioned table, not the partition
+	followed := table
+	written := false
+	ctx, endSpan := w.startSpan(ctx, SpanWrite, map[string]string{"table": table})
+	ctx = w.withTriggerRow(ctx, row)
+	defer func() {
+		if err != nil {
+			w.writeErrors.Add(1)
+		}
+		// Empty rows are not written and not counted
+		if err != nil || written {
+			w.countWrite(followed, row, err)
+			w.observeWrite(followed, start, err)
+		}
+		endSpan(err)
+	}()
+
+	if w.readOnly {
+		return ErrReadOnly
+	}
 
 	// If row is empty or only contains timestamp, do nothing
 	if len(row) <= 1 {
 		return nil
 	}
 
+	// A row that is moved by a rule is written to the table of the rule instead
+	if moved, err := w.applyRules(ctx, table, row); moved || err != nil {
+		return err
+	}
+
+	if err := w.checkQuota(); err != nil {
+		return err
+	}
+
+	// Flatten json maps into separate columns, with the aliases of the fields
+	flattened, err := w.flatten(row)
+	if err != nil {
+		return err
+	}
+	row = flattened
+
+	if template, ok := w.tableTemplate(table); ok {
+		table = template.name(rowTime(row))
+	}
+
+	inserted, err := w.writeRow(ctx, table, row)
+	if err != nil {
+		return err
+	}
+	row = inserted
+
+	written = true
+	w.lastWrite.Store(time.Now().UnixNano())
+	w.rowsWritten.Add(1)
+	if ctx.Value(liveWriteKey{}) == nil {
+		w.followers.publish(followed, row)
+		w.replicators.publish(followed, row)
+	}
+
+	return nil
+}
+
+// writeRow changes the schema of the table for the row and inserts it. The writer is safe for
+// concurrent writes (e.g. of receivers, tailers and pipelines that share it): the columns are read
+// and changed by one write at a time, until the row is inserted with the columns it was checked
+// against. Returns the inserted row.
+func (w *Writer) writeRow(ctx context.Context, table string, row Row) (Row, error) {
+	w.schemaMu.Lock()
+	defer w.schemaMu.Unlock()
+
 	// Get existing columns
 	cols, err := w.getCurrentColumns(table)
 	if err != nil {
-		return fmt.Errorf("failed to get columns: %w", err)
+		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 
 	// Ensure table exists
-	if err := w.ensureTableExists(table, cols); err != nil {
-		return fmt.Errorf("failed to ensure table exists: %w", err)
+	if err := w.ensureTableExists(ctx, table, cols); err != nil {
+		return nil, fmt.Errorf("failed to ensure table exists: %w", err)
 	}
 
-	// Flatten json maps into separate columns
-	row = flattenJsonMaps(row)
-
 	// Promote column types if needed
-	cols, err = w.promoteColumns(table, cols, row)
+	cols, err = w.promoteColumns(ctx, table, cols, row)
 	if err != nil {
-		return fmt.Errorf("before insert new row: %w", err)
+		return nil, fmt.Errorf("before insert new row: %w", err)
 	}
 
 	// Add any missing columns
-	if err := w.addMissingColumns(table, cols, row); err != nil {
-		return fmt.Errorf("failed to add missing columns: %w", err)
+	if err := w.addMissingColumns(ctx, table, cols, row); err != nil {
+		return nil, fmt.Errorf("failed to add missing columns: %w", err)
 	}
 
 	row = w.preprocessRow(row, cols)
 
-	// get duckdb path for logging
-	var seq int
-	var name string
-	var filePath sql.NullString
-	if err := w.DB.QueryRow("PRAGMA database_list").Scan(&seq, &name, &filePath); err != nil {
-		return fmt.Errorf("failed to get database path: %w", err)
+	if err := w.insertRow(ctx, table, row); err != nil {
+		return nil, fmt.Errorf("failed to insert row: %w", err)
 	}
-
-	if err := w.insertRow(table, row); err != nil {
-		return fmt.Errorf("failed to insert row: %w", err)
-	}
-
-	return nil
+	return row, nil
 }
 
 func flattenJsonMaps(row Row) Row {
-	// only when row is a map[string]any, flatten it
-	resultRow := make(Row)
-	for k, v := range row {
-		if vMap, ok := v.(map[string]any); ok {
-			for mmk, mmv := range flattenJsonMaps(vMap) {
-				newKey2 := k + "_" + mmk
-				resultRow[newKey2] = mmv
-			}
-		} else if mvMap, ok := v.([]any); ok {
-			// Json encoded the array
-			jsonBytes, err := json.Marshal(mvMap)
-			if err != nil {
-				resultRow[k] = fmt.Sprintf("%v", mvMap)
-			} else {
-				resultRow[k] = string(jsonBytes)
-			}
-		} else {
-			resultRow[k] = v
-		}
-	}
-	return resultRow
+	// Renaming never fails
+	flattened, _ := flattenRow(row, ConflictRename)
+	return flattened
 }
 
-func (w *Writer) promoteColumns(table string, existingCols map[string]ColumnType, row Row) (map[string]ColumnType, error) {
+func (w *Writer) promoteColumns(ctx context.Context, table string, existingCols map[string]ColumnType, row Row) (map[string]ColumnType, error) {
 	for col, value := range row {
 		oldType, exists := existingCols[col]
 		if !exists {
@@ -174,15 +314,22 @@ func (w *Writer) promoteColumns(table string, existingCols map[string]ColumnType
 		if promoteType == oldType {
 			continue
 		}
-		if err := w.promoteColumn(table, col, oldType, promoteType); err != nil {
+		if err := w.promoteColumn(ctx, table, col, oldType, promoteType); err != nil {
 			return existingCols, fmt.Errorf("from %s to %s given %s: %w", oldType, promoteType, givenType, err)
 		}
+		if metrics := w.metrics(); metrics != nil {
+			metrics.ColumnPromoted(w.metricsTable(table), col, oldType, promoteType)
+		}
+		w.schemaChanged(table, SchemaEvent{Kind: ColumnPromoted, Column: col, Type: promoteType, From: oldType})
 		existingCols[col] = promoteType
 	}
 	return existingCols, nil
 }
 
-func (w *Writer) promoteColumn(table, col string, oldType, promoteType ColumnType) error {
+func (w *Writer) promoteColumn(ctx context.Context, table, col string, oldType, promoteType ColumnType) (err error) {
+	ctx, endSpan := w.startSpan(ctx, SpanPromote, map[string]string{"table": table, "column": col, "from": string(oldType), "to": string(promoteType)})
+	defer func() { endSpan(err) }()
+
 	// Convert Time to Timestamp by combining with date part of existing timestamp column
 	if oldType == Time && promoteType == Timestamp {
 		alterSQL := fmt.Sprintf(`
@@ -191,7 +338,7 @@ func (w *Writer) promoteColumn(table, col string, oldType, promoteType ColumnTyp
 		`, table, col, promoteType, col) // use column timestamp to get the date part
 
 		// Promote column type
-		if _, err := w.DB.Exec(alterSQL); err != nil {
+		if err := w.execDDL(ctx, w.DB, table, alterSQL, string(ColumnPromoted)); err != nil {
 			return fmt.Errorf("failed to promote column %s to %s: %w", col, promoteType, err)
 		}
 		return nil
@@ -203,13 +350,13 @@ func (w *Writer) promoteColumn(table, col string, oldType, promoteType ColumnTyp
 	`, table, col, promoteType, col, promoteType)
 
 	// Promote column type
-	if _, err := w.DB.Exec(alterSQL); err != nil {
+	if err := w.execDDL(ctx, w.DB, table, alterSQL, string(ColumnPromoted)); err != nil {
 		return fmt.Errorf("failed to promote column %s to %s: %w", col, promoteType, err)
 	}
 	return nil
 }
 
-func (w *Writer) insertRow(table string, row Row) error {
+func (w *Writer) insertRow(ctx context.Context, table string, row Row) error {
 	columns := ""
 	valuePlaceholder := ""
 	values := []any{}
@@ -226,7 +373,7 @@ func (w *Writer) insertRow(table string, row Row) error {
 	}
 
 	insertSQL := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, columns, valuePlaceholder)
-	if _, err := w.DB.Exec(insertSQL, values...); err != nil {
+	if _, err := w.DB.ExecContext(ctx, insertSQL, values...); err != nil {
 		return fmt.Errorf("failed to execute: %w", err)
 	}
 	return nil
@@ -245,16 +392,25 @@ func (w *Writer) periodicCheckpoint() {
 			// Use FORCE CHECKPOINT to avoid conflicts with active transactions
 			_, err := w.DB.Exec("FORCE CHECKPOINT")
 			if err != nil {
-				// Log the error but don't fail - checkpointing will be retried
-				fmt.Printf("Warning: failed to execute checkpoint: %v\n", err)
+				// Report the error but don't fail - checkpointing will be retried
+				err = fmt.Errorf("failed to execute checkpoint: %w", err)
+				if !handleError(nil, err, ErrorContext{Component: "checkpoint"}) {
+					w.debug.loggerOf().Printf("timeline: %v", err)
+				}
 			}
 			w.checkpointMu.Unlock()
 		}
 	}
 }
 
-// Checkpoint performs an immediate checkpoint (for backward compatibility)
+// Checkpoint writes the WAL to the database file now, e.g. after a large import. Besides the
+// periodic checkpoints (see CheckpointInterval of ClientConfig), DuckDB checkpoints when the WAL
+// reaches CheckpointThreshold.
 func (w *Writer) Checkpoint() error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+
 	w.checkpointMu.Lock()
 	defer w.checkpointMu.Unlock()
 
@@ -304,9 +460,12 @@ func getDateFromTimestamp(ts any) (string, bool) {
 func (w *Writer) getCurrentColumns(table string) (map[string]ColumnType, error) {
 	existingCols := make(map[string]ColumnType)
 
+	// Only the columns of the table in the database of the writer or in the attached database of
+	// the qualified table name (e.g. billing.app)
+	database, name := splitTableName(table)
 	rows, err := w.DB.Query(
-		"SELECT column_name, data_type FROM information_schema.columns WHERE table_name = ?",
-		table,
+		"SELECT column_name, data_type FROM information_schema.columns WHERE table_catalog = coalesce(nullif(?, ''), current_database()) AND table_schema = 'main' AND table_name = ?",
+		database, name,
 	)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
@@ -325,19 +484,23 @@ func (w *Writer) getCurrentColumns(table string) (map[string]ColumnType, error)
 }
 
 // ensureTableExists creates the table if it does not exist
-func (w *Writer) ensureTableExists(table string, existingCols map[string]ColumnType) error {
+func (w *Writer) ensureTableExists(ctx context.Context, table string, existingCols map[string]ColumnType) (err error) {
 	if len(existingCols) == 0 {
+		ctx, endSpan := w.startSpan(ctx, SpanCreateTable, map[string]string{"table": table})
+		defer func() { endSpan(err) }()
+
 		createSQL := fmt.Sprintf("CREATE TABLE %s (%s)", table, "timestamp TIMESTAMP")
-		if _, err := w.DB.Exec(createSQL); err != nil {
+		if err := w.execDDL(ctx, w.DB, table, createSQL, string(TableCreated)); err != nil {
 			return fmt.Errorf("failed to create table %s: %w", table, err)
 		}
+		w.schemaChanged(table, SchemaEvent{Kind: TableCreated})
 		existingCols["timestamp"] = Timestamp
 	}
 	return nil
 }
 
 // addMissingColumns adds columns that are in the row but not in the table yet
-func (w *Writer) addMissingColumns(table string, existingCols map[string]ColumnType, row Row) error {
+func (w *Writer) addMissingColumns(ctx context.Context, table string, existingCols map[string]ColumnType, row Row) error {
 	for col := range row {
 		if _, exists := existingCols[col]; !exists {
 			_type := duckDbTypeFromInput(row[col])
@@ -348,16 +511,31 @@ func (w *Writer) addMissingColumns(table string, existingCols map[string]ColumnT
 			}
 			// Add columns
 			for col, _type := range columnsToAdd {
-				alterSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, col, _type)
-				if _, err := w.DB.Exec(alterSQL); err != nil {
-					return fmt.Errorf("failed to add column %s: %w", col, err)
+				if err := w.addColumn(ctx, table, col, _type); err != nil {
+					return err
 				}
+				if metrics := w.metrics(); metrics != nil {
+					metrics.ColumnAdded(w.metricsTable(table), col, _type)
+				}
+				w.schemaChanged(table, SchemaEvent{Kind: ColumnAdded, Column: col, Type: _type})
 			}
 		}
 	}
 	return nil
 }
 
+// addColumn adds a column to a table
+func (w *Writer) addColumn(ctx context.Context, table string, col string, _type ColumnType) (err error) {
+	ctx, endSpan := w.startSpan(ctx, SpanAddColumn, map[string]string{"table": table, "column": col, "type": string(_type)})
+	defer func() { endSpan(err) }()
+
+	alterSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, col, _type)
+	if err := w.execDDL(ctx, w.DB, table, alterSQL, string(ColumnAdded)); err != nil {
+		return fmt.Errorf("failed to add column %s: %w", col, err)
+	}
+	return nil
+}
+
 // getFieldsFromMap transforms user:{id:123} to user_id:123
 func getFieldsFromMap(value any, parentKey string) map[string]ColumnType {
 	fields := make(map[string]ColumnType)
@@ -0,0 +1,58 @@
+package timeline
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ClientConfig limits the resources DuckDB uses for a database, so a busy ingestion process can't
+// take all memory of the host. The zero value uses the defaults of DuckDB.
+type ClientConfig struct {
+	// MemoryLimit is the maximum memory, e.g. 512MB or 2GB; defaults to 80% of the RAM
+	MemoryLimit string
+	// Threads is the maximum number of threads of a query, defaults to the number of cores
+	Threads int
+	// TempDirectory is the directory for data that doesn't fit in memory, defaults to <dbPath>.tmp
+	TempDirectory string
+	// CheckpointThreshold is the size of the WAL that triggers a checkpoint, e.g. 16MB; defaults
+	// to 16MB
+	CheckpointThreshold string
+	// CheckpointInterval is the time between the checkpoints of the writer, defaults to 200ms. A
+	// negative interval disables them, so only CheckpointThreshold, Checkpoint and Close checkpoint.
+	CheckpointInterval time.Duration
+}
+
+// defaultCheckpointInterval is the default time between the checkpoints of a writer
+const defaultCheckpointInterval = 200 * time.Millisecond
+
+// checkpointInterval returns the time between the checkpoints, 0 disables them
+func (c ClientConfig) checkpointInterval() time.Duration {
+	if c.CheckpointInterval == 0 {
+		return defaultCheckpointInterval
+	}
+	return max(c.CheckpointInterval, 0)
+}
+
+// dsn returns the data source name of a database with the configuration
+func (c ClientConfig) dsn(dbPath string, params url.Values) string {
+	if params == nil {
+		params = url.Values{}
+	}
+	if c.MemoryLimit != "" {
+		params.Set("memory_limit", c.MemoryLimit)
+	}
+	if c.Threads > 0 {
+		params.Set("threads", strconv.Itoa(c.Threads))
+	}
+	if c.TempDirectory != "" {
+		params.Set("temp_directory", c.TempDirectory)
+	}
+	if c.CheckpointThreshold != "" {
+		params.Set("checkpoint_threshold", c.CheckpointThreshold)
+	}
+	if len(params) == 0 {
+		return dbPath
+	}
+	return dbPath + "?" + params.Encode()
+}
@@ -0,0 +1,77 @@
+package timeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_storage_client_with_config(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+
+	writer, err := NewStorageClientWithConfig(filepath.Join(dir, "app.db"), ClientConfig{
+		MemoryLimit:         "256MB",
+		Threads:             2,
+		TempDirectory:       filepath.Join(dir, "spill"),
+		CheckpointThreshold: "32MB",
+	})
+	is.NoErr(err)
+	defer writer.Close()
+
+	settings := map[string]string{}
+	for _, name := range []string{"memory_limit", "threads", "temp_directory", "checkpoint_threshold"} {
+		var value string
+		is.NoErr(writer.DB.QueryRow("SELECT current_setting(?)", name).Scan(&value))
+		settings[name] = value
+	}
+	is.Equal(settings, map[string]string{
+		"memory_limit":         "244.1 MiB",
+		"threads":              "2",
+		"temp_directory":       filepath.Join(dir, "spill"),
+		"checkpoint_threshold": "30.5 MiB",
+	})
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+}
+
+func Test_storage_client_without_periodic_checkpoints(t *testing.T) {
+	is := is.New(t)
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	walSize := func() int64 {
+		info, err := os.Stat(dbPath + ".wal")
+		if err != nil {
+			return 0
+		}
+		return info.Size()
+	}
+
+	writer, err := NewStorageClientWithConfig(dbPath, ClientConfig{CheckpointInterval: -1})
+	is.NoErr(err)
+	defer writer.Close()
+
+	// The written rows stay in the WAL
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+	time.Sleep(300 * time.Millisecond)
+	is.True(walSize() > 0)
+
+	// Until the database is checkpointed
+	is.NoErr(writer.Checkpoint())
+	is.Equal(walSize(), int64(0))
+}
+
+func Test_close_checkpoints_the_database(t *testing.T) {
+	is := is.New(t)
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	writer, err := NewStorageClientWithConfig(dbPath, ClientConfig{CheckpointInterval: -1})
+	is.NoErr(err)
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+
+	is.NoErr(writer.Close())
+	is.NoErr(writer.Close())
+
+	_, err = os.Stat(dbPath + ".wal")
+	is.True(os.IsNotExist(err))
+}
@@ -0,0 +1,70 @@
+// Command timeline writes logs to timeline database files and reads them, e.g.
+//
+//	myapp | timeline ingest --db app.db --table logs --tee
+//	timeline query --db app.db --table logs --from 1h --where "status >= 500"
+//	timeline tail --db app.db --table logs -f
+//	timeline export --db app.db --table logs --format parquet --output logs.parquet
+//
+// Run timeline <command> -h for the flags of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/confetti-cms/timeline"
+)
+
+// commands are the commands of the binary by name
+var commands = map[string]func(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error{
+	"ingest": timeline.RunIngestCommand,
+	"query":  timeline.RunQueryCommand,
+	"tail":   timeline.RunTailCommand,
+	"export": timeline.RunExportCommand,
+}
+
+const usage = `Usage: timeline <command> [flags]
+
+Commands:
+  ingest  write lines of stdin or files to a table
+  query   write the rows of a table or an SQL query as JSON lines
+  tail    write the newest rows as JSON lines, -f keeps writing new rows
+  export  write the rows of a table as CSV, JSON lines or Parquet
+
+Run timeline <command> -h for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		if os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+			fmt.Fprint(os.Stdout, usage)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "timeline: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	// Interrupts stop ingest and tail -f, ingest writes the lines it read first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[2:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		stop()
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "timeline: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/confetti-cms/timeline"
+	"github.com/matryer/is"
+)
+
+// Test_main_process runs the command of TIMELINE_ARGS, in the process of Test_interrupt_writes_the_pending_rows_of_ingest
+func Test_main_process(t *testing.T) {
+	if os.Getenv("TIMELINE_ARGS") == "" {
+		t.Skip("runs the command in a separate process")
+	}
+	os.Args = append([]string{"timeline"}, strings.Fields(os.Getenv("TIMELINE_ARGS"))...)
+	main()
+	os.Exit(0)
+}
+
+func Test_interrupt_writes_the_pending_rows_of_ingest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupts are not sent to processes on Windows")
+	}
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "app.db")
+
+	// Given an ingestion that doesn't write the lines of an idle stdin yet
+	command := exec.Command(os.Args[0], "-test.run=^Test_main_process$")
+	command.Env = append(os.Environ(), "TIMELINE_ARGS=ingest --db "+path+" --table logs --tee --flush-interval 1h")
+	stdin, err := command.StdinPipe()
+	is.NoErr(err)
+	stdout, err := command.StdoutPipe()
+	is.NoErr(err)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	is.NoErr(command.Start())
+
+	for i := 1; i <= 50; i++ {
+		_, err := fmt.Fprintf(stdin, "level=info n=%d\n", i)
+		is.NoErr(err)
+	}
+	// The lines are copied to stdout when they are read
+	lines := bufio.NewScanner(stdout)
+	for i := 0; i < 50; i++ {
+		is.True(lines.Scan())
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// When the command is interrupted
+	is.NoErr(command.Process.Signal(os.Interrupt))
+
+	// Then it exits after it wrote the read lines
+	is.NoErr(command.Wait())
+	is.True(strings.Contains(stderr.String(), "50 lines, 50 written")) // summary
+	writer, err := timeline.NewStorageClient(path)
+	is.NoErr(err)
+	defer writer.Close()
+	var count int
+	is.NoErr(writer.DB.QueryRow("SELECT count(*) FROM logs").Scan(&count))
+	is.Equal(count, 50)
+}
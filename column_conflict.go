@@ -0,0 +1,114 @@
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrColumnConflict is returned by Write for a row with a flattened field that has the name of
+// another field, with ConflictError
+var ErrColumnConflict = errors.New("the flattened field conflicts with another field")
+
+// ColumnConflict is what a writer does with a field of a nested map that gets the name of another
+// field of the row when it is flattened, e.g. user.name and user_name
+type ColumnConflict int32
+
+const (
+	// ConflictRename writes the flattened field to its name with the first free suffix, e.g.
+	// user_name_2
+	ConflictRename ColumnConflict = iota
+	// ConflictError rejects the row with ErrColumnConflict
+	ConflictError
+	// ConflictJSON doesn't flatten the map of the conflicting field, but writes it as JSON to the
+	// column of the map, e.g. user
+	ConflictJSON
+)
+
+// SetColumnConflict sets what happens with the flattened fields of a nested map that conflict
+// with another field, ConflictRename by default. The other fields always keep their names, and
+// the fields of the maps are flattened in the order of their names, so a row is always written
+// the same way. The names are compared case insensitive, like the columns of DuckDB.
+func (w *Writer) SetColumnConflict(mode ColumnConflict) {
+	w.columnConflict.Store(int32(mode))
+}
+
+// flatten flattens the maps of a row into columns (see SetColumnConflict) and applies the
+// aliases of the fields
+func (w *Writer) flatten(row Row) (Row, error) {
+	flattened, err := flattenRow(row, ColumnConflict(w.columnConflict.Load()))
+	if err != nil {
+		return nil, err
+	}
+	return w.applyFieldAliases(flattened), nil
+}
+
+// flattenRow flattens the nested maps of a row into columns named <key>_<field> and encodes the
+// arrays as JSON
+func flattenRow(row Row, mode ColumnConflict) (Row, error) {
+	result := make(Row, len(row))
+	taken := make(map[string]bool, len(row))
+	var maps []string
+	for key, value := range row {
+		if _, ok := value.(map[string]any); ok {
+			maps = append(maps, key)
+			continue
+		}
+		result[key] = flattenValue(value)
+		taken[strings.ToLower(key)] = true
+	}
+	slices.Sort(maps)
+
+	for _, key := range maps {
+		nested := row[key].(map[string]any)
+		fields, err := flattenRow(nested, mode)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		conflict := ""
+		for _, name := range names {
+			if taken[strings.ToLower(key+"_"+name)] {
+				conflict = key + "_" + name
+				break
+			}
+		}
+		switch {
+		case conflict != "" && mode == ConflictError:
+			return nil, fmt.Errorf("%w: %s", ErrColumnConflict, conflict)
+		case conflict != "" && mode == ConflictJSON:
+			result[key] = flattenValue(nested)
+			taken[strings.ToLower(key)] = true
+			continue
+		}
+		for _, name := range names {
+			column := key + "_" + name
+			for i := 2; taken[strings.ToLower(column)]; i++ {
+				column = fmt.Sprintf("%s_%s_%d", key, name, i)
+			}
+			result[column] = fields[name]
+			taken[strings.ToLower(column)] = true
+		}
+	}
+	return result, nil
+}
+
+// flattenValue encodes an array or a map as JSON
+func flattenValue(value any) any {
+	switch value.(type) {
+	case []any, map[string]any:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("%v", value)
+		}
+		return string(encoded)
+	}
+	return value
+}
@@ -0,0 +1,62 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_column_conflict_renames_the_flattened_field_by_default(t *testing.T) {
+	is, writer := setup(t)
+
+	// Given a field and a nested field with the same flattened name
+	row := NewRow(time.Now(), Row{"user_name": "scalar", "user": map[string]any{"name": "nested", "id": 1}})
+
+	// When the row is written
+	is.NoErr(writer.Write("app", row))
+
+	// Then the field keeps its name and the nested field gets a suffix
+	rows, err := writer.Query(context.Background(), "app", QueryOptions{Columns: []string{"user_name", "user_name_2", "user_id"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"user_name": "scalar", "user_name_2": "nested", "user_id": 1}})
+}
+
+func Test_column_conflict_does_not_depend_on_the_order_of_the_fields(t *testing.T) {
+	is := is.New(t)
+	row := Row{
+		"a":   map[string]any{"b_c": "first"},
+		"a_b": map[string]any{"c": "second"},
+		"A_B": "scalar",
+	}
+	for range 20 {
+		flattened, err := flattenRow(row, ConflictRename)
+		is.NoErr(err)
+		is.Equal(flattened, Row{"A_B": "scalar", "a_b_c": "first", "a_b_c_2": "second"})
+	}
+}
+
+func Test_column_conflict_rejects_the_row(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetColumnConflict(ConflictError)
+
+	err := writer.Write("app", NewRow(time.Now(), Row{"user_name": "scalar", "user": map[string]any{"name": "nested"}}))
+	is.True(errors.Is(err, ErrColumnConflict))
+
+	// And rows without conflicts are written
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"user": map[string]any{"name": "nested"}})))
+}
+
+func Test_column_conflict_writes_the_map_as_json(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetColumnConflict(ConflictJSON)
+
+	row := NewRow(time.Now(), Row{"user_name": "scalar", "user": map[string]any{"name": "nested", "id": 1}})
+	is.NoErr(writer.Write("app", row))
+
+	rows, err := writer.Query(context.Background(), "app", QueryOptions{Columns: []string{"user_name", "user"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"user_name": "scalar", "user": `{"id":1,"name":"nested"}`}})
+}
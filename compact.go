@@ -0,0 +1,118 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Compact rewrites a table (or the partitions of a partitioned table) and checkpoints the
+// database, so the blocks of deleted rows are reclaimed. DuckDB reuses the free blocks of a file
+// for new rows, but partly deleted row groups keep their blocks until the table is rewritten. It
+// returns the reclaimed bytes of the used blocks; the file only shrinks when the free blocks are at
+// its end. Rows that are written to the table while it is compacted can fail.
+func (w *Writer) Compact(ctx context.Context, table string) (int64, error) {
+	if w.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	tables := []string{table}
+	if w.isPartitioned(table) {
+		var err error
+		if tables, err = w.partitions(table, time.Time{}, time.Time{}); err != nil {
+			return 0, err
+		}
+	}
+	return w.compact(ctx, tables)
+}
+
+// CompactAll compacts all tables of the database, see Compact
+func (w *Writer) CompactAll(ctx context.Context) (int64, error) {
+	if w.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	tables, err := w.tableNames(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return w.compact(ctx, tables)
+}
+
+// compact rewrites the tables and returns the reclaimed bytes
+func (w *Writer) compact(ctx context.Context, tables []string) (int64, error) {
+	// The blocks of the deleted rows of the WAL are freed by a checkpoint
+	if err := w.Checkpoint(); err != nil {
+		return 0, err
+	}
+	before, err := w.usedSize(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, table := range tables {
+		if err := w.rewriteTable(ctx, table); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := w.Checkpoint(); err != nil {
+		return 0, err
+	}
+	after, err := w.usedSize(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return max(before-after, 0), nil
+}
+
+// rewriteTable replaces a table with a copy of its rows, in chronological order
+func (w *Writer) rewriteTable(ctx context.Context, table string) error {
+	columns, err := w.getCurrentColumns(table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("%w: %s", ErrTableNotFound, table)
+	}
+	orderBy := ""
+	if _, ok := columns["timestamp"]; ok {
+		orderBy = " ORDER BY timestamp"
+	}
+
+	database, name := splitTableName(table)
+	compacted := "_compact_" + name
+	if database != "" {
+		compacted = database + "." + compacted
+	}
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin compaction of %s: %w", table, err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM %s%s", quoteTableName(compacted), quoteTableName(table), orderBy),
+		fmt.Sprintf("DROP TABLE %s", quoteTableName(table)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", quoteTableName(compacted), quoteIdentifier(name)),
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to compact %s: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit compaction of %s: %w", table, err)
+	}
+	return nil
+}
+
+// usedSize returns the size of the used blocks of the database
+func (w *Writer) usedSize(ctx context.Context) (int64, error) {
+	var size int64
+	if err := w.DB.QueryRowContext(ctx, "SELECT used_blocks * block_size FROM pragma_database_size() WHERE database_name = current_database()").Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to get the size of the database: %w", err)
+	}
+	return size, nil
+}
@@ -0,0 +1,57 @@
+package timeline
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_compact_reclaims_the_blocks_of_deleted_rows(t *testing.T) {
+	is := is.New(t)
+	writer, err := NewStorageClient(filepath.Join(t.TempDir(), "test.db"))
+	is.NoErr(err)
+	defer writer.Close()
+
+	// Given a table of which every other row is deleted
+	_, err = writer.DB.Exec("CREATE TABLE logs AS SELECT TIMESTAMP '2025-01-01' + to_seconds(i) AS timestamp, repeat('x', 100) || i AS message FROM range(300000) t(i)")
+	is.NoErr(err)
+	_, err = writer.DB.Exec("DELETE FROM logs WHERE epoch(timestamp)::BIGINT % 2 = 0")
+	is.NoErr(err)
+
+	// When the table is compacted
+	reclaimed, err := writer.Compact(context.Background(), "logs")
+
+	// Then blocks are reclaimed and the rows are kept
+	is.NoErr(err)
+	is.True(reclaimed > 0)
+	columns, err := writer.getCurrentColumns("logs")
+	is.NoErr(err)
+	is.Equal(columns, map[string]ColumnType{"timestamp": Timestamp, "message": Varchar})
+	waitForCount(t, writer, "SELECT count(*) FROM logs", 150000)
+}
+
+func Test_compact_all_compacts_all_tables(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "hello"})))
+	is.NoErr(writer.Write("metrics", NewRow(time.Now(), Row{"value": 1})))
+
+	_, err := writer.CompactAll(context.Background())
+
+	is.NoErr(err)
+	waitForCount(t, writer, "SELECT count(*) FROM logs", 1)
+	waitForCount(t, writer, "SELECT count(*) FROM metrics", 1)
+	tables, err := writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.Equal(tables, []string{"logs", "metrics"})
+}
+
+func Test_compact_unknown_table(t *testing.T) {
+	is, writer := setup(t)
+
+	_, err := writer.Compact(context.Background(), "missing")
+
+	is.True(err != nil)
+}
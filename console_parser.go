@@ -0,0 +1,142 @@
+package timeline
+
+import (
+	"strings"
+)
+
+// consoleLevels maps the level names of the zap and zerolog console encoders to lowercase level names
+var consoleLevels = map[string]string{
+	// zap
+	"DEBUG":  "debug",
+	"INFO":   "info",
+	"WARN":   "warn",
+	"ERROR":  "error",
+	"DPANIC": "dpanic",
+	"PANIC":  "panic",
+	"FATAL":  "fatal",
+	// zerolog
+	"TRC": "trace",
+	"DBG": "debug",
+	"INF": "info",
+	"WRN": "warn",
+	"ERR": "error",
+	"FTL": "fatal",
+	"PNC": "panic",
+}
+
+// parseConsole parses the human readable console encodings of zap and zerolog.
+// zap separates the parts with tabs and ends with the fields as JSON, zerolog separates
+// the parts with spaces, marks the end of the caller with > and ends with key=value fields.
+// Examples:
+//
+//	zap:     2025-09-21T10:00:00.000Z	INFO	server/main.go:42	Server started	{"port": 8080}
+//	zerolog: 2025-09-21T10:00:00Z INF server/main.go:42 > Server started port=8080
+//
+// Fields: timestamp, level, logger (zap), caller, message, and all JSON or key=value fields
+func parseConsole(l string) Row {
+	l = stripAnsiCodes(l)
+
+	var buffer [32]string
+	var parts []string
+	if tab := strings.IndexByte(l, '\t'); tab != -1 {
+		// Lines that don't start with a time skip the split
+		if !looksLikeConsoleTime(l[:tab]) {
+			return nil
+		}
+		parts = strings.Split(l, "\t")
+	} else {
+		parts = appendFields(buffer[:0], l)
+	}
+	if len(parts) < 3 || !looksLikeConsoleTime(parts[0]) {
+		return nil
+	}
+	level, ok := consoleLevels[parts[1]]
+	if !ok {
+		return nil
+	}
+
+	result := make(Row)
+	result["timestamp"] = parts[0]
+	result["level"] = level
+
+	rest := parts[2:]
+	if strings.Contains(l, "\t") {
+		return parseZapConsole(result, rest)
+	}
+	return parseZerologConsole(result, rest)
+}
+
+// parseZapConsole parses the tab separated parts after the level: [logger] [caller] message [json]
+func parseZapConsole(result Row, parts []string) Row {
+	// Trailing JSON fields
+	if last := parts[len(parts)-1]; strings.HasPrefix(last, "{") {
+		if fields := parseJSON(last); fields != nil {
+			for k, v := range fields {
+				result[k] = v
+			}
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	for i, part := range parts {
+		if isGoCaller(part) {
+			if i > 0 {
+				result["logger"] = strings.Join(parts[:i], "\t")
+			}
+			result["caller"] = part
+			parts = parts[i+1:]
+			break
+		}
+	}
+
+	result["message"] = strings.Join(parts, "\t")
+	return result
+}
+
+// parseZerologConsole parses the space separated parts after the level: [caller >] message [key=value...]
+func parseZerologConsole(result Row, parts []string) Row {
+	if len(parts) >= 2 && parts[1] == ">" {
+		result["caller"] = parts[0]
+		parts = parts[2:]
+	} else if len(parts) >= 1 && parts[0] == ">" {
+		parts = parts[1:]
+	}
+
+	// Trailing key=value fields
+	fieldsStart := len(parts)
+	for fieldsStart > 0 && isLogfmtPair(parts[fieldsStart-1]) {
+		fieldsStart--
+	}
+	if fieldsStart < len(parts) {
+		for k, v := range parseLogfmt(strings.Join(parts[fieldsStart:], " ")) {
+			result[k] = v
+		}
+	}
+
+	result["message"] = strings.Join(parts[:fieldsStart], " ")
+	return result
+}
+
+// looksLikeConsoleTime reports whether the value looks like a timestamp (RFC3339 or 3:04PM)
+func looksLikeConsoleTime(value string) bool {
+	return len(value) >= 6 && value[0] >= '0' && value[0] <= '9' && strings.Contains(value, ":")
+}
+
+// isGoCaller reports whether the value looks like a caller, e.g. server/main.go:42
+func isGoCaller(value string) bool {
+	return strings.Contains(value, ".go:") && !strings.Contains(value, " ")
+}
+
+// isLogfmtPair reports whether the value is a single key=value pair with a simple key
+func isLogfmtPair(value string) bool {
+	key, _, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_zap_console_line(t *testing.T) {
+	is := is.New(t)
+	line := "2025-09-21T10:00:00.000Z\tINFO\tserver/main.go:42\tServer started\t{\"port\": 8080, \"tls\": false}"
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["timestamp"], "2025-09-21T10:00:00.000Z")
+	is.Equal(data["level"], "info")
+	is.Equal(data["caller"], "server/main.go:42")
+	is.Equal(data["message"], "Server started")
+	is.Equal(data["port"], 8080)
+	is.Equal(data["tls"], false)
+}
+
+func Test_parse_zap_console_line_with_logger_name(t *testing.T) {
+	is := is.New(t)
+	line := "2025-09-21T10:00:00.000Z\tWARN\thttp\tserver/main.go:42\tSlow request"
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["level"], "warn")
+	is.Equal(data["logger"], "http")
+	is.Equal(data["caller"], "server/main.go:42")
+	is.Equal(data["message"], "Slow request")
+}
+
+func Test_parse_zerolog_console_line(t *testing.T) {
+	is := is.New(t)
+	line := "2025-09-21T10:00:00Z INF server/main.go:42 > Server started port=8080 env=prod"
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["timestamp"], "2025-09-21T10:00:00Z")
+	is.Equal(data["level"], "info")
+	is.Equal(data["caller"], "server/main.go:42")
+	is.Equal(data["message"], "Server started")
+	is.Equal(data["port"], 8080)
+	is.Equal(data["env"], "prod")
+}
+
+func Test_parse_zerolog_console_line_with_colors_and_kitchen_time(t *testing.T) {
+	is := is.New(t)
+	line := "\x1b[90m10:00AM\x1b[0m \x1b[31mERR\x1b[0m Connection lost \x1b[36mretry=\x1b[0m3"
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["timestamp"], "10:00AM")
+	is.Equal(data["level"], "error")
+	is.Equal(data["message"], "Connection lost")
+	is.Equal(data["retry"], 3)
+}
+
+func Test_parse_console_line_with_unknown_level(t *testing.T) {
+	is := is.New(t)
+
+	data := parseConsole("2025-09-21T10:00:00Z NOTICE something happened")
+
+	is.Equal(data, nil)
+}
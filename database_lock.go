@@ -0,0 +1,19 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDatabaseLocked is returned when a database file can't be opened because another process has
+// it open for writing
+var ErrDatabaseLocked = errors.New("database is locked by another process")
+
+// openError wraps an error of opening a database, with ErrDatabaseLocked for lock conflicts
+func openError(dbPath string, err error) error {
+	if strings.Contains(err.Error(), "Could not set lock on file") {
+		return fmt.Errorf("failed to open database %s: %w: %w", dbPath, ErrDatabaseLocked, err)
+	}
+	return fmt.Errorf("failed to open database %s: %w", dbPath, err)
+}
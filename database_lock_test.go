@@ -0,0 +1,102 @@
+package timeline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test_helper_lock_database is not a test, it holds the lock of the database of the other tests
+// until stdin is closed
+func Test_helper_lock_database(t *testing.T) {
+	dbPath := os.Getenv("TIMELINE_LOCK_DATABASE")
+	if dbPath == "" {
+		t.Skip("helper process")
+	}
+	writer, err := NewStorageClient(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	fmt.Println("locked")
+	_, _ = io.Copy(io.Discard, os.Stdin)
+	_ = writer.Close()
+}
+
+// lockDatabase holds the lock of the database in another process, close the writer to release it
+func lockDatabase(t *testing.T, dbPath string) io.WriteCloser {
+	cmd := exec.Command(os.Args[0], "-test.run=^Test_helper_lock_database$")
+	cmd.Env = append(os.Environ(), "TIMELINE_LOCK_DATABASE="+dbPath)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = stdin.Close()
+		_ = cmd.Wait()
+	})
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "locked\n" {
+		t.Fatalf("expected the helper process to lock the database, got %q: %v", line, err)
+	}
+	return stdin
+}
+
+func Test_locked_database_fails_immediately_without_retry(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "locked.db")
+	lockDatabase(t, dbPath)
+	manager := newTestManager()
+	defer manager.Close()
+
+	start := time.Now()
+	_, err := manager.GetOrCreateConnection(dbPath)
+
+	if !errors.Is(err, ErrDatabaseLocked) {
+		t.Fatalf("expected ErrDatabaseLocked, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("expected to fail immediately, took %s", time.Since(start))
+	}
+}
+
+func Test_locked_database_fails_after_the_retry_timeout(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "locked.db")
+	lockDatabase(t, dbPath)
+	manager := NewConnectionManager(WithLockRetry(200*time.Millisecond, 20*time.Millisecond))
+	defer manager.Close()
+
+	_, err := manager.GetOrCreateConnection(dbPath)
+
+	if !errors.Is(err, ErrDatabaseLocked) {
+		t.Fatalf("expected ErrDatabaseLocked, got %v", err)
+	}
+}
+
+func Test_locked_database_is_opened_when_released_within_the_retry_timeout(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "locked.db")
+	lock := lockDatabase(t, dbPath)
+	manager := NewConnectionManager(WithLockRetry(10*time.Second, 20*time.Millisecond))
+	defer manager.Close()
+
+	time.AfterFunc(300*time.Millisecond, func() { _ = lock.Close() })
+	writer, err := manager.GetOrCreateConnection(dbPath)
+
+	if err != nil {
+		t.Fatalf("expected to open the database after the lock is released, got %v", err)
+	}
+	if err := writer.Write("logs", map[string]any{"message": "unlocked"}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+}
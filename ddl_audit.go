@@ -0,0 +1,74 @@
+package timeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ddlAuditTable is the table of SetDDLAudit. Tables with the _timeline_ prefix are internal, they
+// are not returned by Tail, QueryAll, CompactAll and retention without tables.
+const ddlAuditTable = "_timeline_ddl_audit"
+
+// execer executes statements in a database or in a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// triggerRowKey is the context key of the written row that triggers DDL statements
+type triggerRowKey struct{}
+
+// SetDDLAudit records the DDL statements of the writer in the _timeline_ddl_audit table of the
+// database, so the history of the schema travels with the database file. A record has the time,
+// the changed table, the statement, the reason and the SHA-256 hash of the JSON of the written row
+// that triggered the statement (empty for DropTable, RenameColumn and the other migrations). The
+// reason is the kind of the SchemaEvent of the statement, e.g. column_promoted.
+func (w *Writer) SetDDLAudit(enabled bool) {
+	w.ddlAudit.Store(enabled)
+}
+
+// withTriggerRow returns ctx with the written row that can trigger DDL statements
+func (w *Writer) withTriggerRow(ctx context.Context, row Row) context.Context {
+	if !w.ddlAudit.Load() {
+		return ctx
+	}
+	return context.WithValue(ctx, triggerRowKey{}, row)
+}
+
+// execDDL executes a DDL statement of a table and records it when the DDL audit is enabled
+func (w *Writer) execDDL(ctx context.Context, db execer, table string, statement string, reason string) error {
+	if _, err := db.ExecContext(ctx, statement); err != nil {
+		return err
+	}
+	if !w.ddlAudit.Load() {
+		return nil
+	}
+
+	hash := ""
+	if row, ok := ctx.Value(triggerRowKey{}).(Row); ok {
+		encoded, err := json.Marshal(row)
+		if err != nil {
+			// e.g. NaN values, fmt prints the keys of a map sorted as well
+			encoded = []byte(fmt.Sprint(row))
+		}
+		sum := sha256.Sum256(encoded)
+		hash = hex.EncodeToString(sum[:])
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (timestamp TIMESTAMP, table_name VARCHAR, statement VARCHAR, reason VARCHAR, row_hash VARCHAR)", ddlAuditTable)); err != nil {
+		return fmt.Errorf("failed to create the DDL audit table: %w", err)
+	}
+	_, err := db.ExecContext(ctx,
+		fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?, ?, ?)", ddlAuditTable),
+		time.Now().UTC(), table, strings.TrimSpace(statement), reason, hash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to audit the DDL statement: %w", err)
+	}
+	return nil
+}
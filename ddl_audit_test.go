@@ -0,0 +1,93 @@
+package timeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// auditedStatements returns the table, reason and row hash of the audited statements in order
+func auditedStatements(t *testing.T, writer *Writer) [][3]string {
+	t.Helper()
+	rows, err := writer.DB.Query("SELECT table_name, reason, row_hash FROM _timeline_ddl_audit ORDER BY rowid")
+	if err != nil {
+		t.Fatalf("failed to query the audit: %v", err)
+	}
+	defer rows.Close()
+
+	var statements [][3]string
+	for rows.Next() {
+		var statement [3]string
+		if err := rows.Scan(&statement[0], &statement[1], &statement[2]); err != nil {
+			t.Fatalf("failed to scan the audit: %v", err)
+		}
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+func Test_ddl_audit_records_the_statements_of_written_rows(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetDDLAudit(true)
+
+	// When a row creates a table with a column and another row promotes the column
+	first := NewRow(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Row{"status": 200})
+	encoded, err := json.Marshal(first)
+	is.NoErr(err)
+	sum := sha256.Sum256(encoded)
+	firstHash := hex.EncodeToString(sum[:])
+	is.NoErr(writer.Write("app", first))
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"status": "ok"})))
+
+	// Then the statements are recorded with the hash of the row that triggered them
+	statements := auditedStatements(t, writer)
+	is.Equal(len(statements), 3)
+	is.Equal(statements[0], [3]string{"app", "table_created", firstHash})
+	is.Equal(statements[1], [3]string{"app", "column_added", firstHash})
+	is.Equal(statements[2][1], "column_promoted")
+	is.True(statements[2][2] != firstHash)
+
+	var statement string
+	is.NoErr(writer.DB.QueryRow("SELECT statement FROM _timeline_ddl_audit WHERE reason = 'column_added'").Scan(&statement))
+	is.Equal(statement, "ALTER TABLE app ADD COLUMN status UTINYINT")
+}
+
+func Test_ddl_audit_records_migrations_without_row(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"status": 200})))
+	writer.SetDDLAudit(true)
+
+	is.NoErr(writer.RenameColumn(ctx, "app", "status", "code"))
+	is.NoErr(writer.DropTable(ctx, "app"))
+
+	is.Equal(auditedStatements(t, writer), [][3]string{
+		{"app", "column_renamed", ""},
+		{"app", "table_dropped", ""},
+	})
+}
+
+func Test_ddl_audit_table_is_internal(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetDDLAudit(true)
+	now := time.Now()
+	is.NoErr(writer.Write("app", NewRow(now, Row{"message": "hello"})))
+
+	// The audit isn't part of the timeline of all tables
+	rows, err := writer.QueryAll(context.Background(), now.Add(-time.Hour), now.Add(time.Hour), 0)
+	is.NoErr(err)
+	is.Equal(len(rows), 1)
+	is.Equal(rows[0]["_table"], "app")
+}
+
+func Test_ddl_audit_is_disabled_by_default(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+
+	columns, err := writer.getCurrentColumns(ddlAuditTable)
+	is.NoErr(err)
+	is.Equal(len(columns), 0)
+}
@@ -0,0 +1,181 @@
+package timeline
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Logger logs the messages of a writer, *log.Logger implements it
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// DebugMode is what a writer logs of the SQL statements it executes, see SetDebug
+type DebugMode int32
+
+const (
+	// DebugOff logs no statements
+	DebugOff DebugMode = iota
+	// DebugStatements logs the statements without their bound values, which can contain personal
+	// data
+	DebugStatements
+	// DebugValues logs the statements with their bound values
+	DebugValues
+)
+
+// debugState is the debug mode and the logger of a writer, shared with the connections of the
+// writer and of its read pool
+type debugState struct {
+	mode   atomic.Int32
+	logger atomic.Pointer[loggerRef]
+}
+
+// loggerRef holds a Logger, an interface can't be stored in an atomic.Pointer
+type loggerRef struct {
+	Logger
+}
+
+// SetDebug logs every SQL statement the writer executes (also the queries) with its duration
+// and error, to diagnose the generated SQL. The statements are logged to the logger of SetLogger.
+// The credentials of a CREATE SECRET statement (see ConfigureS3) are never logged.
+func (w *Writer) SetDebug(mode DebugMode) {
+	w.debug.mode.Store(int32(mode))
+}
+
+// SetLogger sets the logger of the writer, log.Default() without logger
+func (w *Writer) SetLogger(logger Logger) {
+	if logger == nil {
+		w.debug.logger.Store(nil)
+		return
+	}
+	w.debug.logger.Store(&loggerRef{logger})
+}
+
+// loggerOf returns the logger of the writer
+func (d *debugState) loggerOf() Logger {
+	if ref := d.logger.Load(); ref != nil {
+		return ref.Logger
+	}
+	return log.Default()
+}
+
+// logStatement logs an executed statement in debug mode
+func (d *debugState) logStatement(query string, args []driver.NamedValue, start time.Time, err error) {
+	mode := DebugMode(d.mode.Load())
+	if mode == DebugOff {
+		return
+	}
+
+	message := "timeline: " + redactStatement(strings.Join(strings.Fields(query), " "))
+	if len(args) > 0 {
+		if mode == DebugValues {
+			values := make([]string, len(args))
+			for i, arg := range args {
+				values[i] = fmt.Sprintf("%#v", arg.Value)
+			}
+			message += " [" + strings.Join(values, ", ") + "]"
+		} else {
+			message += fmt.Sprintf(" [%d values elided]", len(args))
+		}
+	}
+	message += fmt.Sprintf(" (%s)", time.Since(start))
+	if err != nil {
+		message += ": " + err.Error()
+	}
+	d.loggerOf().Printf("%s", message)
+}
+
+// secretStatement matches a CREATE SECRET statement up to its options
+var secretStatement = regexp.MustCompile(`(?i)^(CREATE\s+(OR\s+REPLACE\s+)?((PERSISTENT|TEMPORARY)\s+)?SECRET\b[^(]*)\(.*`)
+
+// redactStatement replaces the options of a CREATE SECRET statement, which contain the
+// credentials (see s3SecretStatement), in every debug mode
+func redactStatement(query string) string {
+	return secretStatement.ReplaceAllString(query, "$1(redacted)")
+}
+
+// debugConnector opens the connections of a writer, which log their statements in debug mode
+type debugConnector struct {
+	driver.Connector
+	debug *debugState
+}
+
+func (c debugConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &debugConn{Conn: conn, debug: c.debug}, nil
+}
+
+// Close closes the database of the connector
+func (c debugConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// debugConn is a connection that logs its statements in debug mode
+type debugConn struct {
+	driver.Conn
+	debug *debugState
+}
+
+func (c *debugConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	c.debug.logStatement(query, args, start, err)
+	return result, err
+}
+
+func (c *debugConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.debug.logStatement(query, args, start, err)
+	return rows, err
+}
+
+// PrepareContext logs the prepared statement, without the values of its executions
+func (c *debugConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	c.debug.logStatement("PREPARE "+query, nil, start, err)
+	return stmt, err
+}
+
+func (c *debugConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// CheckNamedValue lets the driver convert the values, DuckDB accepts e.g. maps and slices
+func (c *debugConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
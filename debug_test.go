@@ -0,0 +1,123 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger records the logged messages
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func (l *recordingLogger) containing(text string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var messages []string
+	for _, message := range l.messages {
+		if strings.Contains(message, text) {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+func Test_debug_logs_statements_without_values(t *testing.T) {
+	is, writer := setup(t)
+	logger := &recordingLogger{}
+	writer.SetLogger(logger)
+	writer.SetDebug(DebugStatements)
+
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "secret"})))
+
+	is.Equal(len(logger.containing("timeline: CREATE TABLE app (timestamp TIMESTAMP)")), 1)
+	inserts := logger.containing("timeline: INSERT INTO app")
+	is.Equal(len(inserts), 1)
+	is.True(strings.Contains(inserts[0], "[2 values elided]"))
+	is.Equal(len(logger.containing("secret")), 0)
+}
+
+func Test_debug_logs_statements_with_values_and_errors(t *testing.T) {
+	is, writer := setup(t)
+	logger := &recordingLogger{}
+	writer.SetLogger(logger)
+	writer.SetDebug(DebugValues)
+
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+	_, err := writer.DB.Exec("SELECT * FROM missing")
+	is.True(err != nil)
+
+	is.True(len(logger.containing(`"hello"`)) > 0)
+	failed := logger.containing("timeline: SELECT * FROM missing")
+	is.Equal(len(failed), 1)
+	is.True(strings.Contains(failed[0], "missing does not exist"))
+}
+
+func Test_debug_redacts_the_credentials_of_secrets(t *testing.T) {
+	is, writer := setup(t)
+	logger := &recordingLogger{}
+	writer.SetLogger(logger)
+	writer.SetDebug(DebugValues)
+
+	// The statement may fail without the httpfs extension, it is logged anyway
+	_, _ = writer.DB.Exec(s3SecretStatement(S3Config{KeyID: "AKIAEXAMPLE", Secret: "s3cr3t", SessionToken: "t0ken", Region: "eu-west-1"}))
+
+	is.Equal(len(logger.containing(`timeline: CREATE OR REPLACE SECRET "timeline_s3" (redacted)`)), 1)
+	is.Equal(len(logger.containing("AKIAEXAMPLE")), 0)
+	is.Equal(len(logger.containing("s3cr3t")), 0)
+	is.Equal(len(logger.containing("t0ken")), 0)
+}
+
+func Test_debug_is_off_by_default_and_can_be_turned_off(t *testing.T) {
+	is, writer := setup(t)
+	logger := &recordingLogger{}
+	writer.SetLogger(logger)
+
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+	writer.SetDebug(DebugStatements)
+	writer.SetDebug(DebugOff)
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "world"})))
+
+	is.Equal(len(logger.messages), 0)
+}
+
+func Test_debug_logs_the_queries_of_the_read_pool_and_of_read_only_clients(t *testing.T) {
+	is, writer := setup(t)
+	logger := &recordingLogger{}
+	writer.SetLogger(logger)
+	writer.SetDebug(DebugStatements)
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+
+	_, err := writer.readPool(2).Query(context.Background(), "app", QueryOptions{})
+	is.NoErr(err)
+	is.True(len(logger.containing(`FROM "app"`)) > 0)
+
+	// A read-only client of a database file
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	storage, err := NewStorageClient(dbPath)
+	is.NoErr(err)
+	is.NoErr(storage.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+	is.NoErr(storage.Close())
+
+	reader, err := NewReadOnlyClient(dbPath)
+	is.NoErr(err)
+	defer reader.Close()
+	readerLogger := &recordingLogger{}
+	reader.SetLogger(readerLogger)
+	reader.SetDebug(DebugStatements)
+	_, err = reader.Query(context.Background(), "app", QueryOptions{})
+	is.NoErr(err)
+	is.True(len(readerLogger.containing(`FROM "app"`)) > 0)
+}
@@ -0,0 +1,160 @@
+package timeline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Parser turns a single log entry into a row.
+// Stateful parsers (e.g. parsers that learn a header) keep their state between calls.
+type Parser interface {
+	Parse(l string) Row
+}
+
+// ParserFunc adapts a plain function to the Parser interface
+type ParserFunc func(l string) Row
+
+// Parse calls f(l)
+func (f ParserFunc) Parse(l string) Row {
+	return f(l)
+}
+
+// DelimitedParser parses delimiter-separated log lines (CSV, TSV).
+// The column names are taken from the configured list, or learned from the first line (the header).
+// Fields may be quoted with double quotes, which allows embedded delimiters and escaped quotes ("").
+// Examples:
+//
+//	CSV: 2025-09-21 22:35:12,info,"User logged in, from web",123
+//	TSV: 2025-09-21 22:35:12	info	User logged in	123
+//
+// Fields: one per column, with automatic type conversion for numbers and booleans
+type DelimitedParser struct {
+	delimiter rune
+	columns   []string
+}
+
+// NewDelimitedParser creates a parser for lines separated by the given delimiter.
+// When no columns are given, the first parsed line is used as the header.
+func NewDelimitedParser(delimiter rune, columns ...string) *DelimitedParser {
+	return &DelimitedParser{delimiter: delimiter, columns: columns}
+}
+
+// NewCSVParser creates a parser for comma-separated lines
+func NewCSVParser(columns ...string) *DelimitedParser {
+	return NewDelimitedParser(',', columns...)
+}
+
+// NewTSVParser creates a parser for tab-separated lines
+func NewTSVParser(columns ...string) *DelimitedParser {
+	return NewDelimitedParser('\t', columns...)
+}
+
+// Columns returns the configured or learned column names
+func (p *DelimitedParser) Columns() []string {
+	return p.columns
+}
+
+// Parse maps the values of the line to the column names.
+// Returns nil for the header line and for lines that can't be split.
+func (p *DelimitedParser) Parse(l string) Row {
+	l = strings.TrimRight(l, "\r")
+	if l == "" {
+		return nil
+	}
+
+	fields, ok := splitDelimited(l, p.delimiter)
+	if !ok {
+		return nil
+	}
+
+	// Learn the header
+	if len(p.columns) == 0 {
+		p.columns = make([]string, len(fields))
+		for i, field := range fields {
+			p.columns[i] = normalizeColumnName(field)
+		}
+		return nil
+	}
+
+	result := make(Row)
+	for i, field := range fields {
+		// Ignore values without a column
+		if i >= len(p.columns) {
+			break
+		}
+		if field == "" {
+			continue
+		}
+		result[p.columns[i]] = convertDelimitedValue(field)
+	}
+	return result
+}
+
+// splitDelimited splits a line on the delimiter, honouring double-quoted fields.
+// Returns false when a quoted field is not closed.
+func splitDelimited(l string, delimiter rune) ([]string, bool) {
+	var fields []string
+	var field strings.Builder
+	inQuotes := false
+	runes := []rune(l)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inQuotes && r == '"':
+			// An escaped quote ("") inside a quoted field
+			if i+1 < len(runes) && runes[i+1] == '"' {
+				field.WriteRune('"')
+				i++
+			} else {
+				inQuotes = false
+			}
+		case inQuotes:
+			field.WriteRune(r)
+		case r == '"' && field.Len() == 0:
+			inQuotes = true
+		case r == delimiter:
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, false
+	}
+
+	return append(fields, field.String()), true
+}
+
+// convertDelimitedValue converts numbers and booleans, other values are kept as string
+func convertDelimitedValue(value string) any {
+	if intVal, err := strconv.Atoi(value); err == nil {
+		return intVal
+	}
+	if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+		return floatVal
+	}
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+	return value
+}
+
+// normalizeColumnName turns a header name like "Response Time (ms)" into response_time_ms
+func normalizeColumnName(name string) string {
+	var result strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			result.WriteRune(r)
+			underscore = false
+			continue
+		}
+		if !underscore && result.Len() > 0 {
+			result.WriteRune('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(result.String(), "_")
+}
@@ -0,0 +1,74 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_csv_learns_header_from_first_line(t *testing.T) {
+	is := is.New(t)
+	parser := NewCSVParser()
+
+	header := parser.Parse("Timestamp,Level,Message,Response Time (ms)")
+	data := parser.Parse("2025-09-21 22:35:12,info,User logged in,12.5")
+
+	is.Equal(header, nil)
+	is.Equal(parser.Columns(), []string{"timestamp", "level", "message", "response_time_ms"})
+	is.Equal(data["timestamp"], "2025-09-21 22:35:12")
+	is.Equal(data["level"], "info")
+	is.Equal(data["message"], "User logged in")
+	is.Equal(data["response_time_ms"], 12.5)
+}
+
+func Test_parse_csv_with_configured_columns(t *testing.T) {
+	is := is.New(t)
+	parser := NewCSVParser("level", "status", "cached")
+
+	data := parser.Parse("error,500,true")
+
+	is.Equal(data["level"], "error")
+	is.Equal(data["status"], 500)
+	is.Equal(data["cached"], true)
+}
+
+func Test_parse_csv_with_quoted_fields_and_embedded_delimiters(t *testing.T) {
+	is := is.New(t)
+	parser := NewCSVParser("id", "message")
+
+	data := parser.Parse(`1,"User ""john"" logged in, from web"`)
+
+	is.Equal(data["id"], 1)
+	is.Equal(data["message"], `User "john" logged in, from web`)
+}
+
+func Test_parse_csv_with_unclosed_quote(t *testing.T) {
+	is := is.New(t)
+	parser := NewCSVParser("id", "message")
+
+	data := parser.Parse(`1,"not closed`)
+
+	is.Equal(data, nil)
+}
+
+func Test_parse_csv_skips_empty_values_and_extra_fields(t *testing.T) {
+	is := is.New(t)
+	parser := NewCSVParser("id", "message")
+
+	data := parser.Parse("1,,extra")
+
+	is.Equal(len(data), 1)
+	is.Equal(data["id"], 1)
+}
+
+func Test_parse_tsv_line(t *testing.T) {
+	is := is.New(t)
+	parser := NewTSVParser()
+
+	parser.Parse("date\tpath\tstatus\r")
+	data := parser.Parse("2025-09-21\t/index.html\t200\r")
+
+	is.Equal(data["date"], "2025-09-21")
+	is.Equal(data["path"], "/index.html")
+	is.Equal(data["status"], 200)
+}
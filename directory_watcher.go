@@ -0,0 +1,193 @@
+package timeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirectoryWatcherConfig configures a DirectoryWatcher
+type DirectoryWatcherConfig struct {
+	// Pattern is the glob of the files to follow, e.g. /var/log/app/*.log
+	Pattern string
+	// TableName returns the table for a file, defaults to TableNameFromPath
+	TableName func(path string) string
+	// Parser parses the lines, defaults to ParseLineToValues
+	Parser Parser
+	// OffsetDir persists the read offset of every file (one file per log file, named after the log
+	// file and a hash of its absolute path), see TailerConfig.OffsetFile
+	OffsetDir string
+	// FromStart reads the files that exist when the watcher starts from the beginning.
+	// Files created later are always read from the beginning.
+	FromStart bool
+	// ScanInterval is the time between scans for new and removed files, defaults to 1s
+	ScanInterval time.Duration
+	// PollInterval is passed to the tailers, see TailerConfig.PollInterval
+	PollInterval time.Duration
+	// OnError is called for invalid patterns and the errors of the tailers
+	OnError func(error)
+}
+
+// DirectoryWatcher follows all files that match a glob. A Tailer is started for every new file
+// and stopped when the file is gone. Each file is written to the table derived from its name.
+type DirectoryWatcher struct {
+	writer  *Writer
+	config  DirectoryWatcherConfig
+	mu      sync.Mutex
+	tailers map[string]*watchedFile
+}
+
+// watchedFile is a file with a running tailer
+type watchedFile struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	missing bool
+}
+
+// NewDirectoryWatcher creates a watcher for the files matching config.Pattern, call Run to start watching
+func NewDirectoryWatcher(writer *Writer, config DirectoryWatcherConfig) *DirectoryWatcher {
+	if config.ScanInterval <= 0 {
+		config.ScanInterval = time.Second
+	}
+	if config.TableName == nil {
+		config.TableName = TableNameFromPath
+	}
+	return &DirectoryWatcher{writer: writer, config: config, tailers: make(map[string]*watchedFile)}
+}
+
+// TableNameFromPath derives a table name from the file name: /var/log/app/api-server.log becomes api_server
+func TableNameFromPath(path string) string {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = normalizeColumnName(name)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "log_" + name
+	}
+	return name
+}
+
+// Files returns the paths of the files that are followed
+func (d *DirectoryWatcher) Files() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	paths := make([]string, 0, len(d.tailers))
+	for path := range d.tailers {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Run watches the files until ctx is done, then stops all tailers and returns the error of ctx
+func (d *DirectoryWatcher) Run(ctx context.Context) error {
+	if _, err := filepath.Match(d.config.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %s: %w", d.config.Pattern, err)
+	}
+
+	ticker := time.NewTicker(d.config.ScanInterval)
+	defer ticker.Stop()
+
+	d.scan(ctx, d.config.FromStart)
+	for {
+		select {
+		case <-ctx.Done():
+			d.stopAll()
+			return ctx.Err()
+		case <-ticker.C:
+			d.scan(ctx, true)
+		}
+	}
+}
+
+// scan starts tailers for new files and stops the tailers of files that are gone
+func (d *DirectoryWatcher) scan(ctx context.Context, fromStart bool) {
+	paths, err := filepath.Glob(d.config.Pattern)
+	if err != nil {
+		d.reportError(fmt.Errorf("failed to scan %s: %w", d.config.Pattern, err))
+		return
+	}
+
+	found := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		found[path] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, path := range paths {
+		if watched, exists := d.tailers[path]; exists {
+			watched.missing = false
+			continue
+		}
+		d.tailers[path] = d.start(ctx, path, fromStart)
+	}
+
+	for path, watched := range d.tailers {
+		if found[path] {
+			continue
+		}
+		// A rotated file is often recreated right away, the tailer gets one scan
+		// interval to read the rest of the old file and to pick up the new one
+		if !watched.missing {
+			watched.missing = true
+			continue
+		}
+		watched.cancel()
+		<-watched.done
+		delete(d.tailers, path)
+	}
+}
+
+// start runs a tailer for the file
+func (d *DirectoryWatcher) start(ctx context.Context, path string, fromStart bool) *watchedFile {
+	config := TailerConfig{
+		Table:        d.config.TableName(path),
+		Parser:       d.config.Parser,
+		FromStart:    fromStart,
+		PollInterval: d.config.PollInterval,
+		OnError:      d.config.OnError,
+	}
+	if d.config.OffsetDir != "" {
+		config.OffsetFile = offsetFileOf(d.config.OffsetDir, path)
+	}
+
+	tailerCtx, cancel := context.WithCancel(ctx)
+	watched := &watchedFile{cancel: cancel, done: make(chan struct{})}
+	tailer := NewTailer(d.writer, path, config)
+	go func() {
+		defer close(watched.done)
+		tailer.Run(tailerCtx)
+	}()
+	return watched
+}
+
+// offsetFileOf returns the offset file of a log file in dir: the name of the file with a hash of its
+// absolute path, so the offset files of different paths don't collide (a-b.log and a_b.log)
+func offsetFileOf(dir string, path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+	hash := sha256.Sum256([]byte(filepath.Clean(path)))
+	return filepath.Join(dir, fmt.Sprintf("%s-%x.offset", normalizeColumnName(filepath.Base(path)), hash[:8]))
+}
+
+// stopAll stops all tailers and waits until they are done
+func (d *DirectoryWatcher) stopAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for path, watched := range d.tailers {
+		watched.cancel()
+		<-watched.done
+		delete(d.tailers, path)
+	}
+}
+
+func (d *DirectoryWatcher) reportError(err error) {
+	handleError(d.config.OnError, err, ErrorContext{Component: "directory_watcher"})
+}
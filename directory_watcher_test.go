@@ -0,0 +1,109 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_table_name_from_path(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(TableNameFromPath("/var/log/app/api-server.log"), "api_server")
+	is.Equal(TableNameFromPath("/var/log/Worker.Jobs.log"), "worker_jobs")
+	is.Equal(TableNameFromPath("/var/log/2025.log"), "log_2025")
+}
+
+func Test_directory_watcher_tails_new_files_into_their_own_table(t *testing.T) {
+	is, writer := setup(t)
+	dir := t.TempDir()
+	appendToFile(t, filepath.Join(dir, "api.log"), "level=info n=1\n")
+	appendToFile(t, filepath.Join(dir, "ignored.txt"), "level=info n=1\n")
+
+	// Given a watcher for all .log files
+	watcher := NewDirectoryWatcher(writer, DirectoryWatcherConfig{
+		Pattern:      filepath.Join(dir, "*.log"),
+		FromStart:    true,
+		ScanInterval: 10 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+		OnError:      func(err error) { t.Errorf("watcher error: %v", err) },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// When a new file is created
+	appendToFile(t, filepath.Join(dir, "worker.log"), "level=info n=2\n")
+
+	// Then both files are written to their own table
+	waitForCount(t, writer, `SELECT count(*) FROM api WHERE n = 1`, 1)
+	waitForCount(t, writer, `SELECT count(*) FROM worker WHERE n = 2`, 1)
+	is.Equal(len(watcher.Files()), 2)
+
+	// When a file is removed
+	is.NoErr(os.Remove(filepath.Join(dir, "worker.log")))
+
+	// Then its tailer is stopped
+	deadline := time.Now().Add(5 * time.Second)
+	for len(watcher.Files()) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	is.Equal(watcher.Files(), []string{filepath.Join(dir, "api.log")})
+}
+
+func Test_directory_watcher_tails_files_into_one_new_table_at_the_same_time(t *testing.T) {
+	is, writer := setup(t)
+	dir := t.TempDir()
+	for _, name := range []string{"first.log", "second.log"} {
+		var lines strings.Builder
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(&lines, "level=info file=%s n=%d %s_%d=1\n", name, i, strings.TrimSuffix(name, ".log"), i%10)
+		}
+		appendToFile(t, filepath.Join(dir, name), lines.String())
+	}
+
+	// Given a watcher that writes both files to the same table, which doesn't exist yet
+	watcher := NewDirectoryWatcher(writer, DirectoryWatcherConfig{
+		Pattern:      filepath.Join(dir, "*.log"),
+		TableName:    func(string) string { return "logs" },
+		FromStart:    true,
+		ScanInterval: 10 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+		OnError:      func(err error) { t.Errorf("watcher error: %v", err) },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Then every line of both files is written
+	waitForCount(t, writer, `SELECT count(*) FROM logs`, 200)
+	is.Equal(len(watcher.Files()), 2)
+}
+
+func Test_offset_files_of_different_paths_do_not_collide(t *testing.T) {
+	is := is.New(t)
+
+	is.True(offsetFileOf("/var/lib/timeline", "/var/log/a-b.log") != offsetFileOf("/var/lib/timeline", "/var/log/a_b.log"))
+	is.Equal(offsetFileOf("/var/lib/timeline", "/var/log/app/../a.log"), offsetFileOf("/var/lib/timeline", "/var/log/a.log"))
+	is.True(strings.HasPrefix(offsetFileOf("/var/lib/timeline", "/var/log/a-b.log"), "/var/lib/timeline/a_b_log-"))
+}
@@ -0,0 +1,93 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ExportFormat is the file format of Export
+type ExportFormat string
+
+const (
+	// ExportCSV writes comma separated values with a header
+	ExportCSV ExportFormat = "csv"
+	// ExportNDJSON writes a JSON object per line (JSON Lines)
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// ExportOptions selects the rows of Export
+type ExportOptions struct {
+	// Columns are the exported columns, all columns by default
+	Columns []string
+	// From (inclusive) and To (exclusive) limit the time range, a zero time is unbounded
+	From time.Time
+	To   time.Time
+	// Filters select the rows
+	Filters []Filter
+}
+
+// Export writes the rows of a table to out in chronological order, e.g. for spreadsheets and
+// other tools. The file is written by DuckDB (COPY), so exports of large tables are fast.
+func (w *Writer) Export(ctx context.Context, table string, format ExportFormat, out io.Writer, opts ExportOptions) error {
+	var copyOptions string
+	switch format {
+	case ExportCSV:
+		copyOptions = "FORMAT csv, HEADER true"
+	case ExportNDJSON:
+		copyOptions = "FORMAT json"
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+
+	file, err := os.CreateTemp("", "timeline-export-*."+string(format))
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	// DuckDB replaces the file, so it is opened after COPY
+	if err := w.copyTo(ctx, table, file.Name(), copyOptions, opts); err != nil {
+		return err
+	}
+	exported, err := os.Open(file.Name())
+	if err != nil {
+		return fmt.Errorf("failed to open export file: %w", err)
+	}
+	defer exported.Close()
+	if _, err := io.Copy(out, exported); err != nil {
+		return fmt.Errorf("failed to write export of %s: %w", table, err)
+	}
+	return nil
+}
+
+// copyTo writes the selected rows of a table to a file with COPY
+func (w *Writer) copyTo(ctx context.Context, table string, path string, copyOptions string, opts ExportOptions) error {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	query, args, err := w.selectQuery(table, QueryOptions{
+		Columns: opts.Columns,
+		From:    opts.From,
+		To:      opts.To,
+		Filters: opts.Filters,
+	})
+	if err != nil {
+		return err
+	}
+
+	statement := fmt.Sprintf("COPY (%s) TO %s (%s)", query, quoteString(path), copyOptions)
+	if _, err := w.DB.ExecContext(ctx, statement, args...); err != nil {
+		return fmt.Errorf("failed to export %s: %w", table, err)
+	}
+	return nil
+}
+
+// quoteString quotes a string literal for a query, e.g. a path
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,103 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// RunExportCommand runs the export command of the timeline binary: it writes the rows of a table
+// of a database file as CSV, JSON lines or Parquet to stdout or to the file of --output. args are
+// the arguments after the command name, e.g.
+//
+//	timeline export --db app.db --table logs --from 2024-06-01 > logs.csv
+//	timeline export --db app.db --table logs --format parquet --output logs.parquet
+//
+// The database is opened read-only, which DuckDB doesn't allow while another process writes to
+// it. Returns flag.ErrHelp for -h.
+func RunExportCommand(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: timeline export --db <path> --table <name> [--format csv|ndjson|parquet] [options]")
+		flags.PrintDefaults()
+	}
+	dbPath := flags.String("db", "", "path of the database file (required)")
+	table := flags.String("table", "", "table of the rows (required)")
+	format := flags.String("format", "csv", "file format: csv, ndjson or parquet")
+	from := flags.String("from", "", "oldest timestamp (inclusive): RFC 3339, a date or a duration ago, e.g. 24h")
+	to := flags.String("to", "", "newest timestamp (exclusive): RFC 3339, a date or a duration ago")
+	output := flags.String("output", "", "path of the exported file, stdout by default")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbPath == "" || *table == "" {
+		flags.Usage()
+		return errors.New("the --db and --table flags are required")
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	if *format != string(ExportCSV) && *format != string(ExportNDJSON) && *format != "parquet" {
+		return fmt.Errorf("unsupported export format %q", *format)
+	}
+
+	var opts ExportOptions
+	var err error
+	now := time.Now()
+	if opts.From, err = commandTime(*from, now); err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	if opts.To, err = commandTime(*to, now); err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+
+	writer, err := NewReadOnlyClient(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	if *format == "parquet" {
+		// DuckDB writes Parquet to a file, stdout gets a copy of a temporary file
+		if *output != "" {
+			return writer.ExportParquet(ctx, *table, *output, opts.From, opts.To)
+		}
+		file, err := os.CreateTemp("", "timeline-export-*.parquet")
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		file.Close()
+		defer os.Remove(file.Name())
+		if err := writer.ExportParquet(ctx, *table, file.Name(), opts.From, opts.To); err != nil {
+			return err
+		}
+		exported, err := os.Open(file.Name())
+		if err != nil {
+			return fmt.Errorf("failed to open export file: %w", err)
+		}
+		defer exported.Close()
+		if _, err := io.Copy(stdout, exported); err != nil {
+			return fmt.Errorf("failed to write export of %s: %w", *table, err)
+		}
+		return nil
+	}
+
+	if *output == "" {
+		return writer.Export(ctx, *table, ExportFormat(*format), stdout, opts)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", *output, err)
+	}
+	defer file.Close()
+	if err := writer.Export(ctx, *table, ExportFormat(*format), file, opts); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,61 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_export_command_writes_csv_to_stdout(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	dbPath := commandDatabase(t,
+		NewRow(start, Row{"status": 200}),
+		NewRow(start.Add(24*time.Hour), Row{"status": 500}),
+	)
+	var stdout, stderr bytes.Buffer
+
+	err := RunExportCommand(context.Background(), []string{"--db", dbPath, "--table", "logs", "--from", "2024-06-02"}, nil, &stdout, &stderr)
+
+	is.NoErr(err)
+	is.Equal(stdout.String(), "timestamp,status\n2024-06-02 12:00:00,500\n")
+}
+
+func Test_export_command_writes_parquet_to_a_file(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	dbPath := commandDatabase(t,
+		NewRow(start, Row{"status": 200}),
+		NewRow(start.Add(time.Minute), Row{"status": 500}),
+	)
+	output := filepath.Join(t.TempDir(), "logs.parquet")
+	var stdout, stderr bytes.Buffer
+
+	err := RunExportCommand(context.Background(), []string{"--db", dbPath, "--table", "logs", "--format", "parquet", "--output", output}, nil, &stdout, &stderr)
+	is.NoErr(err)
+	is.Equal(stdout.Len(), 0)
+
+	// Then the file can be imported
+	_, writer := setup(t)
+	imported, err := writer.ImportParquet(context.Background(), "logs", output)
+	is.NoErr(err)
+	is.Equal(imported, 2)
+}
+
+func Test_export_command_validates_flags(t *testing.T) {
+	is := is.New(t)
+	var stdout, stderr bytes.Buffer
+
+	err := RunExportCommand(context.Background(), []string{"--db", "app.db"}, nil, &stdout, &stderr)
+	is.True(err != nil)
+	is.True(strings.Contains(stderr.String(), "Usage: timeline export"))
+
+	err = RunExportCommand(context.Background(), []string{"--db", "app.db", "--table", "logs", "--format", "xml"}, nil, &stdout, &stderr)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "unsupported export format"))
+}
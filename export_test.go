@@ -0,0 +1,51 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_export_csv_with_columns_and_time_range(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("app", NewRow(start, Row{"level": "info", "message": "starting"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(time.Minute), Row{"level": "error", "message": "failed, retrying"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(2*time.Minute), Row{"level": "info", "message": "done"})))
+
+	var out bytes.Buffer
+	err := writer.Export(context.Background(), "app", ExportCSV, &out, ExportOptions{
+		Columns: []string{"level", "message"},
+		From:    start.Add(time.Minute),
+		To:      start.Add(3 * time.Minute),
+	})
+
+	is.NoErr(err)
+	is.Equal(out.String(), "level,message\nerror,\"failed, retrying\"\ninfo,done\n")
+}
+
+func Test_export_ndjson_with_filters(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("requests", NewRow(start, Row{"status": 200, "path": "/"})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(time.Second), Row{"status": 500, "path": "/api"})))
+
+	var out bytes.Buffer
+	err := writer.Export(context.Background(), "requests", ExportNDJSON, &out, ExportOptions{
+		Columns: []string{"status", "path"},
+		Filters: []Filter{Gte("status", 500)},
+	})
+
+	is.NoErr(err)
+	is.Equal(out.String(), "{\"status\":500,\"path\":\"/api\"}\n")
+}
+
+func Test_export_unknown_table(t *testing.T) {
+	is, writer := setup(t)
+
+	err := writer.Export(context.Background(), "missing", ExportCSV, &bytes.Buffer{}, ExportOptions{})
+
+	is.True(errors.Is(err, ErrTableNotFound))
+}
@@ -0,0 +1,58 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Facet is a value of a column with the number of rows that have it
+type Facet struct {
+	Value any
+	Count int
+}
+
+// Facets returns the topN (0 means all) most frequent values of a column of the rows from
+// (inclusive) to (exclusive), e.g. for the levels, services or status codes of a filter dropdown.
+// The most frequent value is first, rows without a value are not counted. A column that doesn't
+// exist (yet) has no facets.
+func (w *Writer) Facets(ctx context.Context, table string, column string, from, to time.Time, topN int) ([]Facet, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	columns, err := w.tableColumns(table)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := columns[column]; !exists {
+		return nil, nil
+	}
+	source, _, err := w.tableSource(table, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	quoted := quoteIdentifier(column)
+	query := fmt.Sprintf("SELECT %s, count(*) AS _count FROM %s WHERE timestamp >= ? AND timestamp < ? AND %s IS NOT NULL GROUP BY %s ORDER BY _count DESC, %s",
+		quoted, source, quoted, quoted, quoted)
+	if topN > 0 {
+		query += fmt.Sprintf(" LIMIT %d", topN)
+	}
+
+	rows, err := w.DB.QueryContext(ctx, query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facets of %s.%s: %w", table, column, err)
+	}
+	defer rows.Close()
+
+	result, err := scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	facets := make([]Facet, len(result))
+	for i, row := range result {
+		count, _ := row["_count"].(int)
+		facets[i] = Facet{Value: row[column], Count: count}
+	}
+	return facets, nil
+}
@@ -0,0 +1,49 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_facets_returns_the_most_frequent_values(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []int{200, 500, 200, 404, 200, 500}
+	for i, status := range statuses {
+		is.NoErr(writer.Write("requests", NewRow(start.Add(time.Duration(i)*time.Minute), Row{"status": status})))
+	}
+	is.NoErr(writer.Write("requests", NewRow(start, Row{"path": "/"})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(time.Hour), Row{"status": 404})))
+
+	facets, err := writer.Facets(context.Background(), "requests", "status", start, start.Add(time.Hour), 2)
+
+	is.NoErr(err)
+	is.Equal(facets, []Facet{{Value: 200, Count: 3}, {Value: 500, Count: 2}})
+}
+
+func Test_facets_of_an_unknown_column(t *testing.T) {
+	is, writer := setup(t)
+	now := time.Now()
+	is.NoErr(writer.Write("requests", NewRow(now, Row{"status": 200})))
+
+	facets, err := writer.Facets(context.Background(), "requests", "service", now.Add(-time.Hour), now.Add(time.Hour), 10)
+
+	is.NoErr(err)
+	is.Equal(len(facets), 0)
+}
+
+func Test_facets_of_a_partitioned_table(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("requests")
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("requests", NewRow(start, Row{"path": "/"})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(24*time.Hour), Row{"status": 500})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(25*time.Hour), Row{"status": 500})))
+
+	// The column only exists in the partition of the second day
+	facets, err := writer.Facets(context.Background(), "requests", "status", start, start.Add(48*time.Hour), 10)
+
+	is.NoErr(err)
+	is.Equal(facets, []Facet{{Value: 500, Count: 2}})
+}
@@ -0,0 +1,122 @@
+package timeline
+
+import (
+	"regexp"
+	"strings"
+)
+
+// embeddedKeyValueRegex finds key=value and key: value fragments in a free-form message
+var embeddedKeyValueRegex = regexp.MustCompile(`(?:^|[\s,;(\[{])([A-Za-z_][\w.\-]*)(=|: )("(?:[^"\\]|\\.)*"|[^\s,;)\]}]+)`)
+
+// LineParser parses lines like ParseLineToValues, with options for the lines that match no format.
+// The zero value behaves exactly like ParseLineToValues.
+type LineParser struct {
+	// ExtractKeyValues scans unmatched lines for embedded key=value and key: value fragments
+	// (Connection from ip=1.2.3.4 port=443 failed) and stores them as extra columns next to the
+	// message. Logfmt lines with more loose words than pairs are sentences then, and are treated
+	// as unmatched lines instead of as logfmt.
+	ExtractKeyValues bool
+
+	// MessageField is the column for unmatched lines, defaults to message
+	MessageField string
+
+	// KeepAnsiCodes stores unmatched lines with their ANSI escape sequences
+	KeepAnsiCodes bool
+
+	// RawField is a column for the original line of lines with ANSI escape sequences, which are
+	// stripped from the message (e.g. message_raw). The line keeps its colors this way, e.g. to
+	// show it in a terminal.
+	RawField string
+
+	// DropUnmatched makes Parse return nil for unmatched lines, so they are not stored at all
+	DropUnmatched bool
+
+	// TagUnparsed adds _unparsed=true to unmatched lines, so they can be found and reprocessed
+	// once a parser for their format exists
+	TagUnparsed bool
+}
+
+// Parse parses the line, see ParseLineToValues. Returns nil for unmatched lines when DropUnmatched is set.
+func (p *LineParser) Parse(l string) Row {
+	result, format := parseLine(l)
+	if format == FormatLogfmt && p.ExtractKeyValues && isLogfmtSentence(l) {
+		result, format = Row{"message": stripAnsiCodes(l)}, FormatPlain
+	}
+	if format != FormatPlain {
+		return p.withRawLine(result, l)
+	}
+
+	if p.DropUnmatched {
+		return nil
+	}
+
+	if p.MessageField != "" || p.KeepAnsiCodes {
+		message := stripAnsiCodes(l)
+		if p.KeepAnsiCodes {
+			message = l
+		}
+		result = Row{p.messageField(): message}
+	}
+
+	if p.TagUnparsed {
+		result["_unparsed"] = true
+	}
+
+	if p.ExtractKeyValues {
+		for k, v := range extractKeyValues(l) {
+			if _, exists := result[k]; !exists {
+				result[k] = v
+			}
+		}
+	}
+	return p.withRawLine(result, l)
+}
+
+// extractKeyValues returns the key=value and key: value fragments of a free-form message.
+// Only lowercase keys are used for the key: value form, to skip sentences like "Error: disk full".
+// Example: Connection from ip=1.2.3.4 port=443 failed, retry: 3
+// Fields: ip, port, retry
+func extractKeyValues(message string) Row {
+	message = stripAnsiCodes(message)
+	matches := embeddedKeyValueRegex.FindAllStringSubmatch(message, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	result := make(Row)
+	for _, match := range matches {
+		key, separator, value := match[1], match[2], match[3]
+		if separator == ": " && key != strings.ToLower(key) {
+			continue
+		}
+
+		if strings.HasPrefix(value, `"`) {
+			quoted, _ := readLogfmtQuoted(value, 0)
+			result[key] = quoted
+			continue
+		}
+
+		// A sentence may end directly after the value
+		value = strings.TrimRight(value, ".:!?")
+		if value == "" {
+			continue
+		}
+		result[key] = convertLogfmtValue(value)
+	}
+	return result
+}
+
+// withRawLine adds the line to the RawField of the result when it has escape sequences
+func (p *LineParser) withRawLine(result Row, l string) Row {
+	if p.RawField != "" && len(result) > 0 && strings.Contains(l, "\x1b") {
+		result[p.RawField] = l
+	}
+	return result
+}
+
+func (p *LineParser) messageField() string {
+	if p.MessageField == "" {
+		return "message"
+	}
+	return p.MessageField
+}
@@ -0,0 +1,118 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_line_parser_extracts_key_values_from_unmatched_line(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{ExtractKeyValues: true}
+	line := `Connection from ip=1.2.3.4 port=443 failed, retry: 3 user="jane doe".`
+
+	data := parser.Parse(line)
+
+	is.Equal(data["message"], line)
+	is.Equal(data["ip"], "1.2.3.4")
+	is.Equal(data["port"], 443)
+	is.Equal(data["retry"], 3)
+	is.Equal(data["user"], "jane doe")
+}
+
+func Test_line_parser_extracts_key_values_from_a_sentence_instead_of_logfmt(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{ExtractKeyValues: true}
+	line := `Connection from ip=1.2.3.4 port=443 failed after retrying`
+
+	data := parser.Parse(line)
+
+	is.Equal(data, Row{"message": line, "ip": "1.2.3.4", "port": 443})
+
+	// Logfmt with a few flags is still logfmt
+	is.Equal(parser.Parse(`level=info msg=hello cached`), Row{"level": "info", "msg": "hello", "cached": true})
+}
+
+func Test_line_parser_skips_capitalized_colon_prefixes(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{ExtractKeyValues: true}
+
+	data := parser.Parse("Error: disk full on http://example.com/status")
+
+	is.Equal(len(data), 1)
+	is.Equal(data["message"], "Error: disk full on http://example.com/status")
+}
+
+func Test_line_parser_does_not_extract_by_default(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{}
+	line := `Connection from 1.2.3.4 failed, retry: 3`
+
+	data := parser.Parse(line)
+
+	is.Equal(data, Row{"message": line})
+}
+
+func Test_line_parser_keeps_matched_formats(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{ExtractKeyValues: true}
+
+	data := parser.Parse(`level=info msg="User login successful" user_id=123`)
+
+	is.Equal(data["user_id"], 123)
+	_, hasMessage := data["message"]
+	is.True(!hasMessage)
+}
+
+func Test_line_parser_custom_message_field_with_ansi_codes(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{MessageField: "raw", KeepAnsiCodes: true}
+	line := "\x1b[31mSomething went wrong\x1b[0m"
+
+	data := parser.Parse(line)
+
+	is.Equal(data, Row{"raw": line})
+}
+
+func Test_line_parser_drops_unmatched_lines(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{DropUnmatched: true}
+
+	is.Equal(parser.Parse("just some text"), nil)
+	is.Equal(parser.Parse(`level=info msg=hello`)["msg"], "hello")
+}
+
+func Test_line_parser_tags_unparsed_lines(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{TagUnparsed: true}
+
+	data := parser.Parse("just some text")
+
+	is.Equal(data, Row{"message": "just some text", "_unparsed": true})
+	_, tagged := parser.Parse(`level=info msg=hello`)["_unparsed"]
+	is.True(!tagged)
+}
+
+func Test_line_parser_keeps_the_raw_line_of_lines_with_escape_sequences(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{RawField: "message_raw"}
+	line := "\x1b[31mdisk full\x1b[0m"
+
+	data := parser.Parse(line)
+
+	is.Equal(data, Row{"message": "disk full", "message_raw": line})
+
+	// Lines without escape sequences don't get the column
+	is.Equal(parser.Parse("disk full"), Row{"message": "disk full"})
+}
+
+func Test_line_parser_keeps_the_raw_line_of_parsed_lines(t *testing.T) {
+	is := is.New(t)
+	parser := LineParser{RawField: "raw"}
+	line := "2025-09-21T10:00:00Z \x1b[32mINF\x1b[0m Server started port=8080"
+
+	data := parser.Parse(line)
+
+	is.Equal(data["level"], "info")
+	is.Equal(data["raw"], line)
+}
@@ -0,0 +1,77 @@
+package timeline
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultFieldAliases are the common spellings of the message, timestamp and level fields of the
+// logging libraries, see SetFieldAliases
+var DefaultFieldAliases = map[string]string{
+	"msg":        "message",
+	"ts":         "timestamp",
+	"time":       "timestamp",
+	"@timestamp": "timestamp",
+	"lvl":        "level",
+	"loglevel":   "level",
+	"log_level":  "level",
+}
+
+// SetFieldAliases renames the fields of the rows that are written from now on before their
+// columns are detected, so the spellings of a field of different formats land in one column,
+// e.g. DefaultFieldAliases writes msg to message. The aliases and the fields are compared case
+// insensitive, so MESSAGE is written to message too. A field isn't renamed when the row already
+// has the column. An alias of timestamp replaces the timestamp of the row when its value is a time:
+// a time.Time, an RFC 3339 string, or unix seconds or milliseconds. nil removes the aliases.
+func (w *Writer) SetFieldAliases(aliases map[string]string) {
+	if aliases == nil {
+		w.aliases.Store(nil)
+		return
+	}
+	lower := make(map[string]string, len(aliases)*2)
+	for alias, column := range aliases {
+		lower[strings.ToLower(alias)] = column
+		// Other cases of the column itself
+		lower[strings.ToLower(column)] = column
+	}
+	w.aliases.Store(&lower)
+}
+
+// applyFieldAliases renames the fields of a row with the aliases of SetFieldAliases
+func (w *Writer) applyFieldAliases(row Row) Row {
+	aliases := w.aliases.Load()
+	if aliases == nil {
+		return row
+	}
+	for field, value := range row {
+		column, ok := (*aliases)[strings.ToLower(field)]
+		if !ok || column == field {
+			continue
+		}
+		if column == "timestamp" {
+			if timestamp, ok := aliasTime(value); ok {
+				row["timestamp"] = timestamp
+				delete(row, field)
+			}
+			continue
+		}
+		if _, exists := row[column]; exists {
+			continue
+		}
+		row[column] = value
+		delete(row, field)
+	}
+	return row
+}
+
+// aliasTime converts the value of an alias of timestamp to a time. Numbers before 2001 are not
+// taken as a unix time, e.g. a time field with a duration.
+func aliasTime(value any) (time.Time, bool) {
+	if timestamp, ok := value.(time.Time); ok {
+		return timestamp, true
+	}
+	if number, ok := toFloat64(value); ok && number < 1e9 {
+		return time.Time{}, false
+	}
+	return webhookTime(value)
+}
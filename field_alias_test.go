@@ -0,0 +1,58 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_field_aliases_write_the_spellings_of_a_field_to_one_column(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetFieldAliases(DefaultFieldAliases)
+
+	// When rows with the spellings of the message and level are written
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"msg": "first", "lvl": "info"})))
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"MESSAGE": "second", "Level": "warn"})))
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "third", "msg": "kept"})))
+
+	// Then they are written to one column each
+	columns, err := writer.getCurrentColumns("app")
+	is.NoErr(err)
+	_, hasLvl := columns["lvl"]
+	is.True(!hasLvl)
+	rows, err := writer.Query(context.Background(), "app", QueryOptions{Columns: []string{"message", "level", "msg"}})
+	is.NoErr(err)
+	is.Equal(rows[0], Row{"message": "first", "level": "info"})
+	is.Equal(rows[1], Row{"message": "second", "level": "warn"})
+	is.Equal(rows[2], Row{"message": "third", "msg": "kept"})
+}
+
+func Test_field_alias_of_the_timestamp_replaces_the_timestamp(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetFieldAliases(DefaultFieldAliases)
+
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"ts": 1646861401.5, "message": "unix"})))
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"@timestamp": "2025-09-14T12:00:00Z", "message": "rfc3339"})))
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"time": 12, "message": "duration"})))
+
+	rows, err := writer.Query(context.Background(), "app", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(rows[0]["timestamp"], time.Date(2022, 3, 9, 21, 30, 1, 500000000, time.UTC))
+	is.Equal(rows[1]["timestamp"], time.Date(2025, 9, 14, 12, 0, 0, 0, time.UTC))
+	is.Equal(rows[2]["time"], 12)
+	_, hasTs := rows[0]["ts"]
+	is.True(!hasTs)
+}
+
+func Test_without_field_aliases_the_fields_are_kept(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetFieldAliases(DefaultFieldAliases)
+	writer.SetFieldAliases(nil)
+
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"msg": "hello"})))
+
+	columns, err := writer.getCurrentColumns("app")
+	is.NoErr(err)
+	_, hasMsg := columns["msg"]
+	is.True(hasMsg)
+}
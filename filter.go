@@ -0,0 +1,108 @@
+package timeline
+
+import "strings"
+
+// Filter is a condition on columns that is compiled to parameterized SQL. Filters are combined
+// with And and Or, e.g.
+//
+//	Eq("status", 500).And(Gte("response_time", 1.0)).Or(Like("path", "/api/%"))
+//
+// is compiled to ((status = ? AND response_time >= ?) OR path LIKE ?).
+type Filter struct {
+	columns   []string
+	condition string
+	args      []any
+}
+
+// compare creates a filter that compares a column with a value
+func compare(column string, operator string, value any) Filter {
+	return Filter{columns: []string{column}, condition: quoteIdentifier(column) + " " + operator + " ?", args: []any{value}}
+}
+
+// Eq selects the rows where the column equals the value
+func Eq(column string, value any) Filter {
+	return compare(column, "=", value)
+}
+
+// Ne selects the rows where the column doesn't equal the value
+func Ne(column string, value any) Filter {
+	return compare(column, "<>", value)
+}
+
+// Gt selects the rows where the column is greater than the value
+func Gt(column string, value any) Filter {
+	return compare(column, ">", value)
+}
+
+// Gte selects the rows where the column is greater than or equal to the value
+func Gte(column string, value any) Filter {
+	return compare(column, ">=", value)
+}
+
+// Lt selects the rows where the column is less than the value
+func Lt(column string, value any) Filter {
+	return compare(column, "<", value)
+}
+
+// Lte selects the rows where the column is less than or equal to the value
+func Lte(column string, value any) Filter {
+	return compare(column, "<=", value)
+}
+
+// Like selects the rows where the column matches the SQL LIKE pattern, e.g. %timeout%
+func Like(column string, pattern string) Filter {
+	return compare(column, "LIKE", pattern)
+}
+
+// In selects the rows where the column equals one of the values
+func In(column string, values ...any) Filter {
+	if len(values) == 0 {
+		return Filter{columns: []string{column}, condition: "FALSE"}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	return Filter{columns: []string{column}, condition: quoteIdentifier(column) + " IN (" + placeholders + ")", args: values}
+}
+
+// IsNull selects the rows without a value in the column
+func IsNull(column string) Filter {
+	return Filter{columns: []string{column}, condition: quoteIdentifier(column) + " IS NULL"}
+}
+
+// Not selects the rows that don't match the filter
+func Not(filter Filter) Filter {
+	return Filter{columns: filter.columns, condition: "NOT (" + filter.condition + ")", args: filter.args}
+}
+
+// And selects the rows that match the filter and the other filter
+func (f Filter) And(other Filter) Filter {
+	return f.combine("AND", other)
+}
+
+// Or selects the rows that match the filter or the other filter
+func (f Filter) Or(other Filter) Filter {
+	return f.combine("OR", other)
+}
+
+func (f Filter) combine(operator string, other Filter) Filter {
+	return Filter{
+		columns:   append(append([]string(nil), f.columns...), other.columns...),
+		condition: "(" + f.condition + " " + operator + " " + other.condition + ")",
+		args:      append(append([]any(nil), f.args...), other.args...),
+	}
+}
+
+// SQL returns the condition and its arguments, e.g. for a query of Iter
+func (f Filter) SQL() (string, []any) {
+	return f.condition, f.args
+}
+
+// filtersSQL combines the filters with AND
+func filtersSQL(filters []Filter) (string, []any) {
+	conditions := make([]string, len(filters))
+	var args []any
+	for i, filter := range filters {
+		conditions[i] = filter.condition
+		args = append(args, filter.args...)
+	}
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,38 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_filter_compiles_to_parameterized_sql(t *testing.T) {
+	is := is.New(t)
+
+	condition, args := Eq("status", 500).And(Gte("response_time", 1.0)).Or(Like("path", "/api/%")).SQL()
+	is.Equal(condition, `(("status" = ? AND "response_time" >= ?) OR "path" LIKE ?)`)
+	is.Equal(args, []any{500, 1.0, "/api/%"})
+
+	condition, args = Not(In("level", "debug", "trace")).And(IsNull("user_id")).SQL()
+	is.Equal(condition, `(NOT ("level" IN (?, ?)) AND "user_id" IS NULL)`)
+	is.Equal(args, []any{"debug", "trace"})
+}
+
+func Test_query_with_composed_filters(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("requests", NewRow(start, Row{"n": 1, "status": 500, "response_time": 0.2, "path": "/"})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(time.Second), Row{"n": 2, "status": 500, "response_time": 1.5, "path": "/"})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(2*time.Second), Row{"n": 3, "status": 200, "response_time": 0.1, "path": "/api/users"})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(3*time.Second), Row{"n": 4, "status": 200, "response_time": 0.1, "path": "/"})))
+
+	rows, err := writer.Query(context.Background(), "requests", QueryOptions{
+		Columns: []string{"n"},
+		Filters: []Filter{Eq("status", 500).And(Gte("response_time", 1.0)).Or(Like("path", "/api/%"))},
+	})
+
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"n": 2}, {"n": 3}})
+}
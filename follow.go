@@ -0,0 +1,70 @@
+package timeline
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// followBuffer is the number of rows a follower can lag behind before rows are dropped
+const followBuffer = 256
+
+// follower is a subscription of Follow
+type follower struct {
+	table string
+	match func(row Row) bool
+	rows  chan Row
+}
+
+// followers are the subscriptions of a Writer
+type followers struct {
+	mu   sync.Mutex
+	subs map[*follower]struct{}
+}
+
+// Follow returns a channel with the rows that are written to a table from now on, e.g. for a live
+// tail. With an empty table name the rows of all tables are delivered, with the name of their
+// table in the _table column. Only rows for which match returns true are delivered (all rows
+// when match is nil). The rows have the flattened columns of the table. Writes never wait for a
+// follower: when a follower lags more than 256 rows behind, new rows are dropped for it. The
+// channel is closed when ctx is done.
+func (w *Writer) Follow(ctx context.Context, table string, match func(row Row) bool) <-chan Row {
+	f := &follower{table: table, match: match, rows: make(chan Row, followBuffer)}
+
+	w.followers.mu.Lock()
+	if w.followers.subs == nil {
+		w.followers.subs = make(map[*follower]struct{})
+	}
+	w.followers.subs[f] = struct{}{}
+	w.followers.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		w.followers.mu.Lock()
+		defer w.followers.mu.Unlock()
+		delete(w.followers.subs, f)
+		close(f.rows)
+	})
+	return f.rows
+}
+
+// publish delivers a written row to the followers of its table
+func (fs *followers) publish(table string, row Row) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for f := range fs.subs {
+		if f.table != "" && f.table != table {
+			continue
+		}
+		delivered := maps.Clone(row)
+		if f.table == "" {
+			delivered["_table"] = table
+		}
+		if f.match != nil && !f.match(delivered) {
+			continue
+		}
+		select {
+		case f.rows <- delivered:
+		default:
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_follow_delivers_new_rows(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("app", NewRow(now, Row{"level": "error", "message": "before"})))
+
+	// Given a follower of the errors of app
+	rows := writer.Follow(ctx, "app", func(row Row) bool { return row["level"] == "error" })
+
+	// When rows are written
+	is.NoErr(writer.Write("app", NewRow(now, Row{"level": "info", "message": "skipped"})))
+	is.NoErr(writer.Write("other", NewRow(now, Row{"level": "error", "message": "other table"})))
+	is.NoErr(writer.Write("app", NewRow(now, Row{"level": "error", "message": "failed", "user": map[string]any{"id": 7}})))
+
+	// Then only the new errors of app are delivered, with flattened columns
+	select {
+	case row := <-rows:
+		is.Equal(row, Row{"timestamp": now, "level": "error", "message": "failed", "user_id": 7})
+	case <-time.After(time.Second):
+		t.Fatal("no row delivered")
+	}
+
+	// And the channel is closed when the context is done
+	cancel()
+	for range rows {
+		t.Fatal("unexpected row")
+	}
+}
+
+func Test_follow_all_tables(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	rows := writer.Follow(ctx, "", nil)
+	is.NoErr(writer.Write("app", NewRow(now, Row{"message": "one"})))
+	is.NoErr(writer.Write("access", NewRow(now, Row{"message": "two"})))
+
+	is.Equal(<-rows, Row{"timestamp": now, "message": "one", "_table": "app"})
+	is.Equal(<-rows, Row{"timestamp": now, "message": "two", "_table": "access"})
+}
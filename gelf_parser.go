@@ -0,0 +1,42 @@
+package timeline
+
+import "strings"
+
+// isGELF reports whether the JSON row is a GELF message (Graylog Extended Log Format)
+func isGELF(data Row) bool {
+	_, hasVersion := data["version"]
+	_, hasHost := data["host"]
+	_, hasMessage := data["short_message"]
+	return hasVersion && hasHost && hasMessage
+}
+
+// normalizeGELF maps a GELF message, as sent by Docker's gelf log driver and the Graylog libraries.
+// The level is a syslog severity, additional fields lose their underscore prefix.
+// Example: {"version":"1.1","host":"web-1","short_message":"Backend timeout","timestamp":1727000000.123,"level":3,"_container_name":"api","_request_id":"abc"}
+// Fields: timestamp, host, message (short_message), full_message, severity (level), facility, file, line,
+// and the additional fields (container_name, request_id, ...)
+func normalizeGELF(data Row) Row {
+	result := make(Row)
+	// Additional fields first, so they can't overwrite the standard fields
+	for k, v := range data {
+		if name, ok := strings.CutPrefix(k, "_"); ok && name != "" {
+			result[name] = v
+		}
+	}
+	for k, v := range data {
+		switch {
+		case k == "version" || strings.HasPrefix(k, "_"):
+		case k == "short_message":
+			result["message"] = v
+		case k == "level":
+			result["severity"] = v
+		case k == "timestamp":
+			if seconds, ok := toFloat64(v); ok {
+				result["timestamp"] = unixFloatToTime(seconds)
+			}
+		default:
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,33 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_gelf_message(t *testing.T) {
+	is := is.New(t)
+	line := `{"version":"1.1","host":"web-1","short_message":"Backend timeout","full_message":"Backend timeout\nat handler","timestamp":1727000000.5,"level":3,"_container_name":"api","_host":"ignored"}`
+
+	data, format, _ := ParseLine(line)
+
+	is.Equal(format, FormatGELF)
+	is.Equal(data["host"], "web-1")
+	is.Equal(data["message"], "Backend timeout")
+	is.Equal(data["full_message"], "Backend timeout\nat handler")
+	is.Equal(data["timestamp"], time.Unix(1727000000, 500000000).UTC())
+	is.Equal(data["severity"], 3)
+	is.Equal(data["container_name"], "api")
+	_, hasVersion := data["version"]
+	is.True(!hasVersion)
+}
+
+func Test_json_with_version_is_not_gelf_without_short_message(t *testing.T) {
+	is := is.New(t)
+
+	_, format, _ := ParseLine(`{"version":"1.2","host":"web-1","message":"deployed"}`)
+
+	is.True(format != FormatGELF)
+}
@@ -0,0 +1,311 @@
+package timeline
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// gelfMaxChunks is the maximum number of chunks of a GELF message
+	gelfMaxChunks = 128
+	// gelfChunkTimeout is the time to receive all chunks of a message, as in the GELF specification
+	gelfChunkTimeout = 5 * time.Second
+	// gelfMaxPendingMessages limits the memory used by incomplete chunked messages
+	gelfMaxPendingMessages = 1024
+	// maxGELFMessageSize limits the size of a decompressed message
+	maxGELFMessageSize = 8 << 20
+)
+
+// gelfChunkMagic starts every chunk of a chunked GELF message
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELFServerConfig configures a GELFServer. Only the listeners with an address are started.
+type GELFServerConfig struct {
+	// UDPAddr is the address of the UDP listener, e.g. :12201
+	UDPAddr string
+	// TCPAddr is the address of the TCP listener, e.g. :12201
+	TCPAddr string
+	// Table is the table for all messages, defaults to gelf
+	Table string
+	// TableFor chooses the table per message, e.g. GELFTableByField("container_name"); overrides Table
+	TableFor func(row Row) string
+	// OnError is called for invalid messages, messages that could not be written and failing connections
+	OnError func(error)
+}
+
+// GELFServer receives GELF messages, e.g. from Docker's gelf log driver, and writes them to tables.
+// UDP messages can be chunked and gzip or zlib compressed, TCP messages are separated by a null byte.
+type GELFServer struct {
+	writer *Writer
+	config GELFServerConfig
+
+	chunksMu sync.Mutex
+	chunks   map[string]*gelfChunkedMessage
+}
+
+// gelfChunkedMessage collects the chunks of a message
+type gelfChunkedMessage struct {
+	chunks   [][]byte
+	received int
+	started  time.Time
+}
+
+// NewGELFServer creates a GELF server, call ListenAndServe to start it
+func NewGELFServer(writer *Writer, config GELFServerConfig) *GELFServer {
+	if config.Table == "" {
+		config.Table = "gelf"
+	}
+	return &GELFServer{writer: writer, config: config, chunks: make(map[string]*gelfChunkedMessage)}
+}
+
+// GELFTableByField writes the messages to the table named after the value of a field, e.g. container_name
+func GELFTableByField(field string) func(row Row) string {
+	return func(row Row) string {
+		if value, ok := row[field].(string); ok {
+			if name := normalizeColumnName(value); name != "" && (name[0] < '0' || name[0] > '9') {
+				return name
+			}
+		}
+		return "gelf"
+	}
+}
+
+// ListenAndServe starts the configured listeners and serves them until ctx is done.
+// Returns the error of ctx, or the first error of a listener.
+func (g *GELFServer) ListenAndServe(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var serves []func() error
+	if g.config.UDPAddr != "" {
+		conn, err := net.ListenPacket("udp", g.config.UDPAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on udp %s: %w", g.config.UDPAddr, err)
+		}
+		serves = append(serves, func() error { return g.ServeUDP(ctx, conn) })
+	}
+	if g.config.TCPAddr != "" {
+		listener, err := net.Listen("tcp", g.config.TCPAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on tcp %s: %w", g.config.TCPAddr, err)
+		}
+		serves = append(serves, func() error { return g.ServeTCP(ctx, listener) })
+	}
+	if len(serves) == 0 {
+		return errors.New("no GELF listener configured")
+	}
+
+	errs := make(chan error, len(serves))
+	for _, serve := range serves {
+		go func(serve func() error) { errs <- serve() }(serve)
+	}
+
+	// The first listener that stops, stops the others
+	err := <-errs
+	cancel()
+	for i := 1; i < len(serves); i++ {
+		<-errs
+	}
+	return err
+}
+
+// ServeUDP handles the datagrams of conn until ctx is done
+func (g *GELFServer) ServeUDP(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read GELF datagram: %w", err)
+		}
+
+		datagram := append([]byte(nil), buf[:n]...)
+		if bytes.HasPrefix(datagram, gelfChunkMagic) {
+			message, complete, err := g.addChunk(datagram)
+			if err != nil {
+				g.reportError(err)
+				continue
+			}
+			if !complete {
+				continue
+			}
+			datagram = message
+		}
+		g.handleMessage(datagram)
+	}
+}
+
+// ServeTCP accepts connections on listener until ctx is done
+func (g *GELFServer) ServeTCP(ctx context.Context, listener net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to accept GELF connection: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn reads the null byte delimited messages of a connection, it is dropped after a
+// message longer than maxGELFMessageSize
+func (g *GELFServer) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	reader := bufio.NewReader(conn)
+	for {
+		message, err := readDelimited(reader, 0, maxGELFMessageSize+1)
+		message = bytes.TrimSuffix(message, []byte{0})
+		if len(bytes.TrimSpace(message)) > 0 {
+			g.handleMessage(message)
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				g.reportError(fmt.Errorf("GELF connection %s: %w", conn.RemoteAddr(), err))
+			}
+			return
+		}
+	}
+}
+
+// addChunk stores a chunk and returns the message when all chunks are received.
+// Chunk: magic (2 bytes), message id (8 bytes), sequence number (1 byte), sequence count (1 byte), payload
+func (g *GELFServer) addChunk(chunk []byte) ([]byte, bool, error) {
+	if len(chunk) < 12 {
+		return nil, false, errors.New("GELF chunk too short")
+	}
+	id := string(chunk[2:10])
+	sequence, count := int(chunk[10]), int(chunk[11])
+	if count == 0 || count > gelfMaxChunks || sequence >= count {
+		return nil, false, fmt.Errorf("invalid GELF chunk %d of %d", sequence, count)
+	}
+
+	g.chunksMu.Lock()
+	defer g.chunksMu.Unlock()
+
+	now := time.Now()
+	message, exists := g.chunks[id]
+	if !exists {
+		g.expireChunks(now)
+		if len(g.chunks) >= gelfMaxPendingMessages {
+			return nil, false, errors.New("too many incomplete GELF messages")
+		}
+		message = &gelfChunkedMessage{chunks: make([][]byte, count), started: now}
+		g.chunks[id] = message
+	}
+	if len(message.chunks) != count {
+		return nil, false, errors.New("GELF chunks with different sequence counts")
+	}
+	if message.chunks[sequence] == nil {
+		message.chunks[sequence] = chunk[12:]
+		message.received++
+	}
+	if message.received < count {
+		return nil, false, nil
+	}
+
+	delete(g.chunks, id)
+	return bytes.Join(message.chunks, nil), true, nil
+}
+
+// expireChunks drops the messages that didn't receive all chunks in time
+func (g *GELFServer) expireChunks(now time.Time) {
+	for id, message := range g.chunks {
+		if now.Sub(message.started) > gelfChunkTimeout {
+			delete(g.chunks, id)
+			g.reportError(fmt.Errorf("GELF message incomplete after %s: received %d of %d chunks", gelfChunkTimeout, message.received, len(message.chunks)))
+		}
+	}
+}
+
+// handleMessage decompresses, parses and writes a message
+func (g *GELFServer) handleMessage(payload []byte) {
+	message, err := decompressGELF(payload)
+	if err != nil {
+		g.reportError(err)
+		return
+	}
+
+	data := parseJSON(string(message))
+	if data == nil {
+		g.reportError(fmt.Errorf("invalid GELF message: %.100q", message))
+		return
+	}
+	row := data
+	if isGELF(data) {
+		row = normalizeGELF(data)
+	}
+
+	table := g.config.Table
+	if g.config.TableFor != nil {
+		table = g.config.TableFor(row)
+	}
+
+	if err := g.writer.Write(table, NewRow(time.Now(), row)); err != nil {
+		g.reportError(fmt.Errorf("failed to write GELF message: %w", err))
+	}
+}
+
+// decompressGELF decompresses gzip and zlib compressed messages, uncompressed messages are returned as is
+func decompressGELF(payload []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch {
+	case len(payload) >= 2 && payload[0] == 0x1f && payload[1] == 0x8b:
+		reader, err = gzip.NewReader(bytes.NewReader(payload))
+	case len(payload) >= 2 && payload[0] == 0x78:
+		reader, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		return payload, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress GELF message: %w", err)
+	}
+	defer reader.Close()
+
+	message, err := io.ReadAll(io.LimitReader(reader, maxGELFMessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress GELF message: %w", err)
+	}
+	if len(message) > maxGELFMessageSize {
+		return nil, fmt.Errorf("GELF message larger than %d bytes", maxGELFMessageSize)
+	}
+	return message, nil
+}
+
+func (g *GELFServer) reportError(err error) {
+	handleError(g.config.OnError, err, ErrorContext{Component: "gelf_server", Table: g.config.Table})
+}
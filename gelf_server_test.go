@@ -0,0 +1,133 @@
+package timeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func startGELFServer(t *testing.T, server *GELFServer) (net.Addr, net.Addr) {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on udp: %v", err)
+	}
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on tcp: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{}, 2)
+	go func() { server.ServeUDP(ctx, udp); done <- struct{}{} }()
+	go func() { server.ServeTCP(ctx, tcp); done <- struct{}{} }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		<-done
+	})
+
+	return udp.LocalAddr(), tcp.Addr()
+}
+
+func Test_gelf_server_reassembles_compressed_udp_chunks(t *testing.T) {
+	is, writer := setup(t)
+	server := NewGELFServer(writer, GELFServerConfig{OnError: func(err error) { t.Errorf("server error: %v", err) }})
+	udpAddr, _ := startGELFServer(t, server)
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`{"version":"1.1","host":"web-1","short_message":"chunked","_n":1}`))
+	gz.Close()
+
+	// Split the message into 3 chunks and send them out of order
+	payload := compressed.Bytes()
+	size := len(payload)/3 + 1
+	var chunks [][]byte
+	for i := 0; i < 3; i++ {
+		part := payload[min(i*size, len(payload)):min((i+1)*size, len(payload))]
+		chunk := append([]byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8, byte(i), 3}, part...)
+		chunks = append(chunks, chunk)
+	}
+
+	conn, err := net.Dial("udp", udpAddr.String())
+	is.NoErr(err)
+	defer conn.Close()
+	for _, i := range []int{2, 0, 1} {
+		_, err := conn.Write(chunks[i])
+		is.NoErr(err)
+	}
+
+	waitForCount(t, writer, `SELECT count(*) FROM gelf WHERE message = 'chunked' AND n = 1`, 1)
+}
+
+func Test_gelf_server_receives_tcp_and_zlib_messages(t *testing.T) {
+	is, writer := setup(t)
+	server := NewGELFServer(writer, GELFServerConfig{TableFor: GELFTableByField("container_name")})
+	udpAddr, tcpAddr := startGELFServer(t, server)
+
+	conn, err := net.Dial("tcp", tcpAddr.String())
+	is.NoErr(err)
+	_, err = conn.Write([]byte(`{"version":"1.1","host":"a","short_message":"one","_container_name":"api"}` + "\x00" +
+		`{"version":"1.1","host":"a","short_message":"two","_container_name":"api"}` + "\x00"))
+	is.NoErr(err)
+	conn.Close()
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write([]byte(`{"version":"1.1","host":"b","short_message":"three","_container_name":"worker"}`))
+	zw.Close()
+	udp, err := net.Dial("udp", udpAddr.String())
+	is.NoErr(err)
+	defer udp.Close()
+	_, err = udp.Write(compressed.Bytes())
+	is.NoErr(err)
+
+	waitForCount(t, writer, `SELECT count(*) FROM api`, 2)
+	waitForCount(t, writer, `SELECT count(*) FROM worker WHERE message = 'three'`, 1)
+}
+
+func Test_gelf_server_drops_a_connection_without_null_bytes(t *testing.T) {
+	is, writer := setup(t)
+	errs := make(chan error, 1)
+	server := NewGELFServer(writer, GELFServerConfig{OnError: func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}})
+	_, tcpAddr := startGELFServer(t, server)
+
+	conn, err := net.Dial("tcp", tcpAddr.String())
+	is.NoErr(err)
+	defer conn.Close()
+
+	// When a client sends more than the size of a message without a null byte
+	go conn.Write(bytes.Repeat([]byte("a"), 2*maxGELFMessageSize))
+
+	// Then the connection is dropped before the rest is read
+	is.True(errors.Is(<-errs, errFrameTooLong))
+	is.NoErr(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	is.True(err != nil && !errors.Is(err, os.ErrDeadlineExceeded)) // closed, not idle
+}
+
+func Test_gelf_server_rejects_invalid_chunks(t *testing.T) {
+	is := is.New(t)
+	server := NewGELFServer(nil, GELFServerConfig{})
+
+	_, _, err := server.addChunk([]byte{0x1e, 0x0f, 1})
+	is.True(err != nil)
+	_, _, err = server.addChunk([]byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8, 3, 3})
+	is.True(err != nil)
+	_, complete, err := server.addChunk([]byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8, 0, 2, '{'})
+	is.NoErr(err)
+	is.True(!complete)
+}
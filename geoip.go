@@ -0,0 +1,135 @@
+package timeline
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// GeoIP resolves IP addresses to their country, city and autonomous system with MaxMind databases
+// (.mmdb), e.g. the free GeoLite2-City and GeoLite2-ASN databases
+type GeoIP struct {
+	databases []*mmdb
+}
+
+// GeoIPLocation is the location of an IP address, the fields that are not in the databases are empty
+type GeoIPLocation struct {
+	// Country is the ISO 3166-1 code of the country, e.g. NL
+	Country string
+	// City is the English name of the city
+	City string
+	// ASN is the number of the autonomous system, e.g. 15169
+	ASN int
+	// ASOrganization is the organization of the autonomous system, e.g. Google LLC
+	ASOrganization string
+}
+
+// OpenGeoIP reads MaxMind databases into memory, e.g. a City (or Country) and an ASN database.
+// Lookup combines the fields of the databases.
+func OpenGeoIP(paths ...string) (*GeoIP, error) {
+	g := &GeoIP{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GeoIP database: %w", err)
+		}
+		db, err := openMMDB(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+		}
+		g.databases = append(g.databases, db)
+	}
+	return g, nil
+}
+
+// Lookup returns the location of an IP address. An address with a port (1.2.3.4:80) and the first
+// address of an X-Forwarded-For list are resolved too. Returns false for invalid addresses and
+// addresses without a location, e.g. private addresses.
+func (g *GeoIP) Lookup(ip string) (GeoIPLocation, bool) {
+	address, ok := parseGeoIPAddress(ip)
+	if !ok {
+		return GeoIPLocation{}, false
+	}
+
+	var location GeoIPLocation
+	found := false
+	for _, db := range g.databases {
+		record, err := db.lookup(address)
+		fields, ok := record.(map[string]any)
+		if err != nil || !ok {
+			continue
+		}
+		found = true
+		if country, ok := mmdbPath(fields, "country", "iso_code").(string); ok && location.Country == "" {
+			location.Country = country
+		}
+		if city, ok := mmdbPath(fields, "city", "names", "en").(string); ok && location.City == "" {
+			location.City = city
+		}
+		if asn, ok := fields["autonomous_system_number"].(uint64); ok && location.ASN == 0 {
+			location.ASN = int(asn)
+		}
+		if organization, ok := fields["autonomous_system_organization"].(string); ok && location.ASOrganization == "" {
+			location.ASOrganization = organization
+		}
+	}
+	return location, found
+}
+
+// geoIPColumns are the columns of the IP addresses of the parsers
+var geoIPColumns = []string{"remote_host", "client_ip", "forwarded_for"}
+
+// EnrichGeoIP adds the location of the IP addresses of the columns (by default remote_host,
+// client_ip and forwarded_for) to the rows: the columns <column>_country, <column>_city,
+// <column>_asn and <column>_as_org, e.g. remote_host_country. Fields without a value are not added.
+func EnrichGeoIP(geoip *GeoIP, columns ...string) Transformer {
+	if len(columns) == 0 {
+		columns = geoIPColumns
+	}
+	return func(row Row) Row {
+		for _, column := range columns {
+			ip, ok := row[column].(string)
+			if !ok {
+				continue
+			}
+			location, ok := geoip.Lookup(ip)
+			if !ok {
+				continue
+			}
+			setIfPresent(row, column+"_country", location.Country)
+			setIfPresent(row, column+"_city", location.City)
+			if location.ASN != 0 {
+				row[column+"_asn"] = location.ASN
+			}
+			setIfPresent(row, column+"_as_org", location.ASOrganization)
+		}
+		return row
+	}
+}
+
+// parseGeoIPAddress parses an IP address, with a port or as the first address of a list
+func parseGeoIPAddress(ip string) (netip.Addr, bool) {
+	ip, _, _ = strings.Cut(ip, ",")
+	ip = strings.TrimSpace(ip)
+	if address, err := netip.ParseAddr(ip); err == nil {
+		return address, true
+	}
+	if addressPort, err := netip.ParseAddrPort(ip); err == nil {
+		return addressPort.Addr(), true
+	}
+	return netip.Addr{}, false
+}
+
+// mmdbPath returns the value of nested maps of a record
+func mmdbPath(record map[string]any, keys ...string) any {
+	var value any = record
+	for _, key := range keys {
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = fields[key]
+	}
+	return value
+}
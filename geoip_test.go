@@ -0,0 +1,81 @@
+package timeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// testGeoIP opens a City and an ASN test database
+func testGeoIP(t *testing.T) *GeoIP {
+	t.Helper()
+	dir := t.TempDir()
+	city := filepath.Join(dir, "City.mmdb")
+	asn := filepath.Join(dir, "ASN.mmdb")
+	err := os.WriteFile(city, buildMMDB(t, 6, 28, mmdbNetwork{"81.2.69.0/24", map[string]any{
+		"city":    map[string]any{"names": map[string]any{"en": "London", "de": "London"}},
+		"country": map[string]any{"iso_code": "GB", "names": map[string]any{"en": "United Kingdom"}},
+	}}), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(asn, buildMMDB(t, 6, 24, mmdbNetwork{"81.2.0.0/16", map[string]any{
+		"autonomous_system_number":       uint64(20712),
+		"autonomous_system_organization": "Andrews & Arnold Ltd",
+	}}), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	geoip, err := OpenGeoIP(city, asn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return geoip
+}
+
+func Test_geoip_combines_the_fields_of_the_databases(t *testing.T) {
+	is := is.New(t)
+	geoip := testGeoIP(t)
+
+	location, ok := geoip.Lookup("81.2.69.142")
+	is.True(ok)
+	is.Equal(location, GeoIPLocation{Country: "GB", City: "London", ASN: 20712, ASOrganization: "Andrews & Arnold Ltd"})
+
+	// Only in the ASN database
+	location, ok = geoip.Lookup("81.2.1.1:443")
+	is.True(ok)
+	is.Equal(location, GeoIPLocation{ASN: 20712, ASOrganization: "Andrews & Arnold Ltd"})
+
+	_, ok = geoip.Lookup("10.0.0.1")
+	is.True(!ok)
+	_, ok = geoip.Lookup("unknown")
+	is.True(!ok)
+}
+
+func Test_enrich_geoip_adds_the_location_columns(t *testing.T) {
+	is := is.New(t)
+	enrich := EnrichGeoIP(testGeoIP(t))
+
+	row := enrich(Row{"remote_host": "10.0.0.1", "forwarded_for": "81.2.69.142, 10.0.0.2", "status": 200})
+
+	is.Equal(row, Row{
+		"remote_host":           "10.0.0.1",
+		"forwarded_for":         "81.2.69.142, 10.0.0.2",
+		"forwarded_for_country": "GB",
+		"forwarded_for_city":    "London",
+		"forwarded_for_asn":     20712,
+		"forwarded_for_as_org":  "Andrews & Arnold Ltd",
+		"status":                200,
+	})
+}
+
+func Test_open_geoip_rejects_other_files(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "City.mmdb")
+	is.NoErr(os.WriteFile(path, []byte("not a database"), 0o644))
+
+	_, err := OpenGeoIP(path)
+	is.True(err != nil)
+}
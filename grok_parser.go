@@ -0,0 +1,203 @@
+package timeline
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// grokPatterns is the standard grok pattern library, written for Go's RE2 regular expressions
+var grokPatterns = map[string]string{
+	"USERNAME":          `[a-zA-Z0-9._-]+`,
+	"USER":              `%{USERNAME}`,
+	"EMAILLOCALPART":    `[a-zA-Z0-9!#$%&'*+\-/=?^_{|}~]+(?:\.[a-zA-Z0-9!#$%&'*+\-/=?^_{|}~]+)*`,
+	"EMAILADDRESS":      `%{EMAILLOCALPART}@%{HOSTNAME}`,
+	"INT":               `[+-]?\d+`,
+	"BASE10NUM":         `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`,
+	"NUMBER":            `%{BASE10NUM}`,
+	"BASE16NUM":         `[+-]?(?:0x)?[0-9A-Fa-f]+`,
+	"POSINT":            `[1-9]\d*`,
+	"NONNEGINT":         `\d+`,
+	"WORD":              `\w+`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+	"QS":                `%{QUOTEDSTRING}`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"MAC":               `(?:[A-Fa-f0-9]{2}[:-]){5}[A-Fa-f0-9]{2}|(?:[A-Fa-f0-9]{4}\.){2}[A-Fa-f0-9]{4}`,
+	"IPV4":              `(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)`,
+	"IPV6":              `(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}(?:%[0-9A-Za-z]+)?`,
+	"IP":                `%{IPV6}|%{IPV4}`,
+	"HOSTNAME":          `[0-9A-Za-z](?:[0-9A-Za-z-]{0,62})(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?`,
+	"IPORHOST":          `%{IP}|%{HOSTNAME}`,
+	"HOSTPORT":          `%{IPORHOST}:%{POSINT}`,
+	"UNIXPATH":          `(?:/[\w_%!$@:.,+~-]*)+`,
+	"WINPATH":           `(?:[A-Za-z]+:|\\)(?:\\[^\\?*]*)+`,
+	"PATH":              `%{UNIXPATH}|%{WINPATH}`,
+	"URIPROTO":          `[A-Za-z](?:[A-Za-z0-9+\-.]+)+`,
+	"URIHOST":           `%{IPORHOST}(?::%{POSINT})?`,
+	"URIPATH":           `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":          `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM":      `%{URIPATH}(?:%{URIPARAM})?`,
+	"URI":               `%{URIPROTO}://(?:%{USER}(?::[^@]*)?@)?(?:%{URIHOST})?(?:%{URIPATHPARAM})?`,
+	"MONTH":             `\b(?:[Jj]an(?:uary|uar)?|[Ff]eb(?:ruary|ruar)?|[Mm](?:a|ä)?r(?:ch|z)?|[Aa]pr(?:il)?|[Mm]a(?:y|i)?|[Jj]un(?:e|i)?|[Jj]ul(?:y|i)?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo](?:c|k)?t(?:ober)?|[Nn]ov(?:ember)?|[Dd]e(?:c|z)(?:ember)?)\b`,
+	"MONTHNUM":          `0?[1-9]|1[0-2]`,
+	"MONTHDAY":          `(?:0[1-9])|(?:[12][0-9])|(?:3[01])|[1-9]`,
+	"DAY":               `Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?`,
+	"YEAR":              `\d\d(?:\d\d)?`,
+	"HOUR":              `2[0123]|[01]?[0-9]`,
+	"MINUTE":            `[0-5][0-9]`,
+	"SECOND":            `(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"DATE_US":           `%{MONTHNUM}[/-]%{MONTHDAY}[/-]%{YEAR}`,
+	"DATE_EU":           `%{MONTHDAY}[./-]%{MONTHNUM}[./-]%{YEAR}`,
+	"ISO8601_TIMEZONE":  `Z|[+-]%{HOUR}(?::?%{MINUTE})`,
+	"ISO8601_SECOND":    `%{SECOND}|60`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+	"DATE":              `%{DATE_US}|%{DATE_EU}`,
+	"DATESTAMP":         `%{DATE}[- ]%{TIME}`,
+	"TZ":                `[A-Z]{3}`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+	"PROG":              `[\x21-\x5a\x5c\x5e-\x7e]+`,
+	"SYSLOGPROG":        `%{PROG:program}(?:\[%{POSINT:pid}\])?`,
+	"SYSLOGHOST":        `%{IPORHOST}`,
+	"SYSLOGFACILITY":    `<%{NONNEGINT:facility}.%{NONNEGINT:priority}>`,
+	"SYSLOGBASE":        `%{SYSLOGTIMESTAMP:timestamp} (?:%{SYSLOGFACILITY} )?%{SYSLOGHOST:logsource} %{SYSLOGPROG}:`,
+	"SYSLOGLINE":        `%{SYSLOGBASE} %{GREEDYDATA:message}`,
+	"LOGLEVEL":          `[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo(?:rmation)?|INFO(?:RMATION)?|[Ww]arn(?:ing)?|WARN(?:ING)?|[Ee]rr(?:or)?|ERR(?:OR)?|[Cc]rit(?:ical)?|CRIT(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|EMERG(?:ENCY)?|[Ee]merg(?:ency)?`,
+	"COMMONAPACHELOG":   `%{IPORHOST:clientip} %{USER:ident} %{USER:auth} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:verb} %{NOTSPACE:request}(?: HTTP/%{NUMBER:httpversion})?|%{DATA:rawrequest})" %{NUMBER:response:int} (?:%{NUMBER:bytes:int}|-)`,
+	"COMBINEDAPACHELOG": `%{COMMONAPACHELOG} %{QS:referrer} %{QS:agent}`,
+}
+
+// grokReferenceRegex matches %{PATTERN}, %{PATTERN:field} and %{PATTERN:field:type}
+var grokReferenceRegex = regexp.MustCompile(`%\{(\w+)(?::([\w.\-\[\]@]+))?(?::(int|float|string))?\}`)
+
+// grokMaxDepth protects against patterns that (indirectly) reference themselves
+const grokMaxDepth = 32
+
+// grokField is a named capture of a grok expression
+type grokField struct {
+	column    string
+	valueType string
+}
+
+// GrokParser parses lines with a grok expression such as %{COMBINEDAPACHELOG}.
+// Grok expressions combine named regular expressions from the standard pattern library
+// (and custom patterns) so parsers can be defined declaratively.
+// Example:
+//
+//	Expression: %{TIMESTAMP_ISO8601:timestamp} %{LOGLEVEL:level} \[%{DATA:thread}\] %{GREEDYDATA:message}
+//	Line:       2025-09-21T10:00:00Z INFO [main] Application started
+//
+// Fields: one column per named capture; values with the :int or :float suffix are converted to numbers
+type GrokParser struct {
+	regex  *regexp.Regexp
+	fields map[string]grokField
+}
+
+// NewGrokParser compiles the grok expression. Custom patterns extend (or override) the standard library.
+func NewGrokParser(expression string, customPatterns map[string]string) (*GrokParser, error) {
+	patterns := make(map[string]string, len(grokPatterns)+len(customPatterns))
+	for name, pattern := range grokPatterns {
+		patterns[name] = pattern
+	}
+	for name, pattern := range customPatterns {
+		patterns[name] = pattern
+	}
+
+	parser := &GrokParser{fields: make(map[string]grokField)}
+	compiled, err := parser.expand(expression, patterns, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grok expression %q: %w", expression, err)
+	}
+
+	regex, err := regexp.Compile(`^` + compiled + `$`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile grok expression %q: %w", expression, err)
+	}
+	parser.regex = regex
+
+	return parser, nil
+}
+
+// Parse maps the named captures of the line to columns, returns nil when the line doesn't match
+func (p *GrokParser) Parse(l string) Row {
+	match := p.regex.FindStringSubmatch(strings.TrimRight(l, "\r\n"))
+	if match == nil {
+		return nil
+	}
+
+	result := make(Row)
+	for i, name := range p.regex.SubexpNames() {
+		field, ok := p.fields[name]
+		if !ok || match[i] == "" {
+			continue
+		}
+		value := match[i]
+		switch field.valueType {
+		case "int":
+			if number, err := strconv.Atoi(value); err == nil {
+				result[field.column] = number
+				continue
+			}
+		case "float":
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				result[field.column] = number
+				continue
+			}
+		}
+		// Quoted strings are stored without quotes
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		result[field.column] = value
+	}
+	return result
+}
+
+// expand replaces all pattern references with their regular expression
+func (p *GrokParser) expand(expression string, patterns map[string]string, depth int) (string, error) {
+	if depth > grokMaxDepth {
+		return "", fmt.Errorf("patterns are nested too deep (recursive pattern?)")
+	}
+
+	var expandErr error
+	result := grokReferenceRegex.ReplaceAllStringFunc(expression, func(reference string) string {
+		if expandErr != nil {
+			return ""
+		}
+		parts := grokReferenceRegex.FindStringSubmatch(reference)
+		pattern, ok := patterns[parts[1]]
+		if !ok {
+			expandErr = fmt.Errorf("unknown pattern %s", parts[1])
+			return ""
+		}
+		inner, err := p.expand(pattern, patterns, depth+1)
+		if err != nil {
+			expandErr = err
+			return ""
+		}
+		if parts[2] == "" {
+			return `(?:` + inner + `)`
+		}
+
+		// Go requires simple group names, the field name is kept separately
+		group := fmt.Sprintf("grok%d", len(p.fields))
+		p.fields[group] = grokField{column: normalizeGrokFieldName(parts[2]), valueType: parts[3]}
+		return `(?P<` + group + `>` + inner + `)`
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return result, nil
+}
+
+// normalizeGrokFieldName turns ECS style names like [http][request][method] or http.request.method into http_request_method
+func normalizeGrokFieldName(name string) string {
+	name = strings.NewReplacer("][", "_", "[", "", "]", "", ".", "_", "-", "_", "@", "").Replace(name)
+	return strings.Trim(name, "_")
+}
@@ -0,0 +1,75 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_grok_parses_combined_apache_log(t *testing.T) {
+	is := is.New(t)
+	parser, err := NewGrokParser(`%{COMBINEDAPACHELOG}`, nil)
+	is.NoErr(err)
+
+	data := parser.Parse(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`)
+
+	is.Equal(data["clientip"], "127.0.0.1")
+	is.Equal(data["ident"], "-")
+	is.Equal(data["auth"], "frank")
+	is.Equal(data["timestamp"], "10/Oct/2000:13:55:36 -0700")
+	is.Equal(data["verb"], "GET")
+	is.Equal(data["request"], "/apache_pb.gif")
+	is.Equal(data["httpversion"], "1.0")
+	is.Equal(data["response"], 200)
+	is.Equal(data["bytes"], 2326)
+	is.Equal(data["referrer"], "http://www.example.com/start.html")
+	is.Equal(data["agent"], "Mozilla/4.08")
+}
+
+func Test_grok_parses_syslog_line(t *testing.T) {
+	is := is.New(t)
+	parser, err := NewGrokParser(`%{SYSLOGLINE}`, nil)
+	is.NoErr(err)
+
+	data := parser.Parse(`Sep 21 10:00:00 web-1 sshd[4242]: Accepted publickey for deploy`)
+
+	is.Equal(data["timestamp"], "Sep 21 10:00:00")
+	is.Equal(data["logsource"], "web-1")
+	is.Equal(data["program"], "sshd")
+	is.Equal(data["pid"], "4242")
+	is.Equal(data["message"], "Accepted publickey for deploy")
+}
+
+func Test_grok_with_custom_patterns_and_types(t *testing.T) {
+	is := is.New(t)
+	parser, err := NewGrokParser(
+		`%{TIMESTAMP_ISO8601:timestamp} %{LOGLEVEL:level} order=%{ORDER_ID:[order][id]} took=%{NUMBER:duration_ms:float}ms`,
+		map[string]string{"ORDER_ID": `ORD-\d+`},
+	)
+	is.NoErr(err)
+
+	data := parser.Parse(`2025-09-21T10:00:00Z WARN order=ORD-1234 took=12.5ms`)
+
+	is.Equal(data["timestamp"], "2025-09-21T10:00:00Z")
+	is.Equal(data["level"], "WARN")
+	is.Equal(data["order_id"], "ORD-1234")
+	is.Equal(data["duration_ms"], 12.5)
+}
+
+func Test_grok_returns_nil_when_line_does_not_match(t *testing.T) {
+	is := is.New(t)
+	parser, err := NewGrokParser(`%{IP:client} %{WORD:method}`, nil)
+	is.NoErr(err)
+
+	is.Equal(parser.Parse(`not an ip address`), nil)
+}
+
+func Test_grok_rejects_unknown_and_recursive_patterns(t *testing.T) {
+	is := is.New(t)
+
+	_, err := NewGrokParser(`%{DOES_NOT_EXIST:field}`, nil)
+	is.True(err != nil)
+
+	_, err = NewGrokParser(`%{LOOP}`, map[string]string{"LOOP": `a%{LOOP}`})
+	is.True(err != nil)
+}
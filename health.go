@@ -0,0 +1,77 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrClosed is returned by Healthy for a closed writer
+var ErrClosed = errors.New("the writer is closed")
+
+// maxHealthyWALSize is the size of a WAL from which the database is unhealthy. DuckDB
+// checkpoints at CheckpointThreshold (16MB by default), a larger WAL means that checkpoints fail.
+const maxHealthyWALSize = 1 << 30
+
+// Healthy checks the writer for a readiness probe: the writer isn't closed, the database answers
+// a query, the database file still exists, its WAL is checkpointed and the database doesn't
+// exceed its quota (see SetQuota). The checks are cheap, the size for the quota is measured at
+// most once per Interval of the quota.
+func (w *Writer) Healthy(ctx context.Context) error {
+	if w.closed.Load() {
+		return ErrClosed
+	}
+
+	var one int
+	if err := w.DB.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("the database doesn't answer: %w", err)
+	}
+
+	path, err := w.databasePath(ctx)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("the database file is missing: %w", err)
+		}
+		if info, err := os.Stat(path + ".wal"); err == nil && info.Size() > maxHealthyWALSize {
+			return fmt.Errorf("the WAL of %s isn't checkpointed, it is %d bytes", path, info.Size())
+		}
+	}
+
+	if !w.readOnly {
+		if err := w.checkQuota(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// databasePath returns the path of the database file, empty for an in-memory database
+func (w *Writer) databasePath(ctx context.Context) (string, error) {
+	var path string
+	if err := w.DB.QueryRowContext(ctx, "SELECT coalesce(path, '') FROM duckdb_databases() WHERE database_name = current_database()").Scan(&path); err != nil {
+		return "", fmt.Errorf("failed to get the path of the database: %w", err)
+	}
+	return path, nil
+}
+
+// Healthy checks the open connections (see Writer.Healthy), the error joins the errors of the
+// unhealthy connections
+func (m *TimelineConnectionManager) Healthy(ctx context.Context) error {
+	var errs []error
+	for _, dbPath := range m.ListConnections() {
+		lease, err := m.Acquire(dbPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := lease.Healthy(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s is unhealthy: %w", dbPath, err))
+		}
+		lease.Release()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_healthy_writer(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+
+	is.NoErr(writer.Healthy(context.Background()))
+}
+
+func Test_closed_writer_is_unhealthy(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Close())
+
+	is.True(errors.Is(writer.Healthy(context.Background()), ErrClosed))
+}
+
+func Test_writer_of_a_removed_database_file_is_unhealthy(t *testing.T) {
+	is := is.New(t)
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	writer, err := NewStorageClient(dbPath)
+	is.NoErr(err)
+	defer writer.Close()
+	is.NoErr(writer.Healthy(context.Background()))
+
+	// When the file is removed underneath the writer
+	is.NoErr(os.Remove(dbPath))
+
+	err = writer.Healthy(context.Background())
+	is.True(errors.Is(err, os.ErrNotExist))
+}
+
+func Test_writer_that_exceeds_its_quota_is_unhealthy(t *testing.T) {
+	is, writer, size := bigDatabase(t)
+	writer.SetQuota(QuotaConfig{MaxBytes: size / 2, Interval: time.Millisecond})
+
+	is.True(errors.Is(writer.Healthy(context.Background()), ErrQuotaExceeded))
+}
+
+func Test_manager_is_unhealthy_when_a_connection_is_unhealthy(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	manager := newTestManager()
+	defer manager.Close()
+
+	// Given two connections
+	_, err := manager.GetOrCreateConnection(filepath.Join(dir, "a.db"))
+	is.NoErr(err)
+	_, err = manager.GetOrCreateConnection(filepath.Join(dir, "b.db"))
+	is.NoErr(err)
+	is.NoErr(manager.Healthy(context.Background()))
+
+	// When the file of one connection is removed
+	is.NoErr(os.Remove(filepath.Join(dir, "b.db")))
+
+	// Then the manager is unhealthy because of that connection
+	err = manager.Healthy(context.Background())
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "b.db is unhealthy"))
+	is.True(!strings.Contains(err.Error(), "a.db"))
+}
@@ -0,0 +1,110 @@
+package timeline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// herokuRouterFieldNames maps Heroku router fields to the column names of the CLF parser
+var herokuRouterFieldNames = map[string]string{
+	"fwd":   "forwarded_for",
+	"bytes": "response_size",
+}
+
+// parseHerokuLogplex parses a Heroku Logplex (log drain) frame.
+// Frames are octet-counted RFC5424 syslog messages without structured data.
+// Messages of the Heroku router are logfmt key=value pairs and are parsed into separate columns.
+// Examples:
+//
+//	App:    83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up
+//	Router: 265 <158>1 2012-11-30T06:45:26+00:00 host heroku router - at=info method=GET path="/" host=example.herokuapp.com fwd="204.204.204.204" dyno=web.1 connect=1ms service=18ms status=200 bytes=13
+//
+// Fields: priority, facility, severity, version, timestamp, hostname, app_name, dyno, message, and for
+// router lines: at, method, path, host, request_id, forwarded_for, dyno, connect_ms, service_ms, status,
+// response_size, protocol
+func parseHerokuLogplex(l string) Row {
+	// Octet count: "<length> <priority>"
+	space := strings.IndexByte(l, ' ')
+	if space < 1 || space+1 >= len(l) || l[space+1] != '<' {
+		return nil
+	}
+	if _, err := strconv.Atoi(l[:space]); err != nil {
+		return nil
+	}
+	frame := l[space+1:]
+
+	endPri := strings.IndexByte(frame, '>')
+	if endPri == -1 {
+		return nil
+	}
+	priority, err := strconv.Atoi(frame[1:endPri])
+	if err != nil {
+		return nil
+	}
+
+	// version timestamp hostname app-name procid msgid message
+	parts := strings.SplitN(frame[endPri+1:], " ", 7)
+	if len(parts) < 6 {
+		return nil
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil
+	}
+
+	result := make(Row)
+	result["priority"] = priority
+	result["facility"] = priority / 8
+	result["severity"] = priority % 8
+	result["version"] = version
+	result["timestamp"] = parts[1]
+	result["hostname"] = parts[2]
+	result["app_name"] = parts[3]
+	result["dyno"] = parts[4]
+
+	message := ""
+	if len(parts) == 7 {
+		message = strings.TrimSpace(parts[6])
+	}
+	result["message"] = message
+
+	if parts[3] == "heroku" && parts[4] == "router" {
+		for k, v := range parseLogfmt(message) {
+			if name, ok := herokuRouterFieldNames[k]; ok {
+				k = name
+			}
+			// connect=1ms service=18ms
+			if duration, ok := v.(string); ok && strings.HasSuffix(duration, "ms") {
+				if ms, err := strconv.Atoi(strings.TrimSuffix(duration, "ms")); err == nil {
+					result[k+"_ms"] = ms
+					continue
+				}
+			}
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+// SplitOctetCountedFrames splits a body with octet-counted syslog frames (RFC6587),
+// as sent by Heroku log drains, into separate frames.
+// Every returned frame still starts with its length, so it can be passed to ParseLineToValues.
+// Example: "83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up"
+func SplitOctetCountedFrames(body string) []string {
+	var frames []string
+	for len(body) > 0 {
+		body = strings.TrimLeft(body, "\r\n ")
+		space := strings.IndexByte(body, ' ')
+		if space < 1 {
+			break
+		}
+		length, err := strconv.Atoi(body[:space])
+		if err != nil || length <= 0 || space+1+length > len(body) {
+			break
+		}
+		frames = append(frames, strings.TrimRight(body[:space+1+length], "\r\n"))
+		body = body[space+1+length:]
+	}
+	return frames
+}
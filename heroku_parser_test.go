@@ -0,0 +1,64 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_heroku_app_frame(t *testing.T) {
+	is := is.New(t)
+	line := `83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["priority"], 40)
+	is.Equal(data["facility"], 5)
+	is.Equal(data["severity"], 0)
+	is.Equal(data["version"], 1)
+	is.Equal(data["timestamp"], "2012-11-30T06:45:29+00:00")
+	is.Equal(data["hostname"], "host")
+	is.Equal(data["app_name"], "app")
+	is.Equal(data["dyno"], "web.3")
+	is.Equal(data["message"], "State changed from starting to up")
+}
+
+func Test_parse_heroku_router_frame(t *testing.T) {
+	is := is.New(t)
+	line := `265 <158>1 2012-11-30T06:45:26+00:00 host heroku router - at=info method=GET path="/search?q=1" host=example.herokuapp.com request_id=8601b555 fwd="204.204.204.204" dyno=web.1 connect=1ms service=18ms status=200 bytes=13 protocol=https`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["app_name"], "heroku")
+	is.Equal(data["at"], "info")
+	is.Equal(data["method"], "GET")
+	is.Equal(data["path"], "/search?q=1")
+	is.Equal(data["host"], "example.herokuapp.com")
+	is.Equal(data["forwarded_for"], "204.204.204.204")
+	is.Equal(data["dyno"], "web.1")
+	is.Equal(data["connect_ms"], 1)
+	is.Equal(data["service_ms"], 18)
+	is.Equal(data["status"], 200)
+	is.Equal(data["response_size"], 13)
+	is.Equal(data["protocol"], "https")
+}
+
+func Test_parse_heroku_frame_requires_octet_count(t *testing.T) {
+	is := is.New(t)
+
+	data := parseHerokuLogplex(`<40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed`)
+
+	is.Equal(data, nil)
+}
+
+func Test_split_octet_counted_frames(t *testing.T) {
+	is := is.New(t)
+	body := "83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up\n" +
+		"67 <190>1 2012-11-30T06:45:30+00:00 host app web.3 - Starting process\n"
+
+	frames := SplitOctetCountedFrames(body)
+
+	is.Equal(len(frames), 2)
+	is.Equal(frames[0], "83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up")
+	is.Equal(ParseLineToValues(frames[1])["message"], "Starting process")
+}
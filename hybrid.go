@@ -0,0 +1,248 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// HybridConfig configures a Hybrid
+type HybridConfig struct {
+	// Window is the time the rows stay in memory after their timestamp, defaults to 15 minutes
+	Window time.Duration
+	// FlushInterval is the time between the writes of the new rows to the database, defaults to 1s
+	FlushInterval time.Duration
+	// OnError is called for rows that could not be written to the database
+	OnError func(error)
+}
+
+// Hybrid keeps the rows of the last minutes in an in-memory database for fast live queries and
+// writes them to the database of the writer in the background. Query reads the rows of both
+// tiers: the recent rows from memory and the older rows from the database, without duplicates.
+type Hybrid struct {
+	writer *Writer
+	config HybridConfig
+	// alias is the alias of the attached in-memory database
+	alias string
+
+	// mu is held by Query to read a consistent view, and by the flush and the eviction to change it
+	mu sync.RWMutex
+	// boundary is the oldest timestamp of which all rows are in memory, the older rows are read
+	// from the database. It starts at the start of the hybrid and follows the window.
+	boundary time.Time
+	// flushed is the sequence number of the last row that is written to the database
+	flushed int64
+
+	pendingMu sync.Mutex
+	pending   []hybridRow
+	seq       int64
+	tables    map[string]bool
+}
+
+// hybridRow is a row that waits to be written to the database
+type hybridRow struct {
+	table string
+	row   Row
+	seq   int64
+}
+
+// liveWriteKey marks the context of the writes of the in-memory rows of a Hybrid, which are not
+// followed, replicated or matched by the rules
+type liveWriteKey struct{}
+
+// hybridAlias is the alias of the in-memory database of a Hybrid
+const hybridAlias = "_timeline_live"
+
+// NewHybrid attaches an in-memory database to the connection of the writer for the recent rows,
+// call Run to write them to the database of the writer. A writer has one Hybrid.
+func NewHybrid(ctx context.Context, writer *Writer, config HybridConfig) (*Hybrid, error) {
+	if config.Window <= 0 {
+		config.Window = 15 * time.Minute
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if err := writer.Attach(ctx, ":memory:", hybridAlias, false); err != nil {
+		return nil, err
+	}
+	return &Hybrid{
+		writer:   writer,
+		config:   config,
+		alias:    hybridAlias,
+		boundary: time.Now(),
+		tables:   map[string]bool{},
+	}, nil
+}
+
+// Write writes a row to memory, see WriteContext
+func (h *Hybrid) Write(table string, row Row) error {
+	return h.WriteContext(context.Background(), table, row)
+}
+
+// WriteContext writes a row to memory, it is written to the database of the writer by Run. The
+// followers, replicators and rules of the writer get the row when it is written to the database.
+func (h *Hybrid) WriteContext(ctx context.Context, table string, row Row) error {
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	h.seq++
+	live := maps.Clone(row)
+	live["_seq"] = int(h.seq)
+	if err := h.writer.WriteContext(context.WithValue(ctx, liveWriteKey{}, true), h.live(table), live); err != nil {
+		return err
+	}
+	h.pending = append(h.pending, hybridRow{table: table, row: row, seq: h.seq})
+	h.tables[table] = true
+	return nil
+}
+
+// Run writes the rows to the database of the writer every FlushInterval and removes the rows
+// that are older than the window from memory, until ctx is done. The remaining rows are written
+// before Run returns.
+func (h *Hybrid) Run(ctx context.Context) error {
+	ticker := time.NewTicker(h.config.FlushInterval)
+	defer ticker.Stop()
+	// The flushed rows are removed from the queue, so their writes are not canceled with ctx
+	flushCtx := context.WithoutCancel(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			h.Flush(flushCtx)
+			return ctx.Err()
+		case <-ticker.C:
+			h.Flush(flushCtx)
+			if err := h.evict(ctx); err != nil {
+				handleError(h.config.OnError, err, ErrorContext{Component: "hybrid"})
+			}
+		}
+	}
+}
+
+// Flush writes the rows in memory that are not written yet to the database of the writer. Rows
+// that could not be written stay in memory and are written again on the next flush.
+func (h *Hybrid) Flush(ctx context.Context) {
+	h.pendingMu.Lock()
+	batch := h.pending
+	h.pending = nil
+	h.pendingMu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	// Queries wait, so they don't read the written rows in both tiers
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var failed []hybridRow
+	for _, pending := range batch {
+		if err := h.writer.WriteContext(ctx, pending.table, pending.row); err != nil {
+			handleError(h.config.OnError, fmt.Errorf("failed to write row to %s: %w", pending.table, err), ErrorContext{Component: "hybrid", Table: pending.table})
+			failed = append(failed, pending)
+		}
+	}
+	if len(failed) == 0 {
+		h.flushed = batch[len(batch)-1].seq
+		return
+	}
+
+	// The rows from the first failed row are not evicted, the failed rows are queued before the new rows
+	h.flushed = failed[0].seq - 1
+	h.pendingMu.Lock()
+	h.pending = append(failed, h.pending...)
+	h.pendingMu.Unlock()
+}
+
+// evict removes the written rows that are older than the window from memory
+func (h *Hybrid) evict(ctx context.Context) error {
+	h.pendingMu.Lock()
+	tables := make([]string, 0, len(h.tables))
+	for table := range h.tables {
+		tables = append(tables, table)
+	}
+	h.pendingMu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// The rows before the start of the hybrid are only in the database, so the boundary never moves back
+	if boundary := time.Now().Add(-h.config.Window); boundary.After(h.boundary) {
+		h.boundary = boundary
+	}
+	for _, table := range tables {
+		stored := []string{h.live(table)}
+		if h.writer.isPartitioned(stored[0]) {
+			var err error
+			if stored, err = h.writer.partitions(stored[0], time.Time{}, time.Time{}); err != nil {
+				return err
+			}
+		}
+		for _, live := range stored {
+			statement := fmt.Sprintf("DELETE FROM %s WHERE timestamp < ? AND _seq <= ?", quoteTableName(live))
+			if _, err := h.writer.DB.ExecContext(ctx, statement, h.boundary.UTC(), h.flushed); err != nil {
+				return fmt.Errorf("failed to remove the old rows of %s from memory: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Query returns the rows of a table like Writer.Query, of both tiers: the rows in memory and the
+// rows in the database that are older than the window. A time range within the window only
+// reads memory.
+func (h *Hybrid) Query(ctx context.Context, table string, opts QueryOptions) ([]Row, error) {
+	ctx, cancel := h.writer.withQueryTimeout(ctx)
+	defer cancel()
+	if opts.cursor || opts.After != "" {
+		return nil, errors.New("cursors are not supported by a hybrid")
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// The rows in memory: all rows from the boundary and the rows that are not written yet
+	live, _, err := h.writer.tableSource(h.live(table), opts.From, opts.To)
+	if err != nil && !errors.Is(err, ErrTableNotFound) {
+		return nil, err
+	}
+	var selects []string
+	if live != "" {
+		selects = append(selects, fmt.Sprintf("SELECT * EXCLUDE (_seq) FROM %s WHERE timestamp >= %s OR _seq > %d", live, timestampLiteral(h.boundary), h.flushed))
+	}
+	if opts.From.IsZero() || opts.From.Before(h.boundary) {
+		stored, _, err := h.writer.tableSource(table, opts.From, opts.To)
+		if err != nil && !errors.Is(err, ErrTableNotFound) {
+			return nil, err
+		}
+		if stored != "" {
+			selects = append(selects, fmt.Sprintf("SELECT * FROM %s WHERE timestamp < %s", stored, timestampLiteral(h.boundary)))
+		}
+	}
+	if len(selects) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+	}
+
+	source := "(" + selects[0] + ") AS " + quoteIdentifier(table)
+	if len(selects) == 2 {
+		source = "(" + selects[0] + " UNION ALL BY NAME " + selects[1] + ") AS " + quoteIdentifier(table)
+	}
+	query, args, err := selectFrom(source, false, opts)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := h.writer.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// live returns the in-memory table of a table
+func (h *Hybrid) live(table string) string {
+	return h.alias + "." + table
+}
+
+// timestampLiteral returns a time as a TIMESTAMP literal in UTC
+func timestampLiteral(t time.Time) string {
+	return "TIMESTAMP '" + t.UTC().Format("2006-01-02 15:04:05.999999") + "'"
+}
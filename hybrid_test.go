@@ -0,0 +1,117 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_hybrid_queries_rows_before_they_are_flushed(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	hybrid, err := NewHybrid(ctx, writer, HybridConfig{})
+	is.NoErr(err)
+
+	// Given rows in memory
+	now := time.Now().UTC().Truncate(time.Second)
+	is.NoErr(hybrid.Write("app", NewRow(now, Row{"message": "first"})))
+	is.NoErr(hybrid.Write("app", NewRow(now.Add(time.Second), Row{"message": "second"})))
+
+	// Then they are queried from memory, but not written to the database yet
+	rows, err := hybrid.Query(ctx, "app", QueryOptions{Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "first"}, {"message": "second"}})
+	_, err = writer.Query(ctx, "app", QueryOptions{})
+	is.True(err != nil)
+
+	// When they are flushed
+	hybrid.Flush(ctx)
+
+	// Then they are in the database and queried once
+	rows, err = writer.Query(ctx, "app", QueryOptions{Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "first"}, {"message": "second"}})
+	rows, err = hybrid.Query(ctx, "app", QueryOptions{Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "first"}, {"message": "second"}})
+}
+
+func Test_hybrid_merges_old_rows_of_the_database(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Given a row in the database from before the hybrid
+	is.NoErr(writer.Write("app", NewRow(now.Add(-time.Minute), Row{"message": "stored"})))
+	hybrid, err := NewHybrid(ctx, writer, HybridConfig{})
+	is.NoErr(err)
+	is.NoErr(hybrid.Write("app", NewRow(now.Add(time.Second), Row{"message": "live"})))
+
+	// Then both tiers are queried
+	rows, err := hybrid.Query(ctx, "app", QueryOptions{Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "stored"}, {"message": "live"}})
+
+	// And a range within the window only reads memory
+	rows, err = hybrid.Query(ctx, "app", QueryOptions{From: now, Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "live"}})
+}
+
+func Test_hybrid_evicts_flushed_rows_older_than_the_window(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	hybrid, err := NewHybrid(ctx, writer, HybridConfig{Window: 50 * time.Millisecond, FlushInterval: 10 * time.Millisecond})
+	is.NoErr(err)
+	done := make(chan error)
+	go func() { done <- hybrid.Run(ctx) }()
+
+	// Given a row that is older than the window
+	is.NoErr(hybrid.Write("app", NewRow(time.Now(), Row{"message": "old"})))
+
+	// When it is flushed and evicted
+	waitForCount(t, writer, "SELECT count(*) FROM _timeline_live.app", 0)
+
+	// Then it is queried from the database
+	rows, err := hybrid.Query(ctx, "app", QueryOptions{Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "old"}})
+
+	// And the new rows are written when Run stops
+	is.NoErr(hybrid.Write("app", NewRow(time.Now(), Row{"message": "new"})))
+	cancel()
+	<-done
+	waitForCount(t, writer, "SELECT count(*) FROM app", 2)
+}
+
+func Test_hybrid_writes_failed_rows_on_the_next_flush(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	var failures int
+	hybrid, err := NewHybrid(ctx, writer, HybridConfig{OnError: func(error) { failures++ }})
+	is.NoErr(err)
+
+	// Given a table of the database that can't be written to
+	_, err = writer.DB.Exec("CREATE VIEW app AS SELECT 'view' AS message")
+	is.NoErr(err)
+	is.NoErr(hybrid.Write("app", NewRow(time.Now(), Row{"message": "first"})))
+
+	// When the rows are flushed
+	hybrid.Flush(ctx)
+
+	// Then the row is kept in memory and not marked as flushed
+	is.Equal(failures, 1)
+	is.Equal(hybrid.flushed, int64(0))
+	is.Equal(len(hybrid.pending), 1)
+
+	// And it is written on the next flush, before the newer rows
+	_, err = writer.DB.Exec("DROP VIEW app")
+	is.NoErr(err)
+	is.NoErr(hybrid.Write("app", NewRow(time.Now(), Row{"message": "second"})))
+	hybrid.Flush(ctx)
+	is.Equal(failures, 1)
+	is.Equal(hybrid.flushed, int64(2))
+	rows, err := writer.Query(ctx, "app", QueryOptions{Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "first"}, {"message": "second"}})
+}
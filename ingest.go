@@ -0,0 +1,314 @@
+package timeline
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLineTooLong is reported for lines longer than the maximum line size of Ingest
+var ErrLineTooLong = errors.New("line too long")
+
+// LineError is the error of a single line of an ingested stream, Line starts at 1
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e LineError) Unwrap() error {
+	return e.Err
+}
+
+// IngestResult counts the lines of an ingested stream
+type IngestResult struct {
+	// Lines is the number of physical lines read
+	Lines int
+	// Written is the number of rows written to the table
+	Written int
+	// Skipped is the number of empty lines and lines the parser returned nil for
+	Skipped int
+	// Errors holds the lines that were too long or could not be written
+	Errors []LineError
+}
+
+type ingestConfig struct {
+	parser        Parser
+	batchSize     int
+	maxLineSize   int
+	multiline     bool
+	flushInterval time.Duration
+}
+
+// IngestOption configures Ingest
+type IngestOption func(*ingestConfig)
+
+// WithParser parses the lines with the given parser instead of ParseLineToValues
+func WithParser(parser Parser) IngestOption {
+	return func(c *ingestConfig) {
+		c.parser = parser
+	}
+}
+
+// WithBatchSize sets the number of lines that are parsed (concurrently) before their rows are
+// written, default 1000. The rows are written one by one, like Write.
+func WithBatchSize(size int) IngestOption {
+	return func(c *ingestConfig) {
+		if size > 0 {
+			c.batchSize = size
+		}
+	}
+}
+
+// WithMaxLineSize sets the maximum number of bytes of a line, default 1 MiB.
+// Longer lines are skipped and reported with ErrLineTooLong.
+func WithMaxLineSize(size int) IngestOption {
+	return func(c *ingestConfig) {
+		if size > 0 {
+			c.maxLineSize = size
+		}
+	}
+}
+
+// WithMultiline groups stack traces and other continuation lines into one row, see LineAssembler
+func WithMultiline() IngestOption {
+	return func(c *ingestConfig) {
+		c.multiline = true
+	}
+}
+
+// WithFlushInterval writes the pending lines when no new line is read for the interval, so the rows
+// of a slow stream (e.g. the output of a running application) are written without waiting for a
+// full batch. The stream is read in a separate goroutine, which keeps blocking on r after Ingest
+// returned because ctx is done.
+func WithFlushInterval(interval time.Duration) IngestOption {
+	return func(c *ingestConfig) {
+		c.flushInterval = interval
+	}
+}
+
+// ingestRead is the result of readIngestLine
+type ingestRead struct {
+	line    string
+	tooLong bool
+	err     error
+}
+
+// ingestEntry is a logical line with the number of its first physical line
+type ingestEntry struct {
+	line int
+	text string
+}
+
+// Ingest reads the lines of r (LF or CRLF terminated), parses them and writes the rows to the table.
+// Rows without a timestamp get the time of ingestion. Errors of single lines don't stop the ingestion,
+// they are collected in the result. The returned error is only set when reading fails or ctx is done;
+// the lines that were read before are written.
+func (w *Writer) Ingest(ctx context.Context, r io.Reader, table string, opts ...IngestOption) (IngestResult, error) {
+	config := ingestConfig{
+		batchSize:   1000,
+		maxLineSize: 1 << 20,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var result IngestResult
+	var assembler *LineAssembler
+	if config.multiline {
+		assembler = NewLineAssembler()
+	}
+	assemblerStart := 0
+
+	batch := make([]ingestEntry, 0, config.batchSize)
+	// The rows of a batch are reused by the next batch
+	var rows []Row
+	// The read lines are written when ctx is done too, so a canceled ingestion doesn't lose them
+	writeCtx := context.WithoutCancel(ctx)
+	flush := func() {
+		rows = w.writeIngestBatch(writeCtx, table, batch, rows, &config, &result)
+		batch = batch[:0]
+	}
+	// finish writes the pending entry of the assembler and the batch
+	finish := func() {
+		if assembler != nil {
+			if entry, ok := assembler.Flush(); ok {
+				batch = append(batch, ingestEntry{line: assemblerStart, text: entry})
+			}
+		}
+		flush()
+	}
+
+	reader := bufio.NewReader(r)
+	read := func() ingestRead {
+		line, tooLong, err := readIngestLine(reader, config.maxLineSize)
+		return ingestRead{line: line, tooLong: tooLong, err: err}
+	}
+
+	var reads chan ingestRead
+	if config.flushInterval > 0 {
+		reads = make(chan ingestRead)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				next := read()
+				select {
+				case reads <- next:
+				case <-done:
+					return
+				}
+				if next.err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		var next ingestRead
+		if reads == nil {
+			next = read()
+		} else {
+			timer := time.NewTimer(config.flushInterval)
+			select {
+			case next = <-reads:
+				timer.Stop()
+			case <-ctx.Done():
+				timer.Stop()
+				finish()
+				return result, ctx.Err()
+			case <-timer.C:
+				// The stream is idle, the pending entry is complete
+				finish()
+				continue
+			}
+		}
+		line, tooLong, readErr := next.line, next.tooLong, next.err
+		if readErr != nil && readErr != io.EOF {
+			// The lines that were read are written, the incomplete line is not
+			finish()
+			return result, fmt.Errorf("failed to read line %d: %w", result.Lines+1, readErr)
+		}
+		if readErr == io.EOF && line == "" && !tooLong {
+			break
+		}
+		result.Lines++
+
+		switch {
+		case tooLong:
+			result.Errors = append(result.Errors, LineError{Line: result.Lines, Err: ErrLineTooLong})
+		case assembler != nil:
+			if entry, ok := assembler.Add(line); ok {
+				batch = append(batch, ingestEntry{line: assemblerStart, text: entry})
+			}
+			// The line started a new entry
+			if len(assembler.pending) == 1 {
+				assemblerStart = result.Lines
+			}
+		case line == "":
+			result.Skipped++
+		default:
+			batch = append(batch, ingestEntry{line: result.Lines, text: line})
+		}
+
+		if err := ctx.Err(); err != nil {
+			finish()
+			return result, err
+		}
+		if len(batch) >= config.batchSize {
+			flush()
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	finish()
+	return result, nil
+}
+
+// writeIngestBatch parses the entries of a batch and writes their rows one by one. The lines are parsed into the rows of
+// the previous batch, the rows are returned for the next batch.
+func (w *Writer) writeIngestBatch(ctx context.Context, table string, batch []ingestEntry, rows []Row, config *ingestConfig, result *IngestResult) []Row {
+	if len(batch) == 0 {
+		return rows
+	}
+	ctx, endSpan := w.startSpan(ctx, SpanIngestBatch, map[string]string{"table": table, "lines": strconv.Itoa(len(batch))})
+	defer endSpan(nil)
+
+	if config.parser == nil {
+		lines := make([]string, len(batch))
+		for i, entry := range batch {
+			lines[i] = entry.text
+		}
+		// Write doesn't keep the rows, so their maps can be reused
+		rows = parseLinesInto(rows, lines, 0)
+	} else {
+		// Custom parsers can be stateful (e.g. a CSV header), so the lines are parsed in order
+		rows = make([]Row, len(batch))
+		for i, entry := range batch {
+			rows[i] = config.parser.Parse(entry.text)
+		}
+	}
+
+	now := time.Now()
+	written, parseFailures := 0, 0
+	for i, row := range rows {
+		if len(row) == 0 || strings.TrimSpace(batch[i].text) == "" {
+			if strings.TrimSpace(batch[i].text) != "" {
+				parseFailures++
+			}
+			result.Skipped++
+			continue
+		}
+		if err := w.WriteContext(ctx, table, NewRow(now, row)); err != nil {
+			result.Errors = append(result.Errors, LineError{Line: batch[i].line, Err: err})
+			continue
+		}
+		written++
+	}
+	result.Written += written
+	w.observeBatch(table, written, parseFailures)
+	return rows
+}
+
+// readIngestLine reads the next line without its line ending. Lines longer than maxLineSize
+// are read until the end, but not returned, to keep the memory usage bounded.
+func readIngestLine(reader *bufio.Reader, maxLineSize int) (string, bool, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(chunk) > maxLineSize+2 {
+				tooLong = true
+				line = nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if !tooLong && len(line) > maxLineSize {
+			tooLong = true
+		}
+		if tooLong {
+			return "", true, err
+		}
+		return string(line), false, err
+	}
+}
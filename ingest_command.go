@@ -0,0 +1,99 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// RunIngestCommand runs the ingest command of the timeline binary: it reads lines from the files
+// of the arguments, or from stdin without files, parses them and writes the rows to a table of a
+// database file, until the input ends or ctx is done. args are the arguments after the command
+// name, e.g.
+//
+//	myapp | timeline ingest --db app.db --table logs
+//	timeline ingest --db app.db --table logs app.log app.log.1
+//
+// Line errors and a summary are written to stderr. With --tee the lines are copied to stdout, so
+// the output of the application stays visible. Returns flag.ErrHelp for -h.
+func RunIngestCommand(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("ingest", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: timeline ingest --db <path> [--table <name>] [options] [file ...]")
+		flags.PrintDefaults()
+	}
+	dbPath := flags.String("db", "", "path of the database file (required)")
+	table := flags.String("table", "logs", "table the rows are written to")
+	batchSize := flags.Int("batch-size", 1000, "number of lines that are parsed and written together")
+	flushInterval := flags.Duration("flush-interval", time.Second, "write the pending lines when stdin is idle for this duration")
+	maxLineSize := flags.Int("max-line-size", 1<<20, "maximum number of bytes of a line, longer lines are skipped")
+	multiline := flags.Bool("multiline", false, "group stack traces and other continuation lines into one row")
+	tee := flags.Bool("tee", false, "copy the input to stdout")
+	quiet := flags.Bool("quiet", false, "don't report line errors and the summary")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbPath == "" {
+		flags.Usage()
+		return errors.New("the --db flag is required")
+	}
+
+	writer, err := NewStorageClient(*dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", *dbPath, err)
+	}
+	defer writer.Close()
+
+	opts := []IngestOption{
+		WithBatchSize(*batchSize),
+		WithFlushInterval(*flushInterval),
+		WithMaxLineSize(*maxLineSize),
+	}
+	if *multiline {
+		opts = append(opts, WithMultiline())
+	}
+
+	// name prefixes the messages of a file
+	ingest := func(name string, input io.Reader) error {
+		if *tee {
+			input = io.TeeReader(input, stdout)
+		}
+		result, err := writer.Ingest(ctx, input, *table, opts...)
+		if !*quiet {
+			for _, lineErr := range result.Errors {
+				fmt.Fprintf(stderr, "timeline: %s%v\n", name, lineErr)
+			}
+			fmt.Fprintf(stderr, "timeline: %s%d lines, %d written, %d skipped, %d errors\n",
+				name, result.Lines, result.Written, result.Skipped, len(result.Errors))
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("failed to ingest: %w", err)
+		}
+		return nil
+	}
+
+	if flags.NArg() == 0 {
+		return ingest("", stdin)
+	}
+	for _, path := range flags.Args() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		err = ingest(path+": ", file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
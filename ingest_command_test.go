@@ -0,0 +1,73 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_ingest_command_writes_stdin_to_the_database(t *testing.T) {
+	is := is.New(t)
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	stdin := strings.NewReader("level=info n=1\nlevel=error n=2\n\n")
+	var stdout, stderr bytes.Buffer
+
+	err := RunIngestCommand(context.Background(), []string{"--db", dbPath, "--table", "app", "--tee"}, stdin, &stdout, &stderr)
+
+	is.NoErr(err)
+	is.Equal(stdout.String(), "level=info n=1\nlevel=error n=2\n\n")
+	is.Equal(stderr.String(), "timeline: 3 lines, 2 written, 1 skipped, 0 errors\n")
+
+	writer, err := NewStorageClient(dbPath)
+	is.NoErr(err)
+	defer writer.Close()
+	var count int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*) FROM app`).Scan(&count))
+	is.Equal(count, 2)
+}
+
+func Test_ingest_command_validates_flags(t *testing.T) {
+	is := is.New(t)
+	var stdout, stderr bytes.Buffer
+
+	err := RunIngestCommand(context.Background(), []string{"--table", "app"}, strings.NewReader(""), &stdout, &stderr)
+	is.True(err != nil)
+	is.True(strings.Contains(stderr.String(), "Usage: timeline ingest"))
+
+	err = RunIngestCommand(context.Background(), []string{"-h"}, strings.NewReader(""), &stdout, &stderr)
+	is.True(errors.Is(err, flag.ErrHelp))
+}
+
+func Test_ingest_command_reads_the_files_of_the_arguments(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "app.db")
+	is.NoErr(os.WriteFile(filepath.Join(dir, "app.log.1"), []byte("level=info n=1\n"), 0o644))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "app.log"), []byte("level=info n=2\nlevel=error n=3\n"), 0o644))
+	var stdout, stderr bytes.Buffer
+
+	err := RunIngestCommand(context.Background(), []string{"--db", dbPath, "--table", "app", filepath.Join(dir, "app.log.1"), filepath.Join(dir, "app.log")}, strings.NewReader(""), &stdout, &stderr)
+
+	is.NoErr(err)
+	is.True(strings.Contains(stderr.String(), "app.log.1: 1 lines, 1 written"))
+	is.True(strings.Contains(stderr.String(), "app.log: 2 lines, 2 written"))
+
+	// A missing file is an error
+	err = RunIngestCommand(context.Background(), []string{"--db", dbPath, filepath.Join(dir, "missing.log")}, strings.NewReader(""), &stdout, &stderr)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "missing.log"))
+
+	writer, err := NewStorageClient(dbPath)
+	is.NoErr(err)
+	defer writer.Close()
+	var count int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*) FROM app`).Scan(&count))
+	is.Equal(count, 3)
+}
@@ -0,0 +1,163 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func Test_ingest_writes_parsed_lines(t *testing.T) {
+	is, writer := setup(t)
+	input := "{\"level\":\"info\",\"n\":1}\r\n\r\nlevel=warn n=2\nplain text"
+
+	result, err := writer.Ingest(context.Background(), strings.NewReader(input), "timeline")
+
+	is.NoErr(err)
+	is.Equal(result.Lines, 4)
+	is.Equal(result.Written, 3)
+	is.Equal(result.Skipped, 1)
+	is.Equal(len(result.Errors), 0)
+
+	var count int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*) FROM timeline WHERE n IS NOT NULL`).Scan(&count))
+	is.Equal(count, 2)
+}
+
+func Test_ingest_reports_too_long_lines(t *testing.T) {
+	is, writer := setup(t)
+	input := "level=info n=1\n" + strings.Repeat("x", 100) + "\nlevel=info n=3\n"
+
+	result, err := writer.Ingest(context.Background(), strings.NewReader(input), "timeline", WithMaxLineSize(50), WithBatchSize(1))
+
+	is.NoErr(err)
+	is.Equal(result.Lines, 3)
+	is.Equal(result.Written, 2)
+	is.Equal(len(result.Errors), 1)
+	is.Equal(result.Errors[0].Line, 2)
+	is.True(errors.Is(result.Errors[0], ErrLineTooLong))
+}
+
+func Test_ingest_with_parser_and_multiline(t *testing.T) {
+	is, writer := setup(t)
+	input := strings.Join([]string{
+		"level,message",
+		"info,started",
+		"error,failed",
+		"  at retry",
+	}, "\n")
+
+	result, err := writer.Ingest(context.Background(), strings.NewReader(input), "timeline",
+		WithParser(NewCSVParser()), WithMultiline())
+
+	is.NoErr(err)
+	is.Equal(result.Lines, 4)
+	is.Equal(result.Written, 2)
+	is.Equal(result.Skipped, 1) // the header
+
+	var message string
+	is.NoErr(writer.DB.QueryRow(`SELECT message FROM timeline WHERE level = 'error'`).Scan(&message))
+	is.Equal(message, "failed\n  at retry")
+}
+
+func Test_ingest_stops_when_context_is_cancelled(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := writer.Ingest(ctx, strings.NewReader("level=info n=1\n"), "timeline")
+
+	is.True(errors.Is(err, context.Canceled))
+}
+
+func Test_ingest_writes_pending_lines_of_an_idle_stream(t *testing.T) {
+	is, writer := setup(t)
+	reader, input := io.Pipe()
+	defer input.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := writer.Ingest(context.Background(), reader, "timeline", WithFlushInterval(20*time.Millisecond))
+		done <- err
+	}()
+
+	// The line is written before the batch is full and before the stream is closed
+	_, err := io.WriteString(input, "level=info n=1\n")
+	is.NoErr(err)
+	waitForCount(t, writer, `SELECT count(*) FROM timeline WHERE n = 1`, 1)
+
+	input.Close()
+	is.NoErr(<-done)
+}
+
+func Test_ingest_writes_the_read_lines_when_context_is_cancelled(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Given lines in the batch and a stack trace in the assembler, of a stream that stays open
+	var lines strings.Builder
+	for i := 1; i <= 50; i++ {
+		fmt.Fprintf(&lines, "level=info n=%d\n", i)
+	}
+	lines.WriteString("level=error msg=failed\n  at retry\n")
+	stream := &openStream{Reader: strings.NewReader(lines.String()), waiting: make(chan struct{}), closed: make(chan struct{})}
+	defer close(stream.closed)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := writer.Ingest(ctx, stream, "timeline", WithMultiline(), WithFlushInterval(time.Hour))
+		done <- err
+	}()
+
+	// When the ingestion is cancelled while it waits for the next line
+	<-stream.waiting
+	cancel()
+
+	// Then the read lines are written
+	is.True(errors.Is(<-done, context.Canceled))
+	var count int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*) FROM timeline`).Scan(&count))
+	is.Equal(count, 51)
+}
+
+func Test_ingest_writes_the_read_lines_when_reading_fails(t *testing.T) {
+	is, writer := setup(t)
+	failure := errors.New("disk failure")
+
+	// Given a reader that fails after two lines and a stack trace in the assembler
+	lines := `{"level":"info","message":"one"}` + "\n" + `{"level":"info","message":"two"}` + "\n" + "level=error msg=failed\n  at retry\n"
+	r := io.MultiReader(strings.NewReader(lines), iotest.ErrReader(failure))
+
+	result, err := writer.Ingest(context.Background(), r, "logs", WithMultiline())
+
+	// Then the error is returned and the read lines are written
+	is.True(errors.Is(err, failure))
+	is.Equal(result.Written, 3)
+	var count int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*) FROM logs`).Scan(&count))
+	is.Equal(count, 3)
+}
+
+// openStream is a stream that waits for new data after its reader, until it is closed
+type openStream struct {
+	io.Reader
+	// waiting is closed when the reader is read
+	waiting chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func (s *openStream) Read(p []byte) (int, error) {
+	n, err := s.Reader.Read(p)
+	if err != io.EOF {
+		return n, err
+	}
+	s.once.Do(func() { close(s.waiting) })
+	<-s.closed
+	return 0, io.ErrClosedPipe
+}
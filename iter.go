@@ -0,0 +1,84 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// RowIterator streams the rows of a query without loading them all in memory:
+//
+//	it := writer.Iter(ctx, "SELECT * FROM requests WHERE status >= ?", 500)
+//	defer it.Close()
+//	for it.Next() {
+//		row := it.Row()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RowIterator struct {
+	rows    *sql.Rows
+	scanner *rowScanner
+	row     Row
+	err     error
+	cancel  context.CancelFunc
+}
+
+// Iter runs an SQL query and returns an iterator over its rows. The values have the same Go types
+// as the rows of Query. Errors are returned by Err. The query timeout covers the iteration.
+func (w *Writer) Iter(ctx context.Context, query string, args ...any) *RowIterator {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	rows, err := w.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return &RowIterator{err: fmt.Errorf("failed to query: %w", err)}
+	}
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		rows.Close()
+		cancel()
+		return &RowIterator{err: err}
+	}
+	return &RowIterator{rows: rows, scanner: scanner, cancel: cancel}
+}
+
+// Next advances to the next row, it returns false when there are no more rows or on an error
+func (it *RowIterator) Next() bool {
+	if it.err != nil || it.rows == nil {
+		return false
+	}
+	if !it.rows.Next() {
+		if err := it.rows.Err(); err != nil {
+			it.err = fmt.Errorf("failed to read rows: %w", err)
+		}
+		it.Close()
+		return false
+	}
+	it.row, it.err = it.scanner.scan(it.rows)
+	if it.err != nil {
+		it.Close()
+		return false
+	}
+	return true
+}
+
+// Row returns the current row, every call of Next returns a new Row
+func (it *RowIterator) Row() Row {
+	return it.row
+}
+
+// Err returns the error of the query or of reading the rows
+func (it *RowIterator) Err() error {
+	return it.err
+}
+
+// Close releases the result, it is called by Next after the last row
+func (it *RowIterator) Close() error {
+	if it.rows == nil {
+		return nil
+	}
+	err := it.rows.Close()
+	it.rows = nil
+	it.cancel()
+	return err
+}
@@ -0,0 +1,37 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_iter_streams_rows(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		is.NoErr(writer.Write("requests", NewRow(start.Add(time.Duration(i)*time.Second), Row{"n": i})))
+	}
+
+	it := writer.Iter(context.Background(), `SELECT n FROM requests WHERE n >= ? ORDER BY n`, 90)
+	defer it.Close()
+	var numbers []int
+	for it.Next() {
+		numbers = append(numbers, it.Row()["n"].(int))
+	}
+
+	is.NoErr(it.Err())
+	is.Equal(len(numbers), 10)
+	is.Equal(numbers[0], 90)
+	is.Equal(numbers[9], 99)
+}
+
+func Test_iter_reports_query_errors(t *testing.T) {
+	is, writer := setup(t)
+
+	it := writer.Iter(context.Background(), `SELECT * FROM missing`)
+
+	is.True(!it.Next())
+	is.True(it.Err() != nil)
+	is.NoErr(it.Close())
+}
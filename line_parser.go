@@ -1,51 +1,239 @@
 package timeline
 
 import (
-	"bytes"
 	"encoding/json"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
-// stripAnsiCodes removes ANSI color codes from a string.
-// ANSI color codes follow the pattern: \x1b[XXm where XX is a color/style code.
+// ansiEscapeRegex matches ANSI escape sequences:
+//   - CSI: \x1b[ with parameters and a final byte, e.g. colors (\x1b[32m), cursor movement (\x1b[2A)
+//     and erasing (\x1b[2K)
+//   - OSC: \x1b] until BEL or ST (\x1b\), e.g. window titles and hyperlinks, or until the end of the line
+//   - character set selection, e.g. \x1b(B
+//   - other two byte sequences, e.g. \x1bM (reverse index) and \x1b7 (save cursor)
+var ansiEscapeRegex = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\|$)|[()*+][0-9A-Za-z]|[0-9=>@-Z\\-_])`)
+
+// stripAnsiCodes removes ANSI escape sequences (colors, cursor movement, OSC) from a string
 func stripAnsiCodes(s string) string {
-	// Match ANSI escape sequences: \x1b[ followed by any number of parameters separated by ; and ending with m
-	ansiRegex := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	return ansiRegex.ReplaceAllString(s, "")
+	// Most lines have no escape sequences, they skip the regex
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	return ansiEscapeRegex.ReplaceAllString(s, "")
 }
 
+// Format is the log format that was detected by ParseLine
+type Format string
+
+const (
+	FormatEmpty            Format = "empty"
+	FormatMongoDB          Format = "mongodb"
+	FormatJSON             Format = "json"
+	FormatCaddy            Format = "caddy"
+	FormatTraefik          Format = "traefik"
+	FormatGELF             Format = "gelf"
+	FormatJavaStackTrace   Format = "java_stack_trace"
+	FormatGoPanic          Format = "go_panic"
+	FormatRails            Format = "rails"
+	FormatHeroku           Format = "heroku"
+	FormatSyslog           Format = "syslog"
+	FormatMonolog          Format = "monolog"
+	FormatConsole          Format = "console"
+	FormatS3Access         Format = "s3_access"
+	FormatSquid            Format = "squid"
+	FormatCLF              Format = "clf"
+	FormatLogfmt           Format = "logfmt"
+	FormatLograge          Format = "lograge"
+	FormatTimestampMessage Format = "timestamp_message"
+	FormatPlain            Format = "plain"
+)
+
+// formatConfidence tells how certain a detection is. Formats with a distinctive structure (JSON, syslog
+// priorities, Squid's epoch timestamps) are certain; loose formats like logfmt or [timestamp] message
+// also match lines that were meant to be something else.
+var formatConfidence = map[Format]float64{
+	FormatEmpty:            1,
+	FormatMongoDB:          1,
+	FormatJSON:             1,
+	FormatCaddy:            1,
+	FormatTraefik:          1,
+	FormatGELF:             1,
+	FormatJavaStackTrace:   1,
+	FormatGoPanic:          1,
+	FormatRails:            1,
+	FormatHeroku:           1,
+	FormatSyslog:           0.9,
+	FormatMonolog:          0.9,
+	FormatConsole:          0.8,
+	FormatS3Access:         1,
+	FormatSquid:            0.9,
+	FormatCLF:              0.9,
+	FormatLogfmt:           0.6,
+	FormatLograge:          0.9,
+	FormatTimestampMessage: 0.5,
+	FormatPlain:            0,
+}
+
+// ParseLineToValues parses the line with the first parser that recognizes its format.
+// Lines that match no format are stored as {"message": line}.
 func ParseLineToValues(l string) Row {
+	result, _, _ := ParseLine(l)
+	return result
+}
+
+// ParseLine parses the line like ParseLineToValues and also returns the detected format with the
+// confidence of the detection (0 for plain text up to 1). Store them with the row to find mis-detected lines.
+func ParseLine(l string) (Row, Format, float64) {
+	result, format := parseLine(l)
+	return result, format, formatConfidence[format]
+}
+
+// ParseLineInto parses the line like ParseLine into row, which is cleared first. Reusing a row for
+// many lines saves the allocation of its map: the JSON, CLF and logfmt parsers write their fields
+// straight into it. The row must not be nil and is only valid until the next line is parsed into it.
+func ParseLineInto(row Row, l string) (Format, float64) {
+	_, format := parseLineInto(row, l, nil)
+	return format, formatConfidence[format]
+}
+
+func parseLine(l string) (Row, Format) {
+	return parseLineInto(nil, l, nil)
+}
+
+// parseLineInto parses the line into row, or into a new row when row is nil, and returns the row.
+// When rejections is not nil, the parsers that didn't recognize the line add why, in the order they
+// are tried (see ParseLineStrict).
+func parseLineInto(row Row, l string, rejections *[]Rejection) (Row, Format) {
+	clear(row)
 	if l == "" {
-		return Row{}
+		return into(row, Row{}), FormatEmpty
 	}
 
-	if result := parseJSON(l); result != nil {
-		return result
+	if result := parseMongoDB(l); result != nil {
+		return into(row, result), FormatMongoDB
+	}
+	rejectLine(rejections, FormatMongoDB, rejectMongoDB, l)
+
+	if result := parseJSONInto(row, l); result != nil {
+		if accessLog, format := normalizeJSONAccessLog(result); accessLog != nil {
+			return into(row, accessLog), format
+		}
+		if isGELF(result) {
+			return into(row, normalizeGELF(result)), FormatGELF
+		}
+		return result, FormatJSON
+	}
+	rejectLine(rejections, FormatJSON, rejectJSON, l)
+	rejectLine(rejections, FormatCaddy, rejectCaddy, l)
+	rejectLine(rejections, FormatTraefik, rejectTraefik, l)
+	rejectLine(rejections, FormatGELF, rejectGELF, l)
+
+	if result := parseJavaStackTrace(l); result != nil {
+		return into(row, result), FormatJavaStackTrace
+	}
+	rejectLine(rejections, FormatJavaStackTrace, rejectJavaStackTrace, l)
+
+	if result := parseGoPanic(l); result != nil {
+		return into(row, result), FormatGoPanic
+	}
+	rejectLine(rejections, FormatGoPanic, rejectGoPanic, l)
+
+	if result := parseRailsRequest(l); result != nil {
+		return into(row, result), FormatRails
 	}
+	rejectLine(rejections, FormatRails, rejectRails, l)
+
+	if result := parseHerokuLogplex(l); result != nil {
+		return into(row, result), FormatHeroku
+	}
+	rejectLine(rejections, FormatHeroku, rejectHeroku, l)
 
 	if result := parseSyslog(l); result != nil {
-		return result
+		return into(row, result), FormatSyslog
 	}
+	rejectLine(rejections, FormatSyslog, rejectSyslog, l)
 
 	if result := parseMonolog(l); result != nil {
-		return result
+		return into(row, result), FormatMonolog
 	}
+	rejectLine(rejections, FormatMonolog, rejectMonolog, l)
 
-	if result := parseCLF(l); result != nil {
-		return result
+	if result := parseConsole(l); result != nil {
+		return into(row, result), FormatConsole
 	}
+	rejectLine(rejections, FormatConsole, rejectConsole, l)
 
-	if result := parseLogfmt(l); result != nil {
-		return result
+	if result := parseS3Access(l); result != nil {
+		return into(row, result), FormatS3Access
+	}
+	rejectLine(rejections, FormatS3Access, rejectS3Access, l)
+
+	if result := parseSquid(l); result != nil {
+		return into(row, result), FormatSquid
+	}
+	rejectLine(rejections, FormatSquid, rejectSquid, l)
+
+	if result := parseCLFInto(row, l); result != nil {
+		return result, FormatCLF
+	}
+	rejectLine(rejections, FormatCLF, rejectCLF, l)
+
+	if result := parseLogfmtInto(row, l); result != nil {
+		if isLograge(result) {
+			// Lograge rows are normalized in place
+			return normalizeLograge(result), FormatLograge
+		}
+		return result, FormatLogfmt
 	}
+	rejectLine(rejections, FormatLogfmt, rejectLogfmt, l)
+	rejectLine(rejections, FormatLograge, rejectLograge, l)
 
 	if result := parseTimestampMessage(l); result != nil {
+		return into(row, result), FormatTimestampMessage
+	}
+	rejectLine(rejections, FormatTimestampMessage, rejectTimestampMessage, l)
+
+	if row == nil {
+		row = make(Row, 1)
+	}
+	row["message"] = stripAnsiCodes(l)
+	return row, FormatPlain
+}
+
+// into replaces the fields of row with the fields of result, a nil row is replaced by result.
+// result must be another map than row.
+func into(row Row, result Row) Row {
+	if row == nil {
 		return result
 	}
+	clear(row)
+	for k, v := range result {
+		row[k] = v
+	}
+	return row
+}
 
-	return Row{"message": stripAnsiCodes(l)}
+// appendFields appends the fields of s, as split by strings.Fields, to dst. Parsers split into an
+// array on the stack this way, instead of allocating a slice for every line.
+func appendFields(dst []string, s string) []string {
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				dst = append(dst, s[start:i])
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		dst = append(dst, s[start:])
+	}
+	return dst
 }
 
 // parseJSON parses a JSON-formatted log line.
@@ -54,30 +242,63 @@ func ParseLineToValues(l string) Row {
 // Example: {"level": "info", "message": "User logged in", "user_id": 123, "timestamp": "2023-01-01T12:00:00Z"}
 // Fields: all JSON keys with their corresponding values and types preserved
 func parseJSON(l string) Row {
-	var data map[string]interface{}
-	decoder := json.NewDecoder(bytes.NewReader([]byte(l)))
+	return parseJSONInto(nil, l)
+}
+
+// parseJSONInto parses a JSON line into result, or into a new row when result is nil. result is
+// left empty when the line isn't JSON.
+func parseJSONInto(result Row, l string) Row {
+	// Only objects (and null) decode into a row, other lines skip the decoder
+	trimmed := strings.TrimLeft(l, " \t\r\n")
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "null") {
+		return nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(l))
 	decoder.UseNumber()
-	err := decoder.Decode(&data)
-	if err != nil {
+	if err := decoder.Decode(&result); err != nil {
+		clear(result)
 		return nil
 	}
+	if result == nil {
+		// null
+		return make(Row)
+	}
 
 	// Convert json.Number to int if possible, otherwise float64
-	result := make(Row)
-	for k, v := range data {
-		if num, ok := v.(json.Number); ok {
-			if i, err := num.Int64(); err == nil {
-				result[k] = int(i)
-			} else if f, err := num.Float64(); err == nil {
-				result[k] = f
-			} else {
-				result[k] = num.String()
+	for k, v := range result {
+		result[k] = convertJSONNumbers(v)
+	}
+	return result
+}
+
+// convertJSONNumbers converts json.Number values (also inside nested maps and lists)
+// to int if possible, otherwise float64
+func convertJSONNumbers(v any) any {
+	switch value := v.(type) {
+	case json.Number:
+		// Fractions and exponents skip the integer conversion, its error allocates
+		if !strings.ContainsAny(string(value), ".eE") {
+			if i, err := value.Int64(); err == nil {
+				return int(i)
 			}
-		} else {
-			result[k] = v
 		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	case map[string]any:
+		for k, nested := range value {
+			value[k] = convertJSONNumbers(nested)
+		}
+		return value
+	case []any:
+		for i, nested := range value {
+			value[i] = convertJSONNumbers(nested)
+		}
+		return value
 	}
-	return result
+	return v
 }
 
 // parseSyslog parses syslog-formatted log lines (both RFC3164 and RFC5424).
@@ -127,16 +348,24 @@ func parseSyslog(l string) Row {
 		result["procid"] = parts[4]
 		result["msgid"] = parts[5]
 
+		// Skip the six header fields to find the structured data
+		sd := rest
+		for i := 0; i < 6; i++ {
+			_, sd, _ = strings.Cut(strings.TrimLeft(sd, " "), " ")
+		}
+		sd = strings.TrimLeft(sd, " ")
+
 		// Find structured data
-		sdStart := strings.Index(rest, "[")
-		sdEnd := strings.Index(rest, "]")
-		if sdStart != -1 && sdEnd != -1 && sdEnd > sdStart {
-			sdContent := rest[sdStart+1 : sdEnd]
+		sdEnd := strings.Index(sd, "]")
+		if strings.HasPrefix(sd, "[") && sdEnd != -1 {
+			sdContent := sd[1:sdEnd]
 			result["structured_data"] = parseStructuredData(sdContent)
-			result["message"] = strings.TrimSpace(rest[sdEnd+1:])
+			result["message"] = strings.TrimSpace(sd[sdEnd+1:])
 		} else {
+			// No structured data (the NILVALUE -)
 			result["structured_data"] = map[string]any{}
-			result["message"] = strings.TrimSpace(rest)
+			_, message, _ := strings.Cut(sd, " ")
+			result["message"] = strings.TrimSpace(message)
 		}
 	} else {
 		// RFC3164
@@ -221,14 +450,24 @@ func parseStructuredData(sd string) map[string]any {
 //
 // Fields: remote_host, remote_logname, remote_user, timestamp, request, status, response_size, referer (Combined only), user_agent (Combined only), forwarded_for (Extended only)
 func parseCLF(l string) Row {
+	return parseCLFInto(nil, l)
+}
+
+// parseCLFInto parses a CLF line into result, or into a new row when result is nil. result is left
+// empty when the line isn't CLF.
+func parseCLFInto(result Row, l string) Row {
+	// The request is quoted
+	if !strings.Contains(l, "\"") {
+		return nil
+	}
+
 	// Split line by spaces to handle variable spacing
-	parts := strings.Fields(l)
+	var buffer [32]string
+	parts := appendFields(buffer[:0], l)
 	if len(parts) < 6 {
 		return nil
 	}
 
-	result := make(Row)
-
 	// Find the request by looking for quoted string
 	requestIndex := -1
 	for i, part := range parts {
@@ -242,77 +481,30 @@ func parseCLF(l string) Row {
 		return nil
 	}
 
-	// Check if the request looks like a valid HTTP request
-	if requestIndex < len(parts) {
-		request := parts[requestIndex]
-		if strings.HasPrefix(request, "\"") && strings.HasSuffix(request, "\"") {
-			request = request[1 : len(request)-1]
-			// Only reject if this looks like pure JSON data (starts with { or [ and has no HTTP method)
-			if (strings.HasPrefix(request, "{") || strings.HasPrefix(request, "[")) && !strings.Contains(request, " ") {
-				// This looks like pure JSON data, not an HTTP request
-				return nil
-			}
-			requestParts := strings.Split(request, " ")
-			if len(requestParts) < 2 {
-				return nil
-			}
-			// Check if first part looks like HTTP method
-			method := requestParts[0]
-			validMethods := []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "TRACE"}
-			isValidMethod := false
-			for _, validMethod := range validMethods {
-				if method == validMethod {
-					isValidMethod = true
-					break
-				}
-			}
-			if !isValidMethod {
-				return nil
-			}
-		} else {
-			// Handle multi-part quoted request
-			// Combine all parts from requestIndex until we find the closing quote
-			fullRequest := request
-			i := requestIndex + 1
-			for i < len(parts) {
-				fullRequest += " " + parts[i]
-				if strings.HasSuffix(parts[i], "\"") {
-					break
-				}
-				i++
+	// Combine the parts of the request until the closing quote
+	requestEndIndex := requestIndex
+	if !strings.HasSuffix(parts[requestIndex], "\"") || parts[requestIndex] == "\"" {
+		for requestEndIndex = requestIndex + 1; requestEndIndex < len(parts); requestEndIndex++ {
+			if strings.HasSuffix(parts[requestEndIndex], "\"") {
+				break
 			}
+		}
+		if requestEndIndex == len(parts) {
+			// No closing quote found, this is not a valid CLF line
+			return nil
+		}
+	}
+	request := joinFields(l, parts, requestIndex, requestEndIndex+1)
+	request = request[1 : len(request)-1]
 
-			// Remove surrounding quotes
-			if len(fullRequest) >= 2 && fullRequest[0] == '"' && fullRequest[len(fullRequest)-1] == '"' {
-				request = fullRequest[1 : len(fullRequest)-1]
-			} else {
-				// No closing quote found, this is not a valid CLF line
-				return nil
-			}
+	// Check if the request looks like a valid HTTP request, quoted JSON data isn't
+	method, target, ok := strings.Cut(request, " ")
+	if !ok || !isHTTPMethod(method) {
+		return nil
+	}
 
-			// Validate the request
-			if (strings.HasPrefix(request, "{") || strings.HasPrefix(request, "[")) && !strings.Contains(request, " ") {
-				// This looks like pure JSON data, not an HTTP request
-				return nil
-			}
-			requestParts := strings.Split(request, " ")
-			if len(requestParts) < 2 {
-				return nil
-			}
-			// Check if first part looks like HTTP method
-			method := requestParts[0]
-			validMethods := []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "TRACE"}
-			isValidMethod := false
-			for _, validMethod := range validMethods {
-				if method == validMethod {
-					isValidMethod = true
-					break
-				}
-			}
-			if !isValidMethod {
-				return nil
-			}
-		}
+	if result == nil {
+		result = make(Row)
 	}
 
 	// Parse first three fields: remote_host, remote_logname, remote_user
@@ -335,8 +527,7 @@ func parseCLF(l string) Row {
 		// Timestamp starts from parts[3]
 		timestampStart := 3
 		if requestIndex > timestampStart {
-			timestampParts := parts[timestampStart:requestIndex]
-			timestamp := strings.Join(timestampParts, " ")
+			timestamp := joinFields(l, parts, timestampStart, requestIndex)
 
 			// Remove surrounding brackets if present
 			if len(timestamp) >= 2 && timestamp[0] == '[' && timestamp[len(timestamp)-1] == ']' {
@@ -350,49 +541,24 @@ func parseCLF(l string) Row {
 		// Timestamp starts from parts[2]
 		timestampStart := 2
 		if requestIndex > timestampStart {
-			timestampParts := parts[timestampStart:requestIndex]
-			timestamp := strings.Join(timestampParts, " ")
-			result["timestamp"] = timestamp
+			result["timestamp"] = joinFields(l, parts, timestampStart, requestIndex)
 		}
 	}
 
-	// Parse request (combine quoted parts if needed)
-	request := parts[requestIndex]
-	actualRequestEndIndex := requestIndex
-	if !strings.HasSuffix(request, "\"") {
-		// Multi-part quoted request - find the closing quote
-		for i := requestIndex + 1; i < len(parts); i++ {
-			request += " " + parts[i]
-			actualRequestEndIndex = i
-			if strings.HasSuffix(parts[i], "\"") {
-				break
-			}
-		}
-	}
-
-	// Remove surrounding quotes from request
-	if len(request) >= 2 && request[0] == '"' && request[len(request)-1] == '"' {
-		request = request[1 : len(request)-1]
-	}
-
 	// Parse request into method, path, and protocol
-	requestParts := strings.Split(request, " ")
-	if len(requestParts) >= 3 {
-		result["method"] = requestParts[0]
-		result["path"] = requestParts[1]
-		result["protocol"] = requestParts[2]
-	} else if len(requestParts) >= 2 {
+	result["method"] = method
+	path, protocol, ok := strings.Cut(target, " ")
+	result["path"] = path
+	if ok {
+		protocol, _, _ = strings.Cut(protocol, " ")
+		result["protocol"] = protocol
+	} else {
 		// Handle requests without protocol (e.g., "GET /init.php")
-		result["method"] = requestParts[0]
-		result["path"] = requestParts[1]
 		result["protocol"] = "HTTP/1.0" // Default protocol when missing
-	} else {
-		// Fallback to storing full request if parsing fails
-		result["request"] = request
 	}
 
 	// Parse status (should be right after request)
-	statusIndex := actualRequestEndIndex + 1
+	statusIndex := requestEndIndex + 1
 	if statusIndex < len(parts) {
 		if status, err := strconv.Atoi(parts[statusIndex]); err == nil {
 			result["status"] = status
@@ -401,7 +567,7 @@ func parseCLF(l string) Row {
 
 	// Parse response size (should be right after status)
 	// Only parse if we have more parts and the next part looks like a number
-	sizeIndex := actualRequestEndIndex + 2
+	sizeIndex := requestEndIndex + 2
 	if sizeIndex < len(parts) && parts[sizeIndex] != "-" {
 		if size, err := strconv.Atoi(parts[sizeIndex]); err == nil {
 			result["response_size"] = size
@@ -417,6 +583,7 @@ func parseCLF(l string) Row {
 				result["response_size"] = 0
 			} else {
 				// Bracketed format - response size is required, this is not a valid CLF line
+				clear(result)
 				return nil
 			}
 		} else {
@@ -425,9 +592,10 @@ func parseCLF(l string) Row {
 	}
 
 	// Handle remaining optional fields (referer, user_agent, forwarded_for)
-	remainingStart := actualRequestEndIndex + 3
+	remainingStart := requestEndIndex + 3
 	if remainingStart < len(parts) {
-		quotedFields := parseQuotedFieldsFromSlice(parts[remainingStart:])
+		var buffer [4]string
+		quotedFields := parseQuotedFieldsFromSlice(buffer[:0], l, parts, remainingStart)
 
 		// Check if Combined Log Format (has referer and user-agent)
 		if len(quotedFields) > 0 && quotedFields[0] != "-" && quotedFields[0] != "" {
@@ -540,38 +708,29 @@ func parseTimestampMessage(l string) Row {
 	return result
 }
 
-// parseQuotedFieldsFromSlice parses quoted fields from a slice of strings.
-// Returns a slice of field values, handling quoted strings properly.
-func parseQuotedFieldsFromSlice(parts []string) []string {
-	var fields []string
-
-	i := 0
+// parseQuotedFieldsFromSlice appends the quoted fields of parts[from:], which were split from l by
+// appendFields, to fields. Returns the slice of field values, handling quoted strings properly.
+func parseQuotedFieldsFromSlice(fields []string, l string, parts []string, from int) []string {
+	i := from
 	for i < len(parts) {
 		part := parts[i]
 
 		// Check if this part starts with a quote
 		if strings.HasPrefix(part, "\"") {
-			var fieldValue strings.Builder
-			fieldValue.WriteString(part)
+			quotedStr := part
 
 			// Check if this quoted string spans multiple parts
 			if !strings.HasSuffix(part, "\"") {
 				// Multi-part quoted string
+				start := i
 				i++
-				for i < len(parts) {
-					nextPart := parts[i]
-					fieldValue.WriteString(" ")
-					fieldValue.WriteString(nextPart)
-
-					if strings.HasSuffix(nextPart, "\"") {
-						break
-					}
+				for i < len(parts)-1 && !strings.HasSuffix(parts[i], "\"") {
 					i++
 				}
+				quotedStr = joinFields(l, parts, start, min(i+1, len(parts)))
 			}
 
 			// Extract the content between quotes
-			quotedStr := fieldValue.String()
 			if len(quotedStr) >= 2 && quotedStr[0] == '"' && quotedStr[len(quotedStr)-1] == '"' {
 				// Handle empty quoted strings
 				if len(quotedStr) == 2 {
@@ -591,80 +750,247 @@ func parseQuotedFieldsFromSlice(parts []string) []string {
 	return fields
 }
 
+// joinFields joins parts[from:to], which were split from l by appendFields, with single spaces.
+// Fields that are separated by single spaces are a substring of l, which needs no allocation.
+func joinFields(l string, parts []string, from, to int) string {
+	// A field starts at the first occurrence after the end of the previous field, as only
+	// whitespace separates them
+	offset := 0
+	for _, part := range parts[:from] {
+		offset += strings.Index(l[offset:], part) + len(part)
+	}
+	start := offset + strings.Index(l[offset:], parts[from])
+	end := start
+	size := -1
+	for _, part := range parts[from:to] {
+		end += strings.Index(l[end:], part) + len(part)
+		size += len(part) + 1
+	}
+
+	joined := l[start:end]
+	if len(joined) == size && !strings.ContainsAny(joined, "\t\n\v\f\r") {
+		return joined
+	}
+	return strings.Join(parts[from:to], " ")
+}
+
+// isDecimalInteger reports whether the value is a decimal integer with an optional sign, as
+// accepted by strconv.Atoi
+func isDecimalInteger(value string) bool {
+	if value != "" && (value[0] == '+' || value[0] == '-') {
+		value = value[1:]
+	}
+	if value == "" {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isHTTPMethod reports whether the value is an HTTP request method
+func isHTTPMethod(value string) bool {
+	switch value {
+	case "GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "TRACE":
+		return true
+	}
+	return false
+}
+
 // parseLogfmt parses logfmt-formatted log lines.
 // Logfmt is a structured logging format with key=value pairs separated by spaces.
-// Values can be quoted or unquoted, with quoted values supporting spaces.
+// Values can be quoted or unquoted, with quoted values supporting spaces and backslash escapes.
+// Keys without a value (debug) are flags and stored as true, keys with an empty value (user=) as "".
+// Values that are JSON objects or arrays are decoded, nested maps are flattened by the Writer.
 // Examples:
 //
 //	time=2025-09-19T20:35:00Z level=info msg="User login successful" user_id=123
 //	service=user-api status=200 response_time=0.45
+//	level=warn msg="say \"hi\"" cached user= request={"id":7,"tags":["a","b"]}
 //
 // Fields: all key-value pairs with automatic type conversion for numbers
 func parseLogfmt(l string) Row {
-	result := make(Row)
+	return parseLogfmtInto(nil, l)
+}
 
-	// Split by spaces, but be careful with quoted values
-	parts := strings.Fields(l)
-	if len(parts) == 0 {
+// parseLogfmtInto parses a logfmt line into result, or into a new row when result is nil. result
+// is left empty when the line isn't logfmt.
+func parseLogfmtInto(result Row, l string) Row {
+	if result == nil {
+		result = make(Row)
+	}
+	var buffer [8]string
+	flags := scanLogfmt(result, l, buffer[:0])
+
+	// Only return result if we actually parsed some key-value pairs
+	if len(result) == 0 {
 		return nil
 	}
 
+	// A few flags are common (e.g. "cached"), but lines with more loose words than pairs are
+	// sentences that happen to contain a key=value, the words are not stored as flags
+	if len(flags) <= len(result) {
+		for _, flag := range flags {
+			if _, exists := result[flag]; !exists {
+				result[flag] = true
+			}
+		}
+	}
+
+	return result
+}
+
+// isLogfmtSentence reports whether a line parsed as logfmt has more loose words than key=value
+// pairs, like Connection from ip=1.2.3.4 failed
+func isLogfmtSentence(l string) bool {
+	pairs := make(Row)
+	flags := scanLogfmt(pairs, l, nil)
+	return len(pairs) > 0 && len(flags) > len(pairs)
+}
+
+// scanLogfmt stores the key=value pairs of l in result and appends the keys without value to flags
+func scanLogfmt(result Row, l string, flags []string) []string {
 	i := 0
-	for i < len(parts) {
-		part := parts[i]
+	for i < len(l) {
+		// Skip the separating whitespace
+		if l[i] == ' ' || l[i] == '\t' {
+			i++
+			continue
+		}
 
-		// Find the equals sign
-		eqIndex := strings.Index(part, "=")
-		if eqIndex == -1 {
-			// Not a key=value pair, skip
+		keyStart := i
+		for i < len(l) && l[i] != '=' && l[i] != ' ' && l[i] != '\t' {
 			i++
+		}
+		key := l[keyStart:i]
+
+		if i >= len(l) || l[i] != '=' {
+			// A key without value
+			flags = append(flags, key)
 			continue
 		}
+		i++ // Skip the equals sign
 
-		key := part[:eqIndex]
-		value := part[eqIndex+1:]
+		if key == "" {
+			// Not a key=value pair, skip the value
+			_, i = readLogfmtValue(l, i)
+			continue
+		}
 
-		// Check if value starts with quote
-		if strings.HasPrefix(value, "\"") {
-			// Handle quoted value that might span multiple parts
-			if strings.HasSuffix(value, "\"") && len(value) > 1 {
-				// Simple quoted value
-				value = value[1 : len(value)-1]
-			} else {
-				// Multi-part quoted value
-				value = value[1:] // Remove opening quote
-				i++
-				for i < len(parts) {
-					nextPart := parts[i]
-					if strings.HasSuffix(nextPart, "\"") {
-						// This is the last part of the quoted value
-						value += " " + nextPart[:len(nextPart)-1]
-						break
-					} else {
-						value += " " + nextPart
-					}
-					i++
-				}
-			}
+		var value any
+		value, i = readLogfmtValue(l, i)
+		result[key] = value
+	}
+	return flags
+}
+
+// readLogfmtValue reads the value starting at position i and returns it with the position after the value
+func readLogfmtValue(l string, i int) (any, int) {
+	if i >= len(l) || l[i] == ' ' || l[i] == '\t' {
+		return "", i
+	}
+
+	if l[i] == '"' {
+		value, end := readLogfmtQuoted(l, i)
+		if decoded, ok := decodeLogfmtJSON(value); ok {
+			return decoded, end
 		}
+		return convertLogfmtValue(value), end
+	}
 
-		// Try to convert to number
-		if intVal, err := strconv.Atoi(value); err == nil {
-			result[key] = intVal
-		} else if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
-			result[key] = floatVal
-		} else {
-			result[key] = value
+	if l[i] == '{' || l[i] == '[' {
+		decoder := json.NewDecoder(strings.NewReader(l[i:]))
+		decoder.UseNumber()
+		var decoded any
+		if err := decoder.Decode(&decoded); err == nil {
+			end := i + int(decoder.InputOffset())
+			if end >= len(l) || l[end] == ' ' || l[end] == '\t' {
+				return convertJSONNumbers(decoded), end
+			}
 		}
+	}
 
+	start := i
+	for i < len(l) && l[i] != ' ' && l[i] != '\t' {
 		i++
 	}
+	return convertLogfmtValue(l[start:i]), i
+}
 
-	// Only return result if we actually parsed some key-value pairs
-	if len(result) > 0 {
-		return result
+// readLogfmtQuoted reads a quoted value starting at the opening quote and resolves the escapes in it
+func readLogfmtQuoted(l string, i int) (string, int) {
+	i++ // Skip the opening quote
+
+	// Values without escapes are a substring of the line
+	end := strings.IndexAny(l[i:], `"\`)
+	if end == -1 {
+		return l[i:], len(l)
 	}
-	return nil
+	if l[i+end] == '"' {
+		return l[i : i+end], i + end + 1
+	}
+
+	var value strings.Builder
+	for i < len(l) {
+		switch c := l[i]; {
+		case c == '"':
+			return value.String(), i + 1
+		case c == '\\' && i+1 < len(l):
+			i++
+			switch l[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case 'r':
+				value.WriteByte('\r')
+			default:
+				value.WriteByte(l[i])
+			}
+		default:
+			value.WriteByte(c)
+		}
+		i++
+	}
+	// Unterminated quote: the value runs until the end of the line
+	return value.String(), i
+}
+
+// decodeLogfmtJSON decodes quoted values that contain a JSON object
+func decodeLogfmtJSON(value string) (any, bool) {
+	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") {
+		return nil, false
+	}
+	decoded := parseJSON(value)
+	if decoded == nil {
+		return nil, false
+	}
+	return map[string]any(decoded), true
+}
+
+// floatChars are the characters that strconv.ParseFloat accepts, also in hex floats, inf and nan
+const floatChars = "0123456789+-._xXpPaAbBcCdDeEfFiInNtTyY"
+
+// convertLogfmtValue converts numbers, other values are kept as string
+func convertLogfmtValue(value string) any {
+	// Words and timestamps skip the conversions, their errors allocate
+	for i := 0; i < len(value); i++ {
+		if strings.IndexByte(floatChars, value[i]) == -1 {
+			return value
+		}
+	}
+	if isDecimalInteger(value) {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+		return floatVal
+	}
+	return value
 }
 
 // parseMonolog parses Monolog-formatted log lines (Laravel/PHP logging format).
@@ -1,6 +1,7 @@
 package timeline
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/matryer/is"
@@ -367,6 +368,50 @@ func Test_parse_logfmt_simple_unquoted(t *testing.T) {
 	is.Equal(data["count"], 42)
 }
 
+func Test_parse_logfmt_escaped_quotes_and_empty_values(t *testing.T) {
+	is := is.New(t)
+	line := `level=warn msg="say \"hi\" to \\server" user= path="/a b"`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(len(data), 4)
+	is.Equal(data["msg"], `say "hi" to \server`)
+	is.Equal(data["user"], "")
+	is.Equal(data["path"], "/a b")
+}
+
+func Test_parse_logfmt_bare_keys_are_flags(t *testing.T) {
+	is := is.New(t)
+	line := `level=info msg=served cached path=/users`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(len(data), 4)
+	is.Equal(data["cached"], true)
+}
+
+func Test_parse_logfmt_json_values(t *testing.T) {
+	is := is.New(t)
+	line := `level=info request={"id":7,"user":{"name":"jane"}} tags=["a","b"] context="{\"retry\":2}"`
+
+	data := ParseLineToValues(line)
+
+	request, ok := data["request"].(map[string]any)
+	is.True(ok)
+	is.Equal(request["id"], 7)
+	is.Equal(request["user"], map[string]any{"name": "jane"})
+	is.Equal(data["tags"], []any{"a", "b"})
+	is.Equal(data["context"], map[string]any{"retry": 2})
+}
+
+func Test_parse_logfmt_sentence_with_key_value_keeps_the_pairs(t *testing.T) {
+	is := is.New(t)
+
+	data := ParseLineToValues(`Connection from ip=1.2.3.4 port=443 failed after retrying`)
+
+	is.Equal(data, Row{"ip": "1.2.3.4", "port": 443})
+}
+
 func Test_parse_logfmt_invalid_line(t *testing.T) {
 	is := is.New(t)
 	line := `this is not a logfmt line`
@@ -492,6 +537,31 @@ func Test_stripAnsiCodes_basic_colors(t *testing.T) {
 			input:    "\x1b[38;5;196m\x1b[48;5;15mColored text\x1b[0m",
 			expected: "Colored text",
 		},
+		{
+			name:     "cursor movement and erase line",
+			input:    "\x1b[2K\x1b[1A\x1b[10GDownloading 50%\x1b[?25l",
+			expected: "Downloading 50%",
+		},
+		{
+			name:     "window title terminated by bel",
+			input:    "\x1b]0;build\x07Build started",
+			expected: "Build started",
+		},
+		{
+			name:     "hyperlink terminated by st",
+			input:    "See \x1b]8;;https://example.com\x1b\\the docs\x1b]8;;\x1b\\ for more",
+			expected: "See the docs for more",
+		},
+		{
+			name:     "unterminated osc",
+			input:    "Done\x1b]0;title",
+			expected: "Done",
+		},
+		{
+			name:     "character set and save cursor",
+			input:    "\x1b(B\x1b7ready\x1b8",
+			expected: "ready",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -811,3 +881,132 @@ func Test_parse_monolog_with_simplified_json_array(t *testing.T) {
 		t.Errorf("Expected result_data to be []interface{}, got %T", data["result_data"])
 	}
 }
+
+func Test_parse_line_returns_detected_format(t *testing.T) {
+	is := is.New(t)
+
+	_, format, confidence := ParseLine(`{"level":"info","message":"User logged in"}`)
+	is.Equal(format, FormatJSON)
+	is.Equal(confidence, 1.0)
+
+	_, format, _ = ParseLine(`<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed`)
+	is.Equal(format, FormatSyslog)
+
+	_, format, _ = ParseLine(`method=GET path=/users format=html controller=UsersController action=index status=200 duration=12.3`)
+	is.Equal(format, FormatLograge)
+
+	_, format, _ = ParseLine(`time=2025-09-19T20:35:00Z level=info msg="User login successful"`)
+	is.Equal(format, FormatLogfmt)
+}
+
+func Test_parse_line_falls_back_to_plain_without_confidence(t *testing.T) {
+	is := is.New(t)
+
+	data, format, confidence := ParseLine("just some text")
+
+	is.Equal(data["message"], "just some text")
+	is.Equal(format, FormatPlain)
+	is.Equal(confidence, 0.0)
+}
+
+func Test_parse_syslog_rfc5424_line_without_structured_data(t *testing.T) {
+	is := is.New(t)
+	line := `<165>1 2003-10-11T22:14:15.003Z testhost.example.org evntslog - ID47 - Disk [sda] almost full`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["structured_data"], map[string]any{})
+	is.Equal(data["message"], "Disk [sda] almost full")
+}
+
+// benchmarkLines are typical lines of the hot formats
+var benchmarkLines = map[Format]string{
+	FormatLogfmt: `time=2025-09-19T20:35:00Z level=info msg="User login successful" user_id=123 duration=0.45 cached`,
+	FormatCLF:    `10.10.2.2 - - [20/Sep/2025:23:41:41 +0000] "GET /index.html HTTP/1.1" 200 39689 "-" "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7)"`,
+	FormatJSON:   `{"level":"info","message":"User logged in","user_id":123,"duration":0.45,"timestamp":"2023-01-01T12:00:00Z"}`,
+	FormatPlain:  `Waiting for the models to be refreshed`,
+}
+
+func Test_parse_line_into_reuses_the_row(t *testing.T) {
+	is := is.New(t)
+	lines := []string{
+		benchmarkLines[FormatLogfmt],
+		benchmarkLines[FormatCLF],
+		benchmarkLines[FormatJSON],
+		`{"level":"info","short_message":"hello","version":"1.1","host":"web-1","_user":7}`,
+		`method=GET path=/ format=html controller=HomeController action=index status=200 duration=1.2`,
+		`[2025-09-21 22:35:12] Waiting for models`,
+		`10.10.2.11 -  21/Sep/2025:19:41:57 +0000 "GET /init.php" 200`,
+		`Connection from ip=1.2.3.4 failed`,
+		``,
+	}
+
+	// Then every line is parsed like ParseLine, without the fields of the previous line
+	row := Row{"stale": true}
+	for _, line := range lines {
+		expected, expectedFormat, _ := ParseLine(line)
+
+		format, _ := ParseLineInto(row, line)
+
+		is.Equal(format, expectedFormat)
+		is.Equal(row, expected) // line
+	}
+}
+
+func Test_append_fields_splits_like_strings_fields(t *testing.T) {
+	is := is.New(t)
+
+	for _, value := range []string{"", "   ", "a", " a  b\tc\n", "a\u00a0b\u2003c ", "\xffa b"} {
+		is.Equal(appendFields([]string{}, value), strings.Fields(value)) // value
+	}
+}
+
+func Test_join_fields_keeps_the_substring_of_single_spaces(t *testing.T) {
+	is := is.New(t)
+	l := "a  \"GET / HTTP/1.1\" \"x\ty\""
+	parts := appendFields(nil, l)
+
+	is.Equal(joinFields(l, parts, 1, 4), `"GET / HTTP/1.1"`)
+	is.Equal(joinFields(l, parts, 0, 2), `a "GET`)
+	is.Equal(joinFields(l, parts, 4, 6), `"x y"`)
+}
+
+func Test_convert_logfmt_value(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(convertLogfmtValue("123"), 123)
+	is.Equal(convertLogfmtValue("-7"), -7)
+	is.Equal(convertLogfmtValue("0.45"), 0.45)
+	is.Equal(convertLogfmtValue("1e3"), 1000.0)
+	is.Equal(convertLogfmtValue("99999999999999999999"), 1e20)
+	is.Equal(convertLogfmtValue("2025-09-19T20:35:00Z"), "2025-09-19T20:35:00Z")
+	is.Equal(convertLogfmtValue("info"), "info")
+	is.Equal(convertLogfmtValue(""), "")
+}
+
+func Benchmark_parse_line(b *testing.B) {
+	for _, format := range []Format{FormatLogfmt, FormatCLF, FormatJSON, FormatPlain} {
+		line := benchmarkLines[format]
+		b.Run(string(format), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(line)))
+			for i := 0; i < b.N; i++ {
+				ParseLine(line)
+			}
+		})
+	}
+}
+
+func Benchmark_parse_line_into(b *testing.B) {
+	for _, format := range []Format{FormatLogfmt, FormatCLF, FormatJSON, FormatPlain} {
+		line := benchmarkLines[format]
+		b.Run(string(format), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(line)))
+			row := make(Row)
+			for i := 0; i < b.N; i++ {
+				ParseLineInto(row, line)
+			}
+		})
+	}
+}
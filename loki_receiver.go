@@ -0,0 +1,303 @@
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLokiRequestSize limits the (uncompressed) size of a push request
+const maxLokiRequestSize = 32 << 20
+
+// LokiReceiverConfig configures a LokiReceiver
+type LokiReceiverConfig struct {
+	// Table is the table for all streams, defaults to loki
+	Table string
+	// TableFor chooses the table per row, e.g. LokiTableByLabel("job"); overrides Table
+	TableFor func(row Row) string
+	// Parser parses the log lines, defaults to ParseLineToValues
+	Parser Parser
+}
+
+// LokiReceiver is an http.Handler for the Loki push API (usually mounted at /loki/api/v1/push),
+// so Promtail, Grafana Alloy and other Loki clients can send their logs to a timeline database.
+// It accepts snappy compressed protobuf and (gzip compressed) JSON requests.
+// The log lines are parsed with ParseLineToValues, the stream labels and structured metadata
+// are added as columns (fields of the parsed line win) and the entry timestamp becomes the timestamp.
+type LokiReceiver struct {
+	writer *Writer
+	config LokiReceiverConfig
+}
+
+// NewLokiReceiver creates a Loki push API receiver
+func NewLokiReceiver(writer *Writer, config LokiReceiverConfig) *LokiReceiver {
+	if config.Table == "" {
+		config.Table = "loki"
+	}
+	if config.Parser == nil {
+		config.Parser = ParserFunc(ParseLineToValues)
+	}
+	return &LokiReceiver{writer: writer, config: config}
+}
+
+// LokiTableByLabel writes every stream to the table named after the value of the label, e.g. the job
+func LokiTableByLabel(label string) func(row Row) string {
+	return func(row Row) string {
+		if value, ok := row[normalizeColumnName(label)].(string); ok {
+			if name := normalizeColumnName(value); name != "" && (name[0] < '0' || name[0] > '9') {
+				return name
+			}
+		}
+		return "loki"
+	}
+}
+
+// lokiEntry is a log line with its labels
+type lokiEntry struct {
+	timestamp time.Time
+	line      string
+	labels    map[string]string
+}
+
+func (l *LokiReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := readRequestBody(r, maxLokiRequestSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var entries []lokiEntry
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		entries, err = decodeLokiJSON(body)
+	case "application/x-protobuf", "":
+		// Promtail always sends snappy compressed protobuf
+		entries, err = decodeLokiProtobuf(body)
+	default:
+		http.Error(w, "unsupported content type, expected application/x-protobuf or application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid push request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := l.write(entries); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// write parses and writes the entries, returns the last error
+func (l *LokiReceiver) write(entries []lokiEntry) error {
+	var lastErr error
+	failed := 0
+	for _, entry := range entries {
+		row := l.config.Parser.Parse(entry.line)
+		if row == nil {
+			continue
+		}
+		for name, value := range entry.labels {
+			column := normalizeColumnName(name)
+			if _, exists := row[column]; !exists && column != "" {
+				row[column] = value
+			}
+		}
+
+		table := l.config.Table
+		if l.config.TableFor != nil {
+			table = l.config.TableFor(row)
+		}
+		if err := l.writer.Write(table, NewRow(entry.timestamp, row)); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return fmt.Errorf("failed to write %d of %d entries: %w", failed, len(entries), lastErr)
+	}
+	return nil
+}
+
+// decodeLokiJSON decodes {"streams":[{"stream":{"job":"app"},"values":[["<unix nanos>","line",{"metadata":"value"}]]}]}
+func decodeLokiJSON(body []byte) ([]lokiEntry, error) {
+	var request struct {
+		Streams []struct {
+			Stream map[string]string   `json:"stream"`
+			Values [][]json.RawMessage `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	var entries []lokiEntry
+	for _, stream := range request.Streams {
+		for _, value := range stream.Values {
+			if len(value) < 2 {
+				return nil, errors.New("a value needs a timestamp and a line")
+			}
+			var nanos, line string
+			if err := json.Unmarshal(value[0], &nanos); err != nil {
+				return nil, fmt.Errorf("invalid timestamp: %w", err)
+			}
+			unixNanos, err := strconv.ParseInt(nanos, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp %s: %w", nanos, err)
+			}
+			if err := json.Unmarshal(value[1], &line); err != nil {
+				return nil, fmt.Errorf("invalid line: %w", err)
+			}
+
+			labels := make(map[string]string, len(stream.Stream))
+			for k, v := range stream.Stream {
+				labels[k] = v
+			}
+			if len(value) > 2 {
+				var metadata map[string]string
+				if err := json.Unmarshal(value[2], &metadata); err != nil {
+					return nil, fmt.Errorf("invalid structured metadata: %w", err)
+				}
+				for k, v := range metadata {
+					labels[k] = v
+				}
+			}
+
+			entries = append(entries, lokiEntry{timestamp: time.Unix(0, unixNanos).UTC(), line: line, labels: labels})
+		}
+	}
+	return entries, nil
+}
+
+// decodeLokiProtobuf decodes a snappy compressed logproto.PushRequest
+func decodeLokiProtobuf(body []byte) ([]lokiEntry, error) {
+	decoded, err := decodeSnappy(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []lokiEntry
+	err = decodeProtoFields(&protoReader{buf: decoded}, func(field int, r *protoReader) (bool, error) {
+		if field != 1 {
+			return false, nil
+		}
+		// StreamAdapter: labels = 1, entries = 2
+		var labels map[string]string
+		var streamEntries []lokiEntry
+		err := decodeProtoMessage(r, func(r *protoReader) error {
+			return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+				switch field {
+				case 1:
+					selector, err := r.bytes()
+					if err != nil {
+						return true, err
+					}
+					labels, err = parseLokiLabels(string(selector))
+					return true, err
+				case 2:
+					entry, err := decodeLokiEntry(r)
+					streamEntries = append(streamEntries, entry)
+					return true, err
+				}
+				return false, nil
+			})
+		})
+		for _, entry := range streamEntries {
+			for k, v := range labels {
+				if _, exists := entry.labels[k]; !exists {
+					entry.labels[k] = v
+				}
+			}
+			entries = append(entries, entry)
+		}
+		return true, err
+	})
+	return entries, err
+}
+
+// decodeLokiEntry decodes an EntryAdapter: timestamp = 1, line = 2, structured metadata = 3
+func decodeLokiEntry(r *protoReader) (lokiEntry, error) {
+	entry := lokiEntry{labels: make(map[string]string)}
+	err := decodeProtoMessage(r, func(r *protoReader) error {
+		return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+			switch field {
+			case 1:
+				// google.protobuf.Timestamp: seconds = 1, nanos = 2
+				var seconds, nanos int64
+				err := decodeProtoMessage(r, func(r *protoReader) error {
+					return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+						value, err := r.varint()
+						switch field {
+						case 1:
+							seconds = int64(value)
+						case 2:
+							nanos = int64(value)
+						}
+						return true, err
+					})
+				})
+				entry.timestamp = time.Unix(seconds, nanos).UTC()
+				return true, err
+			case 2:
+				line, err := r.bytes()
+				entry.line = string(line)
+				return true, err
+			case 3:
+				var name, value string
+				err := decodeProtoMessage(r, func(r *protoReader) error {
+					return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+						text, err := r.bytes()
+						switch field {
+						case 1:
+							name = string(text)
+						case 2:
+							value = string(text)
+						}
+						return true, err
+					})
+				})
+				entry.labels[name] = value
+				return true, err
+			}
+			return false, nil
+		})
+	})
+	return entry, err
+}
+
+// parseLokiLabels parses a label selector like {job="app", env="prod"}
+func parseLokiLabels(selector string) (map[string]string, error) {
+	labels := make(map[string]string)
+	rest := strings.TrimSpace(selector)
+	if !strings.HasPrefix(rest, "{") || !strings.HasSuffix(rest, "}") {
+		return nil, fmt.Errorf("invalid labels %s", selector)
+	}
+	rest = strings.TrimSpace(rest[1 : len(rest)-1])
+
+	for rest != "" {
+		name, value, found := strings.Cut(rest, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid labels %s", selector)
+		}
+		value = strings.TrimSpace(value)
+		if !strings.HasPrefix(value, `"`) {
+			return nil, fmt.Errorf("invalid labels %s", selector)
+		}
+		quoted, end := readLogfmtQuoted(value, 0)
+		labels[strings.TrimSpace(name)] = quoted
+		rest = strings.TrimLeft(value[end:], ", ")
+	}
+	return labels, nil
+}
@@ -0,0 +1,127 @@
+package timeline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// snappyLiterals compresses data into a snappy block that only contains literals
+func snappyLiterals(data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		chunk := min(len(data), 60)
+		out = append(out, byte(chunk-1)<<2)
+		out = append(out, data[:chunk]...)
+		data = data[chunk:]
+	}
+	return out
+}
+
+func Test_decode_snappy_literals_and_copies(t *testing.T) {
+	is := is.New(t)
+
+	// "abc" as literal, followed by a copy of 6 bytes at offset 3
+	decoded, err := decodeSnappy([]byte{9, 2 << 2, 'a', 'b', 'c', 1 | 2<<2, 3})
+	is.NoErr(err)
+	is.Equal(string(decoded), "abcabcabc")
+
+	long := bytes.Repeat([]byte("0123456789"), 20)
+	decoded, err = decodeSnappy(snappyLiterals(long))
+	is.NoErr(err)
+	is.Equal(decoded, long)
+
+	_, err = decodeSnappy([]byte{9, 1 | 2<<2, 3})
+	is.True(err != nil)
+}
+
+func Test_decode_snappy_limits_the_decoded_size(t *testing.T) {
+	is := is.New(t)
+
+	// A header that declares more than a push request may hold
+	header := binary.AppendUvarint(nil, maxLokiRequestSize+1)
+	_, err := decodeSnappy(append(header, 0, 'a'))
+	is.True(errors.Is(err, errSnappyCorrupt))
+
+	// A copy that grows beyond the declared length is rejected before it is appended
+	_, err = decodeSnappy([]byte{5, 2 << 2, 'a', 'b', 'c', 1 | 2<<2, 3})
+	is.True(errors.Is(err, errSnappyCorrupt))
+
+	// A literal as well
+	_, err = decodeSnappy([]byte{2, 2 << 2, 'a', 'b', 'c'})
+	is.True(errors.Is(err, errSnappyCorrupt))
+}
+
+func Test_parse_loki_labels(t *testing.T) {
+	is := is.New(t)
+
+	labels, err := parseLokiLabels(`{job="api", filename="/var/log/a \"b\".log"}`)
+
+	is.NoErr(err)
+	is.Equal(labels, map[string]string{"job": "api", "filename": `/var/log/a "b".log`})
+}
+
+func Test_loki_receiver_accepts_json_push(t *testing.T) {
+	is, writer := setup(t)
+	receiver := NewLokiReceiver(writer, LokiReceiverConfig{})
+	body := `{"streams":[{"stream":{"job":"api","env":"prod"},"values":[
+		["1727000000000000000","level=error msg=\"payment failed\" status=502",{"trace_id":"abc"}],
+		["1727000001000000000","plain line"]
+	]}]}`
+
+	request := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "application/json")
+	response := httptest.NewRecorder()
+	receiver.ServeHTTP(response, request)
+
+	is.Equal(response.Code, http.StatusNoContent)
+
+	var timestamp time.Time
+	var msg, job, traceID string
+	var status int
+	err := writer.DB.QueryRow(`SELECT timestamp, msg, status, job, trace_id FROM loki WHERE level = 'error'`).
+		Scan(&timestamp, &msg, &status, &job, &traceID)
+	is.NoErr(err)
+	is.Equal(timestamp.UTC(), time.Unix(1727000000, 0).UTC())
+	is.Equal(msg, "payment failed")
+	is.Equal(status, 502)
+	is.Equal(job, "api")
+	is.Equal(traceID, "abc")
+
+	var message string
+	is.NoErr(writer.DB.QueryRow(`SELECT message FROM loki WHERE env = 'prod' AND message IS NOT NULL`).Scan(&message))
+	is.Equal(message, "plain line")
+}
+
+func Test_loki_receiver_accepts_snappy_protobuf_push(t *testing.T) {
+	is, writer := setup(t)
+	receiver := NewLokiReceiver(writer, LokiReceiverConfig{TableFor: LokiTableByLabel("job")})
+
+	timestamp := concat(protoVarintField(1, 1727000000), protoVarintField(2, 500))
+	metadata := concat(protoBytesField(1, []byte("pod")), protoBytesField(2, []byte("web-7")))
+	entry := concat(protoBytesField(1, timestamp), protoBytesField(2, []byte(`{"level":"info","n":1}`)), protoBytesField(3, metadata))
+	stream := concat(protoBytesField(1, []byte(`{job="worker", env="prod"}`)), protoBytesField(2, entry), protoVarintField(3, 12345))
+	push := protoBytesField(1, stream)
+
+	request := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", bytes.NewReader(snappyLiterals(push)))
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	response := httptest.NewRecorder()
+	receiver.ServeHTTP(response, request)
+
+	is.Equal(response.Code, http.StatusNoContent)
+
+	var ts time.Time
+	var n int
+	var env, pod string
+	is.NoErr(writer.DB.QueryRow(`SELECT timestamp, n, env, pod FROM worker`).Scan(&ts, &n, &env, &pod))
+	is.Equal(ts.UTC(), time.Unix(1727000000, 500).UTC().Truncate(time.Microsecond))
+	is.Equal(n, 1)
+	is.Equal(env, "prod")
+	is.Equal(pod, "web-7")
+}
@@ -0,0 +1,326 @@
+package timeline
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxLumberjackPayload limits the size of a (decompressed) frame
+const maxLumberjackPayload = 64 << 20
+
+// LumberjackServerConfig configures a LumberjackServer
+type LumberjackServerConfig struct {
+	// Addr is the address of the listener, e.g. :5044
+	Addr string
+	// TLSConfig enables TLS on the listener
+	TLSConfig *tls.Config
+	// Table is the table for all events, defaults to beats
+	Table string
+	// TableFor chooses the table per event, e.g. by the fields.service column; overrides Table
+	TableFor func(row Row) string
+	// ParseMessage parses the message of an event with ParseLineToValues and adds the fields
+	// (fields of the event win)
+	ParseMessage bool
+	// OnError is called for events that could not be written and for failing connections
+	OnError func(error)
+}
+
+// LumberjackServer implements the Lumberjack v2 protocol of Elastic Beats, so Filebeat, Winlogbeat
+// and other Beats can ship their events with the Logstash output. The nested event fields are
+// written as flattened columns (host.name becomes host_name), @timestamp is the timestamp and
+// @metadata is dropped. A window of events is acknowledged after it is written; events that could
+// not be written are reported to OnError and acknowledged too, to prevent endless redelivery.
+type LumberjackServer struct {
+	writer *Writer
+	config LumberjackServerConfig
+}
+
+// NewLumberjackServer creates a Lumberjack server, call ListenAndServe to start it
+func NewLumberjackServer(writer *Writer, config LumberjackServerConfig) *LumberjackServer {
+	if config.Table == "" {
+		config.Table = "beats"
+	}
+	return &LumberjackServer{writer: writer, config: config}
+}
+
+// ListenAndServe listens on Addr and serves the connections until ctx is done
+func (l *LumberjackServer) ListenAndServe(ctx context.Context) error {
+	var listener net.Listener
+	var err error
+	if l.config.TLSConfig != nil {
+		listener, err = tls.Listen("tcp", l.config.Addr, l.config.TLSConfig)
+	} else {
+		listener, err = net.Listen("tcp", l.config.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", l.config.Addr, err)
+	}
+	return l.Serve(ctx, listener)
+}
+
+// Serve accepts connections on listener until ctx is done
+func (l *LumberjackServer) Serve(ctx context.Context, listener net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to accept Lumberjack connection: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.serveConn(ctx, conn); err != nil && ctx.Err() == nil {
+				l.reportError(fmt.Errorf("Lumberjack connection %s: %w", conn.RemoteAddr(), err))
+			}
+		}()
+	}
+}
+
+// lumberjackWindow collects the events of a window until all are received
+type lumberjackWindow struct {
+	size    uint32
+	lastSeq uint32
+	events  []Row
+}
+
+// serveConn reads the frames of a connection and acknowledges every complete window
+func (l *LumberjackServer) serveConn(ctx context.Context, conn net.Conn) error {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	reader := bufio.NewReader(conn)
+	window := &lumberjackWindow{}
+	for {
+		if err := l.readFrame(reader, window); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if window.size > 0 && uint32(len(window.events)) >= window.size {
+			l.write(window.events)
+			if err := writeLumberjackAck(conn, window.lastSeq); err != nil {
+				return err
+			}
+			window.events = window.events[:0]
+		}
+	}
+}
+
+// readFrame reads a frame: version '2' (or '1'), type and the payload of the type.
+// W = window size, C = zlib compressed frames, J = JSON event, D = key/value event
+func (l *LumberjackServer) readFrame(reader *bufio.Reader, window *lumberjackWindow) error {
+	var header [2]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return err
+	}
+	if header[0] != '2' && header[0] != '1' {
+		return fmt.Errorf("unsupported protocol version %q", header[0])
+	}
+
+	switch header[1] {
+	case 'W':
+		size, err := readUint32(reader)
+		window.size = size
+		return err
+	case 'C':
+		payload, err := readLumberjackPayload(reader)
+		if err != nil {
+			return err
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("invalid compressed frame: %w", err)
+		}
+		defer zr.Close()
+		decompressed, err := io.ReadAll(io.LimitReader(zr, maxLumberjackPayload+1))
+		if err != nil {
+			return fmt.Errorf("invalid compressed frame: %w", err)
+		}
+		if len(decompressed) > maxLumberjackPayload {
+			return fmt.Errorf("compressed frame larger than %d bytes", maxLumberjackPayload)
+		}
+		inner := bufio.NewReader(bytes.NewReader(decompressed))
+		for {
+			if err := l.readFrame(inner, window); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+		}
+	case 'J':
+		seq, err := readUint32(reader)
+		if err != nil {
+			return err
+		}
+		payload, err := readLumberjackPayload(reader)
+		if err != nil {
+			return err
+		}
+		event := parseJSON(string(payload))
+		if event == nil {
+			return fmt.Errorf("invalid JSON event %d", seq)
+		}
+		window.lastSeq = seq
+		window.events = append(window.events, l.eventToRow(event))
+		return nil
+	case 'D':
+		seq, err := readUint32(reader)
+		if err != nil {
+			return err
+		}
+		pairs, err := readUint32(reader)
+		if err != nil {
+			return err
+		}
+		event := make(Row)
+		for i := uint32(0); i < pairs; i++ {
+			key, err := readLumberjackPayload(reader)
+			if err != nil {
+				return err
+			}
+			value, err := readLumberjackPayload(reader)
+			if err != nil {
+				return err
+			}
+			event[string(key)] = string(value)
+		}
+		// Lumberjack v1 forwarders send the log line as line
+		if line, ok := event["line"]; ok {
+			if _, exists := event["message"]; !exists {
+				event["message"] = line
+				delete(event, "line")
+			}
+		}
+		window.lastSeq = seq
+		window.events = append(window.events, l.eventToRow(event))
+		return nil
+	}
+	return fmt.Errorf("unsupported frame type %q", header[1])
+}
+
+// eventToRow maps a Beats event to a row
+func (l *LumberjackServer) eventToRow(event Row) Row {
+	row := make(Row, len(event))
+	if message, ok := event["message"].(string); ok && l.config.ParseMessage {
+		for k, v := range ParseLineToValues(message) {
+			row[k] = v
+		}
+	}
+
+	timestamp := time.Now()
+	for key, value := range event {
+		switch key {
+		case "@metadata":
+		case "@timestamp":
+			if text, ok := value.(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+					timestamp = t.UTC()
+					row["timestamp"] = timestamp
+				}
+			}
+		default:
+			if column := normalizeColumnName(key); column != "" {
+				row[column] = normalizeNestedKeys(value)
+			}
+		}
+	}
+	return NewRow(timestamp, row)
+}
+
+// normalizeNestedKeys normalizes the keys of nested objects, so they can be flattened to column names
+func normalizeNestedKeys(value any) any {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	normalized := make(map[string]any, len(object))
+	for k, v := range object {
+		if column := normalizeColumnName(k); column != "" {
+			normalized[column] = normalizeNestedKeys(v)
+		}
+	}
+	return normalized
+}
+
+// write writes the events of a window
+func (l *LumberjackServer) write(events []Row) {
+	for _, row := range events {
+		table := l.config.Table
+		if l.config.TableFor != nil {
+			table = l.config.TableFor(row)
+		}
+		if err := l.writer.Write(table, row); err != nil {
+			l.reportError(fmt.Errorf("failed to write Beats event: %w", err))
+		}
+	}
+}
+
+// writeLumberjackAck acknowledges all events up to seq
+func writeLumberjackAck(w io.Writer, seq uint32) error {
+	ack := []byte{'2', 'A', 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(ack[2:], seq)
+	if _, err := w.Write(ack); err != nil {
+		return fmt.Errorf("failed to send ack: %w", err)
+	}
+	return nil
+}
+
+func readUint32(reader io.Reader) (uint32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(reader, buf[:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return binary.BigEndian.Uint32(buf[:]), nil
+}
+
+// readLumberjackPayload reads a payload with a 32 bit length
+func readLumberjackPayload(reader io.Reader) ([]byte, error) {
+	length, err := readUint32(reader)
+	if err != nil {
+		return nil, err
+	}
+	if length > maxLumberjackPayload {
+		return nil, fmt.Errorf("frame larger than %d bytes", maxLumberjackPayload)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return payload, nil
+}
+
+// unexpectedEOF turns an EOF inside a frame into io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (l *LumberjackServer) reportError(err error) {
+	handleError(l.config.OnError, err, ErrorContext{Component: "lumberjack_server", Table: l.config.Table})
+}
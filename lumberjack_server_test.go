@@ -0,0 +1,118 @@
+package timeline
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func startLumberjackServer(t *testing.T, server *LumberjackServer) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { server.Serve(ctx, listener); close(done) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return listener.Addr().String()
+}
+
+func lumberjackUint32(value uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, value)
+}
+
+func lumberjackJSONFrame(seq uint32, event string) []byte {
+	frame := append([]byte("2J"), lumberjackUint32(seq)...)
+	frame = append(frame, lumberjackUint32(uint32(len(event)))...)
+	return append(frame, event...)
+}
+
+func readLumberjackAck(t *testing.T, conn net.Conn) uint32 {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	ack := make([]byte, 6)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		t.Fatalf("failed to read ack: %v", err)
+	}
+	if string(ack[:2]) != "2A" {
+		t.Fatalf("unexpected ack %q", ack)
+	}
+	return binary.BigEndian.Uint32(ack[2:])
+}
+
+func Test_lumberjack_server_receives_compressed_beats_events(t *testing.T) {
+	is, writer := setup(t)
+	server := NewLumberjackServer(writer, LumberjackServerConfig{ParseMessage: true, OnError: func(err error) { t.Errorf("server error: %v", err) }})
+	addr := startLumberjackServer(t, server)
+
+	// Given a window of two Filebeat events in a compressed frame
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(lumberjackJSONFrame(1, `{"@timestamp":"2024-09-01T12:00:00.000Z","@metadata":{"beat":"filebeat"},"message":"level=error duration_ms=12","host":{"name":"web-1"},"log":{"file":{"path":"/var/log/app.log"}}}`))
+	zw.Write(lumberjackJSONFrame(2, `{"@timestamp":"2024-09-01T12:00:01.000Z","message":"started","host":{"name":"web-1"}}`))
+	zw.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	is.NoErr(err)
+	defer conn.Close()
+	frames := append([]byte("2W"), lumberjackUint32(2)...)
+	frames = append(frames, '2', 'C')
+	frames = append(frames, lumberjackUint32(uint32(compressed.Len()))...)
+	frames = append(frames, compressed.Bytes()...)
+
+	// When the window is sent
+	_, err = conn.Write(frames)
+	is.NoErr(err)
+
+	// Then the window is acknowledged after the events are written
+	is.Equal(readLumberjackAck(t, conn), uint32(2))
+
+	var host, path, level string
+	var duration int
+	var timestamp time.Time
+	is.NoErr(writer.DB.QueryRow(`SELECT host_name, log_file_path, level, duration_ms, timestamp FROM beats WHERE message LIKE 'level=%'`).Scan(&host, &path, &level, &duration, &timestamp))
+	is.Equal(host, "web-1")
+	is.Equal(path, "/var/log/app.log")
+	is.Equal(level, "error")
+	is.Equal(duration, 12)
+	is.Equal(timestamp.UTC(), time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC))
+
+	var count int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*) FROM beats`).Scan(&count))
+	is.Equal(count, 2)
+}
+
+func Test_lumberjack_server_receives_v1_data_frames(t *testing.T) {
+	is, writer := setup(t)
+	server := NewLumberjackServer(writer, LumberjackServerConfig{Table: "forwarder"})
+	addr := startLumberjackServer(t, server)
+
+	frame := append([]byte("1W"), lumberjackUint32(1)...)
+	frame = append(frame, '1', 'D')
+	frame = append(frame, lumberjackUint32(7)...)
+	frame = append(frame, lumberjackUint32(2)...)
+	for _, text := range []string{"line", "hello", "file", "/var/log/syslog"} {
+		frame = append(frame, lumberjackUint32(uint32(len(text)))...)
+		frame = append(frame, text...)
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	is.NoErr(err)
+	defer conn.Close()
+	_, err = conn.Write(frame)
+	is.NoErr(err)
+
+	is.Equal(readLumberjackAck(t, conn), uint32(7))
+	var message, file string
+	is.NoErr(writer.DB.QueryRow(`SELECT message, file FROM forwarder`).Scan(&message, &file))
+	is.Equal(message, "hello")
+	is.Equal(file, "/var/log/syslog")
+}
@@ -3,6 +3,7 @@ package timeline
 import (
 	"database/sql"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -23,6 +24,40 @@ func setup(t *testing.T) (*is.I, *Writer) {
 	return is, writer
 }
 
+func Test_concurrent_writes_change_the_schema_one_at_a_time(t *testing.T) {
+	is, writer := setup(t)
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	// When goroutines create the same table and add columns and promote their types concurrently
+	var wg sync.WaitGroup
+	errs := make(chan error, 160)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				row := Row{"message": "hello", fmt.Sprintf("field_%d", i%5): i}
+				if g%2 == 0 {
+					row["value"] = fmt.Sprintf("text %d", i)
+				} else {
+					row["value"] = i
+				}
+				errs <- writer.Write("logs", NewRow(now, row))
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// Then every row is written
+	for err := range errs {
+		is.NoErr(err)
+	}
+	var count int
+	is.NoErr(writer.DB.QueryRow("SELECT count(*) FROM logs").Scan(&count))
+	is.Equal(count, 160)
+}
+
 func Test_do_not_create_table_on_empty_row(t *testing.T) {
 	is, writer := setup(t)
 
@@ -135,10 +170,38 @@ func Test_set_timestamp_column_by_user_value(t *testing.T) {
 	is.Equal(rows[0], userTime)
 }
 
+func Test_set_timestamp_column_by_user_string(t *testing.T) {
+	is := is.New(t)
+	currentTime := time.Now().UTC()
+	userTime := time.Date(2022, 12, 31, 23, 59, 59, 0, time.UTC)
+	for _, value := range []string{
+		"2022-12-31T23:59:59Z",
+		"2023-01-01T01:59:59+02:00",
+		"2022-12-31 23:59:59",
+		"31/Dec/2022:23:59:59 +0000",
+	} {
+		row := NewRow(currentTime, Row{"timestamp": value, "title": "my title"})
+		is.Equal(row["timestamp"], userTime) // value
+	}
+}
+
+func Test_parse_timestamp_in_an_appended_format(t *testing.T) {
+	is := is.New(t)
+	layouts := append(TimestampFormats[:len(TimestampFormats):len(TimestampFormats)], "Jan 2 2006 15:04")
+
+	parsed, ok := parseTimestamp("Dec 31 2022 23:59", layouts)
+	is.True(ok)
+	is.Equal(parsed, time.Date(2022, 12, 31, 23, 59, 0, 0, time.UTC))
+
+	// The formats of NewRow are not changed
+	_, ok = parseTimestamp("Dec 31 2022 23:59", TimestampFormats)
+	is.True(!ok)
+}
+
 func Test_set_timestamp_but_rename_if_not_a_timestamp_value(t *testing.T) {
 	is, w := setup(t)
 
-	currentTime := time.Now().UTC()
+	currentTime := time.Now().UTC().Truncate(time.Microsecond)
 	err := w.Write("timeline", NewRow(currentTime, Row{"timestamp": "not a timestamp", "title": "my title"}))
 
 	is.NoErr(err)
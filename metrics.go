@@ -0,0 +1,263 @@
+package timeline
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives the measurements of a writer, see SetMetrics. The tables are the tables that are
+// written to, not their daily partitions. Implement it to feed another metrics library, e.g. with
+// the counters and histograms of a prometheus.Collector, without a dependency of this package on
+// that library. PrometheusMetrics is an implementation without dependencies.
+type Metrics interface {
+	// RowWritten is called for every written row with the duration of Write
+	RowWritten(table string, duration time.Duration)
+	// WriteFailed is called for every row that could not be written
+	WriteFailed(table string)
+	// BatchWritten is called for every batch of Ingest and of a Pipeline with the number of
+	// written rows
+	BatchWritten(table string, size int)
+	// ParseFailed is called for every line of Ingest and of a Pipeline that no parser could parse
+	ParseFailed(table string)
+	// ColumnPromoted is called when the type of a column is promoted by a written row
+	ColumnPromoted(table string, column string, from ColumnType, to ColumnType)
+	// ColumnAdded is called when a column is added by a written row
+	ColumnAdded(table string, column string, columnType ColumnType)
+}
+
+// metricsRef holds the Metrics of a writer, an interface can't be stored in an atomic.Pointer
+type metricsRef struct {
+	Metrics
+}
+
+// SetMetrics sends the measurements of the writer to metrics, nil stops the measurements
+func (w *Writer) SetMetrics(metrics Metrics) {
+	if metrics == nil {
+		w.metricsRef.Store(nil)
+		return
+	}
+	w.metricsRef.Store(&metricsRef{metrics})
+}
+
+// metrics returns the Metrics of the writer, nil without metrics
+func (w *Writer) metrics() Metrics {
+	if ref := w.metricsRef.Load(); ref != nil {
+		return ref.Metrics
+	}
+	return nil
+}
+
+// metricsTable returns the partitioned table of a partition, or the table
+func (w *Writer) metricsTable(table string) string {
+	if partitioned, _, ok := w.partitionOf(table); ok {
+		return partitioned
+	}
+	return table
+}
+
+// observeWrite measures a write of a row that started at start
+func (w *Writer) observeWrite(table string, start time.Time, err error) {
+	metrics := w.metrics()
+	if metrics == nil {
+		return
+	}
+	if err != nil {
+		metrics.WriteFailed(table)
+		return
+	}
+	metrics.RowWritten(table, time.Since(start))
+}
+
+// observeBatch measures a batch of Ingest or of a Pipeline
+func (w *Writer) observeBatch(table string, written int, parseFailures int) {
+	metrics := w.metrics()
+	if metrics == nil {
+		return
+	}
+	for range parseFailures {
+		metrics.ParseFailed(table)
+	}
+	if written > 0 {
+		metrics.BatchWritten(table, written)
+	}
+}
+
+// DefaultLatencyBuckets are the upper bounds (in seconds) of the write latency histogram of
+// PrometheusMetrics
+var DefaultLatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// DefaultBatchSizeBuckets are the upper bounds of the batch size histogram of PrometheusMetrics
+var DefaultBatchSizeBuckets = []float64{1, 10, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// PrometheusMetrics counts the measurements of writers per table and serves them in the Prometheus
+// text format, so they can be scraped without the Prometheus client library:
+//
+//	metrics := timeline.NewPrometheusMetrics()
+//	writer.SetMetrics(metrics)
+//	http.Handle("/metrics", metrics)
+type PrometheusMetrics struct {
+	mu     sync.Mutex
+	tables map[string]*tableMetrics
+}
+
+// tableMetrics are the measurements of a table
+type tableMetrics struct {
+	rowsWritten   atomic.Int64
+	writeErrors   atomic.Int64
+	parseFailures atomic.Int64
+	promotions    atomic.Int64
+	columnsAdded  atomic.Int64
+	latency       histogram
+	batchSize     histogram
+}
+
+// NewPrometheusMetrics creates metrics with DefaultLatencyBuckets and DefaultBatchSizeBuckets
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{tables: make(map[string]*tableMetrics)}
+}
+
+func (p *PrometheusMetrics) table(table string) *tableMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t, ok := p.tables[table]
+	if !ok {
+		t = &tableMetrics{
+			latency:   newHistogram(DefaultLatencyBuckets),
+			batchSize: newHistogram(DefaultBatchSizeBuckets),
+		}
+		p.tables[table] = t
+	}
+	return t
+}
+
+func (p *PrometheusMetrics) RowWritten(table string, duration time.Duration) {
+	t := p.table(table)
+	t.rowsWritten.Add(1)
+	t.latency.observe(duration.Seconds())
+}
+
+func (p *PrometheusMetrics) WriteFailed(table string) {
+	p.table(table).writeErrors.Add(1)
+}
+
+func (p *PrometheusMetrics) BatchWritten(table string, size int) {
+	p.table(table).batchSize.observe(float64(size))
+}
+
+func (p *PrometheusMetrics) ParseFailed(table string) {
+	p.table(table).parseFailures.Add(1)
+}
+
+func (p *PrometheusMetrics) ColumnPromoted(table string, column string, from ColumnType, to ColumnType) {
+	p.table(table).promotions.Add(1)
+}
+
+func (p *PrometheusMetrics) ColumnAdded(table string, column string, columnType ColumnType) {
+	p.table(table).columnsAdded.Add(1)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (p *PrometheusMetrics) WriteTo(out io.Writer) (int64, error) {
+	p.mu.Lock()
+	names := make([]string, 0, len(p.tables))
+	for name := range p.tables {
+		names = append(names, name)
+	}
+	tables := make([]*tableMetrics, len(names))
+	slices.Sort(names)
+	for i, name := range names {
+		tables[i] = p.tables[name]
+	}
+	p.mu.Unlock()
+
+	var b strings.Builder
+	counters := []struct {
+		name  string
+		help  string
+		value func(t *tableMetrics) int64
+	}{
+		{"timeline_rows_written_total", "Number of written rows.", func(t *tableMetrics) int64 { return t.rowsWritten.Load() }},
+		{"timeline_write_errors_total", "Number of rows that could not be written.", func(t *tableMetrics) int64 { return t.writeErrors.Load() }},
+		{"timeline_parse_failures_total", "Number of ingested lines that could not be parsed.", func(t *tableMetrics) int64 { return t.parseFailures.Load() }},
+		{"timeline_column_promotions_total", "Number of column type promotions.", func(t *tableMetrics) int64 { return t.promotions.Load() }},
+		{"timeline_columns_added_total", "Number of added columns.", func(t *tableMetrics) int64 { return t.columnsAdded.Load() }},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name)
+		for i, name := range names {
+			fmt.Fprintf(&b, "%s{table=%s} %d\n", counter.name, prometheusLabel(name), counter.value(tables[i]))
+		}
+	}
+
+	histograms := []struct {
+		name      string
+		help      string
+		histogram func(t *tableMetrics) *histogram
+	}{
+		{"timeline_write_duration_seconds", "Duration of the writes of rows.", func(t *tableMetrics) *histogram { return &t.latency }},
+		{"timeline_batch_size", "Number of written rows of the ingested batches.", func(t *tableMetrics) *histogram { return &t.batchSize }},
+	}
+	for _, h := range histograms {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+		for i, name := range names {
+			h.histogram(tables[i]).write(&b, h.name, prometheusLabel(name))
+		}
+	}
+
+	n, err := io.WriteString(out, b.String())
+	return int64(n), err
+}
+
+// prometheusLabel quotes a label value
+func prometheusLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// histogram counts observations in cumulative buckets
+type histogram struct {
+	mu      sync.Mutex
+	bounds  []float64
+	buckets []int64
+	count   int64
+	sum     float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, buckets: make([]int64, len(bounds))}
+}
+
+func (h *histogram) observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func (h *histogram) write(b *strings.Builder, name string, table string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		fmt.Fprintf(b, "%s_bucket{table=%s,le=\"%s\"} %d\n", name, table, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{table=%s,le=\"+Inf\"} %d\n", name, table, h.count)
+	fmt.Fprintf(b, "%s_sum{table=%s} %s\n", name, table, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count{table=%s} %d\n", name, table, h.count)
+}
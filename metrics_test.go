@@ -0,0 +1,89 @@
+package timeline
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_metrics_count_written_rows_promotions_and_added_columns(t *testing.T) {
+	is, writer := setup(t)
+	metrics := NewPrometheusMetrics()
+	writer.SetMetrics(metrics)
+
+	// Given rows that add a column and promote it
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"status": 200})))
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"status": "ok"})))
+
+	// Then the metrics of the table are counted
+	var out strings.Builder
+	_, err := metrics.WriteTo(&out)
+	is.NoErr(err)
+	is.True(strings.Contains(out.String(), `timeline_rows_written_total{table="logs"} 2`))
+	is.True(strings.Contains(out.String(), `timeline_columns_added_total{table="logs"} 1`))
+	is.True(strings.Contains(out.String(), `timeline_column_promotions_total{table="logs"} 1`))
+	is.True(strings.Contains(out.String(), `timeline_write_duration_seconds_count{table="logs"} 2`))
+	is.True(strings.Contains(out.String(), `timeline_write_duration_seconds_bucket{table="logs",le="+Inf"} 2`))
+}
+
+func Test_metrics_count_write_errors(t *testing.T) {
+	is, writer := setup(t)
+	metrics := NewPrometheusMetrics()
+	writer.SetMetrics(metrics)
+
+	// Given a row that can't be written
+	is.True(writer.Write("logs", NewRow(time.Now(), Row{"bad column": func() {}})) != nil)
+
+	var out strings.Builder
+	_, err := metrics.WriteTo(&out)
+	is.NoErr(err)
+	is.True(strings.Contains(out.String(), `timeline_write_errors_total{table="logs"} 1`))
+	is.True(strings.Contains(out.String(), `timeline_rows_written_total{table="logs"} 0`))
+}
+
+func Test_metrics_count_batches_and_parse_failures_of_ingest(t *testing.T) {
+	is, writer := setup(t)
+	metrics := NewPrometheusMetrics()
+	writer.SetMetrics(metrics)
+
+	// Given a parser that only parses lines with a level
+	parser := ParserFunc(func(l string) Row {
+		if !strings.HasPrefix(l, "level=") {
+			return nil
+		}
+		return ParseLineToValues(l)
+	})
+	input := "level=info message=one\nnot parsed\nlevel=warn message=two\n"
+	result, err := writer.Ingest(context.Background(), strings.NewReader(input), "logs", WithParser(parser))
+	is.NoErr(err)
+	is.Equal(result.Written, 2)
+
+	// Then the batch and the line that wasn't parsed are counted, served over HTTP
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(recorder.Body)
+	is.NoErr(err)
+	is.True(strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+	is.True(strings.Contains(string(body), `timeline_parse_failures_total{table="logs"} 1`))
+	is.True(strings.Contains(string(body), `timeline_batch_size_bucket{table="logs",le="1"} 0`))
+	is.True(strings.Contains(string(body), `timeline_batch_size_bucket{table="logs",le="10"} 1`))
+	is.True(strings.Contains(string(body), `timeline_batch_size_sum{table="logs"} 2`))
+}
+
+func Test_metrics_of_a_partitioned_table_are_counted_for_the_table(t *testing.T) {
+	is, writer := setup(t)
+	metrics := NewPrometheusMetrics()
+	writer.SetMetrics(metrics)
+	writer.PartitionDaily("logs")
+
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "hello"})))
+
+	var out strings.Builder
+	_, err := metrics.WriteTo(&out)
+	is.NoErr(err)
+	is.True(strings.Contains(out.String(), `timeline_rows_written_total{table="logs"} 1`))
+	is.True(strings.Contains(out.String(), `timeline_columns_added_total{table="logs"} 1`))
+}
@@ -0,0 +1,272 @@
+package timeline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+)
+
+var errMMDBCorrupt = errors.New("corrupt MaxMind database")
+
+// mmdbMetadataMarker precedes the metadata at the end of a MaxMind database
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// mmdb is a MaxMind database (the MaxMind DB format 2.0 of the GeoIP2 and GeoLite2 databases): a
+// binary search tree of the bits of the IP addresses with records in a data section
+type mmdb struct {
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	// dataStart is the offset of the data section
+	dataStart uint
+	// ipv4Start is the node of the IPv4 addresses (::/96) of an IPv6 tree
+	ipv4Start uint
+	// databaseType is e.g. GeoLite2-City
+	databaseType string
+}
+
+// openMMDB parses a MaxMind database
+func openMMDB(data []byte) (*mmdb, error) {
+	i := bytes.LastIndex(data, mmdbMetadataMarker)
+	if i < 0 {
+		return nil, errors.New("not a MaxMind database: the metadata is missing")
+	}
+	db := &mmdb{data: data}
+	metadataStart := uint(i + len(mmdbMetadataMarker))
+	value, _, err := db.decode(metadataStart, metadataStart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata of MaxMind database: %w", err)
+	}
+	metadata, ok := value.(map[string]any)
+	if !ok {
+		return nil, errMMDBCorrupt
+	}
+	nodeCount, _ := metadata["node_count"].(uint64)
+	recordSize, _ := metadata["record_size"].(uint64)
+	ipVersion, _ := metadata["ip_version"].(uint64)
+	db.databaseType, _ = metadata["database_type"].(string)
+	if recordSize != 24 && recordSize != 28 && recordSize != 32 {
+		return nil, fmt.Errorf("unsupported record size %d of MaxMind database", recordSize)
+	}
+	if ipVersion != 4 && ipVersion != 6 {
+		return nil, fmt.Errorf("unsupported IP version %d of MaxMind database", ipVersion)
+	}
+	db.nodeCount, db.recordSize, db.ipVersion = uint(nodeCount), uint(recordSize), uint(ipVersion)
+
+	// The search tree is followed by 16 zero bytes and the data section
+	treeSize := db.nodeCount * db.recordSize / 4
+	if treeSize+16 > uint(i) {
+		return nil, errMMDBCorrupt
+	}
+	db.dataStart = treeSize + 16
+
+	if db.ipVersion == 6 {
+		node := uint(0)
+		for bit := 0; bit < 96 && node < db.nodeCount; bit++ {
+			if node, err = db.record(node, 0); err != nil {
+				return nil, err
+			}
+		}
+		db.ipv4Start = node
+	}
+	return db, nil
+}
+
+// lookup returns the record of the network of an IP address, nil when the address isn't in the
+// database
+func (db *mmdb) lookup(ip netip.Addr) (any, error) {
+	ip = ip.Unmap()
+	node, bits := uint(0), 128
+	if ip.Is4() {
+		if db.ipVersion == 6 {
+			node = db.ipv4Start
+		}
+		bits = 32
+	} else if db.ipVersion == 4 {
+		return nil, nil
+	}
+
+	address := ip.AsSlice()
+	for i := 0; i < bits && node < db.nodeCount; i++ {
+		bit := uint(address[i/8]>>(7-i%8)) & 1
+		var err error
+		if node, err = db.record(node, bit); err != nil {
+			return nil, err
+		}
+	}
+	if node <= db.nodeCount {
+		// The address has no record
+		return nil, nil
+	}
+
+	offset := node - db.nodeCount - 16 + db.dataStart
+	if offset >= uint(len(db.data)) {
+		return nil, errMMDBCorrupt
+	}
+	value, _, err := db.decode(offset, db.dataStart)
+	return value, err
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a node of the search tree
+func (db *mmdb) record(node uint, bit uint) (uint, error) {
+	size := db.recordSize / 4
+	offset := node * size
+	if offset+size > uint(len(db.data)) {
+		return 0, errMMDBCorrupt
+	}
+	b := db.data[offset : offset+size]
+	switch db.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+	case 28:
+		// The middle byte has the high 4 bits of both records
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6]), nil
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:])), nil
+	}
+}
+
+// decode decodes the value at offset and returns the offset after it. Pointers are relative to
+// base, the start of the data section (or of the metadata).
+func (db *mmdb) decode(offset uint, base uint) (any, uint, error) {
+	if offset >= uint(len(db.data)) {
+		return nil, 0, errMMDBCorrupt
+	}
+	control := db.data[offset]
+	offset++
+	kind := uint(control >> 5)
+
+	if kind == 1 {
+		// A pointer to a value elsewhere in the data section
+		size := uint(control>>3) & 3
+		if offset+size+1 > uint(len(db.data)) {
+			return nil, 0, errMMDBCorrupt
+		}
+		pointer := uint(control & 7)
+		if size == 3 {
+			pointer = 0
+		}
+		for _, b := range db.data[offset : offset+size+1] {
+			pointer = pointer<<8 | uint(b)
+		}
+		pointer += [4]uint{0, 2048, 526336, 0}[size]
+		// A pointer doesn't point to a pointer, which could loop
+		if base+pointer >= uint(len(db.data)) || db.data[base+pointer]>>5 == 1 {
+			return nil, 0, errMMDBCorrupt
+		}
+		value, _, err := db.decode(base+pointer, base)
+		return value, offset + size + 1, err
+	}
+
+	if kind == 0 {
+		// An extended type
+		if offset >= uint(len(db.data)) {
+			return nil, 0, errMMDBCorrupt
+		}
+		kind = 7 + uint(db.data[offset])
+		offset++
+	}
+	size := uint(control & 0x1f)
+	if size >= 29 {
+		extra := size - 28
+		if offset+extra > uint(len(db.data)) {
+			return nil, 0, errMMDBCorrupt
+		}
+		n := uint(0)
+		for _, b := range db.data[offset : offset+extra] {
+			n = n<<8 | uint(b)
+		}
+		size = [4]uint{0, 29, 285, 65821}[extra] + n
+		offset += extra
+	}
+
+	switch kind {
+	case 7:
+		// A map of size pairs
+		result := make(map[string]any, size)
+		for range size {
+			key, next, err := db.decode(offset, base)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errMMDBCorrupt
+			}
+			value, next, err := db.decode(next, base)
+			if err != nil {
+				return nil, 0, err
+			}
+			result[name] = value
+			offset = next
+		}
+		return result, offset, nil
+	case 11:
+		// An array of size values
+		result := make([]any, 0, size)
+		for range size {
+			value, next, err := db.decode(offset, base)
+			if err != nil {
+				return nil, 0, err
+			}
+			result = append(result, value)
+			offset = next
+		}
+		return result, offset, nil
+	case 14:
+		// A boolean, the value is the size
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(db.data)) {
+		return nil, 0, errMMDBCorrupt
+	}
+	b := db.data[offset : offset+size]
+	offset += size
+	switch kind {
+	case 2:
+		return string(b), offset, nil
+	case 3:
+		if size != 8 {
+			return nil, 0, errMMDBCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+	case 4:
+		return bytes.Clone(b), offset, nil
+	case 5, 6, 9:
+		if size > 8 {
+			return nil, 0, errMMDBCorrupt
+		}
+		n := uint64(0)
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, offset, nil
+	case 8:
+		if size > 4 {
+			return nil, 0, errMMDBCorrupt
+		}
+		n := uint32(0)
+		for _, c := range b {
+			n = n<<8 | uint32(c)
+		}
+		return int64(int32(n)), offset, nil
+	case 10:
+		// A uint128, too large for the records of the GeoIP databases
+		return bytes.Clone(b), offset, nil
+	case 15:
+		if size != 4 {
+			return nil, 0, errMMDBCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), offset, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported type %d in MaxMind database", kind)
+}
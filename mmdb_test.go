@@ -0,0 +1,237 @@
+package timeline
+
+import (
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"sort"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// mmdbNetwork is a network of a test database with its record
+type mmdbNetwork struct {
+	prefix string
+	record any
+}
+
+// mmdbPointer is a pointer to an offset (below 2048) of the data section of a test database
+type mmdbPointer uint
+
+// mmdbTrieNode is a node of the search tree of a test database
+type mmdbTrieNode struct {
+	children [2]*mmdbTrieNode
+	// data is the offset of the record + 1 of a leaf, 0 for a node
+	data uint
+}
+
+// buildMMDB encodes a MaxMind database with the records of the networks. The IPv4 networks of an
+// IPv6 database are stored in ::/96.
+func buildMMDB(t *testing.T, ipVersion int, recordSize int, networks ...mmdbNetwork) []byte {
+	t.Helper()
+	root := &mmdbTrieNode{}
+	var data []byte
+	for _, network := range networks {
+		prefix := netip.MustParsePrefix(network.prefix)
+		offset := uint(len(data))
+		data = append(data, encodeMMDB(network.record)...)
+
+		address := prefix.Addr().AsSlice()
+		bits := prefix.Bits()
+		var path []uint
+		if ipVersion == 6 && prefix.Addr().Is4() {
+			path = make([]uint, 96)
+		}
+		for i := 0; i < bits; i++ {
+			path = append(path, uint(address[i/8]>>(7-i%8))&1)
+		}
+		node := root
+		for _, bit := range path[:len(path)-1] {
+			if node.children[bit] == nil {
+				node.children[bit] = &mmdbTrieNode{}
+			}
+			node = node.children[bit]
+		}
+		node.children[path[len(path)-1]] = &mmdbTrieNode{data: offset + 1}
+	}
+
+	// Number the nodes, the root is node 0
+	var nodes []*mmdbTrieNode
+	index := map[*mmdbTrieNode]uint{}
+	var number func(node *mmdbTrieNode)
+	number = func(node *mmdbTrieNode) {
+		index[node] = uint(len(nodes))
+		nodes = append(nodes, node)
+		for _, child := range node.children {
+			if child != nil && child.data == 0 {
+				number(child)
+			}
+		}
+	}
+	number(root)
+	nodeCount := uint(len(nodes))
+
+	var tree []byte
+	for _, node := range nodes {
+		var records [2]uint
+		for i, child := range node.children {
+			switch {
+			case child == nil:
+				records[i] = nodeCount
+			case child.data > 0:
+				records[i] = nodeCount + 16 + child.data - 1
+			default:
+				records[i] = index[child]
+			}
+		}
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(records[0]>>16), byte(records[0]>>8), byte(records[0]),
+				byte(records[1]>>16), byte(records[1]>>8), byte(records[1]))
+		case 28:
+			tree = append(tree, byte(records[0]>>16), byte(records[0]>>8), byte(records[0]),
+				byte(records[0]>>20&0xf0|records[1]>>24&0x0f),
+				byte(records[1]>>16), byte(records[1]>>8), byte(records[1]))
+		default:
+			tree = binary.BigEndian.AppendUint32(tree, uint32(records[0]))
+			tree = binary.BigEndian.AppendUint32(tree, uint32(records[1]))
+		}
+	}
+
+	database := append(tree, make([]byte, 16)...)
+	database = append(database, data...)
+	database = append(database, mmdbMetadataMarker...)
+	return append(database, encodeMMDB(map[string]any{
+		"node_count":    uint64(nodeCount),
+		"record_size":   uint64(recordSize),
+		"ip_version":    uint64(ipVersion),
+		"database_type": "Test",
+	})...)
+}
+
+// encodeMMDB encodes a value of the data section of a MaxMind database
+func encodeMMDB(value any) []byte {
+	header := func(kind uint, size int) []byte {
+		var b []byte
+		control := byte(kind << 5)
+		if kind > 7 {
+			control = 0
+		}
+		switch {
+		case size < 29:
+			b = []byte{control | byte(size)}
+		case size < 285:
+			b = []byte{control | 29, byte(size - 29)}
+		case size < 65821:
+			b = []byte{control | 30, byte((size - 285) >> 8), byte(size - 285)}
+		default:
+			b = []byte{control | 31, byte((size - 65821) >> 16), byte((size - 65821) >> 8), byte(size - 65821)}
+		}
+		if kind > 7 {
+			b = append(b[:1], append([]byte{byte(kind - 7)}, b[1:]...)...)
+		}
+		return b
+	}
+
+	switch v := value.(type) {
+	case string:
+		return append(header(2, len(v)), v...)
+	case float64:
+		return binary.BigEndian.AppendUint64(header(3, 8), math.Float64bits(v))
+	case uint64:
+		var b []byte
+		for n := v; n > 0; n >>= 8 {
+			b = append([]byte{byte(n)}, b...)
+		}
+		return append(header(9, len(b)), b...)
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		return header(14, size)
+	case mmdbPointer:
+		// Offsets below 2048 are stored in 11 bits
+		return []byte{1<<5 | byte(v>>8&7), byte(v)}
+	case []any:
+		b := header(11, len(v))
+		for _, item := range v {
+			b = append(b, encodeMMDB(item)...)
+		}
+		return b
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b := header(7, len(v))
+		for _, key := range keys {
+			b = append(b, encodeMMDB(key)...)
+			b = append(b, encodeMMDB(v[key])...)
+		}
+		return b
+	}
+	panic("unsupported MaxMind value")
+}
+
+func Test_mmdb_looks_up_the_record_of_the_network(t *testing.T) {
+	for _, recordSize := range []int{24, 28, 32} {
+		for _, ipVersion := range []int{4, 6} {
+			is := is.New(t)
+			networks := []mmdbNetwork{
+				{"81.2.69.0/24", map[string]any{"name": "london", "tags": []any{"a", true}, "score": 1.5}},
+				{"2001:db8::/32", map[string]any{"name": "documentation"}},
+			}
+			if ipVersion == 4 {
+				networks = networks[:1]
+			}
+			db, err := openMMDB(buildMMDB(t, ipVersion, recordSize, networks...))
+			is.NoErr(err)
+			is.Equal(db.databaseType, "Test")
+
+			record, err := db.lookup(netip.MustParseAddr("81.2.69.160"))
+			is.NoErr(err)
+			is.Equal(record, map[string]any{"name": "london", "tags": []any{"a", true}, "score": 1.5})
+
+			record, err = db.lookup(netip.MustParseAddr("81.2.70.1"))
+			is.NoErr(err)
+			is.Equal(record, nil)
+
+			record, err = db.lookup(netip.MustParseAddr("2001:db8::1"))
+			is.NoErr(err)
+			if ipVersion == 6 {
+				is.Equal(record, map[string]any{"name": "documentation"})
+			} else {
+				is.Equal(record, nil)
+			}
+		}
+	}
+}
+
+func Test_mmdb_follows_pointers(t *testing.T) {
+	is := is.New(t)
+	long := string(make([]byte, 300))
+	db, err := openMMDB(buildMMDB(t, 4, 24,
+		mmdbNetwork{"10.0.0.0/8", map[string]any{"name": long, "asn": uint64(15169)}},
+		mmdbNetwork{"11.0.0.0/8", mmdbPointer(0)},
+	))
+	is.NoErr(err)
+
+	record, err := db.lookup(netip.MustParseAddr("11.1.2.3"))
+	is.NoErr(err)
+	is.Equal(record, map[string]any{"name": long, "asn": uint64(15169)})
+}
+
+func Test_mmdb_rejects_other_files(t *testing.T) {
+	is := is.New(t)
+	_, err := openMMDB([]byte("not a database"))
+	is.True(err != nil)
+
+	// A search tree larger than the file
+	database := append([]byte{}, mmdbMetadataMarker...)
+	database = append(database, encodeMMDB(map[string]any{"node_count": uint64(1000), "record_size": uint64(24), "ip_version": uint64(4)})...)
+	_, err = openMMDB(database)
+	is.True(err != nil)
+}
@@ -0,0 +1,43 @@
+package timeline
+
+import "strings"
+
+// parseMongoDB parses the structured JSON log lines of MongoDB 4.4 and newer.
+// Example: {"t":{"$date":"2020-05-01T15:16:17.180+00:00"},"s":"I","c":"NETWORK","id":23016,"ctx":"listener","msg":"Waiting for connections","attr":{"port":27017,"ssl":"off"}}
+// Fields: timestamp (t.$date), severity (s), component (c), id, context (ctx), message (msg),
+// attr (nested map, flattened to attr_* columns by the Writer), plus any other top-level keys
+func parseMongoDB(l string) Row {
+	// MongoDB always starts its log lines with the timestamp
+	if !strings.HasPrefix(l, `{"t":{"$date":`) {
+		return nil
+	}
+
+	data := parseJSON(l)
+	if data == nil {
+		return nil
+	}
+
+	t, ok := data["t"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	result := make(Row)
+	for k, v := range data {
+		switch k {
+		case "t":
+			result["timestamp"] = t["$date"]
+		case "s":
+			result["severity"] = v
+		case "c":
+			result["component"] = v
+		case "ctx":
+			result["context"] = v
+		case "msg":
+			result["message"] = v
+		default:
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_mongodb_log_line(t *testing.T) {
+	is := is.New(t)
+	line := `{"t":{"$date":"2020-05-01T15:16:17.180+00:00"},"s":"I","c":"NETWORK","id":23016,"ctx":"listener","msg":"Waiting for connections","attr":{"port":27017,"ssl":"off"}}`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["timestamp"], "2020-05-01T15:16:17.180+00:00")
+	is.Equal(data["severity"], "I")
+	is.Equal(data["component"], "NETWORK")
+	is.Equal(data["id"], 23016)
+	is.Equal(data["context"], "listener")
+	is.Equal(data["message"], "Waiting for connections")
+	is.Equal(data["attr"], map[string]any{"port": 27017, "ssl": "off"})
+}
+
+func Test_parse_mongodb_log_line_flattens_attributes(t *testing.T) {
+	is := is.New(t)
+	line := `{"t":{"$date":"2020-05-01T15:16:17.180+00:00"},"s":"W","c":"CONTROL","id":22120,"ctx":"initandlisten","msg":"Access control is not enabled","attr":{"user":{"name":"admin"}}}`
+
+	data := flattenJsonMaps(ParseLineToValues(line))
+
+	is.Equal(data["attr_user_name"], "admin")
+	_, hasT := data["t_$date"]
+	is.True(!hasT)
+}
+
+func Test_parse_json_line_that_is_not_mongodb(t *testing.T) {
+	is := is.New(t)
+	line := `{"t":"not a date","s":"I"}`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["t"], "not a date")
+	is.Equal(data["s"], "I")
+}
@@ -0,0 +1,156 @@
+package timeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MQTTTopic is a topic filter with named wildcards, e.g. sensors/+building/+room/temperature.
+// A named single level wildcard (+name) writes the segment to the column name, a named multi
+// level wildcard (#name) writes the remaining segments (joined with /).
+type MQTTTopic struct {
+	Pattern string
+	// Table is the table of the messages, defaults to mqtt
+	Table string
+}
+
+// Filter returns the MQTT topic filter of the pattern to subscribe to, e.g. sensors/+/+/temperature
+func (t MQTTTopic) Filter() string {
+	levels := strings.Split(t.Pattern, "/")
+	for i, level := range levels {
+		if strings.HasPrefix(level, "+") {
+			levels[i] = "+"
+		} else if strings.HasPrefix(level, "#") {
+			levels[i] = "#"
+		}
+	}
+	return strings.Join(levels, "/")
+}
+
+// match reports whether the topic matches the pattern and returns the named segments
+func (t MQTTTopic) match(topic string) (Row, bool) {
+	patternLevels := strings.Split(t.Pattern, "/")
+	topicLevels := strings.Split(topic, "/")
+	segments := make(Row)
+	for i, level := range patternLevels {
+		if strings.HasPrefix(level, "#") {
+			if name := normalizeColumnName(level[1:]); name != "" && i < len(topicLevels) {
+				segments[name] = strings.Join(topicLevels[i:], "/")
+			}
+			return segments, true
+		}
+		if i >= len(topicLevels) {
+			return nil, false
+		}
+		switch {
+		case strings.HasPrefix(level, "+"):
+			if name := normalizeColumnName(level[1:]); name != "" {
+				segments[name] = topicLevels[i]
+			}
+		case level != topicLevels[i]:
+			return nil, false
+		}
+	}
+	return segments, len(patternLevels) == len(topicLevels)
+}
+
+// MQTTSourceConfig configures an MQTTSource
+type MQTTSourceConfig struct {
+	// Topics are the topic filters, the first matching topic of a message is used
+	Topics []MQTTTopic
+	// OnError is called for messages that could not be written
+	OnError func(error)
+}
+
+// MQTTSource writes MQTT messages to tables, for IoT-style event timelines. The package doesn't
+// depend on an MQTT client: subscribe to Filters with your client and call Handle for every
+// message. With paho.mqtt.golang:
+//
+//	for _, filter := range source.Filters() {
+//		client.Subscribe(filter, 1, func(_ mqtt.Client, msg mqtt.Message) {
+//			source.Handle(msg.Topic(), msg.Payload())
+//		})
+//	}
+//
+// JSON object payloads are written as columns, other payloads are written to the value column
+// (numbers as numbers). The topic and the named segments of the topic are added (fields of the
+// payload win).
+type MQTTSource struct {
+	writer *Writer
+	config MQTTSourceConfig
+}
+
+// NewMQTTSource creates an MQTT source
+func NewMQTTSource(writer *Writer, config MQTTSourceConfig) *MQTTSource {
+	for i, topic := range config.Topics {
+		if topic.Table == "" {
+			config.Topics[i].Table = "mqtt"
+		}
+	}
+	return &MQTTSource{writer: writer, config: config}
+}
+
+// Filters returns the topic filters to subscribe to
+func (m *MQTTSource) Filters() []string {
+	filters := make([]string, len(m.config.Topics))
+	for i, topic := range m.config.Topics {
+		filters[i] = topic.Filter()
+	}
+	return filters
+}
+
+// Handle writes a message, it is safe for concurrent use. Errors are also reported to OnError.
+func (m *MQTTSource) Handle(topic string, payload []byte) error {
+	err := m.handle(topic, payload)
+	if err != nil {
+		handleError(m.config.OnError, err, ErrorContext{Component: "mqtt_source"})
+	}
+	return err
+}
+
+func (m *MQTTSource) handle(topic string, payload []byte) error {
+	for _, candidate := range m.config.Topics {
+		segments, ok := candidate.match(topic)
+		if !ok {
+			continue
+		}
+
+		row := parseMQTTPayload(payload)
+		if row == nil {
+			return nil
+		}
+		segments["topic"] = topic
+		for column, value := range segments {
+			if _, exists := row[column]; !exists {
+				row[column] = value
+			}
+		}
+
+		if err := m.writer.Write(candidate.Table, NewRow(time.Now(), row)); err != nil {
+			return fmt.Errorf("failed to write MQTT message of %s: %w", topic, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("no topic matches %s", topic)
+}
+
+// parseMQTTPayload parses a JSON object, other payloads are returned as value
+func parseMQTTPayload(payload []byte) Row {
+	text := strings.TrimSpace(string(payload))
+	if text == "" {
+		return nil
+	}
+	if strings.HasPrefix(text, "{") {
+		if data := parseJSON(text); data != nil {
+			row := make(Row, len(data)+2)
+			for k, v := range data {
+				if column := normalizeColumnName(k); column != "" {
+					row[column] = normalizeNestedKeys(v)
+				}
+			}
+			return row
+		}
+	}
+	return Row{"value": convertLogfmtValue(text)}
+}
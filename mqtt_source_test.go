@@ -0,0 +1,58 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_mqtt_topic_filter_and_match(t *testing.T) {
+	is := is.New(t)
+	topic := MQTTTopic{Pattern: "sensors/+building/+room/temperature"}
+
+	is.Equal(topic.Filter(), "sensors/+/+/temperature")
+
+	segments, ok := topic.match("sensors/hq/kitchen/temperature")
+	is.True(ok)
+	is.Equal(segments, Row{"building": "hq", "room": "kitchen"})
+
+	_, ok = topic.match("sensors/hq/kitchen/humidity")
+	is.True(!ok)
+	_, ok = topic.match("sensors/hq/kitchen")
+	is.True(!ok)
+
+	rest := MQTTTopic{Pattern: "devices/+/#path"}
+	is.Equal(rest.Filter(), "devices/+/#")
+	segments, ok = rest.match("devices/d1/status/battery")
+	is.True(ok)
+	is.Equal(segments, Row{"path": "status/battery"})
+}
+
+func Test_mqtt_source_writes_json_and_raw_payloads(t *testing.T) {
+	is, writer := setup(t)
+	source := NewMQTTSource(writer, MQTTSourceConfig{Topics: []MQTTTopic{
+		{Pattern: "sensors/+building/+room/temperature", Table: "temperature"},
+		{Pattern: "events/#"},
+	}})
+
+	is.Equal(source.Filters(), []string{"sensors/+/+/temperature", "events/#"})
+	is.NoErr(source.Handle("sensors/hq/kitchen/temperature", []byte("21.5")))
+	is.NoErr(source.Handle("sensors/hq/hall/temperature", []byte(`{"value":19,"Battery Level":80}`)))
+	is.NoErr(source.Handle("events/door", []byte("opened")))
+	is.True(source.Handle("other/topic", []byte("ignored")) != nil)
+
+	var count int
+	var average float64
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*), avg(value) FROM temperature WHERE building = 'hq'`).Scan(&count, &average))
+	is.Equal(count, 2)
+	is.Equal(average, 20.25)
+
+	var battery int
+	is.NoErr(writer.DB.QueryRow(`SELECT battery_level FROM temperature WHERE room = 'hall'`).Scan(&battery))
+	is.Equal(battery, 80)
+
+	var value, topic string
+	is.NoErr(writer.DB.QueryRow(`SELECT value, topic FROM mqtt`).Scan(&value, &topic))
+	is.Equal(value, "opened")
+	is.Equal(topic, "events/door")
+}
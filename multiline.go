@@ -0,0 +1,138 @@
+package timeline
+
+import (
+	"regexp"
+	"strings"
+)
+
+// javaExceptionHeaderRegex matches the first line of a Java exception, optionally
+// prefixed with the thread that threw it.
+// Example: Exception in thread "main" java.lang.IllegalStateException: boom
+var javaExceptionHeaderRegex = regexp.MustCompile(`^(?:Exception in thread "([^"]*)" )?([A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)+)(?::\s*(.*))?$`)
+
+// goFrameFunctionRegex matches the function line of a Go stack frame.
+// Example: main.(*Server).handle(0xc000010000, {0x4b2f60, 0x5})
+var goFrameFunctionRegex = regexp.MustCompile(`^(?:created by \S+(?: in goroutine \d+)?|[\w./*()$-]+\(.*\))$`)
+
+// javaFrameRegex matches a single Java stack frame.
+// Example: at com.example.Service.handle(Service.java:42)
+var javaFrameRegex = regexp.MustCompile(`^\s+at\s+(?:[\w.$]+/)?([\w$.<>]+)\.([\w$<>]+)\(([^:)]*)(?::(\d+))?\)`)
+
+// maxRailsBlockLines limits the lines of a Rails request block, so a Started line without a
+// Completed line doesn't swallow the rest of the log
+const maxRailsBlockLines = 1000
+
+// LineAssembler groups physical log lines into logical entries.
+// Stack traces and other continuation lines are appended to the entry they belong to,
+// so a multi-line exception ends up as a single row instead of one row per frame.
+type LineAssembler struct {
+	pending []string
+	// railsCompleted and javaFrames are kept per added line, so the pending entry isn't rescanned
+	railsCompleted bool
+	javaFrames     bool
+}
+
+// NewLineAssembler creates an empty line assembler
+func NewLineAssembler() *LineAssembler {
+	return &LineAssembler{}
+}
+
+// Add feeds the next physical line to the assembler.
+// When the line starts a new entry, the previously pending entry is returned as complete.
+func (a *LineAssembler) Add(line string) (string, bool) {
+	if len(a.pending) > 0 && a.isContinuation(line) {
+		a.add(line)
+		return "", false
+	}
+	entry, ok := a.Flush()
+	a.add(line)
+	return entry, ok
+}
+
+// add appends the line to the pending entry
+func (a *LineAssembler) add(line string) {
+	a.pending = append(a.pending, line)
+	if isRailsStarted(a.pending[0]) && !a.railsCompleted {
+		a.railsCompleted = isRailsCompleted(strings.TrimSpace(line))
+	}
+	if !a.javaFrames {
+		a.javaFrames = javaFrameRegex.MatchString(line)
+	}
+}
+
+// Flush returns the pending entry (if any) and resets the assembler.
+// Call this at the end of the input to get the last entry.
+func (a *LineAssembler) Flush() (string, bool) {
+	if len(a.pending) == 0 {
+		return "", false
+	}
+	entry := strings.Join(a.pending, "\n")
+	a.pending = nil
+	a.railsCompleted, a.javaFrames = false, false
+	return entry, true
+}
+
+// isContinuation reports whether the line belongs to the pending entry
+func (a *LineAssembler) isContinuation(line string) bool {
+	// Go panics contain blank lines, goroutine headers and unindented function lines
+	if isGoPanicHeader(a.pending[0]) && isGoPanicContinuation(line) {
+		return true
+	}
+	// Rails request blocks last until the Completed line
+	if isRailsStarted(a.pending[0]) && !isRailsStarted(line) && !a.railsCompleted && len(a.pending) < maxRailsBlockLines {
+		return true
+	}
+	// Indented lines: stack frames ("\tat ..."), "... 5 more", "Suppressed: ..."
+	if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+		return true
+	}
+	if strings.HasPrefix(line, "Caused by: ") {
+		return true
+	}
+	// An exception header directly after a log message belongs to that message,
+	// as long as the pending entry has no stack frames yet
+	if isJavaExceptionClass(line) {
+		return !a.javaFrames
+	}
+	return false
+}
+
+// isJavaExceptionClass reports whether the class part of an exception header
+// looks like a throwable (e.g. java.lang.NullPointerException or java.lang.OutOfMemoryError)
+func isJavaExceptionClass(header string) bool {
+	match := javaExceptionHeaderRegex.FindStringSubmatch(header)
+	if match == nil {
+		return false
+	}
+	class := match[2]
+	return strings.HasSuffix(class, "Exception") || strings.HasSuffix(class, "Error") || strings.HasSuffix(class, "Throwable")
+}
+
+// isGoPanicHeader reports whether the line starts a Go panic or fatal runtime error
+func isGoPanicHeader(line string) bool {
+	return strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ")
+}
+
+// isGoPanicContinuation reports whether the line can be part of the output of a Go panic
+func isGoPanicContinuation(line string) bool {
+	if line == "" || strings.HasPrefix(line, "goroutine ") || strings.HasPrefix(line, "[signal ") || strings.HasPrefix(line, "exit status ") {
+		return true
+	}
+	return goFrameFunctionRegex.MatchString(line)
+}
+
+// AssembleLines groups the given physical lines into logical entries.
+// See LineAssembler for the rules that decide which lines belong together.
+func AssembleLines(lines []string) []string {
+	assembler := NewLineAssembler()
+	entries := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if entry, ok := assembler.Add(line); ok {
+			entries = append(entries, entry)
+		}
+	}
+	if entry, ok := assembler.Flush(); ok {
+		entries = append(entries, entry)
+	}
+	return entries
+}
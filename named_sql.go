@@ -0,0 +1,97 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SQL runs an SQL query with named parameters and returns its rows, for everything the query
+// helpers can't do:
+//
+//	rows, err := writer.SQL(ctx, "SELECT path, count(*) AS n FROM requests WHERE level = :level GROUP BY path",
+//		map[string]any{"level": "error"})
+//
+// The parameters are bound, not interpolated, so values can't change the query. A parameter can be
+// used multiple times; :: casts and colons in strings, quoted identifiers and comments are left
+// alone. The values have the same Go types as the rows of Query.
+func (w *Writer) SQL(ctx context.Context, query string, params map[string]any) ([]Row, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	query, args, err := bindNamedParameters(query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := w.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %w", err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// bindNamedParameters replaces the :name parameters with ? and returns the values in order
+func bindNamedParameters(query string, params map[string]any) (string, []any, error) {
+	var result strings.Builder
+	var args []any
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			// Copy strings and quoted identifiers, a doubled quote is an escaped quote
+			end := i + 1
+			for end < len(query) {
+				if query[end] == c {
+					if end+1 < len(query) && query[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end, len(query)-1)
+			result.WriteString(query[i : end+1])
+			i = end
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i - 1
+			}
+			result.WriteString(query[i : i+end+1])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 4
+			}
+			result.WriteString(query[i : i+end+4])
+			i += end + 3
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			result.WriteString("::")
+			i++
+		case c == ':' && i+1 < len(query) && isParameterStart(query[i+1]):
+			end := i + 2
+			for end < len(query) && (isParameterStart(query[end]) || query[end] >= '0' && query[end] <= '9') {
+				end++
+			}
+			name := query[i+1 : end]
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("missing value of parameter :%s", name)
+			}
+			result.WriteByte('?')
+			args = append(args, value)
+			i = end - 1
+		default:
+			result.WriteByte(c)
+		}
+	}
+	return result.String(), args, nil
+}
+
+func isParameterStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,47 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_bind_named_parameters(t *testing.T) {
+	is := is.New(t)
+
+	query, args, err := bindNamedParameters(
+		`SELECT ':skip', "a:b", x::VARCHAR FROM t -- :comment
+WHERE level = :level /* :also */ AND (status = :status OR other = :status)`,
+		map[string]any{"level": "error", "status": 500, "unused": true})
+
+	is.NoErr(err)
+	is.Equal(query, `SELECT ':skip', "a:b", x::VARCHAR FROM t -- :comment
+WHERE level = ? /* :also */ AND (status = ? OR other = ?)`)
+	is.Equal(args, []any{"error", 500, 500})
+}
+
+func Test_bind_named_parameters_without_value(t *testing.T) {
+	is := is.New(t)
+
+	_, _, err := bindNamedParameters("SELECT * FROM t WHERE level = :level", nil)
+
+	is.True(err != nil)
+	is.Equal(err.Error(), "missing value of parameter :level")
+}
+
+func Test_sql_with_named_parameters(t *testing.T) {
+	is, writer := setup(t)
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("app", NewRow(now, Row{"level": "error", "message": "it's broken"})))
+	is.NoErr(writer.Write("app", NewRow(now.Add(time.Second), Row{"level": "info", "message": "ok"})))
+	is.NoErr(writer.Write("app", NewRow(now.Add(2*time.Second), Row{"level": "error", "message": "timeout"})))
+
+	rows, err := writer.SQL(context.Background(),
+		"SELECT level, count(*) AS n FROM app WHERE level = :level OR message = :message GROUP BY level ORDER BY level",
+		map[string]any{"level": "error", "message": "' OR 1=1 --"})
+
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"level": "error", "n": 2}})
+}
@@ -0,0 +1,154 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NATSMessage is a message received from a NATS subject or a JetStream consumer
+type NATSMessage struct {
+	Subject string
+	Data    []byte
+	// Time is the time the message was stored by JetStream, zero for core NATS (the receive time is used)
+	Time time.Time
+	// Ack acknowledges a JetStream message after it is written, nil for core NATS
+	Ack func() error
+	// Nak asks JetStream to redeliver a message that could not be written, nil for core NATS
+	Nak func() error
+}
+
+// NATSSubscription delivers the messages of a subscription. The package doesn't depend on a
+// NATS client, wrap the subscription of your client. With nats.go and a durable JetStream consumer:
+//
+//	consumer, _ := js.CreateOrUpdateConsumer(ctx, "LOGS", jetstream.ConsumerConfig{Durable: "timeline", FilterSubject: "logs.>"})
+//	messages, _ := consumer.Messages()
+//	next := func(ctx context.Context) (timeline.NATSMessage, error) {
+//		msg, err := messages.Next()
+//		if err != nil {
+//			return timeline.NATSMessage{}, err
+//		}
+//		meta, _ := msg.Metadata()
+//		return timeline.NATSMessage{Subject: msg.Subject(), Data: msg.Data(), Time: meta.Timestamp, Ack: msg.Ack, Nak: msg.Nak}, nil
+//	}
+type NATSSubscription interface {
+	// Next blocks until the next message is received, or ctx is done
+	Next(ctx context.Context) (NATSMessage, error)
+}
+
+// NATSSubscriptionFunc is an adapter to allow the use of ordinary functions as NATSSubscription
+type NATSSubscriptionFunc func(ctx context.Context) (NATSMessage, error)
+
+// Next calls f(ctx)
+func (f NATSSubscriptionFunc) Next(ctx context.Context) (NATSMessage, error) {
+	return f(ctx)
+}
+
+// NATSSourceConfig configures a NATSSource
+type NATSSourceConfig struct {
+	// SubjectPrefix is removed from the subject before the table name is derived, e.g. logs.
+	SubjectPrefix string
+	// Table is the table for all messages; by default the table is derived from the subject
+	// (logs.api.errors with prefix logs. is written to api_errors)
+	Table string
+	// TableFor chooses the table per row, the row contains the subject; overrides Table
+	TableFor func(row Row) string
+	// Parser parses the message data, defaults to ParseLineToValues
+	Parser Parser
+	// OnError is called for messages that could not be written or acknowledged
+	OnError func(error)
+}
+
+// NATSSource writes the messages of a NATS subscription to tables. JetStream messages are
+// acknowledged after they are written, so a durable consumer continues after the last written
+// message when the source is restarted. Messages that could not be written are not acknowledged.
+type NATSSource struct {
+	writer *Writer
+	config NATSSourceConfig
+}
+
+// NewNATSSource creates a NATS source, call Run with a subscription to start it
+func NewNATSSource(writer *Writer, config NATSSourceConfig) *NATSSource {
+	if config.Parser == nil {
+		config.Parser = ParserFunc(ParseLineToValues)
+	}
+	return &NATSSource{writer: writer, config: config}
+}
+
+// NATSTableFromSubject derives a table name from the subject hierarchy, e.g.
+// logs.api.errors with prefix logs. becomes api_errors
+func NATSTableFromSubject(subject string, prefix string) string {
+	name := normalizeColumnName(strings.ReplaceAll(strings.TrimPrefix(subject, prefix), ".", "_"))
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return "nats"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return "nats_" + name
+	}
+	return name
+}
+
+// Run writes the messages of the subscription until ctx is done and returns the error of ctx,
+// or the error of the subscription.
+func (n *NATSSource) Run(ctx context.Context, subscription NATSSubscription) error {
+	for {
+		message, err := subscription.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to receive NATS message: %w", err)
+		}
+		n.handle(message)
+	}
+}
+
+// handle writes a message and acknowledges it
+func (n *NATSSource) handle(message NATSMessage) {
+	row := n.config.Parser.Parse(string(message.Data))
+	if row == nil {
+		// Nothing to write, e.g. an empty message
+		n.ack(message)
+		return
+	}
+	if _, exists := row["subject"]; !exists {
+		row["subject"] = message.Subject
+	}
+
+	table := NATSTableFromSubject(message.Subject, n.config.SubjectPrefix)
+	if n.config.TableFor != nil {
+		table = n.config.TableFor(row)
+	} else if n.config.Table != "" {
+		table = n.config.Table
+	}
+
+	timestamp := message.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	if err := n.writer.Write(table, NewRow(timestamp, row)); err != nil {
+		n.reportError(fmt.Errorf("failed to write NATS message of %s: %w", message.Subject, err))
+		if message.Nak != nil {
+			if err := message.Nak(); err != nil {
+				n.reportError(fmt.Errorf("failed to nak NATS message of %s: %w", message.Subject, err))
+			}
+		}
+		return
+	}
+	n.ack(message)
+}
+
+func (n *NATSSource) ack(message NATSMessage) {
+	if message.Ack == nil {
+		return
+	}
+	if err := message.Ack(); err != nil {
+		n.reportError(fmt.Errorf("failed to ack NATS message of %s: %w", message.Subject, err))
+	}
+}
+
+func (n *NATSSource) reportError(err error) {
+	handleError(n.config.OnError, err, ErrorContext{Component: "nats_source", Table: n.config.Table})
+}
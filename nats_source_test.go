@@ -0,0 +1,86 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// natsMessages returns a subscription that delivers the messages and then fails with errClosed
+func natsMessages(messages ...NATSMessage) (NATSSubscription, error) {
+	errClosed := errors.New("nats: subscription closed")
+	return NATSSubscriptionFunc(func(ctx context.Context) (NATSMessage, error) {
+		if len(messages) == 0 {
+			return NATSMessage{}, errClosed
+		}
+		message := messages[0]
+		messages = messages[1:]
+		return message, nil
+	}), errClosed
+}
+
+func Test_nats_table_from_subject(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(NATSTableFromSubject("logs.api.errors", "logs."), "api_errors")
+	is.Equal(NATSTableFromSubject("logs.api-gateway", ""), "logs_api_gateway")
+	is.Equal(NATSTableFromSubject("logs.2024", "logs."), "nats_2024")
+	is.Equal(NATSTableFromSubject("logs.", "logs."), "nats")
+}
+
+func Test_nats_source_writes_messages_to_subject_tables(t *testing.T) {
+	is, writer := setup(t)
+	stored := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	acked := 0
+	ack := func() error { acked++; return nil }
+
+	// Given messages on two subjects, one from JetStream
+	subscription, errClosed := natsMessages(
+		NATSMessage{Subject: "logs.api", Data: []byte(`{"level":"info","message":"started"}`)},
+		NATSMessage{Subject: "logs.worker", Data: []byte(`level=error msg="job failed" job_id=12`), Time: stored, Ack: ack},
+		NATSMessage{Subject: "logs.worker", Data: []byte(""), Ack: ack},
+	)
+	source := NewNATSSource(writer, NATSSourceConfig{SubjectPrefix: "logs.", OnError: func(err error) { t.Errorf("source error: %v", err) }})
+
+	// When the source runs
+	err := source.Run(context.Background(), subscription)
+
+	// Then the messages are written and acknowledged
+	is.True(errors.Is(err, errClosed))
+	is.Equal(acked, 2)
+
+	var subject string
+	is.NoErr(writer.DB.QueryRow(`SELECT subject FROM api`).Scan(&subject))
+	is.Equal(subject, "logs.api")
+
+	var jobID int
+	var timestamp time.Time
+	is.NoErr(writer.DB.QueryRow(`SELECT job_id, timestamp FROM worker`).Scan(&jobID, &timestamp))
+	is.Equal(jobID, 12)
+	is.Equal(timestamp.UTC(), stored)
+}
+
+func Test_nats_source_naks_messages_that_could_not_be_written(t *testing.T) {
+	is, writer := setup(t)
+	nacked := false
+	var errs []error
+
+	subscription, _ := natsMessages(NATSMessage{
+		Subject: "logs.api",
+		Data:    []byte(`{"message":"lost"}`),
+		Ack:     func() error { t.Error("unexpected ack"); return nil },
+		Nak:     func() error { nacked = true; return nil },
+	})
+	source := NewNATSSource(writer, NATSSourceConfig{
+		TableFor: func(row Row) string { return "invalid table" },
+		OnError:  func(err error) { errs = append(errs, err) },
+	})
+
+	source.Run(context.Background(), subscription)
+
+	is.True(nacked)
+	is.Equal(len(errs), 1)
+}
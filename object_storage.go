@@ -0,0 +1,94 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// S3Config configures the access of ConfigureS3 to S3 or an S3 compatible object storage
+type S3Config struct {
+	// Region is the region of the buckets, e.g. eu-west-1
+	Region string
+	// KeyID and Secret are the access key. Without a key the credential chain of AWS is used: the
+	// environment variables, ~/.aws and the role of the instance.
+	KeyID  string
+	Secret string
+	// SessionToken is the token of temporary credentials
+	SessionToken string
+	// Endpoint is the host of an S3 compatible storage, e.g. localhost:9000 for MinIO
+	Endpoint string
+	// PathStyle uses s3://bucket URLs as https://endpoint/bucket instead of https://bucket.endpoint,
+	// which MinIO and most other S3 compatible storages need
+	PathStyle bool
+	// Insecure uses http instead of https, e.g. for a local MinIO
+	Insecure bool
+	// Scope limits the credentials to the URLs with the prefix, e.g. s3://bucket/archive, so
+	// ConfigureS3 can configure other credentials for other buckets. Empty for all URLs.
+	Scope string
+}
+
+// ConfigureS3 installs and loads the httpfs extension of DuckDB and configures the credentials of
+// S3 for the connections of the writer, so archives in object storage (see ArchiveTo with an
+// s3:// URL) are read together with the rows in the database, and SQL can read s3:// files, e.g.
+// with read_parquet. The extension is downloaded on the first use. The credentials aren't stored
+// in the database: call ConfigureS3 again after the database is opened.
+func (w *Writer) ConfigureS3(ctx context.Context, config S3Config) error {
+	extensions := []string{"httpfs"}
+	if config.KeyID == "" {
+		// The credential chain is a provider of the aws extension
+		extensions = append(extensions, "aws")
+	}
+	for _, extension := range extensions {
+		if err := w.loadExtension(ctx, extension); err != nil {
+			return err
+		}
+	}
+	if _, err := w.DB.ExecContext(ctx, s3SecretStatement(config)); err != nil {
+		return fmt.Errorf("failed to configure the S3 credentials: %w", err)
+	}
+	return nil
+}
+
+// loadExtension installs (when it isn't installed yet) and loads an extension of DuckDB
+func (w *Writer) loadExtension(ctx context.Context, name string) error {
+	if _, err := w.DB.ExecContext(ctx, "INSTALL "+quoteIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to install extension %s: %w", name, err)
+	}
+	if _, err := w.DB.ExecContext(ctx, "LOAD "+quoteIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to load extension %s: %w", name, err)
+	}
+	return nil
+}
+
+// s3SecretStatement returns the CREATE SECRET statement of the config. The name of the secret is
+// derived from the scope, so a secret per scope is kept.
+func s3SecretStatement(config S3Config) string {
+	var options []string
+	if config.KeyID == "" {
+		options = append(options, "PROVIDER credential_chain")
+	} else {
+		options = append(options, "KEY_ID "+quoteString(config.KeyID), "SECRET "+quoteString(config.Secret))
+	}
+	if config.SessionToken != "" {
+		options = append(options, "SESSION_TOKEN "+quoteString(config.SessionToken))
+	}
+	if config.Region != "" {
+		options = append(options, "REGION "+quoteString(config.Region))
+	}
+	if config.Endpoint != "" {
+		options = append(options, "ENDPOINT "+quoteString(config.Endpoint))
+	}
+	if config.PathStyle {
+		options = append(options, "URL_STYLE 'path'")
+	}
+	if config.Insecure {
+		options = append(options, "USE_SSL false")
+	}
+	name := "timeline_s3"
+	if config.Scope != "" {
+		options = append(options, "SCOPE "+quoteString(config.Scope))
+		name += "_" + normalizeColumnName(config.Scope)
+	}
+	return fmt.Sprintf("CREATE OR REPLACE SECRET %s (TYPE s3, %s)", quoteIdentifier(name), strings.Join(options, ", "))
+}
@@ -0,0 +1,42 @@
+package timeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_s3_secret_statement_with_an_access_key(t *testing.T) {
+	is := is.New(t)
+	statement := s3SecretStatement(S3Config{
+		Region:    "eu-west-1",
+		KeyID:     "AKIA",
+		Secret:    "it's secret",
+		Endpoint:  "localhost:9000",
+		PathStyle: true,
+		Insecure:  true,
+		Scope:     "s3://logs/archive",
+	})
+	is.Equal(statement, `CREATE OR REPLACE SECRET "timeline_s3_s3_logs_archive" (TYPE s3, KEY_ID 'AKIA', SECRET 'it''s secret', REGION 'eu-west-1', ENDPOINT 'localhost:9000', URL_STYLE 'path', USE_SSL false, SCOPE 's3://logs/archive')`)
+}
+
+func Test_s3_secret_statement_without_an_access_key_uses_the_credential_chain(t *testing.T) {
+	is := is.New(t)
+	statement := s3SecretStatement(S3Config{Region: "us-east-1"})
+	is.Equal(statement, `CREATE OR REPLACE SECRET "timeline_s3" (TYPE s3, PROVIDER credential_chain, REGION 'us-east-1')`)
+}
+
+func Test_configure_s3_creates_a_secret(t *testing.T) {
+	is, writer := setup(t)
+	err := writer.ConfigureS3(context.Background(), S3Config{KeyID: "AKIA", Secret: "secret", Scope: "s3://logs"})
+	if err != nil && strings.Contains(err.Error(), "failed to install") {
+		t.Skipf("the httpfs extension can't be installed: %v", err)
+	}
+	is.NoErr(err)
+
+	var scope string
+	is.NoErr(writer.DB.QueryRow(`SELECT scope[1] FROM duckdb_secrets() WHERE name = 'timeline_s3_s3_logs'`).Scan(&scope))
+	is.Equal(scope, "s3://logs")
+}
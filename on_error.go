@@ -0,0 +1,43 @@
+package timeline
+
+import (
+	"sync/atomic"
+)
+
+// ErrorContext describes where an error of a background goroutine happened, see OnError
+type ErrorContext struct {
+	// Component is the component of the error, e.g. tailer, syslog_server, replicator or checkpoint
+	Component string
+	// Table is the table the component writes to, empty when it has none or writes to several
+	// tables
+	Table string
+}
+
+// errorHandler is the handler of OnError
+var errorHandler atomic.Pointer[func(err error, ctx ErrorContext)]
+
+// OnError sets the handler of the errors of the background goroutines of the package (tailers,
+// servers, sources, pipelines, replicators, retention and checkpoints) that have no OnError in
+// their config, so the errors aren't lost, e.g. to log them in the logger of the application. The
+// handler can be called concurrently. nil removes the handler.
+func OnError(handler func(err error, ctx ErrorContext)) {
+	if handler == nil {
+		errorHandler.Store(nil)
+		return
+	}
+	errorHandler.Store(&handler)
+}
+
+// handleError reports an error of a background goroutine to onError, or to the handler of OnError
+// without onError. It returns false when there is no handler.
+func handleError(onError func(error), err error, ctx ErrorContext) bool {
+	if onError != nil {
+		onError(err)
+		return true
+	}
+	if handler := errorHandler.Load(); handler != nil {
+		(*handler)(err, ctx)
+		return true
+	}
+	return false
+}
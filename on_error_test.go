@@ -0,0 +1,78 @@
+package timeline
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// handledErrors sets the handler of OnError until the end of the test and returns the errors
+// of the component
+func handledErrors(t *testing.T, component string) <-chan ErrorContext {
+	handled := make(chan ErrorContext, 100)
+	OnError(func(err error, ctx ErrorContext) {
+		if ctx.Component != component {
+			return
+		}
+		select {
+		case handled <- ctx:
+		default:
+		}
+	})
+	t.Cleanup(func() { OnError(nil) })
+	return handled
+}
+
+func Test_on_error_receives_the_errors_of_background_goroutines(t *testing.T) {
+	is, writer := setup(t)
+	handled := handledErrors(t, "replicator")
+
+	// Given a replicator without OnError of which the target fails
+	startReplicator(t, NewReplicator(writer, ReplicationConfig{
+		Target:       &flakyTarget{failures: 100},
+		Interval:     5 * time.Millisecond,
+		RetryBackoff: 5 * time.Millisecond,
+	}))
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "hello"})))
+
+	// Then the error is handled
+	select {
+	case ctx := <-handled:
+		is.Equal(ctx, ErrorContext{Component: "replicator"})
+	case <-time.After(time.Second):
+		t.Fatalf("the error was not handled")
+	}
+}
+
+func Test_on_error_is_not_called_when_the_component_has_on_error(t *testing.T) {
+	is, writer := setup(t)
+	handled := handledErrors(t, "replicator")
+
+	// Given a replicator with OnError of which the target fails
+	reported := make(chan error, 100)
+	startReplicator(t, NewReplicator(writer, ReplicationConfig{
+		Target:       &flakyTarget{failures: 100},
+		Interval:     5 * time.Millisecond,
+		RetryBackoff: 5 * time.Millisecond,
+		OnError:      func(err error) { reported <- err },
+	}))
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "hello"})))
+
+	// Then the error is reported to the OnError of the component only
+	select {
+	case err := <-reported:
+		is.True(err != nil)
+	case <-time.After(time.Second):
+		t.Fatalf("the error was not reported")
+	}
+	is.Equal(len(handled), 0)
+}
+
+func Test_handle_error_without_handler(t *testing.T) {
+	is := is.New(t)
+	OnError(nil)
+
+	is.True(!handleError(nil, errors.New("lost"), ErrorContext{Component: "checkpoint"}))
+}
@@ -0,0 +1,568 @@
+package timeline
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOTLPRequestSize limits the (uncompressed) size of an export request
+const maxOTLPRequestSize = 32 << 20
+
+// maxOTLPValueDepth limits the nesting of arrays and key-value lists in a protobuf AnyValue
+const maxOTLPValueDepth = 64
+
+// OTLPReceiverConfig configures an OTLPReceiver
+type OTLPReceiverConfig struct {
+	// Table is the table for all log records, defaults to otel_logs
+	Table string
+	// TableFor chooses the table per log record, e.g. OTLPTableByService; overrides Table
+	TableFor func(row Row) string
+}
+
+// OTLPReceiver is an http.Handler for the OpenTelemetry logs endpoint (OTLP/HTTP, usually mounted at /v1/logs).
+// It accepts protobuf and JSON encoded requests, optionally gzip compressed.
+// Every log record becomes a row with the columns: timestamp, severity_number, severity_text, level,
+// level_rank, message (string bodies) or body (other bodies), trace_id, span_id, event_name,
+// scope_name, scope_version, the attributes (http.method becomes http_method) and the resource
+// attributes with a resource_ prefix (resource_service_name).
+type OTLPReceiver struct {
+	writer *Writer
+	config OTLPReceiverConfig
+}
+
+// NewOTLPReceiver creates an OTLP/HTTP logs receiver
+func NewOTLPReceiver(writer *Writer, config OTLPReceiverConfig) *OTLPReceiver {
+	if config.Table == "" {
+		config.Table = "otel_logs"
+	}
+	return &OTLPReceiver{writer: writer, config: config}
+}
+
+// OTLPTableByService writes the log records of every service to its own table (service.name of the resource)
+func OTLPTableByService(row Row) string {
+	if service, ok := row["resource_service_name"].(string); ok {
+		if name := normalizeColumnName(service); name != "" && (name[0] < '0' || name[0] > '9') {
+			return name
+		}
+	}
+	return "otel_logs"
+}
+
+func (o *OTLPReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json"
+	if !isJSON && mediaType != "application/x-protobuf" {
+		http.Error(w, "unsupported content type, expected application/x-protobuf or application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := readRequestBody(r, maxOTLPRequestSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var request otlpRequest
+	if isJSON {
+		err = json.Unmarshal(body, &request)
+	} else {
+		err = request.decode(&protoReader{buf: body})
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid export request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	rejected, lastErr := o.write(request.rows())
+
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]any{}
+		if rejected > 0 {
+			response["partialSuccess"] = map[string]any{
+				"rejectedLogRecords": strconv.Itoa(rejected),
+				"errorMessage":       lastErr.Error(),
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	if rejected > 0 {
+		w.Write(encodeOTLPPartialSuccess(rejected, lastErr.Error()))
+	}
+}
+
+// write writes the rows and returns the number of rows that could not be written
+func (o *OTLPReceiver) write(rows []Row) (int, error) {
+	rejected := 0
+	var lastErr error
+	for _, row := range rows {
+		table := o.config.Table
+		if o.config.TableFor != nil {
+			table = o.config.TableFor(row)
+		}
+		if err := o.writer.Write(table, NewRow(time.Now(), row)); err != nil {
+			rejected++
+			lastErr = err
+		}
+	}
+	return rejected, lastErr
+}
+
+// readRequestBody reads the (gzip compressed) body with a size limit
+func readRequestBody(r *http.Request, limit int64) ([]byte, error) {
+	var reader io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", r.Header.Get("Content-Encoding"))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("body larger than %d bytes", limit)
+	}
+	return body, nil
+}
+
+// encodeOTLPPartialSuccess encodes ExportLogsServiceResponse{partial_success: {rejected_log_records, error_message}}
+func encodeOTLPPartialSuccess(rejected int, message string) []byte {
+	var partial []byte
+	partial = binary.AppendUvarint(partial, 1<<3|protoVarint)
+	partial = binary.AppendUvarint(partial, uint64(rejected))
+	partial = binary.AppendUvarint(partial, 2<<3|protoBytes)
+	partial = binary.AppendUvarint(partial, uint64(len(message)))
+	partial = append(partial, message...)
+
+	var response []byte
+	response = binary.AppendUvarint(response, 1<<3|protoBytes)
+	response = binary.AppendUvarint(response, uint64(len(partial)))
+	return append(response, partial...)
+}
+
+// The OTLP messages, with the field names of the JSON encoding
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         otlpInt        `json:"timeUnixNano"`
+	ObservedTimeUnixNano otlpInt        `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+	TraceID              string         `json:"traceId"`
+	SpanID               string         `json:"spanId"`
+	EventName            string         `json:"eventName"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string        `json:"stringValue"`
+	BoolValue   *bool          `json:"boolValue"`
+	IntValue    *otlpInt       `json:"intValue"`
+	DoubleValue *float64       `json:"doubleValue"`
+	ArrayValue  *otlpArray     `json:"arrayValue"`
+	KvlistValue *otlpKeyValues `json:"kvlistValue"`
+	BytesValue  []byte         `json:"bytesValue"`
+}
+
+type otlpArray struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKeyValues struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// otlpInt is a 64 bit integer, the JSON encoding of OTLP uses strings for them
+type otlpInt int64
+
+func (i *otlpInt) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", data, err)
+	}
+	*i = otlpInt(value)
+	return nil
+}
+
+// otlpLevels maps the severity number ranges (1-4 trace, 5-8 debug, ...) to the canonical levels
+var otlpLevels = []string{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal}
+
+// rows maps every log record to a row
+func (req *otlpRequest) rows() []Row {
+	var rows []Row
+	for _, resourceLogs := range req.ResourceLogs {
+		resource := make(Row)
+		for _, attribute := range resourceLogs.Resource.Attributes {
+			resource["resource_"+normalizeColumnName(attribute.Key)] = attribute.Value.value()
+		}
+
+		for _, scopeLogs := range resourceLogs.ScopeLogs {
+			for _, record := range scopeLogs.LogRecords {
+				row := record.row()
+				setIfPresent(row, "scope_name", scopeLogs.Scope.Name)
+				setIfPresent(row, "scope_version", scopeLogs.Scope.Version)
+				for k, v := range resource {
+					row[k] = v
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
+
+func (record *otlpLogRecord) row() Row {
+	row := make(Row)
+
+	// Attributes first, so they can't overwrite the fields of the record
+	for _, attribute := range record.Attributes {
+		if name := normalizeColumnName(attribute.Key); name != "" {
+			row[name] = attribute.Value.value()
+		}
+	}
+
+	nanos := int64(record.TimeUnixNano)
+	if nanos == 0 {
+		nanos = int64(record.ObservedTimeUnixNano)
+	}
+	if nanos > 0 {
+		row["timestamp"] = time.Unix(0, nanos).UTC()
+	}
+
+	setIfPresent(row, "severity_text", record.SeverityText)
+	if record.SeverityNumber >= 1 && record.SeverityNumber <= 24 {
+		row["severity_number"] = record.SeverityNumber
+		level := otlpLevels[(record.SeverityNumber-1)/4]
+		row["level"] = level
+		row["level_rank"] = levelRanks[level]
+	} else if record.SeverityText != "" {
+		if level, ok := levelFromString("severity_text", record.SeverityText); ok {
+			row["level"] = level
+			row["level_rank"] = levelRanks[level]
+		}
+	}
+
+	switch body := record.Body.value().(type) {
+	case nil:
+	case string:
+		row["message"] = body
+	default:
+		row["body"] = body
+	}
+
+	setIfPresent(row, "trace_id", record.TraceID)
+	setIfPresent(row, "span_id", record.SpanID)
+	setIfPresent(row, "event_name", record.EventName)
+	return row
+}
+
+// value converts the value to the types of the Writer; nested lists are stored as JSON, maps are flattened
+func (v *otlpAnyValue) value() any {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.ArrayValue != nil:
+		values := make([]any, 0, len(v.ArrayValue.Values))
+		for _, value := range v.ArrayValue.Values {
+			values = append(values, value.value())
+		}
+		return values
+	case v.KvlistValue != nil:
+		values := make(map[string]any, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			values[normalizeColumnName(kv.Key)] = kv.Value.value()
+		}
+		return values
+	case v.BytesValue != nil:
+		return hex.EncodeToString(v.BytesValue)
+	}
+	return nil
+}
+
+// Protobuf decoding of opentelemetry/proto/collector/logs/v1/logs_service.proto
+
+func (req *otlpRequest) decode(r *protoReader) error {
+	return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+		if field != 1 {
+			return false, nil
+		}
+		var resourceLogs otlpResourceLogs
+		if err := decodeProtoMessage(r, resourceLogs.decode); err != nil {
+			return true, err
+		}
+		req.ResourceLogs = append(req.ResourceLogs, resourceLogs)
+		return true, nil
+	})
+}
+
+func (rl *otlpResourceLogs) decode(r *protoReader) error {
+	return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+		switch field {
+		case 1:
+			return true, decodeProtoMessage(r, func(r *protoReader) error {
+				return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+					if field != 1 {
+						return false, nil
+					}
+					kv, err := decodeOTLPKeyValue(r, 0)
+					rl.Resource.Attributes = append(rl.Resource.Attributes, kv)
+					return true, err
+				})
+			})
+		case 2:
+			var scopeLogs otlpScopeLogs
+			err := decodeProtoMessage(r, scopeLogs.decode)
+			rl.ScopeLogs = append(rl.ScopeLogs, scopeLogs)
+			return true, err
+		}
+		return false, nil
+	})
+}
+
+func (sl *otlpScopeLogs) decode(r *protoReader) error {
+	return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+		switch field {
+		case 1:
+			return true, decodeProtoMessage(r, func(r *protoReader) error {
+				return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+					switch field {
+					case 1:
+						name, err := r.bytes()
+						sl.Scope.Name = string(name)
+						return true, err
+					case 2:
+						version, err := r.bytes()
+						sl.Scope.Version = string(version)
+						return true, err
+					}
+					return false, nil
+				})
+			})
+		case 2:
+			var record otlpLogRecord
+			err := decodeProtoMessage(r, record.decode)
+			sl.LogRecords = append(sl.LogRecords, record)
+			return true, err
+		}
+		return false, nil
+	})
+}
+
+func (record *otlpLogRecord) decode(r *protoReader) error {
+	return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+		switch field {
+		case 1:
+			nanos, err := r.fixed64()
+			record.TimeUnixNano = otlpInt(nanos)
+			return true, err
+		case 11:
+			nanos, err := r.fixed64()
+			record.ObservedTimeUnixNano = otlpInt(nanos)
+			return true, err
+		case 2:
+			number, err := r.varint()
+			record.SeverityNumber = int(number)
+			return true, err
+		case 3:
+			text, err := r.bytes()
+			record.SeverityText = string(text)
+			return true, err
+		case 5:
+			value, err := decodeOTLPAnyValue(r, 0)
+			record.Body = value
+			return true, err
+		case 6:
+			kv, err := decodeOTLPKeyValue(r, 0)
+			record.Attributes = append(record.Attributes, kv)
+			return true, err
+		case 9:
+			id, err := r.bytes()
+			record.TraceID = hex.EncodeToString(id)
+			return true, err
+		case 10:
+			id, err := r.bytes()
+			record.SpanID = hex.EncodeToString(id)
+			return true, err
+		case 12:
+			name, err := r.bytes()
+			record.EventName = string(name)
+			return true, err
+		}
+		return false, nil
+	})
+}
+
+// decodeOTLPKeyValue decodes a KeyValue, with a value at the nesting depth of the key-value
+func decodeOTLPKeyValue(r *protoReader, depth int) (otlpKeyValue, error) {
+	var kv otlpKeyValue
+	err := decodeProtoMessage(r, func(r *protoReader) error {
+		return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+			switch field {
+			case 1:
+				key, err := r.bytes()
+				kv.Key = string(key)
+				return true, err
+			case 2:
+				value, err := decodeOTLPAnyValue(r, depth)
+				kv.Value = value
+				return true, err
+			}
+			return false, nil
+		})
+	})
+	return kv, err
+}
+
+// decodeOTLPAnyValue decodes an AnyValue, an error is returned for a value nested deeper than
+// maxOTLPValueDepth arrays and key-value lists
+func decodeOTLPAnyValue(r *protoReader, depth int) (otlpAnyValue, error) {
+	var v otlpAnyValue
+	if depth > maxOTLPValueDepth {
+		return v, fmt.Errorf("value nested deeper than %d levels", maxOTLPValueDepth)
+	}
+	err := decodeProtoMessage(r, func(r *protoReader) error {
+		return decodeProtoFields(r, func(field int, r *protoReader) (bool, error) {
+			switch field {
+			case 1:
+				s, err := r.bytes()
+				str := string(s)
+				v.StringValue = &str
+				return true, err
+			case 2:
+				b, err := r.varint()
+				boolean := b != 0
+				v.BoolValue = &boolean
+				return true, err
+			case 3:
+				i, err := r.varint()
+				integer := otlpInt(int64(i))
+				v.IntValue = &integer
+				return true, err
+			case 4:
+				d, err := r.double()
+				v.DoubleValue = &d
+				return true, err
+			case 5, 6:
+				// ArrayValue and KeyValueList both have a repeated field 1
+				array := &otlpArray{}
+				list := &otlpKeyValues{}
+				err := decodeProtoMessage(r, func(r *protoReader) error {
+					return decodeProtoFields(r, func(inner int, r *protoReader) (bool, error) {
+						if inner != 1 {
+							return false, nil
+						}
+						if field == 5 {
+							value, err := decodeOTLPAnyValue(r, depth+1)
+							array.Values = append(array.Values, value)
+							return true, err
+						}
+						kv, err := decodeOTLPKeyValue(r, depth+1)
+						list.Values = append(list.Values, kv)
+						return true, err
+					})
+				})
+				if field == 5 {
+					v.ArrayValue = array
+				} else {
+					v.KvlistValue = list
+				}
+				return true, err
+			case 7:
+				b, err := r.bytes()
+				v.BytesValue = append([]byte{}, b...)
+				return true, err
+			}
+			return false, nil
+		})
+	})
+	return v, err
+}
+
+// decodeProtoMessage reads an embedded message and decodes it
+func decodeProtoMessage(r *protoReader, decode func(r *protoReader) error) error {
+	message, err := r.message()
+	if err != nil {
+		return err
+	}
+	return decode(message)
+}
+
+// decodeProtoFields calls handle for every field; handle returns false for fields it doesn't know, they are skipped
+func decodeProtoFields(r *protoReader, handle func(field int, r *protoReader) (bool, error)) error {
+	for !r.done() {
+		field, wireType, err := r.next()
+		if err != nil {
+			return err
+		}
+		handled, err := handle(field, r)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			if err := r.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
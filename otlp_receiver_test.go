@@ -0,0 +1,170 @@
+package timeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const otlpJSONRequest = `{
+  "resourceLogs": [{
+    "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]},
+    "scopeLogs": [{
+      "scope": {"name": "app.logger", "version": "1.0.0"},
+      "logRecords": [{
+        "timeUnixNano": "1727000000000000000",
+        "severityNumber": 17,
+        "severityText": "ERROR",
+        "body": {"stringValue": "payment failed"},
+        "attributes": [
+          {"key": "http.status_code", "value": {"intValue": "502"}},
+          {"key": "retry", "value": {"boolValue": true}}
+        ],
+        "traceId": "5b8efff798038103d269b633813fc60c",
+        "spanId": "eee19b7ec3c1b174"
+      }]
+    }]
+  }]
+}`
+
+func postOTLP(t *testing.T, receiver http.Handler, contentType string, body []byte, gzipped bool) *httptest.ResponseRecorder {
+	if gzipped {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(body)
+		gz.Close()
+		body = buf.Bytes()
+	}
+	request := httptest.NewRequest(http.MethodPost, "/v1/logs", bytes.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	if gzipped {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+	response := httptest.NewRecorder()
+	receiver.ServeHTTP(response, request)
+	return response
+}
+
+func Test_otlp_receiver_accepts_json_logs(t *testing.T) {
+	is, writer := setup(t)
+	receiver := NewOTLPReceiver(writer, OTLPReceiverConfig{})
+
+	response := postOTLP(t, receiver, "application/json", []byte(otlpJSONRequest), false)
+
+	is.Equal(response.Code, http.StatusOK)
+	is.Equal(response.Body.String(), "{}\n")
+
+	var timestamp time.Time
+	var message, level, service, scope, traceID string
+	var status int
+	var retry bool
+	err := writer.DB.QueryRow(`SELECT timestamp, message, level, resource_service_name, scope_name, trace_id, http_status_code, retry FROM otel_logs`).
+		Scan(&timestamp, &message, &level, &service, &scope, &traceID, &status, &retry)
+	is.NoErr(err)
+	is.Equal(timestamp.UTC(), time.Unix(1727000000, 0).UTC())
+	is.Equal(message, "payment failed")
+	is.Equal(level, LevelError)
+	is.Equal(service, "checkout")
+	is.Equal(scope, "app.logger")
+	is.Equal(traceID, "5b8efff798038103d269b633813fc60c")
+	is.Equal(status, 502)
+	is.True(retry)
+}
+
+// Minimal protobuf encoding helpers to build an export request
+
+func protoField(field int, wireType int) []byte {
+	return binary.AppendUvarint(nil, uint64(field<<3|wireType))
+}
+
+func protoBytesField(field int, value []byte) []byte {
+	out := protoField(field, protoBytes)
+	out = binary.AppendUvarint(out, uint64(len(value)))
+	return append(out, value...)
+}
+
+func protoVarintField(field int, value uint64) []byte {
+	return binary.AppendUvarint(protoField(field, protoVarint), value)
+}
+
+func protoFixed64Field(field int, value uint64) []byte {
+	return binary.LittleEndian.AppendUint64(protoField(field, protoFixed64), value)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func Test_otlp_receiver_accepts_gzipped_protobuf_logs(t *testing.T) {
+	is, writer := setup(t)
+	receiver := NewOTLPReceiver(writer, OTLPReceiverConfig{TableFor: OTLPTableByService})
+
+	anyString := func(s string) []byte { return protoBytesField(1, []byte(s)) }
+	keyValue := func(key string, value []byte) []byte {
+		return concat(protoBytesField(1, []byte(key)), protoBytesField(2, value))
+	}
+	double := binary.LittleEndian.AppendUint64(protoField(4, protoFixed64), math.Float64bits(12.5))
+	kvlist := protoBytesField(6, protoBytesField(1, keyValue("user.id", protoVarintField(3, 42))))
+
+	record := concat(
+		protoFixed64Field(1, 1727000000000000000),
+		protoVarintField(2, 9),
+		protoBytesField(5, kvlist),
+		protoBytesField(6, keyValue("duration_ms", double)),
+		protoBytesField(9, []byte{0x5b, 0x8e}),
+		protoVarintField(99, 1), // unknown fields are skipped
+	)
+	scopeLogs := concat(protoBytesField(1, protoBytesField(1, []byte("app.logger"))), protoBytesField(2, record))
+	resource := protoBytesField(1, keyValue("service.name", anyString("billing")))
+	request := protoBytesField(1, concat(protoBytesField(1, resource), protoBytesField(2, scopeLogs)))
+
+	response := postOTLP(t, receiver, "application/x-protobuf", request, true)
+
+	is.Equal(response.Code, http.StatusOK)
+	is.Equal(response.Header().Get("Content-Type"), "application/x-protobuf")
+
+	var level, traceID string
+	var userID int
+	var duration float64
+	err := writer.DB.QueryRow(`SELECT level, trace_id, body_user_id, duration_ms FROM billing`).Scan(&level, &traceID, &userID, &duration)
+	is.NoErr(err)
+	is.Equal(level, LevelInfo)
+	is.Equal(traceID, "5b8e")
+	is.Equal(userID, 42)
+	is.Equal(duration, 12.5)
+}
+
+func Test_otlp_receiver_limits_the_nesting_of_protobuf_values(t *testing.T) {
+	is, writer := setup(t)
+	receiver := NewOTLPReceiver(writer, OTLPReceiverConfig{})
+	requestWithBody := func(depth int) []byte {
+		// An array in an array, ... with a string
+		value := protoBytesField(1, []byte("deep"))
+		for i := 0; i < depth; i++ {
+			value = protoBytesField(5, protoBytesField(1, value))
+		}
+		record := concat(protoFixed64Field(1, 1727000000000000000), protoBytesField(5, value))
+		return protoBytesField(1, protoBytesField(2, protoBytesField(2, record)))
+	}
+
+	is.Equal(postOTLP(t, receiver, "application/x-protobuf", requestWithBody(maxOTLPValueDepth), false).Code, http.StatusOK)
+	is.Equal(postOTLP(t, receiver, "application/x-protobuf", requestWithBody(maxOTLPValueDepth+1), false).Code, http.StatusBadRequest)
+}
+
+func Test_otlp_receiver_rejects_invalid_requests(t *testing.T) {
+	is, writer := setup(t)
+	receiver := NewOTLPReceiver(writer, OTLPReceiverConfig{})
+
+	is.Equal(postOTLP(t, receiver, "text/plain", []byte("hello"), false).Code, http.StatusUnsupportedMediaType)
+	is.Equal(postOTLP(t, receiver, "application/json", []byte("{"), false).Code, http.StatusBadRequest)
+	is.Equal(postOTLP(t, receiver, "application/x-protobuf", []byte{0x0a, 0x05}, false).Code, http.StatusBadRequest)
+
+	response := httptest.NewRecorder()
+	receiver.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/v1/logs", nil))
+	is.Equal(response.Code, http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,82 @@
+package timeline
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor that isn't returned by QueryPage
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is a page of rows of QueryPage
+type Page struct {
+	Rows []Row
+	// Next is the cursor of the next page, empty on the last page
+	Next string
+}
+
+// QueryPage returns a page of at most opts.Limit rows (defaults to 100) of a table. Pass Next as
+// opts.After to get the next page. The cursors point after a row (its timestamp and row id), not
+// to an offset, so paging through millions of rows stays fast and stable while new rows arrive.
+// Offset is ignored and the rows must be ordered by timestamp.
+func (w *Writer) QueryPage(ctx context.Context, table string, opts QueryOptions) (Page, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	limit := opts.Limit
+	// Get one extra row to know whether there is a next page
+	opts.Limit++
+	opts.Offset = 0
+	opts.cursor = true
+
+	rows, err := w.Query(ctx, table, opts)
+	if err != nil {
+		return Page{}, err
+	}
+
+	var page Page
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		timestamp, _ := last["_cursor_timestamp"].(time.Time)
+		rowID, _ := last["_cursor_rowid"].(int)
+		page.Next = encodeCursor(timestamp, rowID)
+	}
+	for _, row := range rows {
+		delete(row, "_cursor_timestamp")
+		delete(row, "_cursor_rowid")
+	}
+	page.Rows = rows
+	return page, nil
+}
+
+// encodeCursor returns an opaque cursor of the timestamp and row id of a row
+func encodeCursor(timestamp time.Time, rowID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%d", timestamp.UnixMicro(), rowID)))
+}
+
+// decodeCursor returns the timestamp and the row id of a cursor
+func decodeCursor(cursor string) (time.Time, int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	micros, rowID, ok := strings.Cut(string(decoded), ".")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	timestamp, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(rowID)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return time.UnixMicro(timestamp).UTC(), id, nil
+}
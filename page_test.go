@@ -0,0 +1,62 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_query_page_with_cursors(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	// Rows 2 and 3 have the same timestamp
+	for i, offset := range []int{0, 1, 2, 2, 3} {
+		is.NoErr(writer.Write("app", NewRow(start.Add(time.Duration(offset)*time.Second), Row{"n": i})))
+	}
+
+	// When the first page is read
+	page, err := writer.QueryPage(ctx, "app", QueryOptions{Columns: []string{"n"}, Limit: 3})
+	is.NoErr(err)
+	is.Equal(page.Rows, []Row{{"n": 0}, {"n": 1}, {"n": 2}})
+	is.True(page.Next != "")
+
+	// And a row is written before the next page is read
+	is.NoErr(writer.Write("app", NewRow(start, Row{"n": 5})))
+
+	// Then the next page continues after the last row
+	page, err = writer.QueryPage(ctx, "app", QueryOptions{Columns: []string{"n"}, Limit: 3, After: page.Next})
+	is.NoErr(err)
+	is.Equal(page.Rows, []Row{{"n": 3}, {"n": 4}})
+	is.Equal(page.Next, "")
+}
+
+func Test_query_page_descending(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		is.NoErr(writer.Write("app", NewRow(start.Add(time.Duration(i)*time.Second), Row{"n": i})))
+	}
+
+	page, err := writer.QueryPage(ctx, "app", QueryOptions{Columns: []string{"n"}, Descending: true, Limit: 2})
+	is.NoErr(err)
+	is.Equal(page.Rows, []Row{{"n": 3}, {"n": 2}})
+
+	// New rows don't shift the older pages
+	is.NoErr(writer.Write("app", NewRow(start.Add(time.Minute), Row{"n": 4})))
+	page, err = writer.QueryPage(ctx, "app", QueryOptions{Columns: []string{"n"}, Descending: true, Limit: 2, After: page.Next})
+	is.NoErr(err)
+	is.Equal(page.Rows, []Row{{"n": 1}, {"n": 0}})
+	is.Equal(page.Next, "")
+}
+
+func Test_query_page_invalid_cursor(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"n": 1})))
+
+	_, err := writer.QueryPage(context.Background(), "app", QueryOptions{After: "nope"})
+
+	is.True(errors.Is(err, ErrInvalidCursor))
+}
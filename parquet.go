@@ -0,0 +1,134 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportParquet writes the rows of a table from (inclusive) to (exclusive) to a Parquet file in
+// chronological order, e.g. to archive them in object storage. A zero time is unbounded.
+func (w *Writer) ExportParquet(ctx context.Context, table string, path string, from, to time.Time) error {
+	return w.copyTo(ctx, table, path, "FORMAT parquet, COMPRESSION zstd", ExportOptions{From: from, To: to})
+}
+
+// ImportParquet appends the rows of a Parquet file (e.g. of ExportParquet) to a table and returns
+// the number of rows. The table is created when it doesn't exist and columns of the file that are
+// missing in the table are added, like Write does; the rows of a partitioned table are imported
+// into the partitions of their timestamps. The file must have a timestamp column. Either all rows
+// are imported or none.
+func (w *Writer) ImportParquet(ctx context.Context, table string, path string) (int, error) {
+	source := "read_parquet(" + quoteString(path) + ")"
+	fileColumns, err := w.describeQuery(ctx, "SELECT * FROM "+source)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if _, ok := fileColumns["timestamp"]; !ok {
+		return 0, fmt.Errorf("%s has no timestamp column", path)
+	}
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	imported := 0
+	if template, ok := w.tableTemplate(table); ok {
+		// The rows are imported into the partitions of their timestamps
+		var next sql.NullTime
+		if err := tx.QueryRowContext(ctx, "SELECT min(timestamp) FROM "+source).Scan(&next); err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for next.Valid {
+			partition := template.name(next.Time)
+			start, end, _ := template.period(partition)
+			rows := fmt.Sprintf("(SELECT * FROM %s WHERE timestamp >= ? AND timestamp < ?)", source)
+			n, err := w.importInto(ctx, tx, partition, fileColumns, rows, start, end)
+			if err != nil {
+				return 0, err
+			}
+			imported += n
+			if err := tx.QueryRowContext(ctx, "SELECT min(timestamp) FROM "+source+" WHERE timestamp >= ?", end).Scan(&next); err != nil {
+				return 0, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+		}
+	} else {
+		n, err := w.importInto(ctx, tx, table, fileColumns, source)
+		if err != nil {
+			return 0, err
+		}
+		imported = n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import of %s: %w", path, err)
+	}
+	return imported, nil
+}
+
+// importInto appends the rows of source to a table, which is created when it doesn't exist, and
+// adds the missing columns of the file
+func (w *Writer) importInto(ctx context.Context, tx *sql.Tx, table string, fileColumns map[string]ColumnType, source string, args ...any) (int, error) {
+	columns, err := w.getCurrentColumns(table)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(columns) == 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM %s LIMIT 0", quoteTableName(table), source), args...); err != nil {
+			return 0, fmt.Errorf("failed to create table %s: %w", table, err)
+		}
+	} else {
+		for name, columnType := range fileColumns {
+			if _, exists := columns[name]; exists {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", quoteTableName(table), quoteTableName(name), columnType)); err != nil {
+				return 0, fmt.Errorf("failed to add column %s: %w", name, err)
+			}
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s BY NAME SELECT * FROM %s", quoteTableName(table), source), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import into %s: %w", table, err)
+	}
+	imported, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to import into %s: %w", table, err)
+	}
+	return int(imported), nil
+}
+
+// describeQuery returns the column types of the result of a query
+func (w *Writer) describeQuery(ctx context.Context, query string) (map[string]ColumnType, error) {
+	rows, err := w.DB.QueryContext(ctx, "DESCRIBE "+query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]ColumnType)
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]any, len(names))
+	dest := make([]any, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		// The first columns are column_name and column_type
+		name, _ := values[0].(string)
+		columnType, _ := values[1].(string)
+		columns[name] = ColumnType(strings.ToUpper(columnType))
+	}
+	return columns, rows.Err()
+}
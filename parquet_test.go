@@ -0,0 +1,95 @@
+package timeline
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_export_and_import_parquet(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("app", NewRow(start, Row{"level": "info", "message": "starting"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(time.Hour), Row{"level": "error", "message": "failed", "code": 500})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(2*time.Hour), Row{"level": "info", "message": "done"})))
+	path := filepath.Join(t.TempDir(), "app.parquet")
+
+	// When the first two hours are archived
+	is.NoErr(writer.ExportParquet(ctx, "app", path, start, start.Add(2*time.Hour)))
+
+	// Then they can be imported in a new table
+	imported, err := writer.ImportParquet(ctx, "archive", path)
+	is.NoErr(err)
+	is.Equal(imported, 2)
+	rows, err := writer.Query(ctx, "archive", QueryOptions{Columns: []string{"timestamp", "level", "code"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{
+		{"timestamp": start, "level": "info"},
+		{"timestamp": start.Add(time.Hour), "level": "error", "code": 500},
+	})
+}
+
+func Test_import_parquet_adds_missing_columns(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("old", NewRow(start, Row{"level": "error", "user_id": 7})))
+	path := filepath.Join(t.TempDir(), "old.parquet")
+	is.NoErr(writer.ExportParquet(ctx, "old", path, time.Time{}, time.Time{}))
+	is.NoErr(writer.Write("app", NewRow(start.Add(time.Hour), Row{"level": "info"})))
+
+	imported, err := writer.ImportParquet(ctx, "app", path)
+
+	is.NoErr(err)
+	is.Equal(imported, 1)
+	rows, err := writer.Query(ctx, "app", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(rows, []Row{
+		{"timestamp": start, "level": "error", "user_id": 7},
+		{"timestamp": start.Add(time.Hour), "level": "info"},
+	})
+
+	// And new rows can still be written
+	is.NoErr(writer.Write("app", NewRow(start.Add(2*time.Hour), Row{"user_id": 8})))
+}
+
+func Test_import_parquet_without_timestamp(t *testing.T) {
+	is, writer := setup(t)
+	path := filepath.Join(t.TempDir(), "values.parquet")
+	_, err := writer.DB.Exec("COPY (SELECT 1 AS value) TO " + quoteString(path) + " (FORMAT parquet)")
+	is.NoErr(err)
+
+	_, err = writer.ImportParquet(context.Background(), "values", path)
+
+	is.True(err != nil)
+}
+
+func Test_export_and_import_parquet_of_a_partitioned_table(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	writer.PartitionDaily("app")
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("app", NewRow(start, Row{"level": "info"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(24*time.Hour), Row{"level": "error", "code": 500})))
+	path := filepath.Join(t.TempDir(), "app.parquet")
+
+	// When the rows of both days are exported
+	is.NoErr(writer.ExportParquet(ctx, "app", path, time.Time{}, time.Time{}))
+
+	// Then they are imported in the partition of their day
+	writer.PartitionDaily("archive")
+	imported, err := writer.ImportParquet(ctx, "archive", path)
+	is.NoErr(err)
+	is.Equal(imported, 2)
+	partitions, err := writer.partitions("archive", time.Time{}, time.Time{})
+	is.NoErr(err)
+	is.Equal(len(partitions), 2)
+	rows, err := writer.Query(ctx, "archive", QueryOptions{Columns: []string{"timestamp", "level", "code"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{
+		{"timestamp": start, "level": "info"},
+		{"timestamp": start.Add(24 * time.Hour), "level": "error", "code": 500},
+	})
+}
@@ -0,0 +1,65 @@
+package timeline
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// parseBatchSize is the number of lines a worker takes at once, to keep the channel overhead low
+const parseBatchSize = 256
+
+// ParseLines parses every line with ParseLineToValues. The rows have the same order as the lines.
+func ParseLines(lines []string) []Row {
+	rows := make([]Row, len(lines))
+	for i, l := range lines {
+		rows[i] = ParseLineToValues(l)
+	}
+	return rows
+}
+
+// ParseLinesConcurrent parses the lines like ParseLines, with a pool of workers so bulk imports use all cores.
+// The rows keep the order of the lines. With workers <= 0 one worker per CPU is used.
+func ParseLinesConcurrent(lines []string, workers int) []Row {
+	return parseLinesInto(nil, lines, workers)
+}
+
+// parseLinesInto parses the lines like ParseLinesConcurrent into the rows of a previous batch (see
+// ParseLineInto), so their maps are reused. The rows of the previous batch are overwritten.
+func parseLinesInto(rows []Row, lines []string, workers int) []Row {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	rows = slices.Grow(rows[:0], len(lines))[:len(lines)]
+	if workers == 1 || len(lines) <= parseBatchSize {
+		for i, l := range lines {
+			rows[i], _ = parseLineInto(rows[i], l, nil)
+		}
+		return rows
+	}
+
+	batches := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every worker writes to its own indexes of rows, so no locking is needed
+			for start := range batches {
+				end := min(start+parseBatchSize, len(lines))
+				for i := start; i < end; i++ {
+					rows[i], _ = parseLineInto(rows[i], lines[i], nil)
+				}
+			}
+		}()
+	}
+
+	for start := 0; start < len(lines); start += parseBatchSize {
+		batches <- start
+	}
+	close(batches)
+	wg.Wait()
+
+	return rows
+}
@@ -0,0 +1,66 @@
+package timeline
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_lines_keeps_order(t *testing.T) {
+	is := is.New(t)
+
+	rows := ParseLines([]string{`{"n":1}`, `level=info n=2`, `plain text`})
+
+	is.Equal(len(rows), 3)
+	is.Equal(rows[0]["n"], 1)
+	is.Equal(rows[1]["n"], 2)
+	is.Equal(rows[2]["message"], "plain text")
+}
+
+func Test_parse_lines_concurrent_keeps_order(t *testing.T) {
+	is := is.New(t)
+	lines := make([]string, 2000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf(`{"n":%d}`, i)
+	}
+
+	rows := ParseLinesConcurrent(lines, 4)
+
+	is.Equal(len(rows), len(lines))
+	for i, row := range rows {
+		is.Equal(row["n"], i)
+	}
+}
+
+func Test_parse_lines_concurrent_with_default_workers(t *testing.T) {
+	is := is.New(t)
+
+	rows := ParseLinesConcurrent([]string{"a=1", "b=2"}, 0)
+
+	is.Equal(rows, []Row{{"a": 1}, {"b": 2}})
+}
+
+func Test_parse_lines_into_reuses_the_rows(t *testing.T) {
+	is := is.New(t)
+	rows := parseLinesInto(nil, []string{`{"n":1}`, `level=info n=2`}, 1)
+	first := rows[0]
+
+	rows = parseLinesInto(rows, []string{`a=1`, `b=2`, `c=3`}, 1)
+
+	is.Equal(rows, []Row{{"a": 1}, {"b": 2}, {"c": 3}})
+	first["marked"] = true
+	is.Equal(rows[0]["marked"], true) // the map of the first row is reused
+}
+
+func Benchmark_parse_lines_concurrent(b *testing.B) {
+	lines := make([]string, 10000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf(`time=2025-09-19T20:35:00Z level=info msg="User login successful" user_id=%d duration=0.45`, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParseLinesConcurrent(lines, 0)
+	}
+}
@@ -0,0 +1,180 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// partitionLayout is the date of the name of a partition, e.g. logs_20250914
+const partitionLayout = "20060102"
+
+// PartitionDaily writes the rows of the tables to a table per day (UTC) of their timestamp, e.g.
+// logs_20250914 for logs. Query, Between and Export read the partitions of the time range (From and
+// To of QueryOptions) as one table, and MaxAge drops whole partitions, which is much cheaper than
+// deleting rows. QueryPage doesn't support partitioned tables. The partitioning isn't stored in the
+// database: call PartitionDaily again after the database is opened.
+//
+// Other periods are written with a table template: writing to logs_{2006_01} writes the rows to a
+// table per month (UTC) of their timestamp, e.g. logs_2025_09, with the time layout of the time
+// package between the braces. Query the template, e.g. logs_{2006_01}, to read its tables as one
+// table; retention, Compact and DropTable of the template apply to all its tables.
+func (w *Writer) PartitionDaily(tables ...string) {
+	w.partitionMu.Lock()
+	defer w.partitionMu.Unlock()
+	if w.partitioned == nil {
+		w.partitioned = make(map[string]bool)
+	}
+	for _, table := range tables {
+		w.partitioned[table] = true
+	}
+}
+
+// isPartitioned reports whether the rows of the table are written to partitions: the daily
+// partitions of PartitionDaily or the tables of a table template, e.g. logs_{2006_01}
+func (w *Writer) isPartitioned(table string) bool {
+	_, ok := w.tableTemplate(table)
+	return ok
+}
+
+// tableTemplate returns the template of the partitions of a partitioned table. A table template is
+// remembered, so partitionOf recognizes its tables.
+func (w *Writer) tableTemplate(table string) (tableTemplate, bool) {
+	w.partitionMu.RLock()
+	daily := w.partitioned[table]
+	template, known := w.templates[table]
+	w.partitionMu.RUnlock()
+	if daily {
+		return tableTemplate{prefix: table + "_", layout: partitionLayout}, true
+	}
+	if known {
+		return template, true
+	}
+
+	template, ok := parseTableTemplate(table)
+	if !ok {
+		return tableTemplate{}, false
+	}
+	w.partitionMu.Lock()
+	defer w.partitionMu.Unlock()
+	if w.templates == nil {
+		w.templates = make(map[string]tableTemplate)
+	}
+	w.templates[table] = template
+	return template, true
+}
+
+// partitionName returns the partition of a table of the day of the timestamp
+func partitionName(table string, timestamp time.Time) string {
+	return table + "_" + timestamp.UTC().Format(partitionLayout)
+}
+
+// partitionOf returns the partitioned table of a partition and the end of the time of its rows
+func (w *Writer) partitionOf(table string) (string, time.Time, bool) {
+	if i := strings.LastIndex(table, "_"); i >= 0 {
+		day, err := time.Parse(partitionLayout, table[i+1:])
+		if err == nil && w.isPartitioned(table[:i]) {
+			return table[:i], day.AddDate(0, 0, 1), true
+		}
+	}
+
+	w.partitionMu.RLock()
+	defer w.partitionMu.RUnlock()
+	for name, template := range w.templates {
+		if _, end, ok := template.period(table); ok {
+			return name, end, true
+		}
+	}
+	return "", time.Time{}, false
+}
+
+// rowTime returns the timestamp of a row, or the current time for rows without a timestamp
+func rowTime(row Row) time.Time {
+	if timestamp, ok := row["timestamp"].(time.Time); ok {
+		return timestamp
+	}
+	return time.Now()
+}
+
+// partitions returns the partitions of a partitioned table that overlap the time range (a zero
+// time is unbounded), ordered by time
+func (w *Writer) partitions(table string, from, to time.Time) ([]string, error) {
+	template, _ := w.tableTemplate(table)
+	database, prefix := splitTableName(template.prefix)
+	template.prefix = prefix
+	rows, err := w.DB.Query(
+		"SELECT table_name FROM information_schema.tables WHERE table_catalog = coalesce(nullif(?, ''), current_database()) AND table_schema = 'main' AND table_type = 'BASE TABLE' AND starts_with(table_name, ?)",
+		database, prefix,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var partitions []string
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			return nil, fmt.Errorf("failed to scan partition: %w", err)
+		}
+		start, end, ok := template.period(partition)
+		if !ok {
+			continue
+		}
+		if (!to.IsZero() && !start.Before(to)) || (!from.IsZero() && !end.After(from)) {
+			continue
+		}
+		if database != "" {
+			partition = database + "." + partition
+		}
+		partitions = append(partitions, partition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", table, err)
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		start, _, _ := template.period(strings.TrimPrefix(partitions[i], database+"."))
+		other, _, _ := template.period(strings.TrimPrefix(partitions[j], database+"."))
+		return start.Before(other)
+	})
+	return partitions, nil
+}
+
+// partitionSource returns the FROM clause of the partitions of a partitioned table in the time
+// range. Without partitions in the range the source is empty, with the columns of a partition.
+func (w *Writer) partitionSource(table string, from, to time.Time) (string, error) {
+	partitions, err := w.partitions(table, from, to)
+	if err != nil {
+		return "", err
+	}
+	if len(partitions) == 0 {
+		all, err := w.partitions(table, time.Time{}, time.Time{})
+		if err != nil {
+			return "", err
+		}
+		if len(all) == 0 {
+			return "", fmt.Errorf("%w: %s", ErrTableNotFound, table)
+		}
+		return fmt.Sprintf("(SELECT * FROM %s LIMIT 0) AS %s", quoteTableName(all[len(all)-1]), quoteIdentifier(table)), nil
+	}
+
+	selects := make([]string, len(partitions))
+	for i, partition := range partitions {
+		selects[i] = "SELECT * FROM " + quoteTableName(partition)
+	}
+	return fmt.Sprintf("(%s) AS %s", strings.Join(selects, " UNION ALL BY NAME "), quoteIdentifier(table)), nil
+}
+
+// dropPartition drops a partition and returns the number of its rows
+func (w *Writer) dropPartition(ctx context.Context, partition string) (int64, error) {
+	var count int64
+	if err := w.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s", quoteTableName(partition))).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count the rows of partition %s: %w", partition, err)
+	}
+	if _, err := w.DB.ExecContext(ctx, fmt.Sprintf("DROP TABLE %s", quoteTableName(partition))); err != nil {
+		return 0, fmt.Errorf("failed to drop partition %s: %w", partition, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,80 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_partition_daily_writes_a_table_per_day(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("logs")
+
+	// When rows of two days are written
+	is.NoErr(writer.Write("logs", NewRow(time.Date(2025, 9, 14, 23, 0, 0, 0, time.UTC), Row{"message": "first"})))
+	is.NoErr(writer.Write("logs", NewRow(time.Date(2025, 9, 15, 1, 0, 0, 0, time.UTC), Row{"message": "second"})))
+
+	// Then they are in the partitions of their day
+	tables, err := writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.Equal(tables, []string{"logs_20250914", "logs_20250915"})
+}
+
+func Test_partitioned_tables_are_queried_as_one_table(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("logs")
+	for day := 10; day <= 14; day++ {
+		is.NoErr(writer.Write("logs", NewRow(time.Date(2025, 9, day, 12, 0, 0, 0, time.UTC), Row{"day": day})))
+	}
+	is.NoErr(writer.Write("logs", NewRow(time.Date(2025, 9, 12, 13, 0, 0, 0, time.UTC), Row{"day": 12, "extra": "column"})))
+
+	// When a time range of three days is queried
+	rows, err := writer.Between(context.Background(), "logs", time.Date(2025, 9, 11, 12, 0, 0, 0, time.UTC), time.Date(2025, 9, 13, 13, 0, 0, 0, time.UTC), 0)
+
+	// Then the rows of the partitions are returned in chronological order
+	is.NoErr(err)
+	is.Equal(len(rows), 4)
+	is.Equal(rows[0]["day"], 11)
+	is.Equal(rows[1]["day"], 12)
+	is.Equal(rows[2]["extra"], "column")
+	is.Equal(rows[3]["day"], 13)
+}
+
+func Test_partitioned_table_without_partitions_in_the_time_range(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("logs")
+
+	// Without partitions the table doesn't exist
+	_, err := writer.Query(context.Background(), "logs", QueryOptions{})
+	is.True(err != nil)
+
+	// Without partitions in the range no rows are returned
+	is.NoErr(writer.Write("logs", NewRow(time.Date(2025, 9, 14, 12, 0, 0, 0, time.UTC), Row{"message": "hello"})))
+	rows, err := writer.Query(context.Background(), "logs", QueryOptions{From: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)})
+	is.NoErr(err)
+	is.Equal(len(rows), 0)
+}
+
+func Test_max_age_drops_the_old_partitions(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("logs")
+	now := time.Now().UTC()
+
+	// Given partitions of the last 5 days
+	for days := 0; days < 5; days++ {
+		is.NoErr(writer.Write("logs", NewRow(now.AddDate(0, 0, -days), Row{"days": days})))
+	}
+
+	// When the rows of more than 2.5 days old are deleted
+	deleted, err := writer.ApplyRetention(context.Background(), MaxAge(60*time.Hour, "logs"))
+
+	// Then the 3 and 4 days old rows are deleted and the partition of the 4 days old row is dropped
+	is.NoErr(err)
+	is.Equal(deleted, int64(2))
+	tables, err := writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.True(tables[0] > partitionName("logs", now.AddDate(0, 0, -4)))
+	rows, err := writer.Query(context.Background(), "logs", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(len(rows), 3)
+}
@@ -0,0 +1,220 @@
+package timeline
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// patternLayoutConversions maps log4j/Logback conversion words to a column and the regex of its value
+var patternLayoutConversions = map[string]struct {
+	column string
+	regex  string
+}{
+	"d":         {"timestamp", `(.+?)`},
+	"date":      {"timestamp", `(.+?)`},
+	"p":         {"level", `([A-Za-z]+)`},
+	"le":        {"level", `([A-Za-z]+)`},
+	"level":     {"level", `([A-Za-z]+)`},
+	"t":         {"thread", `(.+?)`},
+	"thread":    {"thread", `(.+?)`},
+	"c":         {"logger", `(\S+?)`},
+	"lo":        {"logger", `(\S+?)`},
+	"logger":    {"logger", `(\S+?)`},
+	"C":         {"class", `(\S+?)`},
+	"class":     {"class", `(\S+?)`},
+	"M":         {"method", `(\S+?)`},
+	"method":    {"method", `(\S+?)`},
+	"F":         {"file", `(\S+?)`},
+	"file":      {"file", `(\S+?)`},
+	"L":         {"line", `(\d+)`},
+	"line":      {"line", `(\d+)`},
+	"r":         {"relative", `(\d+)`},
+	"relative":  {"relative", `(\d+)`},
+	"m":         {"message", `(.*)`},
+	"msg":       {"message", `(.*)`},
+	"message":   {"message", `(.*)`},
+	"X":         {"mdc", `(.*?)`},
+	"mdc":       {"mdc", `(.*?)`},
+	"ex":        {"", `(.*)`},
+	"exception": {"", `(.*)`},
+	"throwable": {"", `(.*)`},
+}
+
+// patternLayoutIntColumns are the columns that are stored as numbers
+var patternLayoutIntColumns = map[string]bool{"line": true, "relative": true}
+
+// PatternLayoutParser parses log lines written with a log4j or Logback pattern layout.
+// The layout is compiled to a regular expression once; each conversion becomes a column.
+// Format modifiers (%-5p, %.30c), options (%d{ISO8601}, %logger{36}) and color wrappers
+// (%highlight(%-5level)) are supported.
+// Example:
+//
+//	Layout: %d{yyyy-MM-dd HH:mm:ss} %-5p [%t] %c - %m%n
+//	Line:   2025-09-21 10:00:00 INFO  [main] com.example.App - Application started
+//
+// Fields: timestamp, level, thread, logger, class, method, file, line, relative, message and %X{key} MDC values
+type PatternLayoutParser struct {
+	layout  string
+	regex   *regexp.Regexp
+	columns []string
+}
+
+// NewPatternLayoutParser compiles the log4j/Logback pattern layout into a parser
+func NewPatternLayoutParser(layout string) (*PatternLayoutParser, error) {
+	var columns []string
+	expression, err := compilePatternLayout(layout, &columns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern layout %q: %w", layout, err)
+	}
+
+	regex, err := regexp.Compile(`(?s)^` + expression + `$`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile pattern layout %q: %w", layout, err)
+	}
+
+	return &PatternLayoutParser{layout: layout, regex: regex, columns: columns}, nil
+}
+
+// Parse maps the parts of the line to columns, returns nil when the line doesn't match the layout
+func (p *PatternLayoutParser) Parse(l string) Row {
+	match := p.regex.FindStringSubmatch(strings.TrimRight(l, "\r\n"))
+	if match == nil {
+		return nil
+	}
+
+	result := make(Row)
+	for i, column := range p.columns {
+		value := strings.TrimSpace(match[i+1])
+		if column == "" || value == "" {
+			continue
+		}
+		if patternLayoutIntColumns[column] {
+			if number, err := strconv.Atoi(value); err == nil {
+				result[column] = number
+				continue
+			}
+		}
+		result[column] = value
+	}
+	return result
+}
+
+// compilePatternLayout converts a pattern layout to a regular expression.
+// The column of every capture group is appended to columns.
+func compilePatternLayout(layout string, columns *[]string) (string, error) {
+	var expression strings.Builder
+	for i := 0; i < len(layout); i++ {
+		char := layout[i]
+		if char != '%' {
+			if char == ' ' || char == '\t' {
+				expression.WriteString(`\s+`)
+				// Collapse multiple whitespace characters
+				for i+1 < len(layout) && (layout[i+1] == ' ' || layout[i+1] == '\t') {
+					i++
+				}
+				continue
+			}
+			expression.WriteString(regexp.QuoteMeta(string(char)))
+			continue
+		}
+
+		i++
+		if i >= len(layout) {
+			return "", fmt.Errorf("unexpected end after %%")
+		}
+		if layout[i] == '%' {
+			expression.WriteString("%")
+			continue
+		}
+
+		// Format modifier, e.g. -5 or .30 or 20.-30
+		padded := false
+		for i < len(layout) && strings.IndexByte("-.0123456789", layout[i]) != -1 {
+			padded = true
+			i++
+		}
+
+		// Conversion word
+		start := i
+		for i < len(layout) && isLetter(layout[i]) {
+			i++
+		}
+		word := layout[start:i]
+		if word == "" {
+			return "", fmt.Errorf("missing conversion word at position %d", start)
+		}
+
+		// Color wrapper, e.g. %highlight(%-5level)
+		if i < len(layout) && layout[i] == '(' {
+			end := matchingParenthesis(layout, i)
+			if end == -1 {
+				return "", fmt.Errorf("unclosed parenthesis after %%%s", word)
+			}
+			inner, err := compilePatternLayout(layout[i+1:end], columns)
+			if err != nil {
+				return "", err
+			}
+			expression.WriteString(inner)
+			i = end
+			continue
+		}
+
+		// Option, e.g. {yyyy-MM-dd} or {requestId}
+		option := ""
+		if i < len(layout) && layout[i] == '{' {
+			end := strings.IndexByte(layout[i:], '}')
+			if end == -1 {
+				return "", fmt.Errorf("unclosed option after %%%s", word)
+			}
+			option = layout[i+1 : i+end]
+			i += end
+		} else {
+			i--
+		}
+
+		// End of line
+		if word == "n" {
+			continue
+		}
+
+		conversion, known := patternLayoutConversions[word]
+		if !known {
+			return "", fmt.Errorf("unknown conversion %%%s", word)
+		}
+		column := conversion.column
+		if column == "mdc" {
+			column = normalizeColumnName(option)
+		}
+		if padded {
+			expression.WriteString(`\s*` + conversion.regex + `\s*`)
+		} else {
+			expression.WriteString(conversion.regex)
+		}
+		*columns = append(*columns, column)
+	}
+	return expression.String(), nil
+}
+
+// matchingParenthesis returns the index of the parenthesis that closes the one at start
+func matchingParenthesis(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isLetter reports whether the byte is an ASCII letter
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
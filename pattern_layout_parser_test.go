@@ -0,0 +1,65 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_log4j_pattern_layout(t *testing.T) {
+	is := is.New(t)
+	parser, err := NewPatternLayoutParser("%d{yyyy-MM-dd HH:mm:ss} %-5p [%t] %c - %m%n")
+	is.NoErr(err)
+
+	data := parser.Parse("2025-09-21 10:00:00 INFO  [main-worker 1] com.example.App - Application started on port 8080\n")
+
+	is.Equal(data["timestamp"], "2025-09-21 10:00:00")
+	is.Equal(data["level"], "INFO")
+	is.Equal(data["thread"], "main-worker 1")
+	is.Equal(data["logger"], "com.example.App")
+	is.Equal(data["message"], "Application started on port 8080")
+}
+
+func Test_parse_logback_pattern_with_wrappers_mdc_and_location(t *testing.T) {
+	is := is.New(t)
+	parser, err := NewPatternLayoutParser("%date{ISO8601} %highlight(%-5level) %cyan(%logger{36}) [%X{requestId}] %file:%line - %msg%n")
+	is.NoErr(err)
+
+	data := parser.Parse("2025-09-21 10:00:00,123 ERROR c.e.OrderService [abc-123] OrderService.java:42 - Payment failed")
+
+	is.Equal(data["timestamp"], "2025-09-21 10:00:00,123")
+	is.Equal(data["level"], "ERROR")
+	is.Equal(data["logger"], "c.e.OrderService")
+	is.Equal(data["requestid"], "abc-123")
+	is.Equal(data["file"], "OrderService.java")
+	is.Equal(data["line"], 42)
+	is.Equal(data["message"], "Payment failed")
+}
+
+func Test_parse_pattern_layout_keeps_multi_line_message(t *testing.T) {
+	is := is.New(t)
+	parser, err := NewPatternLayoutParser("%d %p %m%n")
+	is.NoErr(err)
+
+	data := parser.Parse("2025-09-21T10:00:00 ERROR Request failed\njava.lang.IllegalStateException: boom\n\tat com.example.App.main(App.java:5)")
+
+	is.Equal(data["message"], "Request failed\njava.lang.IllegalStateException: boom\n\tat com.example.App.main(App.java:5)")
+}
+
+func Test_parse_pattern_layout_line_that_does_not_match(t *testing.T) {
+	is := is.New(t)
+	parser, err := NewPatternLayoutParser("%d [%t] %p - %m")
+	is.NoErr(err)
+
+	data := parser.Parse("just some text")
+
+	is.Equal(data, nil)
+}
+
+func Test_create_pattern_layout_parser_with_unknown_conversion(t *testing.T) {
+	is := is.New(t)
+
+	_, err := NewPatternLayoutParser("%d %unknown %m")
+
+	is.True(err != nil)
+}
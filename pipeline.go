@@ -0,0 +1,335 @@
+package timeline
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Source delivers the lines of a pipeline. Read calls emit for every line until the input is
+// exhausted or ctx is done; emit blocks while the pipeline is busy.
+type Source interface {
+	Read(ctx context.Context, emit func(line string)) error
+}
+
+// SourceFunc is an adapter to allow the use of ordinary functions as Source
+type SourceFunc func(ctx context.Context, emit func(line string)) error
+
+// Read calls f(ctx, emit)
+func (f SourceFunc) Read(ctx context.Context, emit func(line string)) error {
+	return f(ctx, emit)
+}
+
+// ReaderSource reads the lines of r (LF or CRLF terminated). Lines longer than 1 MiB are skipped.
+func ReaderSource(r io.Reader) Source {
+	return SourceFunc(func(ctx context.Context, emit func(line string)) error {
+		reader := bufio.NewReader(r)
+		for ctx.Err() == nil {
+			line, tooLong, err := readIngestLine(reader, 1<<20)
+			if !tooLong && (line != "" || err == nil) {
+				emit(line)
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read line: %w", err)
+			}
+		}
+		return ctx.Err()
+	})
+}
+
+// ChannelSource reads the lines of a channel until it is closed
+func ChannelSource(lines <-chan string) Source {
+	return SourceFunc(func(ctx context.Context, emit func(line string)) error {
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return nil
+				}
+				emit(line)
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+}
+
+// Transformer changes a parsed row before it is written, e.g. NormalizeSeverity.
+// Returning nil drops the row.
+type Transformer func(row Row) Row
+
+// AddFields adds fields to every row that doesn't have them yet, e.g. the environment
+func AddFields(fields Row) Transformer {
+	return func(row Row) Row {
+		for k, v := range fields {
+			if _, exists := row[k]; !exists {
+				row[k] = v
+			}
+		}
+		return row
+	}
+}
+
+// DropFields removes fields from every row, e.g. fields with secrets
+func DropFields(names ...string) Transformer {
+	return func(row Row) Row {
+		for _, name := range names {
+			delete(row, name)
+		}
+		return row
+	}
+}
+
+// PipelineConfig configures a Pipeline
+type PipelineConfig struct {
+	// Source delivers the lines
+	Source Source
+	// Writer is the database the rows are written to
+	Writer *Writer
+	// Table is the table the rows are written to
+	Table string
+	// TableFor chooses the table per row; overrides Table
+	TableFor func(row Row) string
+	// Parsers parse the lines, the row of the first parser that doesn't return nil is used.
+	// Defaults to ParseLineToValues.
+	Parsers []Parser
+	// Transformers change the parsed rows in order
+	Transformers []Transformer
+	// Multiline groups stack traces and other continuation lines into one row, see LineAssembler
+	Multiline bool
+	// BatchSize is the number of lines that are parsed and written together, defaults to 100
+	BatchSize int
+	// FlushInterval is the maximum time a line waits for its batch, defaults to 1s
+	FlushInterval time.Duration
+	// OnError is called for rows that could not be written
+	OnError func(error)
+	// Spool stores every line before emit returns, until the batch of the line is written. The
+	// lines that were not written when the process stopped are written first by the next Run,
+	// with the time they were read.
+	Spool *Spool
+}
+
+// pipelineLine is a line of a pipeline with the time it was read and its sequence number in the
+// spool (0 without a spool)
+type pipelineLine struct {
+	text string
+	at   time.Time
+	seq  int64
+}
+
+// spooledLine is the spool record of a line of a pipeline
+type spooledLine struct {
+	Time time.Time `json:"time"`
+	Line string    `json:"line"`
+}
+
+// Pipeline reads the lines of a source, parses them, transforms the rows and writes them in batches
+type Pipeline struct {
+	config PipelineConfig
+}
+
+// NewPipeline creates a pipeline, call Run to start it
+func NewPipeline(config PipelineConfig) *Pipeline {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	return &Pipeline{config: config}
+}
+
+// Run runs the pipeline until the source is exhausted or ctx is done. The lines that are read are
+// written before Run returns. Returns the error of the source, or of the replay of the spool.
+func (p *Pipeline) Run(ctx context.Context) error {
+	if p.config.Source == nil || p.config.Writer == nil {
+		return errors.New("a pipeline needs a source and a writer")
+	}
+
+	lines := make(chan pipelineLine, p.config.BatchSize)
+	sourceErr := make(chan error, 1)
+	send := func(line pipelineLine) {
+		select {
+		case lines <- line:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(lines)
+		if err := p.replay(send); err != nil {
+			sourceErr <- err
+			return
+		}
+		sourceErr <- p.config.Source.Read(ctx, func(text string) {
+			line := pipelineLine{text: text, at: time.Now()}
+			if p.config.Spool != nil {
+				seq, err := p.config.Spool.Append(spooledLine{Time: line.at, Line: text})
+				if err != nil {
+					// The line is still written, it is only not crash-safe
+					handleError(p.config.OnError, err, ErrorContext{Component: "pipeline", Table: p.config.Table})
+				}
+				line.seq = seq
+			}
+			send(line)
+		})
+	}()
+
+	var assembler *LineAssembler
+	if p.config.Multiline {
+		assembler = NewLineAssembler()
+	}
+
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]pipelineLine, 0, p.config.BatchSize)
+	// consumed is the sequence number of the last line that was read, pending the first line of
+	// the entry of the assembler: its lines are committed when the entry is written
+	var consumed int64
+	var pending pipelineLine
+	flush := func() {
+		p.write(batch)
+		batch = batch[:0]
+		committed := consumed
+		if assembler != nil && len(assembler.pending) > 0 {
+			committed = pending.seq - 1
+		}
+		p.commit(committed)
+	}
+	idle := true
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if assembler != nil {
+					if entry, ok := assembler.Flush(); ok {
+						batch = append(batch, pipelineLine{text: entry, at: pending.at})
+					}
+				}
+				flush()
+				return <-sourceErr
+			}
+			idle = false
+			if line.seq > 0 {
+				consumed = line.seq
+			}
+			if assembler == nil {
+				batch = append(batch, line)
+			} else {
+				if entry, ok := assembler.Add(line.text); ok {
+					batch = append(batch, pipelineLine{text: entry, at: pending.at})
+				}
+				if len(assembler.pending) == 1 {
+					pending = line
+				}
+			}
+			if len(batch) >= p.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			// Without new lines since the last tick the pending entry is complete
+			if assembler != nil && idle {
+				if entry, ok := assembler.Flush(); ok {
+					batch = append(batch, pipelineLine{text: entry, at: pending.at})
+				}
+			}
+			idle = true
+			flush()
+		}
+	}
+}
+
+// replay sends the lines of the spool that were not written by a previous Run
+func (p *Pipeline) replay(send func(line pipelineLine)) error {
+	if p.config.Spool == nil {
+		return nil
+	}
+	return p.config.Spool.Replay(func(seq int64, record json.RawMessage) error {
+		var spooled spooledLine
+		if err := json.Unmarshal(record, &spooled); err != nil {
+			return fmt.Errorf("invalid spool record %d: %w", seq, err)
+		}
+		send(pipelineLine{text: spooled.Line, at: spooled.Time, seq: seq})
+		return nil
+	})
+}
+
+// commit commits the lines up to and including seq to the spool
+func (p *Pipeline) commit(seq int64) {
+	if p.config.Spool == nil || seq <= 0 {
+		return
+	}
+	if err := p.config.Spool.Commit(seq); err != nil {
+		handleError(p.config.OnError, err, ErrorContext{Component: "pipeline", Table: p.config.Table})
+	}
+}
+
+// write parses, transforms and writes a batch of lines
+func (p *Pipeline) write(batch []pipelineLine) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var rows []Row
+	if len(p.config.Parsers) == 0 {
+		texts := make([]string, len(batch))
+		for i, line := range batch {
+			texts[i] = line.text
+		}
+		rows = ParseLinesConcurrent(texts, 0)
+	} else {
+		rows = make([]Row, len(batch))
+		for i, line := range batch {
+			for _, parser := range p.config.Parsers {
+				if rows[i] = parser.Parse(line.text); rows[i] != nil {
+					break
+				}
+			}
+		}
+	}
+
+	written := map[string]int{}
+	parseFailures := 0
+	for i, row := range rows {
+		if strings.TrimSpace(batch[i].text) == "" {
+			continue
+		}
+		if row == nil {
+			parseFailures++
+			continue
+		}
+		for _, transform := range p.config.Transformers {
+			if row == nil {
+				break
+			}
+			row = transform(row)
+		}
+		if len(row) == 0 {
+			continue
+		}
+
+		table := p.config.Table
+		if p.config.TableFor != nil {
+			table = p.config.TableFor(row)
+		}
+		if err := p.config.Writer.Write(table, NewRow(batch[i].at, row)); err != nil {
+			handleError(p.config.OnError, fmt.Errorf("failed to write row to %s: %w", table, err), ErrorContext{Component: "pipeline", Table: table})
+			continue
+		}
+		written[table]++
+	}
+
+	// Lines that can't be parsed have no table yet
+	p.config.Writer.observeBatch(p.config.Table, 0, parseFailures)
+	for table, n := range written {
+		p.config.Writer.observeBatch(table, n, 0)
+	}
+}
@@ -0,0 +1,119 @@
+package timeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_pipeline_parses_transforms_and_writes_lines(t *testing.T) {
+	is, writer := setup(t)
+	input := strings.Join([]string{
+		`{"level":"warning","message":"disk almost full","password":"secret"}`,
+		"level=error msg=failed",
+		"  at retry",
+		"",
+		"level=debug msg=noise",
+	}, "\n")
+
+	// Given a pipeline that normalizes severities, drops debug lines and secrets
+	pipeline := NewPipeline(PipelineConfig{
+		Source:    ReaderSource(strings.NewReader(input)),
+		Writer:    writer,
+		Table:     "app",
+		Multiline: true,
+		Transformers: []Transformer{
+			NormalizeSeverity,
+			func(row Row) Row {
+				if row["level"] == LevelDebug {
+					return nil
+				}
+				return row
+			},
+			DropFields("password"),
+			AddFields(Row{"env": "production"}),
+		},
+		OnError: func(err error) { t.Errorf("pipeline error: %v", err) },
+	})
+
+	// When the pipeline runs
+	err := pipeline.Run(context.Background())
+
+	// Then the transformed rows are written
+	is.NoErr(err)
+	var count int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*) FROM app WHERE env = 'production'`).Scan(&count))
+	is.Equal(count, 2)
+
+	var level string
+	is.NoErr(writer.DB.QueryRow(`SELECT level FROM app WHERE message = 'disk almost full'`).Scan(&level))
+	is.Equal(level, LevelWarn)
+
+	columns, err := writer.getCurrentColumns("app")
+	is.NoErr(err)
+	_, hasPassword := columns["password"]
+	is.True(!hasPassword)
+}
+
+func Test_pipeline_uses_the_first_matching_parser_and_flushes_on_interval(t *testing.T) {
+	is, writer := setup(t)
+	lines := make(chan string)
+	grok, err := NewGrokParser(`%{WORD:action} %{INT:user_id:int}`, nil)
+	is.NoErr(err)
+
+	pipeline := NewPipeline(PipelineConfig{
+		Source:        ChannelSource(lines),
+		Writer:        writer,
+		TableFor:      func(row Row) string { return "events" },
+		Parsers:       []Parser{grok, ParserFunc(ParseLineToValues)},
+		BatchSize:     1000,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	done := make(chan error, 1)
+	go func() { done <- pipeline.Run(context.Background()) }()
+
+	lines <- "login 42"
+	waitForCount(t, writer, `SELECT count(*) FROM events WHERE action = 'login' AND user_id = 42`, 1)
+
+	lines <- "something else happened"
+	close(lines)
+	is.NoErr(<-done)
+	waitForCount(t, writer, `SELECT count(*) FROM events`, 2)
+}
+
+func Test_pipeline_writes_the_spooled_lines_of_a_previous_run(t *testing.T) {
+	is, writer := setup(t)
+	dir := t.TempDir()
+
+	// Given a spool with a line that was read before a crash
+	spool, err := OpenSpool(SpoolConfig{Dir: dir})
+	is.NoErr(err)
+	readAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	_, err = spool.Append(spooledLine{Time: readAt, Line: "level=error msg=lost"})
+	is.NoErr(err)
+	is.NoErr(spool.Close())
+
+	// When the pipeline runs with the spool
+	spool, err = OpenSpool(SpoolConfig{Dir: dir})
+	is.NoErr(err)
+	defer spool.Close()
+	pipeline := NewPipeline(PipelineConfig{
+		Source:    ReaderSource(strings.NewReader("level=info msg=new\n  at retry\n")),
+		Writer:    writer,
+		Table:     "app",
+		Multiline: true,
+		Spool:     spool,
+		OnError:   func(err error) { t.Errorf("pipeline error: %v", err) },
+	})
+	is.NoErr(pipeline.Run(context.Background()))
+
+	// Then the spooled line is written with the time it was read, and all lines are committed
+	var timestamp time.Time
+	is.NoErr(writer.DB.QueryRow(`SELECT timestamp FROM app WHERE msg = 'lost'`).Scan(&timestamp))
+	is.True(timestamp.Equal(readAt))
+	var count int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*) FROM app`).Scan(&count))
+	is.Equal(count, 2)
+	is.Equal(spool.Pending(), int64(0))
+}
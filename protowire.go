@@ -0,0 +1,110 @@
+package timeline
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Protocol buffer wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+var errProtoTruncated = errors.New("truncated protobuf message")
+
+// protoReader decodes the protocol buffer wire format. The receivers only need a handful of
+// message types, a small decoder avoids a dependency on the protobuf runtime and generated code.
+type protoReader struct {
+	buf []byte
+}
+
+// done reports whether the whole message is read
+func (r *protoReader) done() bool {
+	return len(r.buf) == 0
+}
+
+// next reads the tag of the next field
+func (r *protoReader) next() (field int, wireType int, err error) {
+	tag, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(tag >> 3), int(tag & 7), nil
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	value, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		return 0, errProtoTruncated
+	}
+	r.buf = r.buf[n:]
+	return value, nil
+}
+
+func (r *protoReader) fixed64() (uint64, error) {
+	if len(r.buf) < 8 {
+		return 0, errProtoTruncated
+	}
+	value := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return value, nil
+}
+
+func (r *protoReader) fixed32() (uint32, error) {
+	if len(r.buf) < 4 {
+		return 0, errProtoTruncated
+	}
+	value := binary.LittleEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	return value, nil
+}
+
+func (r *protoReader) double() (float64, error) {
+	bits, err := r.fixed64()
+	return math.Float64frombits(bits), err
+}
+
+// bytes reads a length delimited field: strings, bytes and embedded messages
+func (r *protoReader) bytes() ([]byte, error) {
+	length, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.buf)) < length {
+		return nil, errProtoTruncated
+	}
+	value := r.buf[:length]
+	r.buf = r.buf[length:]
+	return value, nil
+}
+
+// message reads an embedded message
+func (r *protoReader) message() (*protoReader, error) {
+	value, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &protoReader{buf: value}, nil
+}
+
+// skip skips the value of an unknown field
+func (r *protoReader) skip(wireType int) error {
+	var err error
+	switch wireType {
+	case protoVarint:
+		_, err = r.varint()
+	case protoFixed64:
+		_, err = r.fixed64()
+	case protoBytes:
+		_, err = r.bytes()
+	case protoFixed32:
+		_, err = r.fixed32()
+	default:
+		err = errors.New("unsupported protobuf wire type")
+	}
+	return err
+}
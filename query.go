@@ -0,0 +1,526 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+// ErrTableNotFound is returned when a queried table doesn't exist (yet)
+var ErrTableNotFound = errors.New("table not found")
+
+// SetQueryTimeout sets the maximum duration of the queries of the read APIs (Query, Iter, SQL,
+// Aggregate, Export and the others), so a runaway query can't stall the process. A query that
+// exceeds it is interrupted and returns context.DeadlineExceeded. A shorter deadline of the
+// context of a call wins. 0 (the default) disables the timeout.
+func (w *Writer) SetQueryTimeout(timeout time.Duration) {
+	w.queryTimeout.Store(int64(timeout))
+}
+
+// withQueryTimeout applies the query timeout to the context of a query
+func (w *Writer) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(w.queryTimeout.Load())
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// QueryOptions selects the rows of Query
+type QueryOptions struct {
+	// Columns are the selected columns, all columns by default
+	Columns []string
+	// Where is an SQL condition with ? placeholders for Args, e.g. "status >= ?"
+	Where string
+	Args  []any
+	// Filters are combined with Where
+	Filters []Filter
+	// From (inclusive) and To (exclusive) limit the timestamps of the rows, a zero time is unbounded
+	From time.Time
+	To   time.Time
+	// OrderBy is the column the rows are sorted by, defaults to timestamp
+	OrderBy string
+	// Descending sorts the rows from new to old
+	Descending bool
+	// Limit is the maximum number of rows, 0 means no limit
+	Limit int
+	// Offset skips the first rows
+	Offset int
+	// After is a cursor of QueryPage, only the rows after the cursor (in the order of the query)
+	// are returned. Requires ordering by timestamp.
+	After string
+
+	// cursor selects the columns of the cursors of the rows
+	cursor bool
+}
+
+// Query returns the rows of a table. The values are converted back to the Go types of written
+// rows: integers are int, floating point numbers float64, timestamps and dates time.Time and
+// times a string like 12:00:00. Columns without a value are not in the row.
+func (w *Writer) Query(ctx context.Context, table string, opts QueryOptions) ([]Row, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	query, args, err := w.selectQuery(table, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := w.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// selectQuery builds the SELECT statement of the options
+func (w *Writer) selectQuery(table string, opts QueryOptions) (string, []any, error) {
+	source, plain, err := w.tableSource(table, opts.From, opts.To)
+	if err != nil {
+		return "", nil, err
+	}
+	return selectFrom(source, plain, opts)
+}
+
+// selectFrom builds the SELECT statement of the options of a FROM clause, plain reports whether
+// the source is a table with a rowid
+func selectFrom(source string, plain bool, opts QueryOptions) (string, []any, error) {
+	selected := "*"
+	if len(opts.Columns) > 0 {
+		quoted := make([]string, len(opts.Columns))
+		for i, column := range opts.Columns {
+			quoted[i] = quoteIdentifier(column)
+		}
+		selected = strings.Join(quoted, ", ")
+	}
+
+	if opts.cursor {
+		if !plain {
+			return "", nil, errors.New("cursors are not supported for partitioned or archived tables")
+		}
+		selected += ", timestamp AS _cursor_timestamp, rowid AS _cursor_rowid"
+	}
+
+	orderBy := opts.OrderBy
+	if orderBy == "" {
+		orderBy = "timestamp"
+	}
+	direction, after := "ASC", ">"
+	if opts.Descending {
+		direction, after = "DESC", "<"
+	}
+
+	var conditions []string
+	args := append([]any(nil), opts.Args...)
+	if opts.Where != "" {
+		conditions = append(conditions, "("+opts.Where+")")
+	}
+	if where, filterArgs := filtersSQL(opts.Filters); where != "" {
+		conditions = append(conditions, where)
+		args = append(args, filterArgs...)
+	}
+	if !opts.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, opts.From.UTC())
+	}
+	if !opts.To.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, opts.To.UTC())
+	}
+	if opts.After != "" {
+		if orderBy != "timestamp" {
+			return "", nil, errors.New("a cursor requires ordering by timestamp")
+		}
+		timestamp, rowID, err := decodeCursor(opts.After)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("(timestamp %s ? OR (timestamp = ? AND rowid %s ?))", after, after))
+		args = append(args, timestamp, timestamp, rowID)
+	}
+
+	var query strings.Builder
+	fmt.Fprintf(&query, "SELECT %s FROM %s", selected, source)
+	if len(conditions) > 0 {
+		fmt.Fprintf(&query, " WHERE %s", strings.Join(conditions, " AND "))
+	}
+
+	fmt.Fprintf(&query, " ORDER BY %s %s", quoteIdentifier(orderBy), direction)
+	if orderBy == "timestamp" && plain {
+		// Rows with the same timestamp are ordered by insertion, so the order is stable for cursors
+		fmt.Fprintf(&query, ", rowid %s", direction)
+	}
+
+	if opts.Limit > 0 {
+		fmt.Fprintf(&query, " LIMIT %d", opts.Limit)
+	}
+	if opts.Offset > 0 {
+		fmt.Fprintf(&query, " OFFSET %d", opts.Offset)
+	}
+	return query.String(), args, nil
+}
+
+// tableSource returns the FROM clause of a table: the table itself, or the union of its daily
+// partitions (see PartitionDaily) in the time range and of its archive (see ArchiveTo). plain
+// reports whether the source is the table itself, with a rowid.
+func (w *Writer) tableSource(table string, from, to time.Time) (string, bool, error) {
+	source, plain := quoteTableName(table), true
+	if w.isPartitioned(table) {
+		var err error
+		if source, err = w.partitionSource(table, from, to); err != nil {
+			return "", false, err
+		}
+		plain = false
+	} else {
+		columns, err := w.getCurrentColumns(table)
+		if err != nil {
+			return "", false, err
+		}
+		if len(columns) == 0 {
+			return "", false, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+		}
+	}
+
+	archive, err := w.archiveSource(table)
+	if err != nil {
+		return "", false, err
+	}
+	if archive != "" {
+		source = fmt.Sprintf("(SELECT * FROM %s UNION ALL BY NAME SELECT * FROM %s) AS %s", source, archive, quoteIdentifier(table))
+		plain = false
+	}
+	return source, plain, nil
+}
+
+// tableColumns returns the columns of a table, or of all partitions of a partitioned table (see
+// PartitionDaily), with the type of the column of their union
+func (w *Writer) tableColumns(table string) (map[string]ColumnType, error) {
+	tables := []string{table}
+	if w.isPartitioned(table) {
+		var err error
+		if tables, err = w.partitions(table, time.Time{}, time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+
+	columns := map[string]ColumnType{}
+	for _, stored := range tables {
+		current, err := w.getCurrentColumns(stored)
+		if err != nil {
+			return nil, err
+		}
+		for name, columnType := range current {
+			existing, ok := columns[name]
+			if !ok || existing == columnType {
+				columns[name] = columnType
+				continue
+			}
+			if columns[name], err = existing.PromoteTo(columnType); err != nil {
+				columns[name] = Varchar
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+	}
+	return columns, nil
+}
+
+// Between returns at most limit rows (0 means no limit) of a table from (inclusive) to (exclusive)
+// that match all filters, in chronological order
+func (w *Writer) Between(ctx context.Context, table string, from, to time.Time, limit int, filters ...Filter) ([]Row, error) {
+	return w.Query(ctx, table, QueryOptions{
+		From:    from,
+		To:      to,
+		Filters: filters,
+		Limit:   limit,
+	})
+}
+
+// Tail returns the n newest rows of a table that match all filters, in chronological order.
+// With an empty table name the newest rows of all tables are returned, with the name of their
+// table in the _table column; tables without the columns of the filters are skipped.
+func (w *Writer) Tail(ctx context.Context, table string, n int, filters ...Filter) ([]Row, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	if n <= 0 {
+		return nil, nil
+	}
+
+	tables := []string{table}
+	if table == "" {
+		var err error
+		if tables, err = w.tableNames(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var newest []Row
+	for _, name := range tables {
+		if table == "" {
+			columns, err := w.getCurrentColumns(name)
+			if err != nil {
+				return nil, err
+			}
+			if !hasFilterColumns(columns, filters) {
+				continue
+			}
+		}
+
+		rows, err := w.Query(ctx, name, QueryOptions{Filters: filters, Descending: true, Limit: n})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if table == "" {
+				row["_table"] = name
+			}
+			newest = append(newest, row)
+		}
+	}
+
+	// Merge the rows of the tables
+	sort.SliceStable(newest, func(i, j int) bool {
+		ti, _ := newest[i]["timestamp"].(time.Time)
+		tj, _ := newest[j]["timestamp"].(time.Time)
+		return ti.After(tj)
+	})
+	if len(newest) > n {
+		newest = newest[:n]
+	}
+	slices.Reverse(newest)
+	return newest, nil
+}
+
+// QueryAll returns at most limit rows (0 means no limit) of all tables from (inclusive) to
+// (exclusive) that match all filters, in chronological order, e.g. for a timeline of events, logs
+// and access logs. The name of their table is in the _table column; tables without the columns of
+// the filters are skipped. Columns that are missing in a table aren't in its rows.
+func (w *Writer) QueryAll(ctx context.Context, from, to time.Time, limit int, filters ...Filter) ([]Row, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	tables, err := w.tableNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	where, filterArgs := filtersSQL(filters)
+	var selects []string
+	var args []any
+	for _, name := range tables {
+		columns, err := w.getCurrentColumns(name)
+		if err != nil {
+			return nil, err
+		}
+		if !hasFilterColumns(columns, filters) {
+			continue
+		}
+		query := fmt.Sprintf("SELECT *, %s AS _table FROM %s WHERE timestamp >= ? AND timestamp < ?", quoteString(name), quoteTableName(name))
+		args = append(args, from.UTC(), to.UTC())
+		if where != "" {
+			query += " AND " + where
+			args = append(args, filterArgs...)
+		}
+		selects = append(selects, query)
+	}
+	if len(selects) == 0 {
+		return nil, nil
+	}
+
+	query := strings.Join(selects, " UNION ALL BY NAME ") + " ORDER BY timestamp, _table"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := w.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all tables: %w", err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// hasFilterColumns reports whether all columns of the filters exist
+func hasFilterColumns(columns map[string]ColumnType, filters []Filter) bool {
+	for _, filter := range filters {
+		for _, column := range filter.columns {
+			if _, exists := columns[column]; !exists {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// tableNames returns the names of the tables of the database
+func (w *Writer) tableNames(ctx context.Context) ([]string, error) {
+	// Without the internal tables, see ddlAuditTable
+	rows, err := w.DB.QueryContext(ctx, "SELECT table_name FROM information_schema.tables WHERE table_catalog = current_database() AND table_schema = 'main' AND table_type = 'BASE TABLE' AND NOT starts_with(table_name, '_timeline_') ORDER BY table_name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// quoteIdentifier quotes a table or column name for a query
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteTableName quotes a table name that can be qualified by an attached database, e.g. billing.app
+func quoteTableName(table string) string {
+	database, name := splitTableName(table)
+	if database == "" {
+		return quoteIdentifier(name)
+	}
+	return quoteIdentifier(database) + "." + quoteIdentifier(name)
+}
+
+// splitTableName returns the database (empty for the database of the writer) and the name of a table
+func splitTableName(table string) (string, string) {
+	if database, name, ok := strings.Cut(table, "."); ok {
+		return database, name
+	}
+	return "", table
+}
+
+// scanRows reads all rows of a result
+func scanRows(rows *sql.Rows) ([]Row, error) {
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Row
+	for rows.Next() {
+		row, err := scanner.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return result, nil
+}
+
+// rowScanner scans the rows of a result into Rows
+type rowScanner struct {
+	names  []string
+	types  []string
+	values []any
+	dest   []any
+}
+
+func newRowScanner(rows *sql.Rows) (*rowScanner, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+	scanner := &rowScanner{
+		names:  make([]string, len(columnTypes)),
+		types:  make([]string, len(columnTypes)),
+		values: make([]any, len(columnTypes)),
+		dest:   make([]any, len(columnTypes)),
+	}
+	for i, columnType := range columnTypes {
+		scanner.names[i] = columnType.Name()
+		scanner.types[i] = columnType.DatabaseTypeName()
+		scanner.dest[i] = &scanner.values[i]
+	}
+	return scanner, nil
+}
+
+// scan reads the current row of rows
+func (s *rowScanner) scan(rows *sql.Rows) (Row, error) {
+	if err := rows.Scan(s.dest...); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+	row := make(Row, len(s.values))
+	for i, value := range s.values {
+		if value == nil {
+			continue
+		}
+		row[s.names[i]] = convertDatabaseValue(value, s.types[i])
+	}
+	return row, nil
+}
+
+// convertDatabaseValue converts a value of the DuckDB driver to the type of a written value
+func convertDatabaseValue(value any, databaseType string) any {
+	switch v := value.(type) {
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int(v)
+		}
+		return float64(v)
+	case float32:
+		// Go through the shortest representation, so 0.1 stays 0.1 instead of 0.10000000149011612
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+		return f
+	case time.Time:
+		if databaseType == "TIME" {
+			return v.Format("15:04:05.999999")
+		}
+		return v
+	case *big.Int:
+		if v.IsInt64() {
+			return int(v.Int64())
+		}
+		return v.String()
+	case duckdb.Decimal:
+		return v.Float64()
+	case []byte:
+		if databaseType == "UUID" && len(v) == 16 {
+			return fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16])
+		}
+		return v
+	case string:
+		if databaseType == "JSON" {
+			var decoded any
+			if err := json.Unmarshal([]byte(v), &decoded); err == nil {
+				return decoded
+			}
+		}
+		return v
+	}
+	return value
+}
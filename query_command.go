@@ -0,0 +1,104 @@
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RunQueryCommand runs the query command of the timeline binary: it writes the rows of a table of
+// a database file, or the result of an SQL query, to stdout as JSON lines. args are the arguments
+// after the command name, e.g.
+//
+//	timeline query --db app.db --table logs --from 1h --where "status >= 500"
+//	timeline query --db app.db "SELECT status, count(*) AS n FROM logs GROUP BY status"
+//
+// The database is opened read-only, which DuckDB doesn't allow while another process writes to
+// it. Returns flag.ErrHelp for -h.
+func RunQueryCommand(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: timeline query --db <path> --table <name> [options]")
+		fmt.Fprintln(stderr, "       timeline query --db <path> <sql>")
+		flags.PrintDefaults()
+	}
+	dbPath := flags.String("db", "", "path of the database file (required)")
+	table := flags.String("table", "", "table of the rows")
+	from := flags.String("from", "", "oldest timestamp (inclusive): RFC 3339, a date or a duration ago, e.g. 1h")
+	to := flags.String("to", "", "newest timestamp (exclusive): RFC 3339, a date or a duration ago")
+	where := flags.String("where", "", "SQL condition of the rows, e.g. \"status >= 500\"")
+	limit := flags.Int("limit", 100, "maximum number of rows, 0 means no limit")
+	descending := flags.Bool("desc", false, "sort the rows from new to old")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbPath == "" || (*table == "") == (flags.NArg() == 0) || flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("the --db flag and either the --table flag or an SQL query are required")
+	}
+
+	writer, err := NewReadOnlyClient(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	var rows []Row
+	if flags.NArg() == 1 {
+		result, err := writer.DB.QueryContext(ctx, flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("failed to query: %w", err)
+		}
+		defer result.Close()
+		if rows, err = scanRows(result); err != nil {
+			return err
+		}
+	} else {
+		opts := QueryOptions{Where: *where, Limit: *limit, Descending: *descending}
+		now := time.Now()
+		if opts.From, err = commandTime(*from, now); err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+		if opts.To, err = commandTime(*to, now); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+		if rows, err = writer.Query(ctx, *table, opts); err != nil {
+			return err
+		}
+	}
+	return writeJSONLines(stdout, rows)
+}
+
+// commandTime parses a time of a command: RFC 3339, a date (time) in UTC or a duration before now.
+// An empty value is the zero time.
+func commandTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return now.Add(-ago), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time, date or duration", value)
+}
+
+// writeJSONLines writes the rows as a JSON object per line
+func writeJSONLines(out io.Writer, rows []Row) error {
+	encoder := json.NewEncoder(out)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// commandDatabase writes the rows to the table logs of a database file and closes it, so the
+// commands can open it
+func commandDatabase(t *testing.T, rows ...Row) string {
+	is := is.New(t)
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	writer, err := NewStorageClient(dbPath)
+	is.NoErr(err)
+	for _, row := range rows {
+		is.NoErr(writer.Write("logs", row))
+	}
+	is.NoErr(writer.Close())
+	return dbPath
+}
+
+func Test_query_command_writes_the_rows_of_a_table(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	dbPath := commandDatabase(t,
+		NewRow(start, Row{"status": 200}),
+		NewRow(start.Add(time.Minute), Row{"status": 500}),
+		NewRow(start.Add(2*time.Minute), Row{"status": 503}),
+	)
+	var stdout, stderr bytes.Buffer
+
+	err := RunQueryCommand(context.Background(), []string{"--db", dbPath, "--table", "logs", "--where", "status >= 500", "--limit", "1", "--desc"}, nil, &stdout, &stderr)
+
+	is.NoErr(err)
+	is.Equal(stdout.String(), `{"status":503,"timestamp":"2024-06-01T12:02:00Z"}`+"\n")
+}
+
+func Test_query_command_runs_an_sql_query(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	dbPath := commandDatabase(t,
+		NewRow(start, Row{"status": 200}),
+		NewRow(start.Add(time.Minute), Row{"status": 500}),
+		NewRow(start.Add(2*time.Minute), Row{"status": 500}),
+	)
+	var stdout, stderr bytes.Buffer
+
+	err := RunQueryCommand(context.Background(), []string{"--db", dbPath, "SELECT status, count(*)::INTEGER AS n FROM logs GROUP BY status ORDER BY status"}, nil, &stdout, &stderr)
+
+	is.NoErr(err)
+	is.Equal(stdout.String(), `{"n":1,"status":200}`+"\n"+`{"n":2,"status":500}`+"\n")
+}
+
+func Test_query_command_validates_flags(t *testing.T) {
+	is := is.New(t)
+	var stdout, stderr bytes.Buffer
+
+	// Either --table or an SQL query
+	err := RunQueryCommand(context.Background(), []string{"--db", "app.db"}, nil, &stdout, &stderr)
+	is.True(err != nil)
+	is.True(strings.Contains(stderr.String(), "Usage: timeline query"))
+	err = RunQueryCommand(context.Background(), []string{"--db", "app.db", "--table", "logs", "SELECT 1"}, nil, &stdout, &stderr)
+	is.True(err != nil)
+
+	err = RunQueryCommand(context.Background(), []string{"-h"}, nil, &stdout, &stderr)
+	is.True(errors.Is(err, flag.ErrHelp))
+}
+
+func Test_command_time(t *testing.T) {
+	is := is.New(t)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for value, expected := range map[string]time.Time{
+		"":                          {},
+		"1h":                        now.Add(-time.Hour),
+		"2024-05-31":                time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC),
+		"2024-05-31 08:30:00":       time.Date(2024, 5, 31, 8, 30, 0, 0, time.UTC),
+		"2024-05-31T08:30:00+02:00": time.Date(2024, 5, 31, 6, 30, 0, 0, time.UTC),
+	} {
+		parsed, err := commandTime(value, now)
+		is.NoErr(err)
+		is.True(parsed.Equal(expected)) // value
+	}
+
+	_, err := commandTime("yesterday", now)
+	is.True(err != nil)
+}
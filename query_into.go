@@ -0,0 +1,200 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// QueryInto runs an SQL query and scans the rows into dest, a pointer to a slice of structs (or
+// of pointers to structs) or a pointer to a struct for the first row (sql.ErrNoRows without rows):
+//
+//	type Request struct {
+//		Time   time.Time `timeline:"timestamp"`
+//		Path   string
+//		Status int
+//		UserID *int64 `timeline:"user_id"`
+//	}
+//	var requests []Request
+//	err := writer.QueryInto(ctx, &requests, "SELECT * FROM requests WHERE status >= ?", 500)
+//
+// A column is scanned into the field with its name in the timeline tag, or else into the field
+// with the same name ignoring case and underscores. Fields tagged with - and columns without a
+// field are skipped. Numbers are converted to the type of the field (HUGEINT also to *big.Int), a
+// column without a value leaves the field at its zero value (nil for pointers), and JSON columns
+// and arrays are decoded into struct, map and slice fields.
+func (w *Writer) QueryInto(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return errors.New("dest must be a pointer to a slice or a struct")
+	}
+	target = target.Elem()
+
+	single := target.Kind() == reflect.Struct
+	elemType := target.Type()
+	if !single {
+		if target.Kind() != reflect.Slice {
+			return errors.New("dest must be a pointer to a slice or a struct")
+		}
+		elemType = target.Type().Elem()
+	}
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot scan rows into %s", elemType)
+	}
+	fields := structFields(structType)
+
+	rows, err := w.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query: %w", err)
+	}
+	defer rows.Close()
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		return err
+	}
+
+	if !single {
+		target.SetLen(0)
+	}
+	for rows.Next() {
+		row, err := scanner.scan(rows)
+		if err != nil {
+			return err
+		}
+		item := reflect.New(structType).Elem()
+		for column, value := range row {
+			index, ok := fields[fieldKey(column)]
+			if !ok {
+				continue
+			}
+			field, err := item.FieldByIndexErr(index)
+			if err != nil {
+				continue
+			}
+			if err := assignValue(field, value); err != nil {
+				return fmt.Errorf("failed to scan column %s: %w", column, err)
+			}
+		}
+
+		if single {
+			target.Set(item)
+			return nil
+		}
+		if elemType.Kind() == reflect.Pointer {
+			item = item.Addr()
+		}
+		target.Set(reflect.Append(target, item))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+	if single {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// structFields returns the indexes of the exported fields of a struct by their fieldKey
+func structFields(structType reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	for _, field := range reflect.VisibleFields(structType) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("timeline"); ok {
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields[fieldKey(name)] = field.Index
+	}
+	return fields
+}
+
+// fieldKey matches column names and field names ignoring case and underscores
+func fieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	bigIntType = reflect.TypeOf(big.Int{})
+)
+
+// assignValue sets a field to a value of a Row
+func assignValue(field reflect.Value, value any) error {
+	if value == nil {
+		field.SetZero()
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+
+	switch {
+	case field.Type() == bigIntType:
+		var n big.Int
+		if _, ok := n.SetString(fmt.Sprint(value), 10); !ok {
+			return fmt.Errorf("cannot assign %v to big.Int", value)
+		}
+		field.Set(reflect.ValueOf(n))
+		return nil
+	case isNumber(v.Kind()) && isNumber(field.Kind()):
+		field.Set(v.Convert(field.Type()))
+		return nil
+	case v.Kind() == reflect.String && field.Kind() == reflect.String:
+		field.SetString(v.String())
+		return nil
+	case field.Type() != timeType && (field.Kind() == reflect.Struct || field.Kind() == reflect.Map || field.Kind() == reflect.Slice):
+		// JSON columns and arrays (JSON text)
+		data, ok := value.(string)
+		if !ok {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			data = string(encoded)
+		}
+		return json.Unmarshal([]byte(data), field.Addr().Interface())
+	}
+	return fmt.Errorf("cannot assign %T to %s", value, field.Type())
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
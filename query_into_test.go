@@ -0,0 +1,73 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type scannedRequest struct {
+	Time      time.Time `timeline:"timestamp"`
+	Path      string
+	Status    int16
+	Duration  float32
+	UserID    *int64 `timeline:"user_id"`
+	Tags      []string
+	Secret    string `timeline:"-"`
+	unexposed string
+}
+
+func Test_query_into_structs(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("requests", NewRow(start, Row{"path": "/", "status": 200, "duration": 0.5, "user_id": 7, "secret": "x", "tags": []any{"a", "b"}})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(time.Second), Row{"path": "/login", "status": 500, "duration": 1.5})))
+
+	var requests []scannedRequest
+	err := writer.QueryInto(context.Background(), &requests, "SELECT * FROM requests ORDER BY timestamp")
+
+	is.NoErr(err)
+	userID := int64(7)
+	is.Equal(requests, []scannedRequest{
+		{Time: start, Path: "/", Status: 200, Duration: 0.5, UserID: &userID, Tags: []string{"a", "b"}},
+		{Time: start.Add(time.Second), Path: "/login", Status: 500, Duration: 1.5},
+	})
+}
+
+func Test_query_into_a_struct_with_hugeint(t *testing.T) {
+	is, writer := setup(t)
+	var result struct {
+		Small int64
+		Large *big.Int
+		Count uint
+	}
+
+	err := writer.QueryInto(context.Background(), &result,
+		"SELECT 42::HUGEINT AS small, 170141183460469231731687303715884105727::HUGEINT AS large, count(*) AS count FROM range(3)")
+
+	is.NoErr(err)
+	is.Equal(result.Small, int64(42))
+	is.Equal(result.Large.String(), "170141183460469231731687303715884105727")
+	is.Equal(result.Count, uint(3))
+}
+
+func Test_query_into_a_struct_without_rows(t *testing.T) {
+	is, writer := setup(t)
+	var result struct{ Value int }
+
+	err := writer.QueryInto(context.Background(), &result, "SELECT 1 AS value WHERE false")
+
+	is.True(errors.Is(err, sql.ErrNoRows))
+}
+
+func Test_query_into_mismatching_type(t *testing.T) {
+	is, writer := setup(t)
+	var result []struct{ Value time.Time }
+
+	err := writer.QueryInto(context.Background(), &result, "SELECT 'text' AS value")
+
+	is.True(err != nil)
+}
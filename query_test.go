@@ -0,0 +1,149 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_query_returns_rows_with_go_types(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("requests", NewRow(start, Row{"path": "/", "status": 200, "duration": 0.1, "cached": true})))
+	is.NoErr(writer.Write("requests", NewRow(start.Add(time.Second), Row{"path": "/login", "status": 500, "at": "12:00:01"})))
+
+	rows, err := writer.Query(context.Background(), "requests", QueryOptions{})
+
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.Equal(rows[0], Row{"timestamp": start, "path": "/", "status": 200, "duration": 0.1, "cached": true})
+	is.Equal(rows[1]["at"], "12:00:01")
+	_, hasDuration := rows[1]["duration"]
+	is.True(!hasDuration)
+}
+
+func Test_query_options(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		is.NoErr(writer.Write("requests", NewRow(start.Add(time.Duration(i)*time.Second), Row{"n": i, "status": 200 + i*100})))
+	}
+
+	rows, err := writer.Query(context.Background(), "requests", QueryOptions{
+		Columns:    []string{"n"},
+		Where:      "status >= ?",
+		Args:       []any{300},
+		Descending: true,
+		Limit:      2,
+		Offset:     1,
+	})
+
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"n": 3}, {"n": 2}})
+}
+
+func Test_query_unknown_table(t *testing.T) {
+	is, writer := setup(t)
+
+	_, err := writer.Query(context.Background(), "missing", QueryOptions{})
+
+	is.True(errors.Is(err, ErrTableNotFound))
+}
+
+func Test_between_returns_filtered_rows_in_a_time_range(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		level := "info"
+		if i%2 == 1 {
+			level = "error"
+		}
+		message := "request handled"
+		if i == 5 {
+			message = "upstream timeout"
+		}
+		is.NoErr(writer.Write("app", NewRow(start.Add(time.Duration(i)*time.Minute), Row{"n": i, "level": level, "message": message})))
+	}
+
+	rows, err := writer.Between(context.Background(), "app", start.Add(2*time.Minute), start.Add(8*time.Minute), 2, Eq("level", "error"))
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.Equal(rows[0]["n"], 3)
+	is.Equal(rows[1]["n"], 5)
+
+	rows, err = writer.Between(context.Background(), "app", start, start.Add(time.Hour), 0, Eq("level", "error"), Like("message", "%timeout%"))
+	is.NoErr(err)
+	is.Equal(len(rows), 1)
+	is.Equal(rows[0]["timestamp"], start.Add(5*time.Minute))
+}
+
+func Test_tail_returns_the_newest_rows(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		table := "api"
+		if i%3 == 0 {
+			table = "worker"
+		}
+		is.NoErr(writer.Write(table, NewRow(start.Add(time.Duration(i)*time.Second), Row{"n": i, "level": "info"})))
+	}
+	is.NoErr(writer.Write("metrics", NewRow(start.Add(time.Minute), Row{"cpu": 0.5})))
+
+	rows, err := writer.Tail(context.Background(), "api", 2)
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.Equal(rows[0]["n"], 4)
+	is.Equal(rows[1]["n"], 5)
+
+	// Across all tables, metrics has no level column
+	rows, err = writer.Tail(context.Background(), "", 3, Eq("level", "info"))
+	is.NoErr(err)
+	is.Equal(len(rows), 3)
+	is.Equal(rows[0]["n"], 3)
+	is.Equal(rows[0]["_table"], "worker")
+	is.Equal(rows[2]["n"], 5)
+	is.Equal(rows[2]["_table"], "api")
+}
+
+func Test_query_all_tables(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("events", NewRow(start.Add(time.Second), Row{"event": "deploy", "level": "info"})))
+	is.NoErr(writer.Write("app", NewRow(start, Row{"message": "starting", "level": "info"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(2*time.Second), Row{"message": "failed", "level": "error"})))
+	is.NoErr(writer.Write("access", NewRow(start.Add(3*time.Second), Row{"path": "/", "status": 200})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(time.Hour), Row{"message": "later", "level": "info"})))
+
+	rows, err := writer.QueryAll(context.Background(), start, start.Add(time.Minute), 0)
+
+	is.NoErr(err)
+	is.Equal(rows, []Row{
+		{"timestamp": start, "message": "starting", "level": "info", "_table": "app"},
+		{"timestamp": start.Add(time.Second), "event": "deploy", "level": "info", "_table": "events"},
+		{"timestamp": start.Add(2 * time.Second), "message": "failed", "level": "error", "_table": "app"},
+		{"timestamp": start.Add(3 * time.Second), "path": "/", "status": 200, "_table": "access"},
+	})
+
+	// Tables without the columns of the filters are skipped
+	rows, err = writer.QueryAll(context.Background(), start, start.Add(time.Minute), 1, Eq("level", "info"))
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"timestamp": start, "message": "starting", "level": "info", "_table": "app"}})
+}
+
+func Test_query_timeout_interrupts_runaway_queries(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetQueryTimeout(50 * time.Millisecond)
+	started := time.Now()
+
+	_, err := writer.SQL(context.Background(), "SELECT count(*) FROM range(100000000000) r WHERE r.range % 7 = 3", nil)
+
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.True(time.Since(started) < 5*time.Second)
+
+	// And the writer can still be used
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "ok"})))
+	it := writer.Iter(context.Background(), "SELECT * FROM app")
+	is.True(it.Next())
+	is.NoErr(it.Close())
+}
@@ -0,0 +1,126 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQuotaExceeded is returned by Write when the database exceeds its quota, see SetQuota
+var ErrQuotaExceeded = errors.New("the disk quota of the database is exceeded")
+
+// QuotaMode is what a writer does when its database exceeds the quota
+type QuotaMode int
+
+const (
+	// QuotaReject rejects the writes with ErrQuotaExceeded until the database is small enough
+	// again, e.g. after retention
+	QuotaReject QuotaMode = iota
+	// QuotaDropOldest deletes the oldest rows until the database uses 90% of the quota, like
+	// MaxDatabaseSize, and accepts the writes
+	QuotaDropOldest
+)
+
+// QuotaConfig configures the disk quota of a writer
+type QuotaConfig struct {
+	// MaxBytes is the maximum size of the database: the used blocks of the file and the WAL. 0
+	// disables the quota.
+	MaxBytes int64
+	// Mode is what happens when the database exceeds MaxBytes, defaults to QuotaReject
+	Mode QuotaMode
+	// Interval is the minimum time between the measurements of the size, defaults to 10 seconds
+	Interval time.Duration
+	// OnError is called when the size can't be measured or the oldest rows can't be deleted
+	OnError func(error)
+}
+
+// quota is the state of the disk quota of a writer
+type quota struct {
+	config QuotaConfig
+	// mu is held while the size is measured
+	mu sync.Mutex
+	// measuredAt is the time (in Unix nanoseconds) of the last measurement
+	measuredAt atomic.Int64
+	size       atomic.Int64
+}
+
+// SetQuota limits the size of the database, to protect the other applications of the host from
+// a full disk. The size is measured on a write at most once per Interval, so a database can exceed
+// the quota by the rows of an Interval. The quota of an in-memory database only counts its blocks.
+func (w *Writer) SetQuota(config QuotaConfig) {
+	if config.MaxBytes <= 0 {
+		w.quota.Store(nil)
+		return
+	}
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	w.quota.Store(&quota{config: config})
+}
+
+// checkQuota returns ErrQuotaExceeded when the database exceeds the quota and the writes are
+// rejected
+func (w *Writer) checkQuota() error {
+	q := w.quota.Load()
+	if q == nil {
+		return nil
+	}
+
+	if time.Since(time.Unix(0, q.measuredAt.Load())) >= q.config.Interval && q.mu.TryLock() {
+		w.measureQuota(q)
+		q.mu.Unlock()
+	}
+
+	if size := q.size.Load(); size > q.config.MaxBytes && q.config.Mode == QuotaReject {
+		return fmt.Errorf("%w: %d of %d bytes", ErrQuotaExceeded, size, q.config.MaxBytes)
+	}
+	return nil
+}
+
+// measureQuota measures the size of the database and deletes the oldest rows when it exceeds the
+// quota in QuotaDropOldest mode
+func (w *Writer) measureQuota(q *quota) {
+	defer q.measuredAt.Store(time.Now().UnixNano())
+
+	size, err := w.diskSize()
+	if err != nil {
+		q.reportError(err)
+		return
+	}
+	if size > q.config.MaxBytes && q.config.Mode == QuotaDropOldest {
+		if _, err := w.ApplyRetention(context.Background(), MaxDatabaseSize(q.config.MaxBytes/10*9)); err != nil {
+			q.reportError(err)
+		}
+		if size, err = w.diskSize(); err != nil {
+			q.reportError(err)
+			return
+		}
+	}
+	q.size.Store(size)
+}
+
+// diskSize returns the size of the used blocks of the database and of its WAL
+func (w *Writer) diskSize() (int64, error) {
+	size, err := w.usedSize(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	path, err := w.databasePath(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	if path != "" {
+		if info, err := os.Stat(path + ".wal"); err == nil {
+			size += info.Size()
+		}
+	}
+	return size, nil
+}
+
+func (q *quota) reportError(err error) {
+	handleError(q.config.OnError, err, ErrorContext{Component: "quota"})
+}
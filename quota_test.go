@@ -0,0 +1,68 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// bigDatabase returns a writer of a database of a few MB
+func bigDatabase(t *testing.T) (*is.I, *Writer, int64) {
+	is := is.New(t)
+	writer, err := NewStorageClient(filepath.Join(t.TempDir(), "test.db"))
+	is.NoErr(err)
+	t.Cleanup(func() { writer.Close() })
+
+	_, err = writer.DB.Exec("CREATE TABLE logs AS SELECT TIMESTAMP '2025-01-01' + to_seconds(i) AS timestamp, repeat('x', 100) || i AS message FROM range(300000) t(i)")
+	is.NoErr(err)
+	is.NoErr(writer.Checkpoint())
+	size, err := writer.diskSize()
+	is.NoErr(err)
+	return is, writer, size
+}
+
+func Test_quota_rejects_writes_when_exceeded(t *testing.T) {
+	is, writer, size := bigDatabase(t)
+
+	// Given a quota of half the size of the database
+	writer.SetQuota(QuotaConfig{MaxBytes: size / 2, Interval: time.Millisecond})
+
+	// Then the writes are rejected
+	err := writer.Write("logs", NewRow(time.Now(), Row{"message": "rejected"}))
+	is.True(errors.Is(err, ErrQuotaExceeded))
+
+	// Until the database is small enough again
+	is.NoErr(writer.TruncateTable(context.Background(), "logs"))
+	is.NoErr(writer.Checkpoint())
+	time.Sleep(5 * time.Millisecond)
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "accepted"})))
+}
+
+func Test_quota_drops_the_oldest_rows_when_exceeded(t *testing.T) {
+	is, writer, size := bigDatabase(t)
+
+	// Given a quota of half the size of the database that drops the oldest rows
+	writer.SetQuota(QuotaConfig{MaxBytes: size / 2, Mode: QuotaDropOldest, Interval: time.Millisecond})
+
+	// Then the write is accepted and the oldest rows are deleted
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "accepted"})))
+	var count int
+	is.NoErr(writer.DB.QueryRow("SELECT count(*) FROM logs").Scan(&count))
+	is.True(count < 150000)
+	after, err := writer.diskSize()
+	is.NoErr(err)
+	is.True(after <= size/2)
+}
+
+func Test_quota_is_disabled_without_max_bytes(t *testing.T) {
+	is, writer, size := bigDatabase(t)
+	writer.SetQuota(QuotaConfig{MaxBytes: size / 2, Interval: time.Millisecond})
+
+	writer.SetQuota(QuotaConfig{})
+
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "accepted"})))
+}
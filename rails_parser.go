@@ -0,0 +1,131 @@
+package timeline
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// railsStartedRegex matches the first line of a Rails request block, optionally tagged with a request id.
+// Example: [8601b555] Started GET "/users/1" for 127.0.0.1 at 2025-09-21 10:00:00 +0000
+var railsStartedRegex = regexp.MustCompile(`^(?:\[([^\]]*)\] )?Started (\w+) "([^"]*)" for (\S+) at (.+)$`)
+
+// railsProcessingRegex matches the controller line of a Rails request block.
+// Example: Processing by UsersController#show as HTML
+var railsProcessingRegex = regexp.MustCompile(`^(?:\[[^\]]*\] )?Processing by ([\w:]+)#(\w+) as (\S+)`)
+
+// railsCompletedRegex matches the last line of a Rails request block.
+// Example: Completed 200 OK in 12ms (Views: 5.1ms | ActiveRecord: 0.4ms | Allocations: 5678)
+var railsCompletedRegex = regexp.MustCompile(`^(?:\[[^\]]*\] )?Completed (\d{3}) ([^(]*?) in (\d+(?:\.\d+)?)ms(?: \((.*)\))?`)
+
+// railsTimingNames maps the timings of the Completed line to column names
+var railsTimingNames = map[string]string{
+	"Views":        "view_ms",
+	"ActiveRecord": "db_ms",
+	"Allocations":  "allocations",
+}
+
+// isRailsStarted reports whether the line starts a Rails request block
+func isRailsStarted(line string) bool {
+	return railsStartedRegex.MatchString(line)
+}
+
+// isRailsCompleted reports whether the line ends a Rails request block
+func isRailsCompleted(line string) bool {
+	return railsCompletedRegex.MatchString(line)
+}
+
+// parseRailsRequest parses a classic (multi-line) Rails request log block.
+// The block is usually assembled by the LineAssembler.
+// Example:
+//
+//	Started GET "/users/1?tab=posts" for 127.0.0.1 at 2025-09-21 10:00:00 +0000
+//	Processing by UsersController#show as HTML
+//	  Parameters: {"id"=>"1"}
+//	Completed 200 OK in 12ms (Views: 5.1ms | ActiveRecord: 0.4ms | Allocations: 5678)
+//
+// Fields: request_id (tag), method, path, query, remote_host, timestamp, controller, action, format,
+// status, status_text, duration_ms, view_ms, db_ms, allocations, message
+func parseRailsRequest(l string) Row {
+	// Lines without a started request skip the regexes
+	if first, _, _ := strings.Cut(l, "\n"); !strings.Contains(first, "Started ") {
+		return nil
+	}
+
+	lines := strings.Split(l, "\n")
+	started := railsStartedRegex.FindStringSubmatch(lines[0])
+	if started == nil {
+		return nil
+	}
+
+	result := make(Row)
+	if started[1] != "" {
+		result["request_id"] = started[1]
+	}
+	result["method"] = started[2]
+	setPathAndQuery(result, started[3])
+	result["remote_host"] = started[4]
+	result["timestamp"] = started[5]
+	result["message"] = strings.TrimSpace(lines[0])
+
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if processing := railsProcessingRegex.FindStringSubmatch(line); processing != nil {
+			result["controller"] = processing[1]
+			result["action"] = processing[2]
+			result["format"] = processing[3]
+			continue
+		}
+		completed := railsCompletedRegex.FindStringSubmatch(line)
+		if completed == nil {
+			continue
+		}
+		result["status"], _ = strconv.Atoi(completed[1])
+		if statusText := strings.TrimSpace(completed[2]); statusText != "" {
+			result["status_text"] = statusText
+		}
+		result["duration_ms"], _ = strconv.ParseFloat(completed[3], 64)
+		// Views: 5.1ms | ActiveRecord: 0.4ms | Allocations: 5678
+		for _, timing := range strings.Split(completed[4], "|") {
+			name, value, ok := strings.Cut(strings.TrimSpace(timing), ": ")
+			column, known := railsTimingNames[name]
+			if !ok || !known {
+				continue
+			}
+			if column == "allocations" {
+				result[column], _ = strconv.Atoi(value)
+			} else if number, err := strconv.ParseFloat(strings.TrimSuffix(value, "ms"), 64); err == nil {
+				result[column] = number
+			}
+		}
+	}
+
+	return result
+}
+
+// normalizeLograge renames the timings of a Lograge line (logged in milliseconds) to *_ms columns.
+// Lograge lines are logfmt, so they are already split into columns by the logfmt parser.
+// Example: method=GET path=/users format=html controller=UsersController action=index status=200 duration=12.3 view=5.1 db=2.0
+// Fields: method, path, format, controller, action, status, duration_ms, view_ms, db_ms and other logfmt keys
+func normalizeLograge(data Row) Row {
+	if !isLograge(data) {
+		return data
+	}
+	for _, key := range []string{"duration", "view", "db"} {
+		if value, exists := data[key]; exists {
+			data[key+"_ms"] = value
+			delete(data, key)
+		}
+	}
+	return data
+}
+
+// isLograge reports whether a logfmt row has the fields that Lograge always logs
+func isLograge(data Row) bool {
+	for _, key := range []string{"method", "path", "controller", "action", "status", "duration"} {
+		if _, exists := data[key]; !exists {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package timeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_assemble_rails_request_block_into_one_entry(t *testing.T) {
+	is := is.New(t)
+	lines := []string{
+		`Started GET "/users/1" for 127.0.0.1 at 2025-09-21 10:00:00 +0000`,
+		`Processing by UsersController#show as HTML`,
+		`  Parameters: {"id"=>"1"}`,
+		`User Load (0.4ms)  SELECT "users".* FROM "users"`,
+		`Completed 200 OK in 12ms (Views: 5.1ms | ActiveRecord: 0.4ms | Allocations: 5678)`,
+		`Started GET "/users/2" for 127.0.0.1 at 2025-09-21 10:00:01 +0000`,
+	}
+
+	entries := AssembleLines(lines)
+
+	is.Equal(len(entries), 2)
+	is.Equal(entries[1], `Started GET "/users/2" for 127.0.0.1 at 2025-09-21 10:00:01 +0000`)
+}
+
+func Test_assemble_rails_request_block_without_completed_line_is_limited(t *testing.T) {
+	is := is.New(t)
+	lines := []string{`Started GET "/users/1" for 127.0.0.1 at 2025-09-21 10:00:00 +0000`}
+	for len(lines) < 2*maxRailsBlockLines {
+		lines = append(lines, "worker heartbeat")
+	}
+
+	entries := AssembleLines(lines)
+
+	// The block ends at the limit, the lines after it are entries of their own
+	is.Equal(len(entries), maxRailsBlockLines+1)
+	is.Equal(strings.Count(entries[0], "\n"), maxRailsBlockLines-1)
+	is.Equal(entries[1], "worker heartbeat")
+}
+
+func Test_parse_rails_request_block(t *testing.T) {
+	is := is.New(t)
+	entry := `[8601b555] Started GET "/users/1?tab=posts" for 127.0.0.1 at 2025-09-21 10:00:00 +0000` + "\n" +
+		`[8601b555] Processing by Admin::UsersController#show as HTML` + "\n" +
+		`[8601b555]   Parameters: {"id"=>"1"}` + "\n" +
+		`[8601b555] Completed 404 Not Found in 12.5ms (Views: 5.1ms | ActiveRecord: 0.4ms | Allocations: 5678)`
+
+	data := ParseLineToValues(entry)
+
+	is.Equal(data["request_id"], "8601b555")
+	is.Equal(data["method"], "GET")
+	is.Equal(data["path"], "/users/1")
+	is.Equal(data["query"], "tab=posts")
+	is.Equal(data["remote_host"], "127.0.0.1")
+	is.Equal(data["timestamp"], "2025-09-21 10:00:00 +0000")
+	is.Equal(data["controller"], "Admin::UsersController")
+	is.Equal(data["action"], "show")
+	is.Equal(data["format"], "HTML")
+	is.Equal(data["status"], 404)
+	is.Equal(data["status_text"], "Not Found")
+	is.Equal(data["duration_ms"], 12.5)
+	is.Equal(data["view_ms"], 5.1)
+	is.Equal(data["db_ms"], 0.4)
+	is.Equal(data["allocations"], 5678)
+}
+
+func Test_parse_rails_request_block_without_completed_line(t *testing.T) {
+	is := is.New(t)
+	entry := `Started POST "/login" for 10.0.0.1 at 2025-09-21 10:00:00 +0000`
+
+	data := ParseLineToValues(entry)
+
+	is.Equal(data["method"], "POST")
+	is.Equal(data["path"], "/login")
+	_, hasStatus := data["status"]
+	is.True(!hasStatus)
+}
+
+func Test_parse_lograge_line(t *testing.T) {
+	is := is.New(t)
+	line := `method=GET path=/users format=html controller=UsersController action=index status=200 duration=12.3 view=5.1 db=2.0`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["method"], "GET")
+	is.Equal(data["path"], "/users")
+	is.Equal(data["controller"], "UsersController")
+	is.Equal(data["action"], "index")
+	is.Equal(data["status"], 200)
+	is.Equal(data["duration_ms"], 12.3)
+	is.Equal(data["view_ms"], 5.1)
+	is.Equal(data["db_ms"], 2.0)
+	_, hasDuration := data["duration"]
+	is.True(!hasDuration)
+}
+
+func Test_parse_logfmt_with_duration_is_not_lograge(t *testing.T) {
+	is := is.New(t)
+	line := `job=backup duration=12.3`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["duration"], 12.3)
+}
@@ -0,0 +1,42 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+// ErrReadOnly is returned when a read-only client writes
+var ErrReadOnly = errors.New("the database is read-only")
+
+// NewReadOnlyClient opens a database file with access_mode=read_only, for dashboards and ad-hoc
+// queries that must not change the data. Write returns ErrReadOnly and the database is never
+// checkpointed. Multiple processes can open the same file read-only, but DuckDB doesn't allow it
+// while a process has the file open for writing.
+func NewReadOnlyClient(dbPath string) (*Writer, error) {
+	return newReadOnlyClient(dbPath, ClientConfig{})
+}
+
+func newReadOnlyClient(dbPath string, config ClientConfig) (*Writer, error) {
+	// DuckDB would create a missing file, which isn't possible in read-only mode
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", dbPath, err)
+	}
+	connector, err := duckdb.NewConnector(config.dsn(dbPath, url.Values{"access_mode": {"read_only"}}), nil)
+	if err != nil {
+		return nil, openError(dbPath, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	writer := &Writer{
+		ctx:      ctx,
+		cancel:   cancel,
+		readOnly: true,
+	}
+	writer.open(connector)
+	return writer, nil
+}
@@ -0,0 +1,43 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_read_only_client(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "app.db")
+	writer, err := NewStorageClient(path)
+	is.NoErr(err)
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("app", NewRow(now, Row{"message": "hello"})))
+	is.NoErr(writer.Close())
+
+	reader, err := NewReadOnlyClient(path)
+	is.NoErr(err)
+	defer reader.Close()
+
+	// Queries work
+	rows, err := reader.Query(context.Background(), "app", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"timestamp": now, "message": "hello"}})
+
+	// Writes don't
+	is.True(errors.Is(reader.Write("app", NewRow(now, Row{"message": "again"})), ErrReadOnly))
+	_, err = reader.DB.Exec("DELETE FROM app")
+	is.True(err != nil)
+}
+
+func Test_read_only_client_of_a_missing_file(t *testing.T) {
+	is := is.New(t)
+
+	_, err := NewReadOnlyClient(filepath.Join(t.TempDir(), "missing.db"))
+
+	is.True(err != nil)
+}
@@ -0,0 +1,50 @@
+package timeline
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+// defaultReadPoolSize is the default maximum number of connections of a read pool
+const defaultReadPoolSize = 4
+
+// sharedConnector opens connections to the database of another *sql.DB. It hides the Close
+// method of the connector, so closing the read pool doesn't close the database.
+type sharedConnector struct {
+	driver.Connector
+}
+
+// readPool returns a read-only client with its own pool of at most size connections to the
+// same database, so queries don't wait for the connections of the writes. The pool sees all
+// written rows and is closed with the writer. A read-only client is its own read pool.
+func (w *Writer) readPool(size int) *Writer {
+	if w.readOnly || w.connector == nil {
+		return w
+	}
+
+	w.readPoolMu.Lock()
+	defer w.readPoolMu.Unlock()
+	if w.reader == nil {
+		db := sql.OpenDB(sharedConnector{w.connector})
+		db.SetMaxOpenConns(size)
+		db.SetMaxIdleConns(size)
+		w.reader = &Writer{
+			DB:       db,
+			ctx:      w.ctx,
+			cancel:   func() {},
+			readOnly: true,
+		}
+		w.reader.queryTimeout.Store(w.queryTimeout.Load())
+	}
+	return w.reader
+}
+
+// closeReadPool closes the connections of the read pool
+func (w *Writer) closeReadPool() {
+	w.readPoolMu.Lock()
+	defer w.readPoolMu.Unlock()
+	if w.reader != nil {
+		w.reader.Close()
+		w.reader = nil
+	}
+}
@@ -0,0 +1,111 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_read_connection_sees_the_written_rows(t *testing.T) {
+	// Given
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	manager := newTestManager()
+	defer manager.Close()
+	writer, err := manager.GetOrCreateConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	reader, err := manager.GetReadConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create read connection: %v", err)
+	}
+
+	// When
+	if err := writer.Write("logs", NewRow(time.Now(), map[string]any{"message": "hello"})); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	rows, err := reader.Query(context.Background(), "logs", QueryOptions{})
+
+	// Then
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["message"] != "hello" {
+		t.Fatalf("Expected the written row, got %v", rows)
+	}
+	if reader.DB == writer.DB {
+		t.Fatal("Expected a separate pool of connections")
+	}
+}
+
+func Test_read_connection_is_read_only(t *testing.T) {
+	// Given
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	manager := newTestManager()
+	defer manager.Close()
+	reader, err := manager.GetReadConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create read connection: %v", err)
+	}
+
+	// When
+	err = reader.Write("logs", NewRow(time.Now(), map[string]any{"message": "hello"}))
+
+	// Then
+	if !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly, got %v", err)
+	}
+}
+
+func Test_read_connection_is_reused_and_limited(t *testing.T) {
+	// Given
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	manager := NewConnectionManager(WithReadPoolSize(2))
+	defer manager.Close()
+
+	// When
+	first, err := manager.GetReadConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create read connection: %v", err)
+	}
+	second, err := manager.GetReadConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create read connection: %v", err)
+	}
+
+	// Then
+	if first != second {
+		t.Fatal("Expected the same read connection")
+	}
+	if maxOpen := first.DB.Stats().MaxOpenConnections; maxOpen != 2 {
+		t.Fatalf("Expected at most 2 connections, got %d", maxOpen)
+	}
+}
+
+func Test_read_connection_is_closed_with_the_connection(t *testing.T) {
+	// Given
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	manager := newTestManager()
+	defer manager.Close()
+	reader, err := manager.GetReadConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create read connection: %v", err)
+	}
+
+	// When
+	manager.CloseConnection(dbPath)
+
+	// Then
+	if err := reader.DB.Ping(); err == nil {
+		t.Fatal("Expected the read connection to be closed")
+	}
+	reopened, err := manager.GetReadConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen read connection: %v", err)
+	}
+	if err := reopened.DB.Ping(); err != nil {
+		t.Fatalf("Expected the reopened read connection to be open: %v", err)
+	}
+}
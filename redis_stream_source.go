@@ -0,0 +1,208 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisStreamEntry is an entry of a Redis stream
+type RedisStreamEntry struct {
+	// ID is the id of the entry, e.g. 1727000000123-0
+	ID     string
+	Values map[string]any
+}
+
+// RedisStreamClient runs the stream commands for a RedisStreamSource. The package doesn't depend
+// on a Redis client, implement it with your client. With go-redis:
+//
+//	func (c client) ReadGroup(ctx context.Context, stream, group, consumer, id string, count int64, block time.Duration) ([]timeline.RedisStreamEntry, error) {
+//		streams, err := c.XReadGroup(ctx, &redis.XReadGroupArgs{Group: group, Consumer: consumer, Streams: []string{stream, id}, Count: count, Block: block}).Result()
+//		if errors.Is(err, redis.Nil) {
+//			return nil, nil
+//		}
+//		...
+//	}
+type RedisStreamClient interface {
+	// ReadGroup runs XREADGROUP GROUP group consumer COUNT count BLOCK block STREAMS stream id.
+	// Returns no entries (and no error) when the block time passed without new entries.
+	ReadGroup(ctx context.Context, stream, group, consumer, id string, count int64, block time.Duration) ([]RedisStreamEntry, error)
+	// AutoClaim runs XAUTOCLAIM stream group consumer minIdle start COUNT count and returns the
+	// claimed entries and the start for the next call
+	AutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]RedisStreamEntry, string, error)
+	// Ack runs XACK stream group ids...
+	Ack(ctx context.Context, stream, group string, ids ...string) error
+}
+
+// RedisStreamSourceConfig configures a RedisStreamSource
+type RedisStreamSourceConfig struct {
+	// Stream is the key of the stream
+	Stream string
+	// Group is the consumer group, it must exist (XGROUP CREATE stream group $ MKSTREAM)
+	Group string
+	// Consumer is the name of this consumer in the group, e.g. the host name
+	Consumer string
+	// Table is the table the entries are written to, defaults to the stream name
+	Table string
+	// Count is the maximum number of entries per read, defaults to 100
+	Count int64
+	// Block is the time a read waits for new entries, defaults to 5s
+	Block time.Duration
+	// MinIdle is the time after which entries that are delivered but not acknowledged (of crashed
+	// consumers or entries that could not be written) are claimed and written again, defaults to 1m
+	MinIdle time.Duration
+	// OnError is called for entries that could not be written and failing commands
+	OnError func(error)
+}
+
+// RedisStreamSource consumes a Redis stream with a consumer group and writes the field/value pairs
+// of every entry as a row. Entries are acknowledged after they are written. At start the pending
+// entries of the consumer are written first, and pending entries of other consumers that are idle
+// longer than MinIdle are claimed, so no entry is lost when a consumer stops.
+type RedisStreamSource struct {
+	writer *Writer
+	client RedisStreamClient
+	config RedisStreamSourceConfig
+}
+
+// NewRedisStreamSource creates a Redis stream source, call Run to start it
+func NewRedisStreamSource(writer *Writer, client RedisStreamClient, config RedisStreamSourceConfig) *RedisStreamSource {
+	if config.Table == "" {
+		config.Table = normalizeColumnName(config.Stream)
+	}
+	if config.Count <= 0 {
+		config.Count = 100
+	}
+	if config.Block <= 0 {
+		config.Block = 5 * time.Second
+	}
+	if config.MinIdle <= 0 {
+		config.MinIdle = time.Minute
+	}
+	return &RedisStreamSource{writer: writer, client: client, config: config}
+}
+
+// Run consumes the stream until ctx is done and returns the error of ctx
+func (r *RedisStreamSource) Run(ctx context.Context) error {
+	if err := r.recoverOwnPending(ctx); err != nil {
+		return err
+	}
+
+	lastClaim := time.Time{}
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if time.Since(lastClaim) >= r.config.MinIdle {
+			lastClaim = time.Now()
+			r.claimPending(ctx)
+		}
+
+		entries, err := r.client.ReadGroup(ctx, r.config.Stream, r.config.Group, r.config.Consumer, ">", r.config.Count, r.config.Block)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			r.reportError(fmt.Errorf("failed to read stream %s: %w", r.config.Stream, err))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		r.write(ctx, entries)
+	}
+}
+
+// recoverOwnPending writes the entries that were delivered to this consumer but not acknowledged,
+// e.g. because the process stopped before the entries were written
+func (r *RedisStreamSource) recoverOwnPending(ctx context.Context) error {
+	id := "0"
+	for {
+		entries, err := r.client.ReadGroup(ctx, r.config.Stream, r.config.Group, r.config.Consumer, id, r.config.Count, r.config.Block)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read pending entries of stream %s: %w", r.config.Stream, err)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		r.write(ctx, entries)
+		id = entries[len(entries)-1].ID
+	}
+}
+
+// claimPending claims and writes the entries that are pending longer than MinIdle
+func (r *RedisStreamSource) claimPending(ctx context.Context) {
+	start := "0-0"
+	for {
+		entries, next, err := r.client.AutoClaim(ctx, r.config.Stream, r.config.Group, r.config.Consumer, r.config.MinIdle, start, r.config.Count)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.reportError(fmt.Errorf("failed to claim pending entries of stream %s: %w", r.config.Stream, err))
+			}
+			return
+		}
+		r.write(ctx, entries)
+		if next == "" || next == "0-0" {
+			return
+		}
+		start = next
+	}
+}
+
+// write writes the entries and acknowledges the written entries
+func (r *RedisStreamSource) write(ctx context.Context, entries []RedisStreamEntry) {
+	var written []string
+	for _, entry := range entries {
+		// Entries that are deleted while pending are claimed without values
+		if entry.Values == nil {
+			written = append(written, entry.ID)
+			continue
+		}
+		if err := r.writer.Write(r.config.Table, redisStreamEntryToRow(entry)); err != nil {
+			r.reportError(fmt.Errorf("failed to write entry %s of stream %s: %w", entry.ID, r.config.Stream, err))
+			continue
+		}
+		written = append(written, entry.ID)
+	}
+	if len(written) == 0 {
+		return
+	}
+	if err := r.client.Ack(ctx, r.config.Stream, r.config.Group, written...); err != nil && ctx.Err() == nil {
+		r.reportError(fmt.Errorf("failed to ack entries of stream %s: %w", r.config.Stream, err))
+	}
+}
+
+// redisStreamEntryToRow maps the field/value pairs of an entry to a row. Redis stores all values as
+// strings, numbers are converted. The time in the entry id is the timestamp, unless the entry has one.
+func redisStreamEntryToRow(entry RedisStreamEntry) Row {
+	row := make(Row, len(entry.Values)+1)
+	for field, value := range entry.Values {
+		column := normalizeColumnName(field)
+		if column == "" {
+			continue
+		}
+		if text, ok := value.(string); ok {
+			value = convertLogfmtValue(text)
+		}
+		row[column] = value
+	}
+
+	timestamp := time.Now()
+	millis, _, _ := strings.Cut(entry.ID, "-")
+	if unixMillis, err := strconv.ParseInt(millis, 10, 64); err == nil {
+		timestamp = unixMillisToTime(unixMillis)
+	}
+	return NewRow(timestamp, row)
+}
+
+func (r *RedisStreamSource) reportError(err error) {
+	handleError(r.config.OnError, err, ErrorContext{Component: "redis_stream_source", Table: r.config.Table})
+}
@@ -0,0 +1,120 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// fakeRedisStream is a RedisStreamClient with pending entries of this consumer, pending entries
+// of other consumers and new entries. It cancels the context when all new entries are read.
+type fakeRedisStream struct {
+	mu       sync.Mutex
+	pending  []RedisStreamEntry
+	claim    []RedisStreamEntry
+	entries  []RedisStreamEntry
+	acked    []string
+	cancel   context.CancelFunc
+	readErrs int
+}
+
+func (f *fakeRedisStream) ReadGroup(ctx context.Context, stream, group, consumer, id string, count int64, block time.Duration) ([]RedisStreamEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id != ">" {
+		// The pending entries after id
+		var entries []RedisStreamEntry
+		for _, entry := range f.pending {
+			if id == "0" || entry.ID > id {
+				entries = append(entries, entry)
+			}
+		}
+		return entries, nil
+	}
+	if f.readErrs > 0 {
+		f.readErrs--
+		return nil, errors.New("connection reset")
+	}
+	if len(f.entries) == 0 {
+		f.cancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	entries := f.entries
+	f.entries = nil
+	return entries, nil
+}
+
+func (f *fakeRedisStream) AutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, start string, count int64) ([]RedisStreamEntry, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := f.claim
+	f.claim = nil
+	return entries, "0-0", nil
+}
+
+func (f *fakeRedisStream) Ack(ctx context.Context, stream, group string, ids ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = append(f.acked, ids...)
+	return nil
+}
+
+func Test_redis_stream_entry_to_row(t *testing.T) {
+	is := is.New(t)
+
+	row := redisStreamEntryToRow(RedisStreamEntry{
+		ID:     "1727000000123-0",
+		Values: map[string]any{"Level": "error", "duration_ms": "12", "ratio": "0.5"},
+	})
+
+	is.Equal(row["level"], "error")
+	is.Equal(row["duration_ms"], 12)
+	is.Equal(row["ratio"], 0.5)
+	is.Equal(row["timestamp"], time.UnixMilli(1727000000123).UTC())
+}
+
+func Test_redis_stream_source_writes_pending_claimed_and_new_entries(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Given a stream with a pending entry of this consumer, a stale entry of another consumer and new entries
+	client := &fakeRedisStream{
+		cancel:  cancel,
+		pending: []RedisStreamEntry{{ID: "1-0", Values: map[string]any{"message": "pending"}}},
+		claim: []RedisStreamEntry{
+			{ID: "2-0", Values: map[string]any{"message": "claimed"}},
+			{ID: "3-0"},
+		},
+		entries: []RedisStreamEntry{
+			{ID: "4-0", Values: map[string]any{"message": "new", "status": "200"}},
+			{ID: "5-0", Values: map[string]any{"message": "new", "status": "500"}},
+		},
+		readErrs: 1,
+	}
+	var errs []error
+	source := NewRedisStreamSource(writer, client, RedisStreamSourceConfig{
+		Stream:   "app:logs",
+		Group:    "timeline",
+		Consumer: "worker-1",
+		OnError:  func(err error) { errs = append(errs, err) },
+	})
+
+	// When the source runs until all entries are read
+	err := source.Run(ctx)
+
+	// Then all entries are written and acknowledged, and the read error is reported
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(client.acked, []string{"1-0", "2-0", "3-0", "4-0", "5-0"})
+	is.Equal(len(errs), 1)
+
+	var count, statusSum int
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*), sum(coalesce(status, 0)) FROM app_logs`).Scan(&count, &statusSum))
+	is.Equal(count, 4)
+	is.Equal(statusSum, 700)
+}
@@ -0,0 +1,351 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicatedRow is a written row of a table that is replicated
+type ReplicatedRow struct {
+	Table string
+	Row   Row
+}
+
+// ReplicationTarget is the secondary destination of a Replicator, see WriterTarget and HTTPTarget
+type ReplicationTarget interface {
+	// Replicate delivers a batch of rows. When it returns an error the batch is delivered again,
+	// so a target can receive a row more than once.
+	Replicate(ctx context.Context, rows []ReplicatedRow) error
+}
+
+// ReplicationConfig configures a Replicator
+type ReplicationConfig struct {
+	// Target is the destination of the rows
+	Target ReplicationTarget
+	// Tables are the replicated tables, all tables without tables
+	Tables []string
+	// BatchSize is the maximum number of rows of a batch, defaults to 500
+	BatchSize int
+	// Interval is the maximum time a row waits for a full batch, defaults to 1 second
+	Interval time.Duration
+	// MaxPending is the maximum number of rows that wait for delivery, defaults to 100000. When
+	// the target can't keep up, new rows are dropped (see Dropped).
+	MaxPending int
+	// RetryBackoff is the time before a failed batch is delivered again, it doubles up to 1
+	// minute. Defaults to 1 second.
+	RetryBackoff time.Duration
+	// OnError is called for batches that failed to be delivered and for dropped rows
+	OnError func(error)
+	// Spool stores the pending rows until they are delivered, NewReplicator queues the rows that
+	// were not delivered when the process stopped. A spooled row is JSON: a time other than the
+	// timestamp is replayed as a string.
+	Spool *Spool
+}
+
+// Replicator ships the rows that are written to a writer to a secondary destination, e.g. another
+// database file (WriterTarget) or a remote ingestion endpoint (HTTPTarget). The rows are delivered
+// in batches and in the order they were written, at least once: a failed batch is retried until it
+// is delivered. The rows wait in memory, rows that are not delivered when the process stops are
+// lost unless they are spooled (see ReplicationConfig.Spool).
+type Replicator struct {
+	writer *Writer
+	config ReplicationConfig
+
+	mu      sync.Mutex
+	pending []pendingRow
+	// full signals Run that a batch is complete
+	full    chan struct{}
+	dropped atomic.Int64
+}
+
+// pendingRow is a row that waits for delivery
+type pendingRow struct {
+	ReplicatedRow
+	writtenAt time.Time
+	// seq is the sequence number in the spool, 0 without a spool
+	seq int64
+}
+
+// spooledRow is the spool record of a pending row
+type spooledRow struct {
+	Table string    `json:"table"`
+	Row   Row       `json:"row"`
+	Time  time.Time `json:"time"`
+}
+
+// NewReplicator creates a replicator of a writer. The rows that are written from now on, and the
+// rows of the spool that were not delivered, are replicated by Run. An error of the spool is
+// reported to OnError.
+func NewReplicator(writer *Writer, config ReplicationConfig) *Replicator {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = 100000
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
+	r := &Replicator{writer: writer, config: config, full: make(chan struct{}, 1)}
+	if err := r.replay(); err != nil {
+		r.reportError(err)
+	}
+	writer.replicators.add(r)
+	return r
+}
+
+// replay queues the rows of the spool that were not delivered
+func (r *Replicator) replay() error {
+	if r.config.Spool == nil {
+		return nil
+	}
+	return r.config.Spool.Replay(func(seq int64, record json.RawMessage) error {
+		decoder := json.NewDecoder(bytes.NewReader(record))
+		decoder.UseNumber()
+		var spooled spooledRow
+		if err := decoder.Decode(&spooled); err != nil {
+			return fmt.Errorf("invalid spool record %d: %w", seq, err)
+		}
+		for k, v := range spooled.Row {
+			spooled.Row[k] = convertJSONNumbers(v)
+		}
+		if value, ok := spooled.Row["timestamp"].(string); ok {
+			if timestamp, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				spooled.Row["timestamp"] = timestamp
+			}
+		}
+		r.pending = append(r.pending, pendingRow{ReplicatedRow{Table: spooled.Table, Row: spooled.Row}, spooled.Time, seq})
+		return nil
+	})
+}
+
+// Run delivers the written rows until ctx is done. Rows that are written after Run returns are
+// not replicated.
+func (r *Replicator) Run(ctx context.Context) error {
+	defer r.writer.replicators.remove(r)
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	backoff := r.config.RetryBackoff
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-r.full:
+		}
+
+		for {
+			batch := r.batch()
+			if len(batch) == 0 {
+				break
+			}
+			if err := r.config.Target.Replicate(ctx, batch); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				r.reportError(fmt.Errorf("failed to replicate %d rows: %w", len(batch), err))
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(backoff):
+				}
+				backoff = min(backoff*2, time.Minute)
+				continue
+			}
+			backoff = r.config.RetryBackoff
+			r.delivered(len(batch))
+		}
+	}
+}
+
+// Lag returns how long the oldest row that isn't delivered yet waits, 0 when all rows are delivered
+func (r *Replicator) Lag() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) == 0 {
+		return 0
+	}
+	return time.Since(r.pending[0].writtenAt)
+}
+
+// Pending returns the number of rows that wait for delivery
+func (r *Replicator) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending)
+}
+
+// Dropped returns the number of rows that were dropped because MaxPending rows were waiting
+func (r *Replicator) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// enqueue adds a written row to the rows that wait for delivery
+func (r *Replicator) enqueue(table string, row Row) {
+	if len(r.config.Tables) > 0 && !slices.Contains(r.config.Tables, table) {
+		return
+	}
+
+	r.mu.Lock()
+	if len(r.pending) >= r.config.MaxPending {
+		r.mu.Unlock()
+		if r.dropped.Add(1) == 1 {
+			r.reportError(fmt.Errorf("the replication of table %s lags %d rows behind, rows are dropped", table, r.config.MaxPending))
+		}
+		return
+	}
+	pending := pendingRow{ReplicatedRow{Table: table, Row: maps.Clone(row)}, time.Now(), 0}
+	var spoolErr error
+	if r.config.Spool != nil {
+		// Appended under the lock, so the spool has the order of the pending rows
+		pending.seq, spoolErr = r.config.Spool.Append(spooledRow{Table: table, Row: pending.Row, Time: pending.writtenAt})
+	}
+	r.pending = append(r.pending, pending)
+	complete := len(r.pending) >= r.config.BatchSize
+	r.mu.Unlock()
+
+	if spoolErr != nil {
+		r.reportError(fmt.Errorf("failed to spool row of %s: %w", table, spoolErr))
+	}
+
+	if complete {
+		select {
+		case r.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// batch returns the next rows to deliver
+func (r *Replicator) batch() []ReplicatedRow {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := min(len(r.pending), r.config.BatchSize)
+	batch := make([]ReplicatedRow, n)
+	for i := range batch {
+		batch[i] = r.pending[i].ReplicatedRow
+	}
+	return batch
+}
+
+// delivered removes the first n rows that are delivered and commits them to the spool
+func (r *Replicator) delivered(n int) {
+	r.mu.Lock()
+	seq := r.pending[n-1].seq
+	r.pending = slices.Delete(r.pending, 0, n)
+	r.mu.Unlock()
+
+	if r.config.Spool != nil && seq > 0 {
+		if err := r.config.Spool.Commit(seq); err != nil {
+			r.reportError(err)
+		}
+	}
+}
+
+func (r *Replicator) reportError(err error) {
+	handleError(r.config.OnError, err, ErrorContext{Component: "replicator"})
+}
+
+// replicators are the replicators of a writer
+type replicators struct {
+	mu   sync.RWMutex
+	subs map[*Replicator]struct{}
+}
+
+func (rs *replicators) add(r *Replicator) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.subs == nil {
+		rs.subs = make(map[*Replicator]struct{})
+	}
+	rs.subs[r] = struct{}{}
+}
+
+func (rs *replicators) remove(r *Replicator) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.subs, r)
+}
+
+// publish queues a written row for the replicators
+func (rs *replicators) publish(table string, row Row) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	for r := range rs.subs {
+		r.enqueue(table, row)
+	}
+}
+
+// WriterTarget replicates the rows to another writer, e.g. of a database file on another disk or
+// of MotherDuck (NewStorageClient("md:my_database") with a motherduck_token)
+func WriterTarget(writer *Writer) ReplicationTarget {
+	return writerTarget{writer: writer}
+}
+
+type writerTarget struct {
+	writer *Writer
+}
+
+func (t writerTarget) Replicate(ctx context.Context, rows []ReplicatedRow) error {
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := t.writer.WriteContext(ctx, row.Table, maps.Clone(row.Row)); err != nil {
+			// The rows before are written again when the batch is retried
+			return fmt.Errorf("failed to write row %d of %s: %w", i, row.Table, err)
+		}
+	}
+	return nil
+}
+
+// HTTPTarget replicates the rows to an HTTP endpoint, e.g. the WebhookReceiver of another timeline
+// with TimestampPath timestamp. A batch is POSTed as a JSON array of the rows with the name of
+// their table in the source_table field; a response without a 2xx status fails the batch.
+func HTTPTarget(url string) ReplicationTarget {
+	return httpTarget{url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+type httpTarget struct {
+	url    string
+	client *http.Client
+}
+
+func (t httpTarget) Replicate(ctx context.Context, rows []ReplicatedRow) error {
+	objects := make([]Row, len(rows))
+	for i, row := range rows {
+		objects[i] = maps.Clone(row.Row)
+		objects[i]["source_table"] = row.Table
+	}
+	body, err := json.Marshal(objects)
+	if err != nil {
+		return fmt.Errorf("failed to encode rows: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := t.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s of %s", response.Status, t.url)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startReplicator runs a replicator until the end of the test
+func startReplicator(t *testing.T, replicator *Replicator) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		replicator.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// flakyTarget fails the first batches and records the delivered rows
+type flakyTarget struct {
+	mu       sync.Mutex
+	failures int
+	batches  [][]ReplicatedRow
+}
+
+func (f *flakyTarget) Replicate(ctx context.Context, rows []ReplicatedRow) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("unavailable")
+	}
+	f.batches = append(f.batches, rows)
+	return nil
+}
+
+func (f *flakyTarget) delivered() []ReplicatedRow {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rows []ReplicatedRow
+	for _, batch := range f.batches {
+		rows = append(rows, batch...)
+	}
+	return rows
+}
+
+func Test_replicator_writes_the_rows_to_another_writer(t *testing.T) {
+	is, writer := setup(t)
+	_, secondary := setup(t)
+	startReplicator(t, NewReplicator(writer, ReplicationConfig{Target: WriterTarget(secondary), Interval: 10 * time.Millisecond}))
+
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "hello", "http": map[string]any{"status": 200}})))
+	is.NoErr(writer.Write("metrics", NewRow(time.Now(), Row{"value": 1.5})))
+
+	waitForCount(t, secondary, "SELECT count(*) FROM logs WHERE http_status = 200", 1)
+	waitForCount(t, secondary, "SELECT count(*) FROM metrics", 1)
+}
+
+func Test_replicator_retries_failed_batches_in_order(t *testing.T) {
+	is, writer := setup(t)
+	target := &flakyTarget{failures: 2}
+	var errs []error
+	replicator := NewReplicator(writer, ReplicationConfig{
+		Target:       target,
+		Tables:       []string{"logs"},
+		BatchSize:    2,
+		Interval:     10 * time.Millisecond,
+		RetryBackoff: 10 * time.Millisecond,
+		OnError:      func(err error) { errs = append(errs, err) },
+	})
+
+	// Given rows that are written before the replicator runs
+	for i := 0; i < 5; i++ {
+		is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"n": i})))
+	}
+	is.NoErr(writer.Write("other", NewRow(time.Now(), Row{"n": 1})))
+	is.Equal(replicator.Pending(), 5)
+	is.True(replicator.Lag() > 0)
+
+	// When the target fails twice
+	startReplicator(t, replicator)
+
+	// Then all rows are delivered in batches, in order
+	deadline := time.Now().Add(5 * time.Second)
+	for replicator.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	rows := target.delivered()
+	is.Equal(len(rows), 5)
+	for i, row := range rows {
+		is.Equal(row.Table, "logs")
+		is.Equal(row.Row["n"], i)
+	}
+	is.Equal(len(target.batches[0]), 2)
+	is.Equal(replicator.Lag(), time.Duration(0))
+	is.Equal(len(errs), 2)
+}
+
+func Test_replicator_drops_rows_when_too_many_are_pending(t *testing.T) {
+	is, writer := setup(t)
+	replicator := NewReplicator(writer, ReplicationConfig{Target: &flakyTarget{}, MaxPending: 2})
+
+	for i := 0; i < 5; i++ {
+		is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"n": i})))
+	}
+
+	is.Equal(replicator.Pending(), 2)
+	is.Equal(replicator.Dropped(), int64(3))
+}
+
+func Test_replicator_posts_the_rows_to_a_webhook_receiver(t *testing.T) {
+	is, writer := setup(t)
+	_, secondary := setup(t)
+	server := httptest.NewServer(NewWebhookReceiver(secondary, WebhookReceiverConfig{Table: "replica", TimestampPath: "timestamp"}))
+	defer server.Close()
+	startReplicator(t, NewReplicator(writer, ReplicationConfig{Target: HTTPTarget(server.URL), Interval: 10 * time.Millisecond}))
+
+	is.NoErr(writer.Write("logs", NewRow(time.Date(2025, 9, 14, 12, 0, 0, 0, time.UTC), Row{"message": "hello"})))
+
+	waitForCount(t, secondary, "SELECT count(*) FROM replica WHERE source_table = 'logs' AND message = 'hello' AND timestamp = '2025-09-14 12:00:00'", 1)
+}
+
+func Test_replicator_delivers_the_spooled_rows_after_a_restart(t *testing.T) {
+	is, writer := setup(t)
+	dir := t.TempDir()
+
+	// Given a replicator with a spool of which the rows are not delivered
+	spool, err := OpenSpool(SpoolConfig{Dir: dir})
+	is.NoErr(err)
+	NewReplicator(writer, ReplicationConfig{Target: &flakyTarget{}, Spool: spool})
+	timestamp := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("logs", NewRow(timestamp, Row{"n": 1, "message": "lost"})))
+	is.NoErr(spool.Close())
+
+	// When a new replicator opens the spool
+	spool, err = OpenSpool(SpoolConfig{Dir: dir})
+	is.NoErr(err)
+	defer spool.Close()
+	target := &flakyTarget{}
+	replicator := NewReplicator(writer, ReplicationConfig{Target: target, Spool: spool, Interval: 10 * time.Millisecond})
+	is.Equal(replicator.Pending(), 1)
+	startReplicator(t, replicator)
+
+	// Then the row is delivered with its types and committed
+	deadline := time.Now().Add(5 * time.Second)
+	for spool.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	is.Equal(spool.Pending(), int64(0))
+	rows := target.delivered()
+	is.Equal(len(rows), 1)
+	is.Equal(rows[0].Table, "logs")
+	is.Equal(rows[0].Row["n"], 1)
+	is.Equal(rows[0].Row["message"], "lost")
+	delivered, ok := rows[0].Row["timestamp"].(time.Time)
+	is.True(ok)
+	is.True(delivered.Equal(timestamp))
+}
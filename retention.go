@@ -0,0 +1,247 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetentionPolicy removes old rows from a database, see MaxAge, MaxDatabaseSize and ArchiveAfter
+type RetentionPolicy struct {
+	maxAge  time.Duration
+	maxSize int64
+	tables  []string
+	// archive moves the rows to the archive of the table instead of deleting them
+	archive bool
+}
+
+// MaxAge deletes the rows that are older than age from the tables, or from all tables without tables.
+// Daily partitions (see PartitionDaily) that are older than age are dropped.
+func MaxAge(age time.Duration, tables ...string) RetentionPolicy {
+	return RetentionPolicy{maxAge: age, tables: tables}
+}
+
+// MaxDatabaseSize deletes the oldest rows of the tables until the database uses at most bytes.
+// Every round the oldest 10% of the rows of every table are deleted and the database is
+// checkpointed. The size is the size of the used blocks of the file: DuckDB reuses the blocks of
+// deleted rows, but the file itself doesn't shrink.
+func MaxDatabaseSize(bytes int64) RetentionPolicy {
+	return RetentionPolicy{maxSize: bytes}
+}
+
+// ApplyRetention applies the policies to the tables of the database and returns the number of
+// deleted (or archived) rows. Tables without a timestamp column are skipped.
+func (w *Writer) ApplyRetention(ctx context.Context, policies ...RetentionPolicy) (int64, error) {
+	if w.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	var deleted int64
+	for _, policy := range policies {
+		n, err := policy.apply(ctx, w)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// apply applies the policy to the database of the writer
+func (p RetentionPolicy) apply(ctx context.Context, w *Writer) (int64, error) {
+	if p.maxAge <= 0 && p.maxSize <= 0 {
+		return 0, nil
+	}
+	if p.archive && len(p.tables) == 0 {
+		if p.tables = w.archivedTables(); len(p.tables) == 0 {
+			return 0, nil
+		}
+	}
+	tables, err := p.timestampTables(ctx, w)
+	if err != nil {
+		return 0, err
+	}
+	if p.maxSize > 0 {
+		return p.applyMaxSize(ctx, w, tables)
+	}
+
+	cutoff := time.Now().Add(-p.maxAge).UTC()
+	var deleted int64
+	for _, table := range tables {
+		if p.archive {
+			n, err := w.archiveRows(ctx, table, cutoff)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+			continue
+		}
+		if _, end, ok := w.partitionOf(table); ok && !end.After(cutoff) {
+			n, err := w.dropPartition(ctx, table)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+			continue
+		}
+		result, err := w.DB.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE timestamp < ?", quoteTableName(table)), cutoff)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to apply retention to %s: %w", table, err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// applyMaxSize deletes the oldest rows of the tables until the database is small enough
+func (p RetentionPolicy) applyMaxSize(ctx context.Context, w *Writer, tables []string) (int64, error) {
+	var deleted int64
+	for {
+		// The blocks of deleted rows are freed by a checkpoint
+		if err := w.Checkpoint(); err != nil {
+			return deleted, err
+		}
+		size, err := w.usedSize(ctx)
+		if err != nil {
+			return deleted, err
+		}
+		if size <= p.maxSize {
+			return deleted, nil
+		}
+
+		var round int64
+		for _, table := range tables {
+			var count int64
+			if err := w.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s", quoteTableName(table))).Scan(&count); err != nil {
+				return deleted, fmt.Errorf("failed to apply retention to %s: %w", table, err)
+			}
+			if count == 0 {
+				continue
+			}
+			result, err := w.DB.ExecContext(ctx, fmt.Sprintf("DELETE FROM %[1]s WHERE rowid IN (SELECT rowid FROM %[1]s ORDER BY timestamp LIMIT %d)",
+				quoteTableName(table), max(count/10, 1)))
+			if err != nil {
+				return deleted, fmt.Errorf("failed to apply retention to %s: %w", table, err)
+			}
+			n, _ := result.RowsAffected()
+			round += n
+		}
+		if round == 0 {
+			// Nothing is left to delete
+			return deleted, nil
+		}
+		deleted += round
+	}
+}
+
+// timestampTables returns the tables of the policy (all tables without tables) with a timestamp column
+func (p RetentionPolicy) timestampTables(ctx context.Context, w *Writer) ([]string, error) {
+	tables := p.tables
+	if len(tables) == 0 {
+		var err error
+		if tables, err = w.tableNames(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []string
+	for _, table := range tables {
+		if w.isPartitioned(table) {
+			partitions, err := w.partitions(table, time.Time{}, time.Time{})
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, partitions...)
+			continue
+		}
+		columns, err := w.getCurrentColumns(table)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := columns["timestamp"]; ok {
+			result = append(result, table)
+		}
+	}
+	return result, nil
+}
+
+// RetentionConfig configures the retention of the connections of a manager, see SetRetention
+type RetentionConfig struct {
+	// Policies are applied to all databases
+	Policies []RetentionPolicy
+	// Interval is the time between the runs, defaults to 1 hour
+	Interval time.Duration
+	// OnError is called for databases that the policies failed to apply to
+	OnError func(error)
+}
+
+// WithRetention applies retention policies to the connections, see SetRetention
+func WithRetention(config RetentionConfig) ManagerOption {
+	return func(m *TimelineConnectionManager) {
+		m.retention = config
+	}
+}
+
+// SetRetention applies the policies of the configuration to the databases of the open connections
+// every Interval. A configuration without policies stops applying retention. SetRetention waits
+// until the policies of the previous configuration are applied.
+func (m *TimelineConnectionManager) SetRetention(config RetentionConfig) {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	m.mutex.Lock()
+	m.retention = config
+	stop, done := m.stopRetention, m.retentionDone
+	m.stopRetention, m.retentionDone = nil, nil
+	if len(config.Policies) > 0 {
+		m.stopRetention, m.retentionDone = make(chan struct{}), make(chan struct{})
+		go m.runRetention(config, m.stopRetention, m.retentionDone)
+	}
+	m.mutex.Unlock()
+
+	// The goroutine needs the lock to apply the policies
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// runRetention applies the retention policies every interval until stop is closed, then closes done
+func (m *TimelineConnectionManager) runRetention(config RetentionConfig, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := m.ApplyRetention(context.Background(), config.Policies...); err != nil {
+				handleError(config.OnError, err, ErrorContext{Component: "retention"})
+			}
+		}
+	}
+}
+
+// ApplyRetention applies the policies to the databases of the open connections, read-only
+// connections are skipped. The connections are not closed while the policies are applied.
+func (m *TimelineConnectionManager) ApplyRetention(ctx context.Context, policies ...RetentionPolicy) error {
+	var errs []error
+	for _, dbPath := range m.ListConnections() {
+		lease, err := m.Acquire(dbPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !lease.readOnly {
+			if _, err := lease.ApplyRetention(ctx, policies...); err != nil {
+				errs = append(errs, fmt.Errorf("failed to apply retention to %s: %w", dbPath, err))
+			}
+		}
+		lease.Release()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,154 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_max_age_deletes_the_old_rows_of_all_tables(t *testing.T) {
+	is, writer := setup(t)
+	now := time.Now().UTC()
+
+	// Given old and new rows in two tables
+	is.NoErr(writer.Write("logs", NewRow(now.Add(-48*time.Hour), Row{"message": "old"})))
+	is.NoErr(writer.Write("logs", NewRow(now, Row{"message": "new"})))
+	is.NoErr(writer.Write("metrics", NewRow(now.Add(-48*time.Hour), Row{"value": 1})))
+
+	// When the rows older than a day are deleted
+	deleted, err := writer.ApplyRetention(context.Background(), MaxAge(24*time.Hour))
+
+	// Then only the new row is left
+	is.NoErr(err)
+	is.Equal(deleted, int64(2))
+	waitForCount(t, writer, "SELECT count(*) FROM logs WHERE message = 'new'", 1)
+	waitForCount(t, writer, "SELECT count(*) FROM logs", 1)
+	waitForCount(t, writer, "SELECT count(*) FROM metrics", 0)
+}
+
+func Test_max_age_deletes_the_rows_of_the_tables(t *testing.T) {
+	is, writer := setup(t)
+	old := time.Now().UTC().Add(-48 * time.Hour)
+
+	// Given old rows in two tables and a table without a timestamp column
+	is.NoErr(writer.Write("logs", NewRow(old, Row{"message": "old"})))
+	is.NoErr(writer.Write("audit", NewRow(old, Row{"message": "old"})))
+	_, err := writer.DB.Exec("CREATE TABLE settings (name VARCHAR)")
+	is.NoErr(err)
+
+	// When the policy only applies to logs and settings
+	deleted, err := writer.ApplyRetention(context.Background(), MaxAge(24*time.Hour, "logs", "settings"))
+
+	// Then the rows of audit are kept
+	is.NoErr(err)
+	is.Equal(deleted, int64(1))
+	waitForCount(t, writer, "SELECT count(*) FROM audit", 1)
+}
+
+func Test_retention_of_a_read_only_client_fails(t *testing.T) {
+	is := is.New(t)
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	writer, err := NewStorageClient(dbPath)
+	is.NoErr(err)
+	is.NoErr(writer.Close())
+	reader, err := NewReadOnlyClient(dbPath)
+	is.NoErr(err)
+	defer reader.Close()
+
+	_, err = reader.ApplyRetention(context.Background(), MaxAge(time.Hour))
+
+	is.True(errors.Is(err, ErrReadOnly))
+}
+
+func Test_manager_applies_retention_to_the_open_connections(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	manager := NewConnectionManager(WithRetention(RetentionConfig{
+		Policies: []RetentionPolicy{MaxAge(time.Hour)},
+		Interval: 20 * time.Millisecond,
+		OnError:  func(err error) { t.Errorf("unexpected error: %v", err) },
+	}))
+	defer manager.Close()
+
+	// Given old rows in two databases
+	var writers []*Writer
+	for _, name := range []string{"a.db", "b.db"} {
+		writer, err := manager.GetOrCreateConnection(filepath.Join(dir, name))
+		is.NoErr(err)
+		is.NoErr(writer.Write("logs", NewRow(time.Now().Add(-2*time.Hour), Row{"message": "old"})))
+		is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "new"})))
+		writers = append(writers, writer)
+	}
+
+	// Then the scheduler deletes the old rows of both databases
+	for _, writer := range writers {
+		waitForCount(t, writer, "SELECT count(*) FROM logs", 1)
+	}
+}
+
+func Test_manager_stops_applying_retention_without_policies(t *testing.T) {
+	is := is.New(t)
+	manager := newTestManager()
+	defer manager.Close()
+	writer, err := manager.GetOrCreateConnection(filepath.Join(t.TempDir(), "test.db"))
+	is.NoErr(err)
+
+	// Given retention that is stopped
+	manager.SetRetention(RetentionConfig{Policies: []RetentionPolicy{MaxAge(time.Hour)}, Interval: 10 * time.Millisecond})
+	manager.SetRetention(RetentionConfig{})
+
+	// When an old row is written
+	is.NoErr(writer.Write("logs", NewRow(time.Now().Add(-2*time.Hour), Row{"message": "old"})))
+	time.Sleep(50 * time.Millisecond)
+
+	// Then it is kept
+	waitForCount(t, writer, "SELECT count(*) FROM logs", 1)
+}
+
+func Test_max_database_size_deletes_the_oldest_rows_until_the_database_is_small_enough(t *testing.T) {
+	is := is.New(t)
+	writer, err := NewStorageClient(filepath.Join(t.TempDir(), "test.db"))
+	is.NoErr(err)
+	defer writer.Close()
+	size := func() int64 {
+		var size int64
+		is.NoErr(writer.DB.QueryRow("SELECT used_blocks * block_size FROM pragma_database_size() WHERE database_name = current_database()").Scan(&size))
+		return size
+	}
+
+	// Given a database of a few MB, one row per second
+	_, err = writer.DB.Exec("CREATE TABLE logs AS SELECT TIMESTAMP '2025-01-01' + to_seconds(i) AS timestamp, repeat('x', 100) || i AS message FROM range(300000) t(i)")
+	is.NoErr(err)
+	is.NoErr(writer.Checkpoint())
+	before := size()
+
+	// When the database may use half of it
+	deleted, err := writer.ApplyRetention(context.Background(), MaxDatabaseSize(before/2))
+
+	// Then the oldest rows are deleted
+	is.NoErr(err)
+	is.True(deleted > 0)
+	is.True(size() <= before/2)
+	var count, oldest int64
+	is.NoErr(writer.DB.QueryRow("SELECT count(*), epoch(min(timestamp) - TIMESTAMP '2025-01-01') FROM logs").Scan(&count, &oldest))
+	is.Equal(count, 300000-deleted)
+	is.Equal(oldest, deleted) // the remaining rows are the newest
+}
+
+func Test_max_database_size_keeps_a_small_database(t *testing.T) {
+	is := is.New(t)
+	writer, err := NewStorageClient(filepath.Join(t.TempDir(), "test.db"))
+	is.NoErr(err)
+	defer writer.Close()
+	is.NoErr(writer.Write("logs", NewRow(time.Now().Add(-48*time.Hour), Row{"message": "old"})))
+
+	deleted, err := writer.ApplyRetention(context.Background(), MaxDatabaseSize(100<<20))
+
+	is.NoErr(err)
+	is.Equal(deleted, int64(0))
+	waitForCount(t, writer, "SELECT count(*) FROM logs", 1)
+}
@@ -0,0 +1,132 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RolloverPeriod is the period of the database files of GetRolloverConnection
+type RolloverPeriod int
+
+const (
+	// RolloverDaily writes to a file per day, e.g. app-2025-09-14.db
+	RolloverDaily RolloverPeriod = iota + 1
+	// RolloverWeekly writes to a file per ISO week, e.g. app-2025-W37.db
+	RolloverWeekly
+	// RolloverMonthly writes to a file per month, e.g. app-2025-09.db
+	RolloverMonthly
+)
+
+// Path returns the path of the database file of the period of t (in UTC), e.g. data/app-2025-09.db
+// for data/app.db
+func (p RolloverPeriod) Path(basePath string, t time.Time) string {
+	t = t.UTC()
+	var suffix string
+	switch p {
+	case RolloverDaily:
+		suffix = t.Format("2006-01-02")
+	case RolloverWeekly:
+		year, week := t.ISOWeek()
+		suffix = fmt.Sprintf("%d-W%02d", year, week)
+	default:
+		suffix = t.Format("2006-01")
+	}
+	ext := filepath.Ext(basePath)
+	return strings.TrimSuffix(basePath, ext) + "-" + suffix + ext
+}
+
+// start returns the start of the period of t
+func (p RolloverPeriod) start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case RolloverDaily:
+		return day
+	case RolloverWeekly:
+		// ISO weeks start on Monday
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// next returns the start of the period after the period that starts at start
+func (p RolloverPeriod) next(start time.Time) time.Time {
+	switch p {
+	case RolloverDaily:
+		return start.AddDate(0, 0, 1)
+	case RolloverWeekly:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// GetRolloverConnection returns the connection of the database file of the current period of
+// basePath, e.g. data/app-2025-09.db for data/app.db with RolloverMonthly. Get the connection for
+// every write (or batch of writes) to switch to the next file at the boundary of the period; the
+// files stay small and complete periods can be archived. Like GetOrCreateConnection the connection
+// stays open, close the files of past periods with CloseConnection. Acquire the Path of the period
+// instead to let SetIdleTimeout close them.
+func (m *TimelineConnectionManager) GetRolloverConnection(basePath string, period RolloverPeriod, options ...ConnectionOption) (*Writer, error) {
+	return m.GetOrCreateConnection(period.Path(basePath, time.Now()), options...)
+}
+
+// RolloverPaths returns the existing database files of basePath of the periods from (inclusive) to
+// (exclusive), oldest first. The periods are those of the files, so of the write time of the rows:
+// a row that is written after its period, e.g. with the timestamp of its source, is in a later file.
+func (m *TimelineConnectionManager) RolloverPaths(basePath string, period RolloverPeriod, from, to time.Time) []string {
+	var paths []string
+	for start := period.start(from); start.Before(to); start = period.next(start) {
+		path := period.Path(basePath, start)
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// QueryRollover returns at most limit rows (0 means no limit) of a table from (inclusive) to
+// (exclusive) that match all filters, like Between, from all database files of basePath that can
+// have rows in the time range: the files of the periods from the period of from up to the current
+// period, as rows are written after their timestamp. Files without the table are skipped.
+func (m *TimelineConnectionManager) QueryRollover(ctx context.Context, basePath string, period RolloverPeriod, table string, from, to time.Time, limit int, filters ...Filter) ([]Row, error) {
+	until := period.next(period.start(time.Now()))
+	if to.After(until) {
+		until = to
+	}
+
+	var result []Row
+	for _, path := range m.RolloverPaths(basePath, period, from, until) {
+		lease, err := m.Acquire(path)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := lease.Between(ctx, table, from, to, limit, filters...)
+		lease.Release()
+		if errors.Is(err, ErrTableNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", path, err)
+		}
+		result = append(result, rows...)
+	}
+
+	// Rows can be written to the file of another period, e.g. with the timestamp of the source
+	sort.SliceStable(result, func(i, j int) bool {
+		ti, _ := result[i]["timestamp"].(time.Time)
+		tj, _ := result[j]["timestamp"].(time.Time)
+		return ti.Before(tj)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
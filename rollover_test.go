@@ -0,0 +1,90 @@
+package timeline
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_rollover_paths(t *testing.T) {
+	// Given
+	at := time.Date(2025, 9, 14, 23, 30, 0, 0, time.UTC)
+
+	// Then
+	for period, expected := range map[RolloverPeriod]string{
+		RolloverDaily:   "data/app-2025-09-14.db",
+		RolloverWeekly:  "data/app-2025-W37.db",
+		RolloverMonthly: "data/app-2025-09.db",
+	} {
+		if path := period.Path("data/app.db", at); path != expected {
+			t.Fatalf("Expected %s, got %s", expected, path)
+		}
+	}
+	// 2025-09-14 is a Sunday, the week started on Monday
+	if start := RolloverWeekly.start(at); !start.Equal(time.Date(2025, 9, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected start of the week %s", start)
+	}
+}
+
+func Test_rollover_connection_and_query_across_files(t *testing.T) {
+	// Given
+	basePath := filepath.Join(t.TempDir(), "app.db")
+	manager := newTestManager()
+	defer manager.CloseAllConnections()
+	august := time.Date(2025, 8, 31, 23, 0, 0, 0, time.UTC)
+	september := time.Date(2025, 9, 1, 1, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{august, september} {
+		writer, err := manager.GetOrCreateConnection(RolloverMonthly.Path(basePath, at))
+		if err != nil {
+			t.Fatalf("Failed to create connection: %v", err)
+		}
+		if err := writer.Write("app", NewRow(at, Row{"month": at.Format("January")})); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	// When
+	current, err := manager.GetRolloverConnection(basePath, RolloverMonthly)
+	if err != nil {
+		t.Fatalf("Failed to get rollover connection: %v", err)
+	}
+	rows, err := manager.QueryRollover(context.Background(), basePath, RolloverMonthly, "app", august.Add(-time.Hour), september.Add(time.Hour), 0)
+
+	// Then
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["month"] != "August" || rows[1]["month"] != "September" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+	if manager.connections[RolloverMonthly.Path(basePath, time.Now())] != current {
+		t.Fatal("Expected the connection of the current month")
+	}
+}
+
+func Test_rollover_query_finds_rows_written_in_a_later_file(t *testing.T) {
+	// Given a row of August that is written in the file of September
+	basePath := filepath.Join(t.TempDir(), "app.db")
+	manager := newTestManager()
+	defer manager.CloseAllConnections()
+	august := time.Date(2025, 8, 31, 23, 0, 0, 0, time.UTC)
+	writer, err := manager.GetOrCreateConnection(RolloverMonthly.Path(basePath, august.AddDate(0, 1, 0)))
+	if err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	if err := writer.Write("app", NewRow(august, Row{"month": "August"})); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	// When the rows of August are queried
+	rows, err := manager.QueryRollover(context.Background(), basePath, RolloverMonthly, "app", august.Add(-time.Hour), august.Add(time.Hour), 0)
+
+	// Then the row is found
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["month"] != "August" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+}
@@ -0,0 +1,153 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RollupConfig configures a Rollup
+type RollupConfig struct {
+	// Table is the table that is rolled up
+	Table string
+	// Name is the table of the rollup, defaults to <Table>_rollup
+	Name string
+	// Bucket is the duration of a bucket, e.g. time.Minute
+	Bucket time.Duration
+	// GroupBy are the columns the rows of a bucket are grouped by, e.g. level
+	GroupBy []string
+	// Metrics are calculated per bucket and group, defaults to Count
+	Metrics []Metric
+	// Lookback recalculates older buckets on a refresh, for rows that arrive late
+	Lookback time.Duration
+	// Interval is the time between refreshes of Run, defaults to 1 minute
+	Interval time.Duration
+	// OnError is called for refreshes of Run that failed
+	OnError func(error)
+}
+
+// Rollup maintains a table with the metrics per time bucket and group of a table, e.g. the number
+// of rows per minute and level, so dashboards over months of data don't scan every row. The rollup
+// table has a timestamp column with the start of the bucket, the GroupBy columns and a column per
+// metric, so it can be read with Query, Between and the other query helpers.
+//
+// A refresh only recalculates the buckets from the last bucket of the rollup table (minus
+// Lookback); rows that are written for older buckets are not counted.
+type Rollup struct {
+	writer *Writer
+	config RollupConfig
+}
+
+// NewRollup creates a rollup, call Refresh or Run to maintain it
+func NewRollup(writer *Writer, config RollupConfig) *Rollup {
+	if config.Name == "" {
+		config.Name = config.Table + "_rollup"
+	}
+	if len(config.Metrics) == 0 {
+		config.Metrics = []Metric{Count()}
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	return &Rollup{writer: writer, config: config}
+}
+
+// Run refreshes the rollup every Interval until ctx is done
+func (r *Rollup) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+			r.reportError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh recalculates the buckets of the rows that are written since the last refresh. The rollup
+// table is created on the first refresh after rows are written to the table.
+func (r *Rollup) Refresh(ctx context.Context) error {
+	if r.config.Bucket <= 0 {
+		return errors.New("the bucket of a rollup must be positive")
+	}
+
+	// The partitions of a partitioned table are read as one table
+	source, _, err := r.writer.tableSource(r.config.Table, time.Time{}, time.Time{})
+	if errors.Is(err, ErrTableNotFound) {
+		// Nothing is written yet
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rollupColumns, err := r.writer.getCurrentColumns(r.config.Name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.writer.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin refresh of rollup %s: %w", r.config.Name, err)
+	}
+	defer tx.Rollback()
+
+	if len(rollupColumns) == 0 {
+		query, args := r.selectQuery(source, time.Time{})
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s AS %s", quoteTableName(r.config.Name), query), args...); err != nil {
+			return fmt.Errorf("failed to create rollup %s: %w", r.config.Name, err)
+		}
+	} else {
+		var last sql.NullTime
+		if err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT max(timestamp) FROM %s", quoteTableName(r.config.Name))).Scan(&last); err != nil {
+			return fmt.Errorf("failed to get the last bucket of rollup %s: %w", r.config.Name, err)
+		}
+		from := time.Time{}
+		if last.Valid {
+			// The buckets start at a multiple of the bucket duration, like the buckets of time_bucket
+			from = last.Time.Add(-r.config.Lookback).Truncate(r.config.Bucket)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE timestamp >= ?", quoteTableName(r.config.Name)), from); err != nil {
+			return fmt.Errorf("failed to refresh rollup %s: %w", r.config.Name, err)
+		}
+		query, args := r.selectQuery(source, from)
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s BY NAME %s", quoteTableName(r.config.Name), query), args...); err != nil {
+			return fmt.Errorf("failed to refresh rollup %s: %w", r.config.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit refresh of rollup %s: %w", r.config.Name, err)
+	}
+	return nil
+}
+
+// selectQuery calculates the buckets of the rows of source from a time (a zero time calculates all
+// buckets)
+func (r *Rollup) selectQuery(source string, from time.Time) (string, []any) {
+	selected := []string{"time_bucket(to_microseconds(?::BIGINT), timestamp) AS timestamp"}
+	for _, column := range r.config.GroupBy {
+		selected = append(selected, quoteIdentifier(column))
+	}
+	for _, metric := range r.config.Metrics {
+		selected = append(selected, metric.expression())
+	}
+
+	args := []any{r.config.Bucket.Microseconds()}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selected, ", "), source)
+	if !from.IsZero() {
+		query += " WHERE timestamp >= ?"
+		args = append(args, from)
+	}
+	return query + " GROUP BY ALL", args
+}
+
+func (r *Rollup) reportError(err error) {
+	handleError(r.config.OnError, err, ErrorContext{Component: "rollup", Table: r.config.Table})
+}
@@ -0,0 +1,71 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_rollup_is_maintained_incrementally(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	rollup := NewRollup(writer, RollupConfig{
+		Table:   "app",
+		Bucket:  time.Minute,
+		GroupBy: []string{"level"},
+		Metrics: []Metric{Count(), Sum("duration")},
+	})
+
+	// Without rows there is nothing to roll up
+	is.NoErr(rollup.Refresh(ctx))
+
+	// Given rows in two minutes
+	is.NoErr(writer.Write("app", NewRow(start, Row{"level": "info", "duration": 1})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(10*time.Second), Row{"level": "info", "duration": 2})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(70*time.Second), Row{"level": "error", "duration": 3})))
+	is.NoErr(rollup.Refresh(ctx))
+
+	// When more rows are written in the last minute and the next minute
+	is.NoErr(writer.Write("app", NewRow(start.Add(80*time.Second), Row{"level": "error", "duration": 4})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(130*time.Second), Row{"level": "info", "duration": 5})))
+	is.NoErr(rollup.Refresh(ctx))
+
+	// Then the rollup table has the metrics per minute and level
+	rows, err := writer.Query(ctx, "app_rollup", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(rows, []Row{
+		{"timestamp": start, "level": "info", "count": 2, "sum_duration": 3},
+		{"timestamp": start.Add(time.Minute), "level": "error", "count": 2, "sum_duration": 7},
+		{"timestamp": start.Add(2 * time.Minute), "level": "info", "count": 1, "sum_duration": 5},
+	})
+}
+
+func Test_rollup_run_refreshes_on_an_interval(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"level": "info"})))
+
+	rollup := NewRollup(writer, RollupConfig{Table: "app", Name: "app_per_minute", Bucket: time.Minute, Interval: 10 * time.Millisecond})
+	go rollup.Run(ctx)
+
+	waitForCount(t, writer, "SELECT count(*) FROM information_schema.tables WHERE table_name = 'app_per_minute'", 1)
+}
+
+func Test_rollup_of_a_partitioned_table(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	writer.PartitionDaily("app")
+	start := time.Date(2024, 9, 1, 23, 59, 0, 0, time.UTC)
+	rollup := NewRollup(writer, RollupConfig{Table: "app", Name: "app_per_minute", Bucket: time.Minute, Metrics: []Metric{Count()}})
+	is.NoErr(rollup.Refresh(ctx))
+
+	is.NoErr(writer.Write("app", NewRow(start, Row{"level": "info"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(time.Minute), Row{"level": "info"})))
+	is.NoErr(rollup.Refresh(ctx))
+
+	var count int
+	is.NoErr(writer.DB.QueryRow("SELECT sum(count) FROM app_per_minute").Scan(&count))
+	is.Equal(count, 2)
+}
@@ -0,0 +1,157 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String returns a column as a string: a string as is, a []byte as text, a time in RFC 3339 and
+// the other values formatted with fmt. Returns "" when the row has no value of the column.
+func (r Row) String(column string) string {
+	return r.StringOr(column, "")
+}
+
+// StringOr is String with a fallback for a row without a value of the column or an empty value
+func (r Row) StringOr(column string, fallback string) string {
+	var s string
+	switch v := r[column].(type) {
+	case nil:
+		return fallback
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// Int returns a column as an integer: every integer type, a float without its fraction and a
+// numeric string. Returns 0 when the column has no value that converts to an integer.
+func (r Row) Int(column string) int {
+	return r.IntOr(column, 0)
+}
+
+// IntOr is Int with a fallback for a row without an integer value of the column
+func (r Row) IntOr(column string, fallback int) int {
+	switch v := r[column].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int(v)
+		}
+	case *big.Int:
+		if v.IsInt64() {
+			return int(v.Int64())
+		}
+	case string, []byte:
+		s := strings.TrimSpace(r.String(column))
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return int(n)
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return int(f)
+		}
+	default:
+		if f, ok := r.float(column); ok && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return int(f)
+		}
+	}
+	return fallback
+}
+
+// Float returns a column as a float: every number type (also a DECIMAL) and a numeric string.
+// Returns 0 when the column has no value that converts to a float.
+func (r Row) Float(column string) float64 {
+	return r.FloatOr(column, 0)
+}
+
+// FloatOr is Float with a fallback for a row without a numeric value of the column
+func (r Row) FloatOr(column string, fallback float64) float64 {
+	if f, ok := r.float(column); ok {
+		return f
+	}
+	return fallback
+}
+
+// float converts a numeric value of a column to a float
+func (r Row) float(column string) (float64, bool) {
+	switch v := r[column].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		f, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		return f, err == nil
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case interface{ Float64() float64 }:
+		// A DECIMAL of the driver
+		return v.Float64(), true
+	case string, []byte:
+		f, err := strconv.ParseFloat(strings.TrimSpace(r.String(column)), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Bool returns a column as a bool: a bool, a string like true, 1 or false, or a number that isn't
+// 0. Returns false when the column has no value that converts to a bool.
+func (r Row) Bool(column string) bool {
+	switch v := r[column].(type) {
+	case bool:
+		return v
+	case string, []byte:
+		b, err := strconv.ParseBool(strings.TrimSpace(r.String(column)))
+		return err == nil && b
+	}
+	f, ok := r.float(column)
+	return ok && f != 0
+}
+
+// Time returns a column as a time: a time.Time, or a string in RFC 3339 or in the format of
+// DuckDB (2006-01-02 15:04:05.999999). Returns the zero time when the column has no value that
+// converts to a time, check it with IsZero.
+func (r Row) Time(column string) time.Time {
+	switch v := r[column].(type) {
+	case time.Time:
+		return v
+	case string, []byte:
+		s := strings.TrimSpace(r.String(column))
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,66 @@
+package timeline
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_row_accessors_convert_the_scanned_values(t *testing.T) {
+	is, writer := setup(t)
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("requests", NewRow(now, Row{"status": 200, "duration": 0.25, "cached": true, "path": "/"})))
+
+	// When the rows are queried
+	rows, err := writer.Query(context.Background(), "requests", QueryOptions{})
+	is.NoErr(err)
+	row := rows[0]
+
+	// Then the columns are read without type switches
+	is.Equal(row.Int("status"), 200)
+	is.Equal(row.Float("duration"), 0.25)
+	is.Equal(row.Float("status"), 200.0)
+	is.Equal(row.Bool("cached"), true)
+	is.Equal(row.String("path"), "/")
+	is.Equal(row.String("status"), "200")
+	is.True(row.Time("timestamp").Equal(now))
+
+	// And the missing columns have a fallback
+	is.Equal(row.StringOr("level", "info"), "info")
+	is.Equal(row.IntOr("bytes", -1), -1)
+	is.Equal(row.FloatOr("path", 1.5), 1.5)
+	is.True(row.Time("level").IsZero())
+}
+
+func Test_row_accessors_convert_the_types_of_the_driver(t *testing.T) {
+	is := is.New(t)
+	row := Row{
+		"int64":   int64(42),
+		"uint8":   uint8(7),
+		"huge":    big.NewInt(123),
+		"float32": float32(1.5),
+		"bytes":   []byte("12"),
+		"text":    " 3.9 ",
+		"flag":    "true",
+		"time":    "2024-09-01 12:00:00.5",
+		"rfc3339": "2024-09-01T12:00:00Z",
+	}
+
+	is.Equal(row.Int("int64"), 42)
+	is.Equal(row.Int("uint8"), 7)
+	is.Equal(row.Int("huge"), 123)
+	is.Equal(row.Int("float32"), 1)
+	is.Equal(row.Int("bytes"), 12)
+	is.Equal(row.Int("text"), 3)
+	is.Equal(row.Float("text"), 3.9)
+	is.Equal(row.Float("huge"), 123.0)
+	is.Equal(row.String("bytes"), "12")
+	is.Equal(row.Bool("flag"), true)
+	is.Equal(row.Bool("int64"), true)
+	is.Equal(row.Time("time"), time.Date(2024, 9, 1, 12, 0, 0, 5e8, time.UTC))
+	is.Equal(row.Time("rfc3339"), time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC))
+	is.Equal(row.Int("flag"), 0)
+}
@@ -0,0 +1,246 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Rule routes, copies or alerts on the written rows that match a predicate, see SetRules
+type Rule struct {
+	// Name identifies the rule in its alerts
+	Name string
+	// Tables are the tables of the rule, all tables without tables
+	Tables []string
+	// Match selects the rows, e.g. LevelAtLeast(LevelError). The row has the flattened columns,
+	// with the aliases of SetFieldAliases.
+	Match func(row Row) bool
+	// Table is the table the matched rows are copied to, e.g. errors; none without a table
+	Table string
+	// Move writes the matched rows only to Table, not to their own table
+	Move bool
+	// Alert is called for the matched rows by the worker of the rule, one alert at a time, so
+	// writes never wait for it. When 100 alerts wait for the worker, the matched rows
+	// are counted in Suppressed of the next alert.
+	Alert func(alert Alert)
+	// Webhook is a URL the alerts are POSTed to as JSON
+	Webhook string
+	// Cooldown is the minimum time between the alerts of the rule, the matched rows in between
+	// are counted in Suppressed of the next alert. 0 alerts for every row.
+	Cooldown time.Duration
+	// OnError is called for rows that could not be copied and alerts that could not be POSTed
+	OnError func(error)
+}
+
+// Alert is a row that matched a rule with Alert or Webhook
+type Alert struct {
+	Rule  string    `json:"rule"`
+	Table string    `json:"table"`
+	Time  time.Time `json:"time"`
+	Row   Row       `json:"row"`
+	// Suppressed is the number of matched rows since the previous alert, in the cooldown or while
+	// the queue of the rule was full
+	Suppressed int `json:"suppressed"`
+}
+
+// LevelAtLeast matches the rows with a level (see NormalizeSeverity) of at least level, e.g.
+// LevelError matches error and fatal
+func LevelAtLeast(level string) func(row Row) bool {
+	minimum, ok := levelRanks[level]
+	return func(row Row) bool {
+		rank, known := levelRanks[fmt.Sprint(row["level"])]
+		return ok && known && rank >= minimum
+	}
+}
+
+// FieldEquals matches the rows where the column equals the value, numbers of any type are equal
+// when their values are equal
+func FieldEquals(column string, value any) func(row Row) bool {
+	return func(row Row) bool {
+		if a, ok := toFloat64(row[column]); ok {
+			b, ok := toFloat64(value)
+			return ok && a == b
+		}
+		return row[column] == value
+	}
+}
+
+// FieldAtLeast matches the rows where the column is a number of at least minimum, e.g. a status
+// of at least 500
+func FieldAtLeast(column string, minimum float64) func(row Row) bool {
+	return func(row Row) bool {
+		value, ok := toFloat64(row[column])
+		return ok && value >= minimum
+	}
+}
+
+// FieldMatches matches the rows where the column is a string that matches the expression
+func FieldMatches(column string, expression *regexp.Regexp) func(row Row) bool {
+	return func(row Row) bool {
+		value, ok := row[column].(string)
+		return ok && expression.MatchString(value)
+	}
+}
+
+// ruleSet holds the rules of a writer
+type ruleSet struct {
+	rules []*ruleState
+}
+
+// ruleState is a rule with the state of its cooldown and its alerts
+type ruleState struct {
+	Rule
+	mu         sync.Mutex
+	lastAlert  time.Time
+	suppressed int
+	// alerts is the queue of the worker that calls Alert and posts to Webhook, the first alert
+	// starts the worker
+	alerts chan Alert
+	// stopped is true when the rules are replaced, the worker sends the queued alerts and stops
+	stopped bool
+}
+
+// alertQueueSize is the number of alerts of a rule that can wait for its worker
+const alertQueueSize = 100
+
+// ruleWriteKey marks the context of the writes of the rules, which the rules don't apply to
+type ruleWriteKey struct{}
+
+// webhookClient posts the alerts of the rules
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// SetRules applies rules to the rows that are written from now on, by Write and everything that
+// writes with it (Ingest, pipelines, receivers and sources), for lightweight alerting at
+// ingestion: every rule that matches a row copies (or moves) the row to its table and alerts.
+// The copies aren't matched by the rules again. SetRules without rules removes the rules.
+func (w *Writer) SetRules(rules ...Rule) {
+	var set *ruleSet
+	if len(rules) > 0 {
+		set = &ruleSet{}
+		for _, rule := range rules {
+			set.rules = append(set.rules, &ruleState{Rule: rule})
+		}
+	}
+	w.rulesRef.Swap(set).stop()
+}
+
+// stop stops the workers of the rules after their queued alerts
+func (s *ruleSet) stop() {
+	if s == nil {
+		return
+	}
+	for _, rule := range s.rules {
+		rule.mu.Lock()
+		if !rule.stopped && rule.alerts != nil {
+			close(rule.alerts)
+		}
+		rule.stopped = true
+		rule.mu.Unlock()
+	}
+}
+
+// applyRules applies the rules to a row that is written to a table. moved reports whether the
+// row was moved to the table of a rule, so it isn't written to its own table.
+func (w *Writer) applyRules(ctx context.Context, table string, row Row) (moved bool, err error) {
+	set := w.rulesRef.Load()
+	if set == nil || ctx.Value(ruleWriteKey{}) != nil || ctx.Value(liveWriteKey{}) != nil {
+		return false, nil
+	}
+
+	var flattened Row
+	for _, rule := range set.rules {
+		if len(rule.Tables) > 0 && !slices.Contains(rule.Tables, table) {
+			continue
+		}
+		if flattened == nil {
+			if flattened, err = w.flatten(row); err != nil {
+				return false, err
+			}
+		}
+		if rule.Match != nil && !rule.Match(flattened) {
+			continue
+		}
+
+		rule.alert(table, flattened)
+		if rule.Table == "" {
+			continue
+		}
+		copyErr := w.WriteContext(context.WithValue(ctx, ruleWriteKey{}, rule.Name), rule.Table, row)
+		if rule.Move {
+			moved = true
+			err = errors.Join(err, copyErr)
+		} else if copyErr != nil {
+			handleError(rule.OnError, fmt.Errorf("failed to copy row of %s to %s: %w", table, rule.Table, copyErr), ErrorContext{Component: "rules", Table: rule.Table})
+		}
+	}
+	return moved, err
+}
+
+// alert queues an alert of a matched row for the worker of the rule, unless the rule is in its
+// cooldown or the queue is full
+func (r *ruleState) alert(table string, row Row) {
+	if r.Alert == nil && r.Webhook == "" {
+		return
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	if r.Cooldown > 0 && !r.lastAlert.IsZero() && now.Sub(r.lastAlert) < r.Cooldown {
+		r.suppressed++
+		return
+	}
+	if r.alerts == nil {
+		r.alerts = make(chan Alert, alertQueueSize)
+		go r.sendAlerts(r.alerts)
+	}
+	select {
+	case r.alerts <- Alert{Rule: r.Name, Table: table, Time: now, Row: maps.Clone(row), Suppressed: r.suppressed}:
+		r.lastAlert, r.suppressed = now, 0
+	default:
+		// The worker is behind, the row is counted in the next alert
+		r.suppressed++
+	}
+}
+
+// sendAlerts calls Alert and posts to Webhook for the queued alerts until the queue is closed
+func (r *ruleState) sendAlerts(alerts <-chan Alert) {
+	for alert := range alerts {
+		if r.Alert != nil {
+			r.Alert(alert)
+		}
+		if r.Webhook != "" {
+			if err := postAlert(r.Webhook, alert); err != nil {
+				handleError(r.OnError, err, ErrorContext{Component: "rules", Table: alert.Table})
+			}
+		}
+	}
+}
+
+// postAlert posts an alert as JSON to a webhook
+func postAlert(url string, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert of rule %s: %w", alert.Rule, err)
+	}
+	response, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post alert of rule %s: %w", alert.Rule, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s of the webhook of rule %s", response.Status, alert.Rule)
+	}
+	return nil
+}
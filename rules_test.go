@@ -0,0 +1,147 @@
+package timeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_rules_copy_and_move_the_matched_rows(t *testing.T) {
+	is, writer := setup(t)
+	writer.SetRules(
+		Rule{Name: "errors", Match: LevelAtLeast(LevelError), Table: "errors"},
+		Rule{Name: "health", Tables: []string{"access"}, Match: FieldMatches("path", regexp.MustCompile(`^/health`)), Table: "health", Move: true},
+	)
+
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"level": "error", "message": "failed"})))
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"level": "info", "message": "started"})))
+	is.NoErr(writer.Write("access", NewRow(time.Now(), Row{"path": "/healthz", "status": 200})))
+	is.NoErr(writer.Write("access", NewRow(time.Now(), Row{"path": "/", "status": 200})))
+
+	// The error is copied, the health check is moved
+	waitForCount(t, writer, "SELECT count(*) FROM app", 2)
+	waitForCount(t, writer, "SELECT count(*) FROM errors WHERE message = 'failed'", 1)
+	waitForCount(t, writer, "SELECT count(*) FROM access", 1)
+	waitForCount(t, writer, "SELECT count(*) FROM health WHERE path = '/healthz'", 1)
+
+	// Without rules the rows are written as they are
+	writer.SetRules()
+	is.NoErr(writer.Write("access", NewRow(time.Now(), Row{"path": "/healthz", "status": 200})))
+	waitForCount(t, writer, "SELECT count(*) FROM access", 2)
+}
+
+func Test_rules_alert_with_a_cooldown(t *testing.T) {
+	is, writer := setup(t)
+	alerts := make(chan Alert, 10)
+	writer.SetRules(Rule{
+		Name:     "server errors",
+		Match:    FieldAtLeast("http_status", 500),
+		Alert:    func(alert Alert) { alerts <- alert },
+		Cooldown: time.Hour,
+	})
+
+	// When three server errors are written within the cooldown
+	for _, status := range []int{200, 500, 502, 503} {
+		is.NoErr(writer.Write("access", NewRow(time.Now(), Row{"http": map[string]any{"status": status}})))
+	}
+
+	// Then one alert is sent, with the flattened row
+	alert := <-alerts
+	is.Equal(alert.Rule, "server errors")
+	is.Equal(alert.Table, "access")
+	is.Equal(alert.Row["http_status"], 500)
+	is.Equal(alert.Suppressed, 0)
+	select {
+	case alert := <-alerts:
+		t.Fatalf("unexpected alert %v", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+	is.Equal(writer.rulesRef.Load().rules[0].suppressed, 2)
+}
+
+func Test_rules_queue_the_alerts_of_a_slow_alert_function(t *testing.T) {
+	is, writer := setup(t)
+	alerts := make(chan Alert, 2*alertQueueSize)
+	release := make(chan struct{})
+	writer.SetRules(Rule{
+		Name:  "errors",
+		Match: LevelAtLeast(LevelError),
+		Alert: func(alert Alert) {
+			<-release
+			alerts <- alert
+		},
+	})
+
+	// When more rows match than the queue can hold, while the alert function blocks
+	for i := 0; i < 2*alertQueueSize; i++ {
+		is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"level": "error", "n": i})))
+	}
+	rule := writer.rulesRef.Load().rules[0]
+	close(release)
+	writer.SetRules()
+
+	// Then the alerts are sent one at a time and every matched row is sent or counted as suppressed
+	sent, counted := 0, 0
+	for {
+		select {
+		case alert := <-alerts:
+			sent++
+			counted += 1 + alert.Suppressed
+			continue
+		case <-time.After(100 * time.Millisecond):
+		}
+		break
+	}
+	is.True(sent <= alertQueueSize+1)
+	is.Equal(counted+rule.suppressed, 2*alertQueueSize)
+}
+
+func Test_rules_post_alerts_to_a_webhook(t *testing.T) {
+	is, writer := setup(t)
+	posted := make(chan Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("invalid alert: %v", err)
+		}
+		posted <- alert
+	}))
+	defer server.Close()
+	writer.SetRules(Rule{
+		Name:    "panics",
+		Match:   FieldEquals("level", "fatal"),
+		Webhook: server.URL,
+		OnError: func(err error) { t.Errorf("rule error: %v", err) },
+	})
+
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"level": "fatal", "message": "panic"})))
+
+	select {
+	case alert := <-posted:
+		is.Equal(alert.Rule, "panics")
+		is.Equal(alert.Row["message"], "panic")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert was posted")
+	}
+}
+
+func Test_rule_predicates(t *testing.T) {
+	is := is.New(t)
+	row := Row{"level": LevelWarn, "status": 404, "duration": 1.5, "message": "not found"}
+
+	is.True(LevelAtLeast(LevelWarn)(row))
+	is.True(!LevelAtLeast(LevelError)(row))
+	is.True(!LevelAtLeast("unknown")(row))
+	is.True(FieldEquals("status", 404.0)(row))
+	is.True(FieldEquals("message", "not found")(row))
+	is.True(!FieldEquals("missing", 1)(row))
+	is.True(FieldAtLeast("duration", 1)(row))
+	is.True(!FieldAtLeast("message", 1)(row))
+	is.True(FieldMatches("message", regexp.MustCompile("not"))(row))
+	is.True(!FieldMatches("status", regexp.MustCompile("4"))(row))
+}
@@ -0,0 +1,111 @@
+package timeline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// s3AccessLogFields are the column names of the S3 server access log fields, in order
+var s3AccessLogFields = []string{
+	"bucket_owner", "bucket", "timestamp", "remote_host", "requester", "request_id", "operation", "key",
+	"request", "status", "error_code", "response_size", "object_size", "total_time", "turn_around_time",
+	"referer", "user_agent", "version_id", "host_id", "signature_version", "cipher_suite",
+	"authentication_type", "host_header", "tls_version", "access_point_arn", "acl_required",
+}
+
+// parseS3Access parses an Amazon S3 server access log line.
+// Fields are separated by spaces, the timestamp is in brackets and the request, referer and user agent are quoted.
+// Example: 79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be awsexamplebucket1 [06/Feb/2019:00:00:38 +0000] 192.0.2.3 79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be 3E57427F3EXAMPLE REST.GET.VERSIONING - "GET /awsexamplebucket1?versioning HTTP/1.1" 200 - 113 - 7 - "-" "S3Console/0.4" - s9lzHYrFp76ZVxRcpX9+5cjAnEH2ROuNkd2BHfIa6UkFVdtjf5mKR3/eTPFvsiP/XV/VLi31234= SigV2 ECDHE-RSA-AES128-GCM-SHA256 AuthHeader awsexamplebucket1.s3.us-west-1.amazonaws.com TLSV1.1 - Yes
+// Fields: bucket_owner, bucket, timestamp, remote_host, requester, request_id, operation, key, method, path,
+// protocol, status, error_code, response_size, object_size, total_time, turn_around_time, referer, user_agent,
+// version_id, host_id, signature_version, cipher_suite, authentication_type, host_header, tls_version,
+// access_point_arn, acl_required
+func parseS3Access(l string) Row {
+	// Lines that don't start with the bucket owner skip the split
+	if owner, _, _ := strings.Cut(strings.TrimLeft(l, " "), " "); !isCanonicalUserID(owner) {
+		return nil
+	}
+
+	fields := splitS3Fields(l)
+	// Older logs end after the user agent or version id
+	if len(fields) < 17 || !isCanonicalUserID(fields[0]) || !strings.HasPrefix(fields[2], "[") {
+		return nil
+	}
+
+	result := make(Row)
+	for i, value := range fields {
+		if i >= len(s3AccessLogFields) {
+			break
+		}
+		value = strings.Trim(value, `"`)
+		if value == "-" || value == "" {
+			continue
+		}
+
+		column := s3AccessLogFields[i]
+		switch column {
+		case "timestamp":
+			result[column] = strings.Trim(value, "[]")
+		case "request":
+			requestParts := strings.Split(value, " ")
+			if len(requestParts) == 3 {
+				result["method"] = requestParts[0]
+				result["path"] = requestParts[1]
+				result["protocol"] = requestParts[2]
+			} else {
+				result[column] = value
+			}
+		case "status", "response_size", "object_size", "total_time", "turn_around_time":
+			if number, err := strconv.Atoi(value); err == nil {
+				result[column] = number
+			}
+		default:
+			result[column] = value
+		}
+	}
+	return result
+}
+
+// splitS3Fields splits a line on spaces, keeping [bracketed] and "quoted" values together
+func splitS3Fields(l string) []string {
+	var fields []string
+	for i := 0; i < len(l); {
+		if l[i] == ' ' {
+			i++
+			continue
+		}
+
+		end := -1
+		switch l[i] {
+		case '[':
+			end = strings.IndexByte(l[i+1:], ']')
+		case '"':
+			end = strings.IndexByte(l[i+1:], '"')
+		}
+		if end != -1 {
+			// Include the closing character
+			end = i + 1 + end + 1
+		} else if space := strings.IndexByte(l[i:], ' '); space != -1 {
+			end = i + space
+		} else {
+			end = len(l)
+		}
+
+		fields = append(fields, l[i:end])
+		i = end
+	}
+	return fields
+}
+
+// isCanonicalUserID reports whether the value is a 64 character hexadecimal AWS canonical user id
+func isCanonicalUserID(value string) bool {
+	if len(value) != 64 {
+		return false
+	}
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_s3_access_log_line(t *testing.T) {
+	is := is.New(t)
+	line := `79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be awsexamplebucket1 [06/Feb/2019:00:00:38 +0000] 192.0.2.3 79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be 3E57427F3EXAMPLE REST.GET.VERSIONING - "GET /awsexamplebucket1?versioning HTTP/1.1" 200 - 113 - 7 - "-" "S3Console/0.4" - s9lzHYrFp76ZVxRcpX9+5cjAnEH2ROuNkd2BHfIa6UkFVdtjf5mKR3/eTPFvsiP/XV/VLi31234= SigV2 ECDHE-RSA-AES128-GCM-SHA256 AuthHeader awsexamplebucket1.s3.us-west-1.amazonaws.com TLSV1.1 - Yes`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["bucket_owner"], "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be")
+	is.Equal(data["bucket"], "awsexamplebucket1")
+	is.Equal(data["timestamp"], "06/Feb/2019:00:00:38 +0000")
+	is.Equal(data["remote_host"], "192.0.2.3")
+	is.Equal(data["operation"], "REST.GET.VERSIONING")
+	is.Equal(data["method"], "GET")
+	is.Equal(data["path"], "/awsexamplebucket1?versioning")
+	is.Equal(data["protocol"], "HTTP/1.1")
+	is.Equal(data["status"], 200)
+	is.Equal(data["response_size"], 113)
+	is.Equal(data["total_time"], 7)
+	is.Equal(data["user_agent"], "S3Console/0.4")
+	is.Equal(data["signature_version"], "SigV2")
+	is.Equal(data["tls_version"], "TLSV1.1")
+	is.Equal(data["acl_required"], "Yes")
+	_, hasKey := data["key"]
+	is.True(!hasKey)
+	_, hasReferer := data["referer"]
+	is.True(!hasReferer)
+}
+
+func Test_parse_s3_access_log_requires_canonical_user_id(t *testing.T) {
+	is := is.New(t)
+	line := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`
+
+	data := parseS3Access(line)
+
+	is.Equal(data, nil)
+}
@@ -0,0 +1,97 @@
+package timeline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SchemaEventKind is the kind of change of a SchemaEvent
+type SchemaEventKind string
+
+const (
+	// TableCreated is a table (or a daily partition) created by a written row
+	TableCreated SchemaEventKind = "table_created"
+	// ColumnAdded is a column added by a written row
+	ColumnAdded SchemaEventKind = "column_added"
+	// ColumnPromoted is a column of which the type is promoted by a written row
+	ColumnPromoted SchemaEventKind = "column_promoted"
+	// TableDropped is a table dropped by DropTable
+	TableDropped SchemaEventKind = "table_dropped"
+	// TableRenamed is a table renamed by RenameTable
+	TableRenamed SchemaEventKind = "table_renamed"
+	// ColumnRenamed is a column renamed by RenameColumn
+	ColumnRenamed SchemaEventKind = "column_renamed"
+	// ColumnDropped is a column dropped by DropColumn
+	ColumnDropped SchemaEventKind = "column_dropped"
+	// ColumnTypeChanged is a column of which the type is changed by SetColumnType
+	ColumnTypeChanged SchemaEventKind = "column_type_changed"
+)
+
+// SchemaEvent is a change of the schema of a table, see SchemaEvents
+type SchemaEvent struct {
+	Kind SchemaEventKind
+	// Table is the changed table, the partitioned table for a daily partition
+	Table string
+	// Partition is the changed daily partition of a partitioned table, empty for other tables
+	Partition string
+	Column    string
+	// Type is the type of an added column, or the new type of a promoted or changed column
+	Type ColumnType
+	// From is the previous type of a promoted column
+	From ColumnType
+	// Name is the new name of a renamed table or column
+	Name string
+	Time time.Time
+}
+
+// schemaEventBuffer is the number of events a subscriber can lag behind before events are dropped
+const schemaEventBuffer = 256
+
+// schemaSubscribers are the subscriptions of SchemaEvents
+type schemaSubscribers struct {
+	mu   sync.Mutex
+	subs map[chan SchemaEvent]struct{}
+}
+
+// SchemaEvents returns a channel with the changes of the schema of the tables from now on, e.g. to
+// invalidate a cache of the columns or to alert on new columns. Writes never wait for a subscriber:
+// when a subscriber lags more than 256 events behind, new events are dropped for it. The channel
+// is closed when ctx is done.
+func (w *Writer) SchemaEvents(ctx context.Context) <-chan SchemaEvent {
+	events := make(chan SchemaEvent, schemaEventBuffer)
+
+	w.schemaSubscribers.mu.Lock()
+	if w.schemaSubscribers.subs == nil {
+		w.schemaSubscribers.subs = make(map[chan SchemaEvent]struct{})
+	}
+	w.schemaSubscribers.subs[events] = struct{}{}
+	w.schemaSubscribers.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		w.schemaSubscribers.mu.Lock()
+		defer w.schemaSubscribers.mu.Unlock()
+		delete(w.schemaSubscribers.subs, events)
+		close(events)
+	})
+	return events
+}
+
+// schemaChanged publishes a change of the schema of a stored table (a table or a partition)
+func (w *Writer) schemaChanged(table string, event SchemaEvent) {
+	event.Table = table
+	if partitioned, _, ok := w.partitionOf(table); ok {
+		event.Table = partitioned
+		event.Partition = table
+	}
+	event.Time = time.Now()
+
+	w.schemaSubscribers.mu.Lock()
+	defer w.schemaSubscribers.mu.Unlock()
+	for events := range w.schemaSubscribers.subs {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// nextSchemaEvent returns the next event without its time
+func nextSchemaEvent(t *testing.T, events <-chan SchemaEvent) SchemaEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		if event.Time.IsZero() {
+			t.Fatalf("event %s without time", event.Kind)
+		}
+		event.Time = time.Time{}
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("no schema event")
+		return SchemaEvent{}
+	}
+}
+
+func Test_schema_events_of_written_rows(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	events := writer.SchemaEvents(ctx)
+
+	// When a row creates a table with a column and another row promotes the column
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"status": 200})))
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"status": "ok"})))
+
+	// Then the changes are published
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: TableCreated, Table: "app"})
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: ColumnAdded, Table: "app", Column: "status", Type: Utinyint})
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: ColumnPromoted, Table: "app", Column: "status", Type: Varchar, From: Utinyint})
+
+	// And the channel is closed when ctx is done
+	cancel()
+	_, open := <-events
+	is.True(!open)
+}
+
+func Test_schema_events_of_migrations(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"status": 200, "user": "ann"})))
+	events := writer.SchemaEvents(ctx)
+
+	is.NoErr(writer.RenameColumn(ctx, "app", "status", "code"))
+	is.NoErr(writer.SetColumnType(ctx, "app", "code", Bigint, ""))
+	is.NoErr(writer.DropColumn(ctx, "app", "user"))
+	is.NoErr(writer.RenameTable(ctx, "app", "web"))
+	is.NoErr(writer.DropTable(ctx, "web"))
+
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: ColumnRenamed, Table: "app", Column: "status", Name: "code"})
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: ColumnTypeChanged, Table: "app", Column: "code", Type: Bigint})
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: ColumnDropped, Table: "app", Column: "user"})
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: TableRenamed, Table: "app", Name: "web"})
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: TableDropped, Table: "web"})
+}
+
+func Test_schema_events_of_daily_partitions(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("app")
+	events := writer.SchemaEvents(context.Background())
+
+	now := time.Now()
+	is.NoErr(writer.Write("app", NewRow(now, Row{"message": "hello"})))
+
+	partition := partitionName("app", now)
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: TableCreated, Table: "app", Partition: partition})
+	is.Equal(nextSchemaEvent(t, events), SchemaEvent{Kind: ColumnAdded, Table: "app", Partition: partition, Column: "message", Type: Varchar})
+}
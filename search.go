@@ -0,0 +1,77 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Search returns at most limit rows (0 means no limit) of a table from (inclusive) to (exclusive)
+// with a text column that contains the text (case insensitive), in chronological order. The name of
+// the matching column (message first) is in the _match column of the rows.
+//
+// The search uses ILIKE instead of the full-text search extension of DuckDB, because the index of
+// that extension isn't updated when rows are written.
+func (w *Writer) Search(ctx context.Context, table string, text string, from, to time.Time, limit int) ([]Row, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	columns, err := w.tableColumns(table)
+	if err != nil {
+		return nil, err
+	}
+	source, _, err := w.tableSource(table, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var textColumns []string
+	for name, columnType := range columns {
+		if columnType == Varchar || columnType == Json {
+			textColumns = append(textColumns, name)
+		}
+	}
+	if len(textColumns) == 0 {
+		return nil, nil
+	}
+	sort.Slice(textColumns, func(i, j int) bool {
+		if (textColumns[i] == "message") != (textColumns[j] == "message") {
+			return textColumns[i] == "message"
+		}
+		return textColumns[i] < textColumns[j]
+	})
+
+	pattern := "%" + escapeLike(text) + "%"
+	var matches, cases []string
+	var caseArgs, matchArgs []any
+	for _, column := range textColumns {
+		condition := quoteIdentifier(column) + ` ILIKE ? ESCAPE '\'`
+		cases = append(cases, fmt.Sprintf("WHEN %s THEN '%s'", condition, strings.ReplaceAll(column, "'", "''")))
+		caseArgs = append(caseArgs, pattern)
+		matches = append(matches, condition)
+		matchArgs = append(matchArgs, pattern)
+	}
+
+	var query strings.Builder
+	fmt.Fprintf(&query, "SELECT *, CASE %s END AS _match FROM %s WHERE timestamp >= ? AND timestamp < ? AND (%s) ORDER BY timestamp",
+		strings.Join(cases, " "), source, strings.Join(matches, " OR "))
+	if limit > 0 {
+		fmt.Fprintf(&query, " LIMIT %d", limit)
+	}
+	args := append(caseArgs, from.UTC(), to.UTC())
+	args = append(args, matchArgs...)
+
+	rows, err := w.DB.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", table, err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
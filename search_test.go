@@ -0,0 +1,46 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_search_finds_text_in_varchar_columns(t *testing.T) {
+	is, writer := setup(t)
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("app", NewRow(start, Row{"message": "Upstream TIMEOUT after 30s", "path": "/api"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(time.Minute), Row{"message": "request handled", "path": "/timeout-test"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(2*time.Minute), Row{"message": "100% done", "status": 200})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(time.Hour), Row{"message": "timeout again"})))
+
+	rows, err := writer.Search(context.Background(), "app", "timeout", start, start.Add(time.Hour), 0)
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.Equal(rows[0]["_match"], "message")
+	is.Equal(rows[1]["_match"], "path")
+	is.Equal(rows[1]["timestamp"], start.Add(time.Minute))
+
+	// Wildcards in the text are matched literally
+	rows, err = writer.Search(context.Background(), "app", "0% d", start, start.Add(time.Hour), 10)
+	is.NoErr(err)
+	is.Equal(len(rows), 1)
+	rows, err = writer.Search(context.Background(), "app", "_", start, start.Add(time.Hour), 10)
+	is.NoErr(err)
+	is.Equal(len(rows), 0)
+}
+
+func Test_search_of_a_partitioned_table(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("app")
+	start := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	is.NoErr(writer.Write("app", NewRow(start, Row{"message": "upstream timeout"})))
+	is.NoErr(writer.Write("app", NewRow(start.Add(24*time.Hour), Row{"error": "timeout again"})))
+
+	rows, err := writer.Search(context.Background(), "app", "timeout", start, start.Add(48*time.Hour), 0)
+
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.Equal(rows[0]["_match"], "message")
+	is.Equal(rows[1]["_match"], "error")
+}
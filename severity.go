@@ -0,0 +1,197 @@
+package timeline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Canonical severity levels, ordered by their rank
+const (
+	LevelTrace = "trace"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// levelRanks gives every canonical level a numeric rank so rows can be filtered with level_rank >= 3
+var levelRanks = map[string]int{
+	LevelTrace: 0,
+	LevelDebug: 1,
+	LevelInfo:  2,
+	LevelWarn:  3,
+	LevelError: 4,
+	LevelFatal: 5,
+}
+
+// levelNames maps free-text levels of the common logging libraries to the canonical level
+var levelNames = map[string]string{
+	// Trace
+	"trace": LevelTrace, "trc": LevelTrace, "finest": LevelTrace, "finer": LevelTrace, "verbose": LevelTrace,
+	// Debug
+	"debug": LevelDebug, "dbg": LevelDebug, "fine": LevelDebug, "config": LevelDebug,
+	// Info
+	"info": LevelInfo, "inf": LevelInfo, "information": LevelInfo, "informational": LevelInfo,
+	"notice": LevelInfo, "ok": LevelInfo,
+	// Warn
+	"warn": LevelWarn, "wrn": LevelWarn, "warning": LevelWarn,
+	// Error
+	"error": LevelError, "err": LevelError, "eror": LevelError, "severe": LevelError,
+	// Fatal
+	"fatal": LevelFatal, "ftl": LevelFatal, "crit": LevelFatal, "critical": LevelFatal, "alert": LevelFatal,
+	"emerg": LevelFatal, "emergency": LevelFatal, "panic": LevelFatal, "dpanic": LevelFatal,
+}
+
+// mongoSeverities maps the single letter severities of MongoDB (and glog/klog) to the canonical level
+var mongoSeverities = map[string]string{
+	"d": LevelDebug,
+	"i": LevelInfo,
+	"w": LevelWarn,
+	"e": LevelError,
+	"f": LevelFatal,
+}
+
+// levelColumns are checked in order for the severity of a row, the level_name of Monolog before its
+// numeric level
+var levelColumns = []string{"level_name", "level", "lvl", "log_level", "loglevel", "severity_text", "severity"}
+
+// NormalizeSeverity sets a canonical level column (trace, debug, info, warn, error or fatal) and a numeric
+// level_rank column (0 for trace up to 5 for fatal), so rows of different sources can be filtered by severity.
+// The level is derived from (in order) free-text levels like WARNING or crit, Bunyan/Pino numbers (10-60),
+// Monolog numbers (100-600), syslog and RFC5424 severity numbers (0-7), MongoDB severities like I or D2 and finally the HTTP status code.
+// Rows without a recognizable severity are returned unchanged.
+func NormalizeSeverity(data Row) Row {
+	for _, column := range levelColumns {
+		value, ok := data[column]
+		if !ok {
+			continue
+		}
+		if level, ok := canonicalLevel(column, value); ok {
+			data["level"] = level
+			data["level_rank"] = levelRanks[level]
+			return data
+		}
+	}
+
+	if level, ok := levelFromStatus(data["status"]); ok {
+		data["level"] = level
+		data["level_rank"] = levelRanks[level]
+	}
+	return data
+}
+
+// canonicalLevel converts the value of a level column to the canonical level
+func canonicalLevel(column string, value any) (string, bool) {
+	switch v := value.(type) {
+	case int:
+		return levelFromNumber(column, v)
+	case float64:
+		if v == float64(int(v)) {
+			return levelFromNumber(column, int(v))
+		}
+	case string:
+		return levelFromString(column, v)
+	}
+	return "", false
+}
+
+// levelFromString converts free-text levels, numbers in strings and MongoDB severities
+func levelFromString(column string, value string) (string, bool) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	if level, ok := levelNames[name]; ok {
+		return level, true
+	}
+	if number, err := strconv.Atoi(name); err == nil {
+		return levelFromNumber(column, number)
+	}
+
+	// MongoDB uses D1 till D5 for the debug verbosity levels
+	if len(name) > 0 && (len(name) == 1 || name[0] == 'd' && isDigits(name[1:])) {
+		level, ok := mongoSeverities[name[:1]]
+		return level, ok
+	}
+	return "", false
+}
+
+// levelFromNumber converts syslog severities (0 emergency - 7 debug), Bunyan/Pino levels (10 trace - 60 fatal)
+// and Monolog levels (100 debug - 600 emergency)
+func levelFromNumber(column string, number int) (string, bool) {
+	if number >= 0 && number <= 7 {
+		switch {
+		case number <= 2:
+			return LevelFatal, true
+		case number == 3:
+			return LevelError, true
+		case number == 4:
+			return LevelWarn, true
+		case number <= 6:
+			return LevelInfo, true
+		}
+		return LevelDebug, true
+	}
+	if column == "severity" {
+		return "", false
+	}
+
+	// Monolog: 250 is notice, 500 critical, 550 alert and 600 emergency
+	if number >= 100 {
+		switch {
+		case number > 600:
+			return "", false
+		case number >= 500:
+			return LevelFatal, true
+		case number >= 400:
+			return LevelError, true
+		case number >= 300:
+			return LevelWarn, true
+		case number >= 200:
+			return LevelInfo, true
+		}
+		return LevelDebug, true
+	}
+
+	switch {
+	case number >= 60:
+		return LevelFatal, true
+	case number >= 50:
+		return LevelError, true
+	case number >= 40:
+		return LevelWarn, true
+	case number >= 30:
+		return LevelInfo, true
+	case number >= 20:
+		return LevelDebug, true
+	case number >= 10:
+		return LevelTrace, true
+	}
+	return "", false
+}
+
+// levelFromStatus derives the level of access logs: 5xx is an error, 4xx a warning and everything else info
+func levelFromStatus(status any) (string, bool) {
+	code, ok := status.(int)
+	if !ok || code < 100 || code > 599 {
+		return "", false
+	}
+	switch {
+	case code >= 500:
+		return LevelError, true
+	case code >= 400:
+		return LevelWarn, true
+	}
+	return LevelInfo, true
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_normalize_severity_of_free_text_levels(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(NormalizeSeverity(Row{"level": "WARNING"})["level"], LevelWarn)
+	is.Equal(NormalizeSeverity(Row{"level": "crit"})["level"], LevelFatal)
+	is.Equal(NormalizeSeverity(Row{"level": "NOTICE"})["level"], LevelInfo)
+	is.Equal(NormalizeSeverity(Row{"lvl": "eror"})["level"], LevelError)
+	is.Equal(NormalizeSeverity(Row{"level": "SEVERE"})["level_rank"], 4)
+}
+
+func Test_normalize_severity_from_monolog_line(t *testing.T) {
+	is := is.New(t)
+
+	data := NormalizeSeverity(ParseLineToValues(`[2025-09-21 10:00:00] app.WARNING: Disk almost full {"free_mb":120}`))
+
+	is.Equal(data["level"], LevelWarn)
+	is.Equal(data["level_rank"], 3)
+}
+
+func Test_normalize_severity_from_syslog_severity(t *testing.T) {
+	is := is.New(t)
+
+	data := NormalizeSeverity(ParseLineToValues(`<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed for lonvick on /dev/pts/8`))
+
+	is.Equal(data["severity"], 2)
+	is.Equal(data["level"], LevelFatal)
+	is.Equal(data["level_rank"], 5)
+}
+
+func Test_normalize_severity_of_monolog_json_levels(t *testing.T) {
+	is := is.New(t)
+	levels := []struct {
+		number int
+		name   string
+		level  string
+	}{
+		{100, "DEBUG", LevelDebug},
+		{200, "INFO", LevelInfo},
+		{250, "NOTICE", LevelInfo},
+		{300, "WARNING", LevelWarn},
+		{400, "ERROR", LevelError},
+		{500, "CRITICAL", LevelFatal},
+		{550, "ALERT", LevelFatal},
+		{600, "EMERGENCY", LevelFatal},
+	}
+	for _, monolog := range levels {
+		// The numeric level alone and with the level_name of Monolog
+		is.Equal(NormalizeSeverity(Row{"level": monolog.number})["level"], monolog.level)                             // number
+		is.Equal(NormalizeSeverity(Row{"level": float64(monolog.number)})["level"], monolog.level)                    // JSON number
+		is.Equal(NormalizeSeverity(Row{"level": monolog.number, "level_name": monolog.name})["level"], monolog.level) // name
+	}
+}
+
+func Test_normalize_severity_of_numeric_levels(t *testing.T) {
+	is := is.New(t)
+
+	// Bunyan and Pino use 10 (trace) till 60 (fatal)
+	is.Equal(NormalizeSeverity(Row{"level": 30})["level"], LevelInfo)
+	is.Equal(NormalizeSeverity(Row{"level": 50.0})["level"], LevelError)
+	is.Equal(NormalizeSeverity(Row{"severity": 7})["level"], LevelDebug)
+	is.Equal(NormalizeSeverity(Row{"severity": 42})["level"], nil)
+}
+
+func Test_normalize_severity_of_mongodb_severity(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(NormalizeSeverity(Row{"severity": "I"})["level"], LevelInfo)
+	is.Equal(NormalizeSeverity(Row{"severity": "D2"})["level"], LevelDebug)
+	is.Equal(NormalizeSeverity(Row{"severity": "E"})["level"], LevelError)
+}
+
+func Test_normalize_severity_from_status_code(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(NormalizeSeverity(Row{"status": 503})["level"], LevelError)
+	is.Equal(NormalizeSeverity(Row{"status": 404})["level"], LevelWarn)
+	is.Equal(NormalizeSeverity(Row{"status": 200})["level"], LevelInfo)
+	// An explicit level wins over the status code
+	is.Equal(NormalizeSeverity(Row{"status": 500, "level": "info"})["level"], LevelInfo)
+}
+
+func Test_normalize_severity_leaves_unknown_rows_unchanged(t *testing.T) {
+	is := is.New(t)
+
+	data := NormalizeSeverity(Row{"message": "hello", "level": "loud"})
+
+	is.Equal(data["level"], "loud")
+	_, hasRank := data["level_rank"]
+	is.True(!hasRank)
+}
@@ -0,0 +1,99 @@
+package timeline
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errSnappyCorrupt = errors.New("corrupt snappy block")
+
+// decodeSnappy decodes a snappy compressed block (not the framed stream format),
+// as used by the Loki push API and Prometheus remote write. The decoded block may not
+// exceed maxLokiRequestSize, nor the length declared in its header.
+func decodeSnappy(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || length > maxLokiRequestSize {
+		return nil, errSnappyCorrupt
+	}
+	src = src[n:]
+	dst := make([]byte, 0, length)
+
+	for len(src) > 0 {
+		tag := src[0]
+		switch tag & 3 {
+		case 0:
+			// Literal, the length is stored in the tag or in the 1-4 bytes after it
+			literalLength := int(tag >> 2)
+			src = src[1:]
+			if literalLength >= 60 {
+				extra := literalLength - 59
+				if len(src) < extra {
+					return nil, errSnappyCorrupt
+				}
+				literalLength = 0
+				for i := extra - 1; i >= 0; i-- {
+					literalLength = literalLength<<8 | int(src[i])
+				}
+				src = src[extra:]
+			}
+			literalLength++
+			if literalLength > len(src) || uint64(len(dst)+literalLength) > length {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, src[:literalLength]...)
+			src = src[literalLength:]
+			continue
+		case 1:
+			// Copy with a 1 byte offset
+			if len(src) < 2 {
+				return nil, errSnappyCorrupt
+			}
+			copyLength := 4 + int(tag>>2)&7
+			offset := int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+			if err := snappyCopy(&dst, offset, copyLength, length); err != nil {
+				return nil, err
+			}
+		case 2:
+			// Copy with a 2 byte offset
+			if len(src) < 3 {
+				return nil, errSnappyCorrupt
+			}
+			copyLength := 1 + int(tag>>2)
+			offset := int(binary.LittleEndian.Uint16(src[1:3]))
+			src = src[3:]
+			if err := snappyCopy(&dst, offset, copyLength, length); err != nil {
+				return nil, err
+			}
+		case 3:
+			// Copy with a 4 byte offset
+			if len(src) < 5 {
+				return nil, errSnappyCorrupt
+			}
+			copyLength := 1 + int(tag>>2)
+			offset := int(binary.LittleEndian.Uint32(src[1:5]))
+			src = src[5:]
+			if err := snappyCopy(&dst, offset, copyLength, length); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if uint64(len(dst)) != length {
+		return nil, errSnappyCorrupt
+	}
+	return dst, nil
+}
+
+// snappyCopy appends length bytes starting offset bytes back; the ranges may overlap.
+// The copy may not grow dst beyond the declared length of the block.
+func snappyCopy(dst *[]byte, offset, length int, declared uint64) error {
+	if offset <= 0 || offset > len(*dst) || uint64(len(*dst)+length) > declared {
+		return errSnappyCorrupt
+	}
+	start := len(*dst) - offset
+	for i := 0; i < length; i++ {
+		*dst = append(*dst, (*dst)[start+i])
+	}
+	return nil
+}
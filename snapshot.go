@@ -0,0 +1,100 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// snapshotSeq numbers the aliases of the attached snapshots, so snapshots can be taken concurrently
+var snapshotSeq atomic.Int64
+
+// SnapshotTo copies the tables of the database (with their rows, columns and types) to a new
+// database file, e.g. to capture the state of a test or of a bug and reproduce it with
+// LoadSnapshot. The file must not exist. The attached databases are not copied.
+func (w *Writer) SnapshotTo(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("failed to snapshot to %s: the file exists", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to snapshot to %s: %w", path, err)
+	}
+
+	return w.withSnapshot(ctx, path, false, func(current, snapshot string) error {
+		statement := fmt.Sprintf("COPY FROM DATABASE %s TO %s", quoteIdentifier(current), quoteIdentifier(snapshot))
+		if _, err := w.DB.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to snapshot to %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// LoadSnapshot replaces all tables of the database with the tables of a snapshot of SnapshotTo.
+// The configuration of the writer, e.g. PartitionDaily, SetRules and ArchiveTo, is kept. The
+// tables are kept as well when the snapshot can't be loaded.
+func (w *Writer) LoadSnapshot(ctx context.Context, path string) error {
+	if w.readOnly {
+		return ErrReadOnly
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to load snapshot %s: %w", path, err)
+	}
+
+	return w.withSnapshot(ctx, path, true, func(current, snapshot string) error {
+		// All tables, also the internal tables, see ddlAuditTable
+		rows, err := w.DB.QueryContext(ctx, "SELECT table_name FROM information_schema.tables WHERE table_catalog = current_database() AND table_schema = 'main' AND table_type = 'BASE TABLE'")
+		if err != nil {
+			return fmt.Errorf("failed to list tables: %w", err)
+		}
+		var tables []string
+		for rows.Next() {
+			var table string
+			if err := rows.Scan(&table); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan table name: %w", err)
+			}
+			tables = append(tables, table)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to list tables: %w", err)
+		}
+
+		// The tables are dropped and copied in one transaction, a failed copy keeps the tables
+		tx, err := w.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin loading snapshot %s: %w", path, err)
+		}
+		defer tx.Rollback()
+
+		for _, table := range tables {
+			if _, err := tx.ExecContext(ctx, "DROP TABLE "+quoteIdentifier(table)); err != nil {
+				return fmt.Errorf("failed to drop table %s: %w", table, err)
+			}
+		}
+		statement := fmt.Sprintf("COPY FROM DATABASE %s TO %s", quoteIdentifier(snapshot), quoteIdentifier(current))
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to load snapshot %s: %w", path, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit snapshot %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// withSnapshot attaches a snapshot file during fn, with the name of the database of the writer and
+// the alias of the snapshot
+func (w *Writer) withSnapshot(ctx context.Context, path string, readOnly bool, fn func(current, snapshot string) error) error {
+	var current string
+	if err := w.DB.QueryRowContext(ctx, "SELECT current_database()").Scan(&current); err != nil {
+		return fmt.Errorf("failed to get database name: %w", err)
+	}
+	alias := fmt.Sprintf("_timeline_snapshot_%d", snapshotSeq.Add(1))
+	if err := w.Attach(ctx, path, alias, readOnly); err != nil {
+		return err
+	}
+	err := fn(current, alias)
+	return errors.Join(err, w.Detach(ctx, alias))
+}
@@ -0,0 +1,75 @@
+package timeline
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_snapshot_restores_the_state_of_the_database(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	// Given a snapshot of a table
+	is.NoErr(writer.Write("app", NewRow(now, Row{"message": "started", "status": 200})))
+	is.NoErr(writer.SnapshotTo(ctx, path))
+
+	// When the database changes after the snapshot
+	is.NoErr(writer.Write("app", NewRow(now.Add(time.Second), Row{"message": "stopped", "status": "unknown"})))
+	is.NoErr(writer.Write("other", NewRow(now, Row{"message": "new table"})))
+
+	// Then loading the snapshot restores the rows and the columns
+	is.NoErr(writer.LoadSnapshot(ctx, path))
+	rows, err := writer.Query(ctx, "app", QueryOptions{Columns: []string{"message", "status"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "started", "status": 200}})
+	tables, err := writer.tableNames(ctx)
+	is.NoErr(err)
+	is.Equal(tables, []string{"app"})
+
+	// And the snapshot is loaded in another database the same way
+	other, err := NewMemoryClient()
+	is.NoErr(err)
+	defer other.Close()
+	is.NoErr(other.LoadSnapshot(ctx, path))
+	rows, err = other.Query(ctx, "app", QueryOptions{Columns: []string{"message", "status"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "started", "status": 200}})
+}
+
+func Test_snapshot_does_not_overwrite_a_file(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+	is.NoErr(writer.SnapshotTo(ctx, path))
+
+	is.True(writer.SnapshotTo(ctx, path) != nil)
+	is.True(writer.LoadSnapshot(ctx, filepath.Join(t.TempDir(), "missing.db")) != nil)
+}
+
+func Test_snapshot_that_fails_to_load_keeps_the_tables(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	// Given a snapshot with a macro that also exists in the database, which can't be copied
+	_, err := writer.DB.Exec("CREATE MACRO double(x) AS x * 2")
+	is.NoErr(err)
+	is.NoErr(writer.Write("app", NewRow(now, Row{"message": "snapshot"})))
+	is.NoErr(writer.SnapshotTo(ctx, path))
+	is.NoErr(writer.Write("app", NewRow(now.Add(time.Second), Row{"message": "current"})))
+
+	// When the snapshot is loaded
+	err = writer.LoadSnapshot(ctx, path)
+
+	// Then it fails and the tables are left as they were
+	is.True(err != nil)
+	rows, err := writer.Query(ctx, "app", QueryOptions{Columns: []string{"message"}})
+	is.NoErr(err)
+	is.Equal(rows, []Row{{"message": "snapshot"}, {"message": "current"}})
+}
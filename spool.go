@@ -0,0 +1,302 @@
+package timeline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SpoolConfig configures a Spool
+type SpoolConfig struct {
+	// Dir is the directory of the segment files, it is created when it doesn't exist
+	Dir string
+	// SegmentSize is the size from which a new segment file is started, defaults to 16 MiB.
+	// A segment is removed when all its records are committed.
+	SegmentSize int64
+	// Sync writes every record to the disk (fsync) before Append returns, so the records also
+	// survive a crash of the machine. Without Sync they survive a crash of the process.
+	Sync bool
+}
+
+// Spool is a crash-safe write-ahead log for rows that wait in memory to be written, e.g. the
+// batch of a Pipeline (see PipelineConfig.Spool) or the pending rows of a Replicator (see
+// ReplicationConfig.Spool). A record is appended before it is acknowledged and committed once it
+// is written; the records that were not committed when the process stopped are replayed on
+// startup. Records can be written twice this way (at least once), they are never lost.
+//
+// The records are stored as JSON lines in segment files named after the sequence number of their
+// first record, the last committed sequence number is stored in the file committed.
+type Spool struct {
+	config SpoolConfig
+
+	mu sync.Mutex
+	// segments are the first sequence numbers of the segment files, in order
+	segments []int64
+	// file is the segment that records are appended to, nil until the next Append
+	file *os.File
+	size int64
+	// next is the sequence number of the next record
+	next      int64
+	committed int64
+}
+
+// spoolSegmentExt is the extension of the segment files
+const spoolSegmentExt = ".ndjson"
+
+// OpenSpool opens the spool in the directory of the config. The records of a previous process
+// that were not committed are returned by Replay.
+func OpenSpool(config SpoolConfig) (*Spool, error) {
+	if config.Dir == "" {
+		return nil, errors.New("a spool needs a directory")
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = 16 << 20
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{config: config, next: 1}
+	if data, err := os.ReadFile(s.committedPath()); err == nil {
+		if s.committed, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid committed file of spool %s: %w", config.Dir, err)
+		}
+		s.next = s.committed + 1
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read committed file of spool %s: %w", config.Dir, err)
+	}
+
+	entries, err := os.ReadDir(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), spoolSegmentExt)
+		if !ok {
+			continue
+		}
+		if start, err := strconv.ParseInt(name, 10, 64); err == nil {
+			s.segments = append(s.segments, start)
+		}
+	}
+	slices.Sort(s.segments)
+
+	// The records of the last segment continue the sequence, a record that was written partly
+	// when the process crashed is removed
+	if len(s.segments) > 0 {
+		last := s.segments[len(s.segments)-1]
+		count, err := repairSegment(s.segmentPath(last))
+		if err != nil {
+			return nil, err
+		}
+		s.next = max(s.next, last+int64(count))
+	}
+	if len(s.segments) > 0 && s.segments[len(s.segments)-1] == s.next {
+		// An empty segment, Append creates it again
+		os.Remove(s.segmentPath(s.next))
+		s.segments = s.segments[:len(s.segments)-1]
+	}
+	if err := s.removeCommittedSegments(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Append appends a record (any JSON value) and returns its sequence number. The record is written
+// to the segment file (and synced to the disk with Sync) when Append returns.
+func (s *Spool) Append(record any) (int64, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode spool record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		file, err := os.OpenFile(s.segmentPath(s.next), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create spool segment: %w", err)
+		}
+		s.file, s.size = file, 0
+		s.segments = append(s.segments, s.next)
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return 0, fmt.Errorf("failed to append to spool: %w", err)
+	}
+	if s.config.Sync {
+		if err := s.file.Sync(); err != nil {
+			return 0, fmt.Errorf("failed to sync spool: %w", err)
+		}
+	}
+
+	seq := s.next
+	s.next++
+	s.size += int64(len(data)) + 1
+	if s.size >= s.config.SegmentSize {
+		s.file.Close()
+		s.file = nil
+	}
+	return seq, nil
+}
+
+// Commit marks the records up to and including seq as written: they are not replayed anymore and
+// the segments of which all records are committed are removed
+func (s *Spool) Commit(seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seq <= s.committed {
+		return nil
+	}
+	seq = min(seq, s.next-1)
+
+	// The committed file is replaced, so it is never written partly
+	temp := s.committedPath() + ".tmp"
+	if err := writeFileSync(temp, []byte(strconv.FormatInt(seq, 10)), s.config.Sync); err != nil {
+		return fmt.Errorf("failed to commit spool: %w", err)
+	}
+	if err := os.Rename(temp, s.committedPath()); err != nil {
+		return fmt.Errorf("failed to commit spool: %w", err)
+	}
+	s.committed = seq
+	return s.removeCommittedSegments()
+}
+
+// Replay calls fn for every record that is not committed, in order. Replay is meant for startup,
+// before new records are appended.
+func (s *Spool) Replay(fn func(seq int64, record json.RawMessage) error) error {
+	s.mu.Lock()
+	segments := slices.Clone(s.segments)
+	committed, next := s.committed, s.next
+	s.mu.Unlock()
+
+	for _, start := range segments {
+		file, err := os.Open(s.segmentPath(start))
+		if errors.Is(err, os.ErrNotExist) {
+			// Removed by a commit
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open spool segment: %w", err)
+		}
+		err = replaySegment(file, start, func(seq int64, record json.RawMessage) error {
+			if seq <= committed || seq >= next {
+				return nil
+			}
+			return fn(seq, record)
+		})
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of records that are not committed
+func (s *Spool) Pending() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next - 1 - s.committed
+}
+
+// Close closes the segment that records are appended to. The records that are not committed stay
+// in the spool.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// removeCommittedSegments removes the segments of which all records are committed
+func (s *Spool) removeCommittedSegments() error {
+	for len(s.segments) > 0 {
+		// A segment ends before the start of the next segment, the last segment at next
+		end := s.next
+		if len(s.segments) > 1 {
+			end = s.segments[1]
+		}
+		if end-1 > s.committed {
+			return nil
+		}
+		if len(s.segments) == 1 && s.file != nil {
+			s.file.Close()
+			s.file = nil
+		}
+		if err := os.Remove(s.segmentPath(s.segments[0])); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove spool segment: %w", err)
+		}
+		s.segments = s.segments[1:]
+	}
+	return nil
+}
+
+func (s *Spool) segmentPath(start int64) string {
+	return filepath.Join(s.config.Dir, fmt.Sprintf("%020d%s", start, spoolSegmentExt))
+}
+
+func (s *Spool) committedPath() string {
+	return filepath.Join(s.config.Dir, "committed")
+}
+
+// replaySegment calls fn for the records of a segment that starts at sequence number start
+func replaySegment(file *os.File, start int64, fn func(seq int64, record json.RawMessage) error) error {
+	reader := bufio.NewReader(file)
+	for seq := start; ; seq++ {
+		line, _ := reader.ReadBytes('\n')
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			// The end, or a record that was written partly
+			return nil
+		}
+		if err := fn(seq, json.RawMessage(bytes.TrimSuffix(line, []byte("\n")))); err != nil {
+			return err
+		}
+	}
+}
+
+// writeFileSync writes a file like os.WriteFile, with sync it is also synced to the disk
+func writeFileSync(path string, data []byte, sync bool) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if sync {
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// repairSegment removes a partly written record at the end of a segment and returns the number of
+// complete records
+func repairSegment(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read spool segment: %w", err)
+	}
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete < len(data) {
+		if err := os.Truncate(path, int64(complete)); err != nil {
+			return 0, fmt.Errorf("failed to repair spool segment: %w", err)
+		}
+	}
+	return bytes.Count(data[:complete], []byte("\n")), nil
+}
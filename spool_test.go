@@ -0,0 +1,117 @@
+package timeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// replayed returns the records of the spool that are not committed
+func replayed(t *testing.T, spool *Spool) map[int64]string {
+	t.Helper()
+	records := map[int64]string{}
+	err := spool.Replay(func(seq int64, record json.RawMessage) error {
+		records[seq] = string(record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+	return records
+}
+
+func Test_spool_replays_the_records_that_are_not_committed(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+
+	// Given a spool with 3 records of which the first is committed
+	spool, err := OpenSpool(SpoolConfig{Dir: dir, Sync: true})
+	is.NoErr(err)
+	for _, n := range []int{1, 2, 3} {
+		seq, err := spool.Append(map[string]int{"n": n})
+		is.NoErr(err)
+		is.Equal(seq, int64(n))
+	}
+	is.NoErr(spool.Commit(1))
+	is.Equal(spool.Pending(), int64(2))
+	is.NoErr(spool.Close())
+
+	// When the spool is opened again
+	spool, err = OpenSpool(SpoolConfig{Dir: dir})
+	is.NoErr(err)
+	defer spool.Close()
+
+	// Then the other records are replayed and the sequence continues
+	is.Equal(replayed(t, spool), map[int64]string{2: `{"n":2}`, 3: `{"n":3}`})
+	seq, err := spool.Append(map[string]int{"n": 4})
+	is.NoErr(err)
+	is.Equal(seq, int64(4))
+}
+
+func Test_spool_skips_a_record_that_was_written_partly(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	spool, err := OpenSpool(SpoolConfig{Dir: dir})
+	is.NoErr(err)
+	_, err = spool.Append("complete")
+	is.NoErr(err)
+	is.NoErr(spool.Close())
+
+	// Given a crash while a record was appended
+	file, err := os.OpenFile(spool.segmentPath(1), os.O_APPEND|os.O_WRONLY, 0)
+	is.NoErr(err)
+	_, err = file.WriteString(`"parti`)
+	is.NoErr(err)
+	is.NoErr(file.Close())
+
+	// When the spool is opened again
+	spool, err = OpenSpool(SpoolConfig{Dir: dir})
+	is.NoErr(err)
+	defer spool.Close()
+
+	// Then the partial record is removed and its sequence number is reused
+	is.Equal(replayed(t, spool), map[int64]string{1: `"complete"`})
+	seq, err := spool.Append("next")
+	is.NoErr(err)
+	is.Equal(seq, int64(2))
+	is.Equal(replayed(t, spool), map[int64]string{1: `"complete"`, 2: `"next"`})
+}
+
+func Test_spool_removes_the_committed_segments(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+
+	// Given a spool that starts a segment for every 2 records
+	spool, err := OpenSpool(SpoolConfig{Dir: dir, SegmentSize: 8})
+	is.NoErr(err)
+	defer spool.Close()
+	for i := 0; i < 5; i++ {
+		_, err := spool.Append("abc")
+		is.NoErr(err)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	is.Equal(len(segments), 3)
+
+	// When 3 records are committed
+	is.NoErr(spool.Commit(3))
+
+	// Then the first segment is removed
+	segments, _ = filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	is.Equal(len(segments), 2)
+	is.Equal(replayed(t, spool), map[int64]string{4: `"abc"`, 5: `"abc"`})
+
+	// When all records are committed, all segments are removed
+	is.NoErr(spool.Commit(5))
+	segments, _ = filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	is.Equal(len(segments), 0)
+	is.Equal(spool.Pending(), int64(0))
+}
+
+func Test_spool_needs_a_directory(t *testing.T) {
+	is := is.New(t)
+	_, err := OpenSpool(SpoolConfig{})
+	is.True(err != nil)
+}
@@ -0,0 +1,203 @@
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQSMessage is a message received from an SQS queue
+type SQSMessage struct {
+	ReceiptHandle string
+	Body          string
+	// ReceiveCount is the ApproximateReceiveCount attribute, 1 for the first delivery
+	ReceiveCount int
+	// SentTime is the SentTimestamp attribute, zero when not requested
+	SentTime time.Time
+}
+
+// SQSClient runs the queue operations for an SQSSource. The package doesn't depend on the AWS SDK,
+// implement it with your client. With aws-sdk-go-v2:
+//
+//	func (c client) Receive(ctx context.Context, max int, wait time.Duration) ([]timeline.SQSMessage, error) {
+//		out, err := c.sqs.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+//			QueueUrl: &c.url, MaxNumberOfMessages: int32(max), WaitTimeSeconds: int32(wait.Seconds()),
+//			MessageSystemAttributeNames: []types.MessageSystemAttributeName{"ApproximateReceiveCount", "SentTimestamp"},
+//		})
+//		...
+//	}
+type SQSClient interface {
+	// Receive long polls the queue (ReceiveMessage with WaitTimeSeconds) for at most max messages
+	Receive(ctx context.Context, max int, wait time.Duration) ([]SQSMessage, error)
+	// Delete deletes the messages (DeleteMessageBatch)
+	Delete(ctx context.Context, receiptHandles ...string) error
+	// ChangeVisibility changes the visibility timeout of a message (ChangeMessageVisibility)
+	ChangeVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error
+}
+
+// SQSSourceConfig configures an SQSSource
+type SQSSourceConfig struct {
+	// Table is the table the messages are written to
+	Table string
+	// Parser parses the message bodies (or the message of an SNS notification), defaults to ParseLineToValues
+	Parser Parser
+	// MaxMessages is the maximum number of messages per receive, defaults to 10 (the SQS maximum)
+	MaxMessages int
+	// WaitTime is the long polling time of a receive, defaults to 20s (the SQS maximum)
+	WaitTime time.Duration
+	// RetryDelay is the visibility timeout of a message that could not be written, it doubles with
+	// every receive up to the SQS maximum of 12 hours. Defaults to 30s.
+	RetryDelay time.Duration
+	// MaxReceiveCount is the maxReceiveCount of the redrive policy of the queue. A message that could
+	// not be written for the last time is made visible immediately, so SQS moves it to the dead-letter
+	// queue without waiting for the retry delay. Zero means the queue has no dead-letter queue.
+	MaxReceiveCount int
+	// OnError is called for messages that could not be written and failing operations
+	OnError func(error)
+}
+
+// SQSSource long polls an SQS queue and writes the messages to a table. Messages are deleted after
+// they are written. SNS notifications (of a subscription without raw message delivery) are unwrapped:
+// the message is parsed and the topic and time of the notification are added.
+type SQSSource struct {
+	writer *Writer
+	client SQSClient
+	config SQSSourceConfig
+}
+
+// maxSQSVisibilityTimeout is the maximum visibility timeout of SQS
+const maxSQSVisibilityTimeout = 12 * time.Hour
+
+// NewSQSSource creates an SQS source, call Run to start it
+func NewSQSSource(writer *Writer, client SQSClient, config SQSSourceConfig) *SQSSource {
+	if config.Table == "" {
+		config.Table = "sqs"
+	}
+	if config.Parser == nil {
+		config.Parser = ParserFunc(ParseLineToValues)
+	}
+	if config.MaxMessages <= 0 || config.MaxMessages > 10 {
+		config.MaxMessages = 10
+	}
+	if config.WaitTime <= 0 {
+		config.WaitTime = 20 * time.Second
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = 30 * time.Second
+	}
+	return &SQSSource{writer: writer, client: client, config: config}
+}
+
+// Run receives and writes messages until ctx is done and returns the error of ctx
+func (s *SQSSource) Run(ctx context.Context) error {
+	for {
+		messages, err := s.client.Receive(ctx, s.config.MaxMessages, s.config.WaitTime)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.reportError(fmt.Errorf("failed to receive SQS messages: %w", err))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		var written []string
+		for _, message := range messages {
+			if err := s.write(message); err != nil {
+				s.reportError(err)
+				s.retryLater(ctx, message)
+				continue
+			}
+			written = append(written, message.ReceiptHandle)
+		}
+		if len(written) > 0 {
+			// Written messages that are not deleted are received and written again
+			if err := s.client.Delete(ctx, written...); err != nil && ctx.Err() == nil {
+				s.reportError(fmt.Errorf("failed to delete SQS messages: %w", err))
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// write parses and writes a message, an empty message is not written
+func (s *SQSSource) write(message SQSMessage) error {
+	body := message.Body
+	timestamp := message.SentTime
+	var topic string
+	if notification, ok := parseSNSNotification(body); ok {
+		body = notification.Message
+		topic = notification.TopicArn
+		if t, err := time.Parse(time.RFC3339Nano, notification.Timestamp); err == nil {
+			timestamp = t.UTC()
+		}
+	}
+
+	row := s.config.Parser.Parse(body)
+	if row == nil {
+		return nil
+	}
+	if _, exists := row["topic_arn"]; !exists && topic != "" {
+		row["topic_arn"] = topic
+	}
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	if err := s.writer.Write(s.config.Table, NewRow(timestamp, row)); err != nil {
+		return fmt.Errorf("failed to write SQS message: %w", err)
+	}
+	return nil
+}
+
+// retryLater makes a message that could not be written visible again after the retry delay, or
+// immediately when it is received for the last time, so it moves to the dead-letter queue
+func (s *SQSSource) retryLater(ctx context.Context, message SQSMessage) {
+	timeout := sqsRetryTimeout(s.config.RetryDelay, message.ReceiveCount)
+	if s.config.MaxReceiveCount > 0 && message.ReceiveCount >= s.config.MaxReceiveCount {
+		timeout = 0
+	}
+	if err := s.client.ChangeVisibility(ctx, message.ReceiptHandle, timeout); err != nil && ctx.Err() == nil {
+		s.reportError(fmt.Errorf("failed to change the visibility of an SQS message: %w", err))
+	}
+}
+
+// sqsRetryTimeout doubles the delay for every receive, up to the maximum visibility timeout
+func sqsRetryTimeout(delay time.Duration, receiveCount int) time.Duration {
+	timeout := delay
+	for i := 1; i < receiveCount && timeout < maxSQSVisibilityTimeout; i++ {
+		timeout *= 2
+	}
+	return min(timeout, maxSQSVisibilityTimeout)
+}
+
+// snsNotification is the envelope of an SNS message delivered to SQS
+type snsNotification struct {
+	Type      string
+	TopicArn  string
+	Message   string
+	Timestamp string
+}
+
+// parseSNSNotification decodes the SNS envelope of a message body
+func parseSNSNotification(body string) (snsNotification, bool) {
+	if !strings.Contains(body, `"TopicArn"`) {
+		return snsNotification{}, false
+	}
+	var notification snsNotification
+	if err := json.Unmarshal([]byte(body), &notification); err != nil || notification.Type != "Notification" {
+		return snsNotification{}, false
+	}
+	return notification, true
+}
+
+func (s *SQSSource) reportError(err error) {
+	handleError(s.config.OnError, err, ErrorContext{Component: "sqs_source", Table: s.config.Table})
+}
@@ -0,0 +1,101 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// fakeSQS delivers the messages once and cancels the context when the queue is empty
+type fakeSQS struct {
+	messages   []SQSMessage
+	deleted    []string
+	visibility map[string]time.Duration
+	cancel     context.CancelFunc
+}
+
+func (f *fakeSQS) Receive(ctx context.Context, max int, wait time.Duration) ([]SQSMessage, error) {
+	if len(f.messages) == 0 {
+		f.cancel()
+		return nil, ctx.Err()
+	}
+	n := min(max, len(f.messages))
+	messages := f.messages[:n]
+	f.messages = f.messages[n:]
+	return messages, nil
+}
+
+func (f *fakeSQS) Delete(ctx context.Context, receiptHandles ...string) error {
+	f.deleted = append(f.deleted, receiptHandles...)
+	return nil
+}
+
+func (f *fakeSQS) ChangeVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	f.visibility[receiptHandle] = timeout
+	return nil
+}
+
+func Test_sqs_retry_timeout(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(sqsRetryTimeout(30*time.Second, 1), 30*time.Second)
+	is.Equal(sqsRetryTimeout(30*time.Second, 3), 2*time.Minute)
+	is.Equal(sqsRetryTimeout(30*time.Second, 100), 12*time.Hour)
+}
+
+func Test_sqs_source_writes_messages_and_unwraps_sns(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Given a plain message, an SNS notification and an empty message
+	client := &fakeSQS{cancel: cancel, visibility: map[string]time.Duration{}, messages: []SQSMessage{
+		{ReceiptHandle: "a", Body: `{"level":"info","order_id":1}`, SentTime: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)},
+		{ReceiptHandle: "b", Body: `{"Type":"Notification","TopicArn":"arn:aws:sns:eu-west-1:123:orders","Message":"{\"level\":\"error\",\"order_id\":2}","Timestamp":"2024-09-01T12:01:00.000Z"}`},
+		{ReceiptHandle: "c", Body: ""},
+	}}
+	source := NewSQSSource(writer, client, SQSSourceConfig{Table: "orders", OnError: func(err error) { t.Errorf("source error: %v", err) }})
+
+	// When the source runs
+	err := source.Run(ctx)
+
+	// Then the messages are written and deleted
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(client.deleted, []string{"a", "b", "c"})
+
+	var topic string
+	var timestamp time.Time
+	is.NoErr(writer.DB.QueryRow(`SELECT topic_arn, timestamp FROM orders WHERE order_id = 2`).Scan(&topic, &timestamp))
+	is.Equal(topic, "arn:aws:sns:eu-west-1:123:orders")
+	is.Equal(timestamp.UTC(), time.Date(2024, 9, 1, 12, 1, 0, 0, time.UTC))
+	is.NoErr(writer.DB.QueryRow(`SELECT timestamp FROM orders WHERE order_id = 1`).Scan(&timestamp))
+	is.Equal(timestamp.UTC(), time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC))
+}
+
+func Test_sqs_source_retries_failed_messages_until_the_dead_letter_queue(t *testing.T) {
+	is, writer := setup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &fakeSQS{cancel: cancel, visibility: map[string]time.Duration{}, messages: []SQSMessage{
+		{ReceiptHandle: "retry", Body: "message", ReceiveCount: 2},
+		{ReceiptHandle: "dead", Body: "message", ReceiveCount: 5},
+	}}
+	var errs []error
+	source := NewSQSSource(writer, client, SQSSourceConfig{
+		Table:           "invalid table",
+		RetryDelay:      time.Minute,
+		MaxReceiveCount: 5,
+		OnError:         func(err error) { errs = append(errs, err) },
+	})
+
+	source.Run(ctx)
+
+	is.Equal(len(client.deleted), 0)
+	is.Equal(len(errs), 2)
+	is.Equal(client.visibility["retry"], 2*time.Minute)
+	is.Equal(client.visibility["dead"], time.Duration(0))
+}
@@ -0,0 +1,77 @@
+package timeline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseSquid parses a Squid native access log line.
+// Format: time.millis duration client result/status bytes method URL user hierarchy/peer type
+// Example: 1286536308.779    180 192.168.0.224 TCP_MISS/200 411 GET http://www.google.com/ - DIRECT/74.125.227.148 text/html
+// Fields: timestamp, duration_ms, remote_host, result_code, status, response_size, method, url, remote_user,
+// hierarchy, peer_host, content_type
+func parseSquid(l string) Row {
+	// The line starts with the unix time
+	if trimmed := strings.TrimSpace(l); trimmed == "" || trimmed[0] < '0' || trimmed[0] > '9' {
+		return nil
+	}
+
+	var buffer [32]string
+	parts := appendFields(buffer[:0], l)
+	if len(parts) != 10 {
+		return nil
+	}
+
+	// Unix time with milliseconds
+	seconds, millis, ok := strings.Cut(parts[0], ".")
+	if !ok || len(millis) != 3 {
+		return nil
+	}
+	unixSeconds, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return nil
+	}
+	unixMillis, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	duration, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil
+	}
+
+	resultCode, statusCode, ok := strings.Cut(parts[3], "/")
+	if !ok {
+		return nil
+	}
+	status, err := strconv.Atoi(statusCode)
+	if err != nil {
+		return nil
+	}
+
+	result := make(Row)
+	result["timestamp"] = unixMillisToTime(unixSeconds*1000 + unixMillis)
+	result["duration_ms"] = duration
+	result["remote_host"] = parts[2]
+	result["result_code"] = resultCode
+	result["status"] = status
+	if size, err := strconv.Atoi(parts[4]); err == nil {
+		result["response_size"] = size
+	}
+	result["method"] = parts[5]
+	result["url"] = parts[6]
+	if parts[7] != "-" {
+		result["remote_user"] = parts[7]
+	}
+	hierarchy, peer, _ := strings.Cut(parts[8], "/")
+	result["hierarchy"] = hierarchy
+	if peer != "" && peer != "-" {
+		result["peer_host"] = peer
+	}
+	if parts[9] != "-" {
+		result["content_type"] = parts[9]
+	}
+
+	return result
+}
@@ -0,0 +1,53 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_squid_access_log_line(t *testing.T) {
+	is := is.New(t)
+	line := `1286536308.779    180 192.168.0.224 TCP_MISS/200 411 GET http://www.google.com/ - DIRECT/74.125.227.148 text/html`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["timestamp"], time.Date(2010, 10, 8, 11, 11, 48, 779000000, time.UTC))
+	is.Equal(data["duration_ms"], 180)
+	is.Equal(data["remote_host"], "192.168.0.224")
+	is.Equal(data["result_code"], "TCP_MISS")
+	is.Equal(data["status"], 200)
+	is.Equal(data["response_size"], 411)
+	is.Equal(data["method"], "GET")
+	is.Equal(data["url"], "http://www.google.com/")
+	is.Equal(data["hierarchy"], "DIRECT")
+	is.Equal(data["peer_host"], "74.125.227.148")
+	is.Equal(data["content_type"], "text/html")
+	_, hasUser := data["remote_user"]
+	is.True(!hasUser)
+}
+
+func Test_parse_squid_denied_line_without_peer(t *testing.T) {
+	is := is.New(t)
+	line := `1286536309.001 0 10.0.0.5 TCP_DENIED/403 3712 CONNECT example.com:443 alice HIER_NONE/- -`
+
+	data := ParseLineToValues(line)
+
+	is.Equal(data["result_code"], "TCP_DENIED")
+	is.Equal(data["status"], 403)
+	is.Equal(data["remote_user"], "alice")
+	is.Equal(data["hierarchy"], "HIER_NONE")
+	_, hasPeer := data["peer_host"]
+	is.True(!hasPeer)
+	_, hasType := data["content_type"]
+	is.True(!hasType)
+}
+
+func Test_parse_squid_requires_epoch_timestamp(t *testing.T) {
+	is := is.New(t)
+
+	data := parseSquid(`yesterday 180 192.168.0.224 TCP_MISS/200 411 GET http://www.google.com/ - DIRECT/74.125.227.148 text/html`)
+
+	is.Equal(data, nil)
+}
@@ -0,0 +1,180 @@
+package timeline
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// goroutineHeaderRegex matches the header of a goroutine stack.
+// Example: goroutine 1 [running]:
+var goroutineHeaderRegex = regexp.MustCompile(`^goroutine (\d+) \[([^\]]+)\]:$`)
+
+// goFrameFileRegex matches the file line that follows a Go frame function.
+// Example: /app/main.go:20 +0x1d (indented with a tab)
+var goFrameFileRegex = regexp.MustCompile(`^\s+(\S+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+// parseJavaStackTrace parses a multi-line entry containing a Java exception.
+// The entry is usually produced by the LineAssembler. Lines before the exception header
+// (e.g. the log message that reported the exception) are parsed with ParseLineToValues.
+// Example:
+//
+//	2025-09-21 22:35:12 ERROR Request failed
+//	java.lang.IllegalStateException: Connection closed
+//		at com.example.db.Pool.get(Pool.java:42)
+//		at com.example.Service.handle(Service.java:17)
+//	Caused by: java.net.SocketException: Broken pipe
+//		at java.base/java.net.SocketOutputStream.write(SocketOutputStream.java:150)
+//		... 2 more
+//
+// Fields: thread, exception_class, exception_message, caused_by (list of classes), root_cause_class,
+// root_cause_message, frame_class, frame_method, frame_file, frame_line (top frame), stack_trace, message
+func parseJavaStackTrace(l string) Row {
+	if !strings.Contains(l, "\n") {
+		return nil
+	}
+	lines := strings.Split(l, "\n")
+
+	// Find the exception header
+	headerIndex := -1
+	for i, line := range lines {
+		if isJavaExceptionClass(strings.TrimSpace(line)) {
+			headerIndex = i
+			break
+		}
+	}
+	if headerIndex == -1 {
+		return nil
+	}
+
+	result := make(Row)
+	if headerIndex > 0 {
+		for k, v := range ParseLineToValues(strings.Join(lines[:headerIndex], "\n")) {
+			result[k] = v
+		}
+	}
+
+	header := javaExceptionHeaderRegex.FindStringSubmatch(strings.TrimSpace(lines[headerIndex]))
+	if header[1] != "" {
+		result["thread"] = header[1]
+	}
+	result["exception_class"] = header[2]
+	if header[3] != "" {
+		result["exception_message"] = header[3]
+	}
+
+	hasFrame := false
+	causedBy := []any{}
+	for _, line := range lines[headerIndex+1:] {
+		if frame := javaFrameRegex.FindStringSubmatch(line); frame != nil {
+			// Only the top frame of the outer exception is stored in columns
+			if !hasFrame {
+				result["frame_class"] = frame[1]
+				result["frame_method"] = frame[2]
+				result["frame_file"] = frame[3]
+				if lineNumber, err := strconv.Atoi(frame[4]); err == nil {
+					result["frame_line"] = lineNumber
+				}
+			}
+			hasFrame = true
+			continue
+		}
+		if cause, ok := strings.CutPrefix(strings.TrimSpace(line), "Caused by: "); ok {
+			match := javaExceptionHeaderRegex.FindStringSubmatch(cause)
+			if match == nil {
+				continue
+			}
+			causedBy = append(causedBy, match[2])
+			result["root_cause_class"] = match[2]
+			if match[3] != "" {
+				result["root_cause_message"] = match[3]
+			} else {
+				delete(result, "root_cause_message")
+			}
+		}
+	}
+
+	// Without any frame this is just a line that happens to mention an exception
+	if !hasFrame {
+		return nil
+	}
+
+	if len(causedBy) > 0 {
+		result["caused_by"] = causedBy
+	}
+	result["stack_trace"] = strings.Join(lines[headerIndex:], "\n")
+	if _, exists := result["message"]; !exists {
+		result["message"] = strings.TrimSpace(lines[headerIndex])
+	}
+
+	return result
+}
+
+// parseGoPanic parses a multi-line entry containing a Go panic or fatal runtime error.
+// The entry is usually produced by the LineAssembler.
+// Example:
+//
+//	panic: runtime error: index out of range [5] with length 3
+//
+//	goroutine 1 [running]:
+//	main.process(...)
+//		/app/main.go:12
+//	main.main()
+//		/app/main.go:20 +0x1d
+//	exit status 2
+//
+// Fields: panic_message, goroutine_id, goroutine_state, frame_function, frame_file, frame_line
+// (first non-runtime frame), stack_trace, message
+func parseGoPanic(l string) Row {
+	if !isGoPanicHeader(l) || !strings.Contains(l, "\n") {
+		return nil
+	}
+	lines := strings.Split(l, "\n")
+
+	result := make(Row)
+	header := strings.TrimSpace(lines[0])
+	if msg, ok := strings.CutPrefix(header, "panic: "); ok {
+		result["panic_message"] = msg
+	} else {
+		result["panic_message"] = strings.TrimPrefix(header, "fatal error: ")
+	}
+
+	hasGoroutine := false
+	for i, line := range lines[1:] {
+		if !hasGoroutine {
+			if match := goroutineHeaderRegex.FindStringSubmatch(line); match != nil {
+				result["goroutine_id"], _ = strconv.Atoi(match[1])
+				result["goroutine_state"] = match[2]
+				hasGoroutine = true
+			}
+			continue
+		}
+
+		// The function line is followed by its file line
+		if !goFrameFunctionRegex.MatchString(line) || strings.HasPrefix(line, "created by ") || i+2 >= len(lines) {
+			continue
+		}
+		function := line[:strings.LastIndex(line, "(")]
+		if strings.HasPrefix(function, "runtime.") || strings.HasPrefix(function, "panic") {
+			continue
+		}
+		file := goFrameFileRegex.FindStringSubmatch(lines[i+2])
+		if file == nil {
+			continue
+		}
+		result["frame_function"] = function
+		result["frame_file"] = file[1]
+		result["frame_line"], _ = strconv.Atoi(file[2])
+		break
+	}
+
+	// Without a goroutine stack this is just a line that starts with "panic: "
+	if !hasGoroutine {
+		return nil
+	}
+
+	result["stack_trace"] = l
+	result["message"] = header
+
+	return result
+}
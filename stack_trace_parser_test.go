@@ -0,0 +1,180 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_assemble_java_stack_trace_into_one_entry(t *testing.T) {
+	is := is.New(t)
+	lines := []string{
+		"first line",
+		"java.lang.IllegalStateException: Connection closed",
+		"\tat com.example.db.Pool.get(Pool.java:42)",
+		"Caused by: java.net.SocketException: Broken pipe",
+		"\t... 2 more",
+		"second line",
+	}
+
+	entries := AssembleLines(lines)
+
+	is.Equal(len(entries), 2)
+	is.Equal(entries[1], "second line")
+}
+
+func Test_assemble_new_exception_after_frames_starts_new_entry(t *testing.T) {
+	is := is.New(t)
+	lines := []string{
+		"java.lang.IllegalStateException: first",
+		"\tat com.example.Service.handle(Service.java:17)",
+		"java.lang.IllegalStateException: second",
+		"\tat com.example.Service.handle(Service.java:17)",
+	}
+
+	entries := AssembleLines(lines)
+
+	is.Equal(len(entries), 2)
+}
+
+func Test_parse_java_stack_trace(t *testing.T) {
+	is := is.New(t)
+	entry := "java.lang.IllegalStateException: Connection closed\n" +
+		"\tat com.example.db.Pool.get(Pool.java:42)\n" +
+		"\tat com.example.Service.handle(Service.java:17)"
+
+	data := ParseLineToValues(entry)
+
+	is.Equal(data["exception_class"], "java.lang.IllegalStateException")
+	is.Equal(data["exception_message"], "Connection closed")
+	is.Equal(data["frame_class"], "com.example.db.Pool")
+	is.Equal(data["frame_method"], "get")
+	is.Equal(data["frame_file"], "Pool.java")
+	is.Equal(data["frame_line"], 42)
+	is.Equal(data["stack_trace"], entry)
+	is.Equal(data["message"], "java.lang.IllegalStateException: Connection closed")
+}
+
+func Test_parse_java_stack_trace_with_caused_by_chain(t *testing.T) {
+	is := is.New(t)
+	entry := "java.lang.RuntimeException: Request failed\n" +
+		"\tat com.example.Service.handle(Service.java:17)\n" +
+		"Caused by: java.lang.IllegalStateException: Connection closed\n" +
+		"\tat com.example.db.Pool.get(Pool.java:42)\n" +
+		"Caused by: java.net.SocketException: Broken pipe\n" +
+		"\tat java.base/java.net.SocketOutputStream.write(SocketOutputStream.java:150)\n" +
+		"\t... 2 more"
+
+	data := ParseLineToValues(entry)
+
+	is.Equal(data["caused_by"], []any{"java.lang.IllegalStateException", "java.net.SocketException"})
+	is.Equal(data["root_cause_class"], "java.net.SocketException")
+	is.Equal(data["root_cause_message"], "Broken pipe")
+	// The top frame belongs to the outer exception
+	is.Equal(data["frame_class"], "com.example.Service")
+}
+
+func Test_parse_java_stack_trace_with_thread_and_native_frame(t *testing.T) {
+	is := is.New(t)
+	entry := "Exception in thread \"main\" java.lang.NullPointerException\n" +
+		"\tat sun.misc.Unsafe.park(Native Method)"
+
+	data := ParseLineToValues(entry)
+
+	is.Equal(data["thread"], "main")
+	is.Equal(data["exception_class"], "java.lang.NullPointerException")
+	is.Equal(data["frame_file"], "Native Method")
+	_, hasLine := data["frame_line"]
+	is.True(!hasLine)
+}
+
+func Test_parse_java_stack_trace_keeps_preceding_log_message(t *testing.T) {
+	is := is.New(t)
+	entry := "[2025-09-21 22:35:12] Request failed\n" +
+		"java.lang.IllegalStateException: Connection closed\n" +
+		"\tat com.example.db.Pool.get(Pool.java:42)"
+
+	data := ParseLineToValues(entry)
+
+	is.Equal(data["timestamp"], "2025-09-21 22:35:12")
+	is.Equal(data["message"], "Request failed")
+	is.Equal(data["exception_class"], "java.lang.IllegalStateException")
+}
+
+func Test_parse_java_exception_without_frames_is_not_a_stack_trace(t *testing.T) {
+	is := is.New(t)
+
+	data := parseJavaStackTrace("java.lang.IllegalStateException: boom\nnext line")
+
+	is.Equal(data, nil)
+}
+
+func Test_assemble_go_panic_into_one_entry(t *testing.T) {
+	is := is.New(t)
+	lines := []string{
+		"starting server",
+		"panic: runtime error: index out of range [5] with length 3",
+		"",
+		"goroutine 1 [running]:",
+		"main.process(...)",
+		"\t/app/main.go:12",
+		"main.main()",
+		"\t/app/main.go:20 +0x1d",
+		"exit status 2",
+		"starting server",
+	}
+
+	entries := AssembleLines(lines)
+
+	is.Equal(len(entries), 3)
+	is.Equal(entries[2], "starting server")
+}
+
+func Test_parse_go_panic(t *testing.T) {
+	is := is.New(t)
+	entry := "panic: runtime error: index out of range [5] with length 3\n" +
+		"\n" +
+		"goroutine 7 [running]:\n" +
+		"panic({0x4b2f60, 0xc000012345})\n" +
+		"\t/usr/local/go/src/runtime/panic.go:914 +0x21f\n" +
+		"runtime.goPanicIndex(0x5, 0x3)\n" +
+		"\t/usr/local/go/src/runtime/panic.go:114 +0x7f\n" +
+		"main.(*Server).handle(0xc000010000, {0x4b2f60, 0x5})\n" +
+		"\t/app/server.go:42 +0x1d\n" +
+		"created by main.main in goroutine 1\n" +
+		"\t/app/main.go:20 +0x65"
+
+	data := ParseLineToValues(entry)
+
+	is.Equal(data["panic_message"], "runtime error: index out of range [5] with length 3")
+	is.Equal(data["goroutine_id"], 7)
+	is.Equal(data["goroutine_state"], "running")
+	is.Equal(data["frame_function"], "main.(*Server).handle")
+	is.Equal(data["frame_file"], "/app/server.go")
+	is.Equal(data["frame_line"], 42)
+	is.Equal(data["stack_trace"], entry)
+	is.Equal(data["message"], "panic: runtime error: index out of range [5] with length 3")
+}
+
+func Test_parse_go_fatal_error(t *testing.T) {
+	is := is.New(t)
+	entry := "fatal error: all goroutines are asleep - deadlock!\n" +
+		"\n" +
+		"goroutine 1 [chan receive]:\n" +
+		"main.main()\n" +
+		"\t/app/main.go:8 +0x2d"
+
+	data := ParseLineToValues(entry)
+
+	is.Equal(data["panic_message"], "all goroutines are asleep - deadlock!")
+	is.Equal(data["goroutine_state"], "chan receive")
+	is.Equal(data["frame_function"], "main.main")
+}
+
+func Test_parse_panic_line_without_goroutine_is_not_a_panic(t *testing.T) {
+	is := is.New(t)
+
+	data := parseGoPanic("panic: something\nnext line")
+
+	is.Equal(data, nil)
+}
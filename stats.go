@@ -0,0 +1,102 @@
+package timeline
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// WriterStats are the counters of a writer since it was opened
+type WriterStats struct {
+	RowsWritten int64
+	// BytesWritten is the size of the values of the written rows, an estimate of the growth of
+	// the database before compression
+	BytesWritten int64
+	// WriteErrors is the number of failed writes
+	WriteErrors int64
+	// LastWrite is the time of the last written row, zero without writes
+	LastWrite time.Time
+	// Tables are the counters per table, the partitioned table for the daily partitions
+	Tables map[string]TableWriteStats
+}
+
+// TableWriteStats are the counters of the writes to a table
+type TableWriteStats struct {
+	RowsWritten  int64
+	BytesWritten int64
+	WriteErrors  int64
+	LastWrite    time.Time
+}
+
+// writeStats are the counters of a writer per table
+type writeStats struct {
+	mu     sync.Mutex
+	tables map[string]TableWriteStats
+}
+
+// Stats returns the counters of the writes since the writer was opened, e.g. for a health endpoint
+func (w *Writer) Stats() WriterStats {
+	stats := WriterStats{
+		RowsWritten: w.rowsWritten.Load(),
+		WriteErrors: w.writeErrors.Load(),
+	}
+	if lastWrite := w.lastWrite.Load(); lastWrite > 0 {
+		stats.LastWrite = time.Unix(0, lastWrite)
+	}
+
+	w.writeStats.mu.Lock()
+	defer w.writeStats.mu.Unlock()
+	stats.Tables = maps.Clone(w.writeStats.tables)
+	if stats.Tables == nil {
+		stats.Tables = map[string]TableWriteStats{}
+	}
+	for _, table := range stats.Tables {
+		stats.BytesWritten += table.BytesWritten
+	}
+	return stats
+}
+
+// countWrite counts a write of a row to a table
+func (w *Writer) countWrite(table string, row Row, err error) {
+	w.writeStats.mu.Lock()
+	defer w.writeStats.mu.Unlock()
+	if w.writeStats.tables == nil {
+		w.writeStats.tables = make(map[string]TableWriteStats)
+	}
+
+	stats := w.writeStats.tables[table]
+	if err != nil {
+		stats.WriteErrors++
+	} else {
+		stats.RowsWritten++
+		stats.BytesWritten += rowSize(row)
+		stats.LastWrite = time.Now()
+	}
+	w.writeStats.tables[table] = stats
+}
+
+// rowSize returns the size of the values of a row
+func rowSize(row Row) int64 {
+	var size int64
+	for _, value := range row {
+		switch v := value.(type) {
+		case nil:
+		case bool, int8:
+			size++
+		case int16:
+			size += 2
+		case int32, float32:
+			size += 4
+		case int, int64, float64, time.Time:
+			size += 8
+		case string:
+			size += int64(len(v))
+		case []byte:
+			size += int64(len(v))
+		default:
+			size += int64(len(fmt.Sprint(v)))
+		}
+	}
+	return size
+}
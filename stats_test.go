@@ -0,0 +1,47 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_stats_count_the_writes_per_table(t *testing.T) {
+	is, writer := setup(t)
+
+	// Given a new writer
+	stats := writer.Stats()
+	is.Equal(stats.RowsWritten, int64(0))
+	is.True(stats.LastWrite.IsZero())
+	is.Equal(len(stats.Tables), 0)
+
+	// When rows are written to two tables, an empty row and a row that can't be written
+	before := time.Now()
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})))
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "world", "status": 200})))
+	is.NoErr(writer.Write("jobs", NewRow(time.Now(), Row{"message": "done"})))
+	is.NoErr(writer.Write("jobs", NewRow(time.Now(), Row{})))
+	is.True(writer.Write("jobs", NewRow(time.Now(), Row{"bad column": func() {}})) != nil)
+
+	// Then the writes are counted
+	stats = writer.Stats()
+	is.Equal(stats.RowsWritten, int64(3))
+	is.Equal(stats.WriteErrors, int64(1))
+	is.True(!stats.LastWrite.Before(before))
+	is.Equal(stats.Tables["app"].RowsWritten, int64(2))
+	is.Equal(stats.Tables["app"].BytesWritten, int64(8+5+8+5+8))
+	is.Equal(stats.Tables["jobs"].RowsWritten, int64(1))
+	is.Equal(stats.Tables["jobs"].WriteErrors, int64(1))
+	is.Equal(stats.BytesWritten, stats.Tables["app"].BytesWritten+stats.Tables["jobs"].BytesWritten)
+}
+
+func Test_stats_count_partitioned_tables_as_the_table(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("app")
+
+	is.NoErr(writer.Write("app", NewRow(time.Now(), Row{"message": "today"})))
+	is.NoErr(writer.Write("app", NewRow(time.Now().Add(-48*time.Hour), Row{"message": "before"})))
+
+	stats := writer.Stats()
+	is.Equal(len(stats.Tables), 1)
+	is.Equal(stats.Tables["app"].RowsWritten, int64(2))
+}
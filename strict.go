@@ -0,0 +1,239 @@
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrEmptyLine is returned by ParseLineStrict for empty lines
+var ErrEmptyLine = errors.New("empty line")
+
+// Rejection describes why the parser of a format didn't accept a line
+type Rejection struct {
+	Format Format
+	Reason string
+}
+
+// ParseError is returned by ParseLineStrict when no parser recognized the line
+type ParseError struct {
+	Line       string
+	Rejections []Rejection
+}
+
+func (e *ParseError) Error() string {
+	reasons := make([]string, 0, len(e.Rejections))
+	for _, rejection := range e.Rejections {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", rejection.Format, rejection.Reason))
+	}
+	return fmt.Sprintf("no parser recognized the line %q (%s)", e.Line, strings.Join(reasons, "; "))
+}
+
+// rejectLine adds the reason why the parser of a format didn't recognize the line to the
+// rejections, when parseLineInto collects them for ParseLineStrict. The reject functions explain
+// the first check of their parser that failed on the line.
+func rejectLine(rejections *[]Rejection, format Format, reject func(l string) string, l string) {
+	if rejections != nil {
+		*rejections = append(*rejections, Rejection{Format: format, Reason: reject(l)})
+	}
+}
+
+// rejectMongoDB explains why the line is not a MongoDB line
+func rejectMongoDB(l string) string {
+	if !strings.HasPrefix(l, `{"t":{"$date":`) {
+		return `expected a JSON object starting with {"t":{"$date":`
+	}
+	return rejectJSON(l)
+}
+
+// rejectCaddy explains why the line is not a Caddy line
+func rejectCaddy(l string) string {
+	return rejectJSONFields(l, "no request object with a method and uri")
+}
+
+// rejectTraefik explains why the line is not a Traefik line
+func rejectTraefik(l string) string {
+	return rejectJSONFields(l, "no RequestMethod and DownstreamStatus fields")
+}
+
+// rejectGELF explains why the line is not a GELF line
+func rejectGELF(l string) string {
+	return rejectJSONFields(l, "no version, host and short_message fields")
+}
+
+// rejectJavaStackTrace explains why the line is not a Java stack trace line
+func rejectJavaStackTrace(l string) string {
+	lines := strings.Split(l, "\n")
+	if len(lines) == 1 {
+		return "a single line, expected an exception header followed by indented at ... frames"
+	}
+	for _, line := range lines {
+		if isJavaExceptionClass(strings.TrimSpace(line)) {
+			return "no indented at ... frames after the exception header"
+		}
+	}
+	return "no line starts with an exception class"
+}
+
+// rejectGoPanic explains why the line is not a Go panic line
+func rejectGoPanic(l string) string {
+	if !isGoPanicHeader(l) {
+		return "expected a panic: or fatal error: header"
+	}
+	return "no goroutine trace after the panic: header"
+}
+
+// rejectRails explains why the line is not a Rails line
+func rejectRails(l string) string {
+	first, _, _ := strings.Cut(l, "\n")
+	if !strings.Contains(first, "Started ") {
+		return "the first line has no Started request"
+	}
+	return `the first line doesn't match Started METHOD "path" for ip at time`
+}
+
+// rejectHeroku explains why the line is not a Heroku line
+func rejectHeroku(l string) string {
+	count, frame, ok := strings.Cut(l, " ")
+	if !ok || !strings.HasPrefix(frame, "<") {
+		return "expected an octet count followed by a <priority>"
+	}
+	if _, err := strconv.Atoi(count); err != nil {
+		return fmt.Sprintf("the octet count %q is not a number", count)
+	}
+	return "expected a Logplex frame with version, timestamp, host, app and process after the octet count"
+}
+
+// rejectSyslog explains why the line is not a syslog line
+func rejectSyslog(l string) string {
+	if !strings.HasPrefix(l, "<") {
+		return "expected a <priority> prefix"
+	}
+	priority, _, ok := strings.Cut(l[1:], ">")
+	if !ok {
+		return "the <priority> prefix is not closed"
+	}
+	if _, err := strconv.Atoi(priority); err != nil {
+		return fmt.Sprintf("the priority %q is not a number", priority)
+	}
+	return "no timestamp and host after the <priority>"
+}
+
+// rejectMonolog explains why the line is not a Monolog line
+func rejectMonolog(l string) string {
+	if !strings.Contains(l, "[") {
+		return "no [timestamp]"
+	}
+	return "expected channel.LEVEL: message {context} [extra] after the [timestamp]"
+}
+
+// rejectConsole explains why the line is not a console line
+func rejectConsole(l string) string {
+	l = stripAnsiCodes(l)
+	var parts []string
+	if tab := strings.IndexByte(l, '\t'); tab != -1 {
+		parts = strings.Split(l, "\t")
+	} else {
+		parts = strings.Fields(l)
+	}
+	switch {
+	case len(parts) < 3:
+		return "expected timestamp, level and message fields"
+	case !looksLikeConsoleTime(parts[0]):
+		return fmt.Sprintf("the first field %q is not a time", parts[0])
+	}
+	return fmt.Sprintf("the second field %q is not a level of the zap or zerolog console encoder", parts[1])
+}
+
+// rejectS3Access explains why the line is not a S3 access line
+func rejectS3Access(l string) string {
+	if owner, _, _ := strings.Cut(strings.TrimLeft(l, " "), " "); !isCanonicalUserID(owner) {
+		return "expected a canonical user id at the start"
+	}
+	return "expected a bucket and [time] after the canonical user id"
+}
+
+// rejectSquid explains why the line is not a Squid line
+func rejectSquid(l string) string {
+	if trimmed := strings.TrimSpace(l); trimmed == "" || trimmed[0] < '0' || trimmed[0] > '9' {
+		return "expected an epoch.millis time at the start"
+	}
+	if fields := len(strings.Fields(l)); fields != 10 {
+		return fmt.Sprintf("%d fields, expected the 10 fields of a Squid native access log", fields)
+	}
+	return "the first field is not an epoch.millis time"
+}
+
+// rejectCLF explains why the line is not a CLF line
+func rejectCLF(l string) string {
+	if !strings.Contains(l, `"`) {
+		return "no quoted request"
+	}
+	return `expected host ident user [time] "request" status size`
+}
+
+// rejectLograge explains why the line is not a Lograge line
+func rejectLograge(l string) string {
+	return "not logfmt, " + rejectLogfmt(l)
+}
+
+// rejectTimestampMessage explains why the line is not a [timestamp] message line
+func rejectTimestampMessage(l string) string {
+	if !strings.HasPrefix(l, "[") {
+		return "expected a [timestamp] at the start"
+	}
+	timestamp, rest, ok := strings.Cut(l[1:], "]")
+	switch {
+	case !ok:
+		return "the [timestamp] is not closed"
+	case strings.TrimSpace(rest) == "":
+		return fmt.Sprintf("no message after [%s]", timestamp)
+	}
+	return "the message looks like structured data of another format"
+}
+
+// rejectJSON returns the error of decoding the line as a JSON object
+func rejectJSON(l string) string {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(l), &data); err != nil {
+		return "invalid JSON object: " + err.Error()
+	}
+	return "the JSON object has no fields"
+}
+
+// rejectJSONFields rejects a line that is not a JSON object, or else a JSON object without the
+// fields of a format
+func rejectJSONFields(l string, missing string) string {
+	if !json.Valid([]byte(l)) || !strings.HasPrefix(strings.TrimSpace(l), "{") {
+		return "not a JSON object"
+	}
+	return missing
+}
+
+// rejectLogfmt rejects a line without key=value pairs
+func rejectLogfmt(l string) string {
+	for _, field := range strings.Fields(l) {
+		if key, _, ok := strings.Cut(field, "="); ok && key != "" {
+			return "expected key=value pairs separated by spaces"
+		}
+	}
+	return "no key=value pairs"
+}
+
+// ParseLineStrict parses the line like ParseLineToValues, but returns a *ParseError instead of
+// falling back to {"message": line} when no parser recognizes the line. The error lists every
+// format with the reason its parser rejected the line, which helps when developing new log formats.
+func ParseLineStrict(l string) (Row, error) {
+	if l == "" {
+		return nil, ErrEmptyLine
+	}
+
+	var rejections []Rejection
+	result, format := parseLineInto(nil, l, &rejections)
+	if format != FormatPlain {
+		return result, nil
+	}
+	return nil, &ParseError{Line: l, Rejections: rejections}
+}
@@ -0,0 +1,91 @@
+package timeline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_line_strict_returns_row_of_recognized_format(t *testing.T) {
+	is := is.New(t)
+
+	data, err := ParseLineStrict(`{"level":"info","message":"User logged in"}`)
+
+	is.NoErr(err)
+	is.Equal(data["message"], "User logged in")
+}
+
+func Test_parse_line_strict_explains_why_the_line_was_rejected(t *testing.T) {
+	is := is.New(t)
+
+	data, err := ParseLineStrict("just some text")
+
+	is.Equal(data, nil)
+	var parseErr *ParseError
+	is.True(errors.As(err, &parseErr))
+	is.Equal(parseErr.Line, "just some text")
+	is.Equal(parseErr.Rejections[0].Format, FormatMongoDB)
+	is.True(strings.Contains(err.Error(), "syslog: expected a <priority> prefix"))
+}
+
+func Test_parse_line_strict_rejects_every_format_once(t *testing.T) {
+	is := is.New(t)
+
+	_, err := ParseLineStrict("just some text")
+
+	// Every format that a parser detects is rejected, only empty and plain lines have no parser
+	var parseErr *ParseError
+	is.True(errors.As(err, &parseErr))
+	rejected := map[Format]int{}
+	for _, rejection := range parseErr.Rejections {
+		rejected[rejection.Format]++
+	}
+	for format := range formatConfidence {
+		if format == FormatEmpty || format == FormatPlain {
+			continue
+		}
+		is.Equal(rejected[format], 1) // every format is rejected once
+	}
+	is.Equal(len(parseErr.Rejections), len(formatConfidence)-2)
+}
+
+func Test_parse_line_strict_explains_the_failed_check_of_each_parser(t *testing.T) {
+	is := is.New(t)
+	reasons := func(line string) map[Format]string {
+		_, err := ParseLineStrict(line)
+		var parseErr *ParseError
+		is.True(errors.As(err, &parseErr))
+		reasons := map[Format]string{}
+		for _, rejection := range parseErr.Rejections {
+			reasons[rejection.Format] = rejection.Reason
+		}
+		return reasons
+	}
+
+	broken := reasons(`{"level":"info",`)
+	is.True(strings.HasPrefix(broken[FormatJSON], "invalid JSON object: "))
+	is.Equal(broken[FormatGELF], "not a JSON object")
+	is.Equal(broken[FormatCaddy], "not a JSON object")
+	is.Equal(broken[FormatTraefik], "not a JSON object")
+
+	priority := reasons("<abc>message")
+	is.Equal(priority[FormatSyslog], `the priority "abc" is not a number`)
+
+	sentence := reasons("Connection failed, retry: 3")
+	is.Equal(sentence[FormatLogfmt], "no key=value pairs")
+	is.Equal(sentence[FormatLograge], "not logfmt, no key=value pairs")
+	is.Equal(sentence[FormatSquid], "expected an epoch.millis time at the start")
+
+	console := reasons("2024-09-01T12:00:00Z NOTICE starting")
+	is.Equal(console[FormatConsole], `the second field "NOTICE" is not a level of the zap or zerolog console encoder`)
+}
+
+func Test_parse_line_strict_rejects_empty_lines(t *testing.T) {
+	is := is.New(t)
+
+	_, err := ParseLineStrict("")
+
+	is.True(errors.Is(err, ErrEmptyLine))
+}
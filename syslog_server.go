@@ -0,0 +1,277 @@
+package timeline
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSyslogMessageSize limits the size of a single message, RFC5425 requires at least 2048 bytes
+const maxSyslogMessageSize = 64 * 1024
+
+// SyslogServerConfig configures a SyslogServer. Only the listeners with an address are started.
+type SyslogServerConfig struct {
+	// UDPAddr is the address of the UDP listener, e.g. :514
+	UDPAddr string
+	// TCPAddr is the address of the TCP listener, e.g. :514
+	TCPAddr string
+	// TLSAddr is the address of the TLS listener, e.g. :6514; requires TLSConfig
+	TLSAddr   string
+	TLSConfig *tls.Config
+	// Table is the table for all messages, defaults to syslog
+	Table string
+	// TableFor chooses the table per message, e.g. SyslogTableByHost; overrides Table
+	TableFor func(row Row) string
+	// OnError is called for messages that could not be written and for failing connections
+	OnError func(error)
+}
+
+// SyslogServer receives syslog messages (RFC3164 and RFC5424) over UDP, TCP and TLS and writes them to tables.
+// TCP and TLS connections can use newline delimited and octet-counted framing (RFC6587).
+// Every row gets a remote_host column with the address of the sender.
+type SyslogServer struct {
+	writer *Writer
+	config SyslogServerConfig
+}
+
+// NewSyslogServer creates a syslog server, call ListenAndServe to start it
+func NewSyslogServer(writer *Writer, config SyslogServerConfig) *SyslogServer {
+	if config.Table == "" {
+		config.Table = "syslog"
+	}
+	return &SyslogServer{writer: writer, config: config}
+}
+
+// SyslogTableByHost writes the messages of every host to its own table, e.g. web-1 to web_1
+func SyslogTableByHost(row Row) string {
+	return syslogTableName(row["hostname"])
+}
+
+// SyslogTableByApp writes the messages of every application to its own table (app_name or tag)
+func SyslogTableByApp(row Row) string {
+	if app, ok := row["app_name"]; ok {
+		return syslogTableName(app)
+	}
+	// RFC3164 tags often contain the process id: sshd[123]
+	if tag, ok := row["tag"].(string); ok {
+		name, _, _ := strings.Cut(tag, "[")
+		return syslogTableName(name)
+	}
+	return "syslog"
+}
+
+func syslogTableName(value any) string {
+	name, ok := value.(string)
+	if !ok || name == "-" {
+		return "syslog"
+	}
+	name = normalizeColumnName(name)
+	if name == "" {
+		return "syslog"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "syslog_" + name
+	}
+	return name
+}
+
+// ListenAndServe starts the configured listeners and serves them until ctx is done.
+// Returns the error of ctx, or the first error of a listener.
+func (s *SyslogServer) ListenAndServe(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var serves []func() error
+	if s.config.UDPAddr != "" {
+		conn, err := net.ListenPacket("udp", s.config.UDPAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on udp %s: %w", s.config.UDPAddr, err)
+		}
+		serves = append(serves, func() error { return s.ServeUDP(ctx, conn) })
+	}
+	if s.config.TCPAddr != "" {
+		listener, err := net.Listen("tcp", s.config.TCPAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on tcp %s: %w", s.config.TCPAddr, err)
+		}
+		serves = append(serves, func() error { return s.ServeTCP(ctx, listener) })
+	}
+	if s.config.TLSAddr != "" {
+		if s.config.TLSConfig == nil {
+			return errors.New("TLSAddr requires a TLSConfig")
+		}
+		listener, err := tls.Listen("tcp", s.config.TLSAddr, s.config.TLSConfig)
+		if err != nil {
+			return fmt.Errorf("failed to listen on tls %s: %w", s.config.TLSAddr, err)
+		}
+		serves = append(serves, func() error { return s.ServeTCP(ctx, listener) })
+	}
+	if len(serves) == 0 {
+		return errors.New("no syslog listener configured")
+	}
+
+	errs := make(chan error, len(serves))
+	for _, serve := range serves {
+		go func(serve func() error) { errs <- serve() }(serve)
+	}
+
+	// The first listener that stops, stops the others
+	err := <-errs
+	cancel()
+	for i := 1; i < len(serves); i++ {
+		<-errs
+	}
+	return err
+}
+
+// ServeUDP handles the datagrams of conn until ctx is done, every datagram is one message
+func (s *SyslogServer) ServeUDP(ctx context.Context, conn net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, maxSyslogMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read syslog datagram: %w", err)
+		}
+		s.handleMessage(string(buf[:n]), addr)
+	}
+}
+
+// ServeTCP accepts connections on listener until ctx is done. Use a tls.Listener for TLS.
+func (s *SyslogServer) ServeTCP(ctx context.Context, listener net.Listener) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to accept syslog connection: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn reads the messages of a single stream connection
+func (s *SyslogServer) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	reader := bufio.NewReaderSize(conn, 4096)
+	for {
+		message, err := readSyslogFrame(reader)
+		if message != "" {
+			s.handleMessage(message, conn.RemoteAddr())
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				s.reportError(fmt.Errorf("syslog connection %s: %w", conn.RemoteAddr(), err))
+			}
+			return
+		}
+	}
+}
+
+// readSyslogFrame reads one message: octet-counted (starting with the length) or terminated by a newline
+func readSyslogFrame(reader *bufio.Reader) (string, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return "", err
+	}
+
+	if first[0] >= '1' && first[0] <= '9' {
+		lengthField, err := readDelimited(reader, ' ', len(strconv.Itoa(maxSyslogMessageSize))+1)
+		if err != nil {
+			return "", err
+		}
+		length, err := strconv.Atoi(strings.TrimSuffix(string(lengthField), " "))
+		if err != nil || length > maxSyslogMessageSize {
+			return "", fmt.Errorf("invalid octet count %q", lengthField)
+		}
+		message := make([]byte, length)
+		if _, err := io.ReadFull(reader, message); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(message), "\r\n"), nil
+	}
+
+	line, err := readDelimited(reader, '\n', maxSyslogMessageSize)
+	return strings.TrimRight(string(line), "\r\n\x00"), err
+}
+
+// errFrameTooLong is returned by readDelimited for a frame longer than its limit
+var errFrameTooLong = errors.New("frame too long")
+
+// readDelimited reads up to and including delim, like ReadBytes, but stops at limit bytes
+// with errFrameTooLong, so a client without delimiters can't fill the memory. The
+// connection should be dropped then, the rest of the frame is still unread.
+func readDelimited(reader *bufio.Reader, delim byte, limit int) ([]byte, error) {
+	var frame []byte
+	for {
+		chunk, err := reader.ReadSlice(delim)
+		if len(frame)+len(chunk) > limit {
+			return nil, fmt.Errorf("%w: longer than %d bytes", errFrameTooLong, limit)
+		}
+		frame = append(frame, chunk...)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return frame, err
+		}
+	}
+}
+
+// handleMessage parses the message and writes it
+func (s *SyslogServer) handleMessage(message string, addr net.Addr) {
+	message = strings.TrimRight(message, "\r\n\x00")
+	if message == "" {
+		return
+	}
+
+	row := parseSyslog(message)
+	if row == nil {
+		row = Row{"message": message}
+	}
+	if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+		row["remote_host"] = host
+	}
+
+	table := s.config.Table
+	if s.config.TableFor != nil {
+		table = s.config.TableFor(row)
+	}
+
+	if err := s.writer.Write(table, NewRow(time.Now(), row)); err != nil {
+		s.reportError(fmt.Errorf("failed to write syslog message: %w", err))
+	}
+}
+
+func (s *SyslogServer) reportError(err error) {
+	handleError(s.config.OnError, err, ErrorContext{Component: "syslog_server", Table: s.config.Table})
+}
@@ -0,0 +1,101 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func startSyslogServer(t *testing.T, server *SyslogServer) (net.Addr, net.Addr) {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on udp: %v", err)
+	}
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on tcp: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{}, 2)
+	go func() { server.ServeUDP(ctx, udp); done <- struct{}{} }()
+	go func() { server.ServeTCP(ctx, tcp); done <- struct{}{} }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		<-done
+	})
+
+	return udp.LocalAddr(), tcp.Addr()
+}
+
+func Test_syslog_server_receives_udp_messages(t *testing.T) {
+	is, writer := setup(t)
+	server := NewSyslogServer(writer, SyslogServerConfig{OnError: func(err error) { t.Errorf("server error: %v", err) }})
+	udpAddr, _ := startSyslogServer(t, server)
+
+	conn, err := net.Dial("udp", udpAddr.String())
+	is.NoErr(err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8\n"))
+	is.NoErr(err)
+
+	waitForCount(t, writer, `SELECT count(*) FROM syslog WHERE hostname = 'mymachine' AND tag = 'su' AND remote_host = '127.0.0.1'`, 1)
+}
+
+func Test_syslog_server_receives_tcp_frames_per_host(t *testing.T) {
+	is, writer := setup(t)
+	server := NewSyslogServer(writer, SyslogServerConfig{TableFor: SyslogTableByHost})
+	_, tcpAddr := startSyslogServer(t, server)
+
+	conn, err := net.Dial("tcp", tcpAddr.String())
+	is.NoErr(err)
+	// An octet-counted frame with a newline in the message, followed by a newline delimited message
+	framed := "<165>1 2003-10-11T22:14:15.003Z web-1 app - ID47 - line one\nline two"
+	_, err = conn.Write([]byte("68 " + framed + "<34>Oct 11 22:14:15 db-1 postgres: checkpoint starting\n"))
+	is.NoErr(err)
+	conn.Close()
+
+	waitForCount(t, writer, `SELECT count(*) FROM web_1 WHERE message = 'line one'||chr(10)||'line two'`, 1)
+	waitForCount(t, writer, `SELECT count(*) FROM db_1 WHERE tag = 'postgres'`, 1)
+}
+
+func Test_syslog_server_drops_a_connection_without_newlines(t *testing.T) {
+	is, writer := setup(t)
+	errs := make(chan error, 1)
+	server := NewSyslogServer(writer, SyslogServerConfig{OnError: func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}})
+	_, tcpAddr := startSyslogServer(t, server)
+
+	conn, err := net.Dial("tcp", tcpAddr.String())
+	is.NoErr(err)
+	defer conn.Close()
+
+	// When a client sends more than the size of a message without a newline
+	go conn.Write(bytes.Repeat([]byte("a"), 4*maxSyslogMessageSize))
+
+	// Then the connection is dropped before the rest is read
+	is.True(errors.Is(<-errs, errFrameTooLong))
+	is.NoErr(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	is.True(err != nil && !errors.Is(err, os.ErrDeadlineExceeded)) // closed, not idle
+}
+
+func Test_syslog_table_by_app(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(SyslogTableByApp(Row{"app_name": "nginx"}), "nginx")
+	is.Equal(SyslogTableByApp(Row{"tag": "sshd[4242]"}), "sshd")
+	is.Equal(SyslogTableByApp(Row{"app_name": "-"}), "syslog")
+	is.Equal(SyslogTableByApp(Row{"message": "hello"}), "syslog")
+}
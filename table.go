@@ -0,0 +1,242 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DropTable drops a table, or all partitions of a partitioned table (see PartitionDaily) or the
+// tables of a table template (e.g. logs_{2006_01}).
+// The table is no longer partitioned or archived (see ArchiveTo); the archived files are kept.
+func (w *Writer) DropTable(ctx context.Context, table string) error {
+	tables, err := w.storedTables(table)
+	if err != nil {
+		return err
+	}
+
+	err = w.inTransaction(ctx, func(tx *sql.Tx) error {
+		for _, stored := range tables {
+			if err := w.execDDL(ctx, tx, stored, fmt.Sprintf("DROP TABLE %s", quoteTableName(stored)), string(TableDropped)); err != nil {
+				return fmt.Errorf("failed to drop table %s: %w", stored, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.partitionMu.Lock()
+	delete(w.partitioned, table)
+	delete(w.templates, table)
+	delete(w.archives, table)
+	w.partitionMu.Unlock()
+
+	w.schemaChanged(table, SchemaEvent{Kind: TableDropped})
+	return nil
+}
+
+// TruncateTable deletes all rows of a table and keeps its columns. The daily partitions of a
+// partitioned table are dropped. The archived rows (see ArchiveTo) are kept.
+func (w *Writer) TruncateTable(ctx context.Context, table string) error {
+	tables, err := w.storedTables(table)
+	if err != nil {
+		return err
+	}
+
+	partitioned := w.isPartitioned(table)
+	return w.inTransaction(ctx, func(tx *sql.Tx) error {
+		for _, stored := range tables {
+			var err error
+			if partitioned {
+				err = w.execDDL(ctx, tx, stored, fmt.Sprintf("DROP TABLE %s", quoteTableName(stored)), string(TableDropped))
+			} else {
+				_, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", quoteTableName(stored)))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to truncate table %s: %w", stored, err)
+			}
+		}
+		return nil
+	})
+}
+
+// RenameTable renames a table, or the daily partitions of a partitioned table. The partitioning
+// and the archive of the table (see PartitionDaily and ArchiveTo) move to the new name; the
+// directory of a local archive is renamed. Tables with an archive in object storage can't be
+// renamed. The new name can't be qualified with another database, table templates (e.g.
+// logs_{2006_01}) can't be renamed.
+func (w *Writer) RenameTable(ctx context.Context, table string, name string) error {
+	if strings.Contains(name, ".") {
+		return fmt.Errorf("the new name of table %s can't be qualified: %s", table, name)
+	}
+	if isTableTemplate(table) || isTableTemplate(name) {
+		return fmt.Errorf("table template %s can't be renamed", table)
+	}
+	tables, err := w.storedTables(table)
+	if err != nil {
+		return err
+	}
+	dir, archived := w.archiveDir(table)
+	if archived && strings.Contains(dir, "://") {
+		return fmt.Errorf("the archive of table %s in %s can't be renamed", table, dir)
+	}
+
+	_, oldName := splitTableName(table)
+	err = w.inTransaction(ctx, func(tx *sql.Tx) error {
+		for _, stored := range tables {
+			_, storedName := splitTableName(stored)
+			renamed := name + strings.TrimPrefix(storedName, oldName)
+			if err := w.execDDL(ctx, tx, stored, fmt.Sprintf("ALTER TABLE %s RENAME TO %s", quoteTableName(stored), quoteIdentifier(renamed)), string(TableRenamed)); err != nil {
+				return fmt.Errorf("failed to rename table %s: %w", stored, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	database, _ := splitTableName(table)
+	if database != "" {
+		name = database + "." + name
+	}
+	if archived {
+		if err := os.Rename(archivePath(dir, table), archivePath(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rename the archive of table %s: %w", table, err)
+		}
+	}
+
+	w.partitionMu.Lock()
+	if w.partitioned[table] {
+		delete(w.partitioned, table)
+		w.partitioned[name] = true
+	}
+	if archived {
+		delete(w.archives, table)
+		w.archives[name] = dir
+	}
+	w.partitionMu.Unlock()
+
+	w.schemaChanged(table, SchemaEvent{Kind: TableRenamed, Name: name})
+	return nil
+}
+
+// storedTables returns the table, or the daily partitions of a partitioned table
+func (w *Writer) storedTables(table string) ([]string, error) {
+	if w.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	if w.isPartitioned(table) {
+		partitions, err := w.partitions(table, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		if len(partitions) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+		}
+		return partitions, nil
+	}
+
+	columns, err := w.getCurrentColumns(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+	}
+	return []string{table}, nil
+}
+
+// inTransaction runs the statements of fn in a transaction, either all succeed or none
+func (w *Writer) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RenameColumn renames a column of a table, or of all daily partitions of a partitioned table.
+// The timestamp column can't be renamed.
+func (w *Writer) RenameColumn(ctx context.Context, table string, column string, name string) error {
+	if column == "timestamp" {
+		return errors.New("the timestamp column can't be renamed")
+	}
+	return w.alterColumn(ctx, table, column, fmt.Sprintf("RENAME COLUMN %s TO %s", quoteIdentifier(column), quoteIdentifier(name)), SchemaEvent{Kind: ColumnRenamed, Name: name})
+}
+
+// DropColumn drops a column of a table, or of all daily partitions of a partitioned table. The
+// timestamp column can't be dropped.
+func (w *Writer) DropColumn(ctx context.Context, table string, column string) error {
+	if column == "timestamp" {
+		return errors.New("the timestamp column can't be dropped")
+	}
+	return w.alterColumn(ctx, table, column, fmt.Sprintf("DROP COLUMN %s", quoteIdentifier(column)), SchemaEvent{Kind: ColumnDropped})
+}
+
+// SetColumnType changes the type of a column, e.g. back to BIGINT when a column was promoted to
+// VARCHAR by a malformed value. castExpr is the SQL expression of the new values, e.g.
+// TRY_CAST(status AS BIGINT); an empty castExpr casts the values and fails for values that can't
+// be cast. Written rows still promote the column when their values don't fit the type.
+func (w *Writer) SetColumnType(ctx context.Context, table string, column string, columnType ColumnType, castExpr string) error {
+	statement := fmt.Sprintf("ALTER COLUMN %s SET DATA TYPE %s", quoteIdentifier(column), columnType)
+	if castExpr != "" {
+		statement += " USING " + castExpr
+	}
+	return w.alterColumn(ctx, table, column, statement, SchemaEvent{Kind: ColumnTypeChanged, Type: columnType})
+}
+
+// alterColumn alters a column of a table, or of the daily partitions that have the column, and
+// publishes the event of the alteration
+func (w *Writer) alterColumn(ctx context.Context, table string, column string, alteration string, event SchemaEvent) (err error) {
+	ctx, endSpan := w.startSpan(ctx, SpanAlterColumn, map[string]string{"table": table, "column": column, "alteration": alteration})
+	defer func() { endSpan(err) }()
+
+	tables, err := w.storedTables(table)
+	if err != nil {
+		return err
+	}
+
+	err = w.inTransaction(ctx, func(tx *sql.Tx) error {
+		altered := 0
+		for _, stored := range tables {
+			columns, err := w.getCurrentColumns(stored)
+			if err != nil {
+				return err
+			}
+			if _, ok := columns[column]; !ok {
+				continue
+			}
+			if err := w.execDDL(ctx, tx, stored, fmt.Sprintf("ALTER TABLE %s %s", quoteTableName(stored), alteration), string(event.Kind)); err != nil {
+				return fmt.Errorf("failed to alter column %s of %s: %w", column, stored, err)
+			}
+			altered++
+		}
+		if altered == 0 {
+			return fmt.Errorf("table %s has no column %s", table, column)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	event.Column = column
+	w.schemaChanged(table, event)
+	return nil
+}
@@ -0,0 +1,75 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TableStats describes the stored rows of a table, see Writer.TableStats
+type TableStats struct {
+	// Rows is the number of rows, with the archived rows (see ArchiveTo)
+	Rows int64
+	// Bytes is an estimate of the size of the table in the database file: the size of its blocks,
+	// which small tables share. The rows that are not checkpointed yet and the rows of an
+	// in-memory database are not counted.
+	Bytes int64
+	// Earliest and Latest are the timestamps of the oldest and the newest row, zero without rows
+	Earliest time.Time
+	Latest   time.Time
+	// Columns is the number of columns, with the timestamp
+	Columns int
+}
+
+// TableStats returns the number of rows, the size, the time range and the number of columns of a
+// table (or of all partitions of a partitioned table) in one call, e.g. for a dashboard that lists
+// the tables. Returns ErrTableNotFound for a table that doesn't exist.
+func (w *Writer) TableStats(ctx context.Context, table string) (TableStats, error) {
+	ctx, cancel := w.withQueryTimeout(ctx)
+	defer cancel()
+
+	tables := []string{table}
+	if w.isPartitioned(table) {
+		var err error
+		if tables, err = w.partitions(table, time.Time{}, time.Time{}); err != nil {
+			return TableStats{}, err
+		}
+	}
+
+	var stats TableStats
+	columns := map[string]bool{}
+	for _, stored := range tables {
+		current, err := w.getCurrentColumns(stored)
+		if err != nil {
+			return TableStats{}, err
+		}
+		if len(current) == 0 {
+			return TableStats{}, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+		}
+		for column := range current {
+			columns[column] = true
+		}
+
+		var bytes int64
+		query := fmt.Sprintf("SELECT count(DISTINCT block_id) * (SELECT block_size FROM pragma_database_size() WHERE database_name = coalesce(nullif(?, ''), current_database())) FROM pragma_storage_info(%s) WHERE persistent AND block_id >= 0", quoteString(stored))
+		database, _ := splitTableName(stored)
+		if err := w.DB.QueryRowContext(ctx, query, database).Scan(&bytes); err != nil {
+			return TableStats{}, fmt.Errorf("failed to get the size of %s: %w", stored, err)
+		}
+		stats.Bytes += bytes
+	}
+	stats.Columns = len(columns)
+
+	source, _, err := w.tableSource(table, time.Time{}, time.Time{})
+	if err != nil {
+		return TableStats{}, err
+	}
+	var earliest, latest sql.NullTime
+	query := fmt.Sprintf("SELECT count(*), min(timestamp), max(timestamp) FROM %s", source)
+	if err := w.DB.QueryRowContext(ctx, query).Scan(&stats.Rows, &earliest, &latest); err != nil {
+		return TableStats{}, fmt.Errorf("failed to get the stats of %s: %w", table, err)
+	}
+	stats.Earliest, stats.Latest = earliest.Time, latest.Time
+	return stats, nil
+}
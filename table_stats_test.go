@@ -0,0 +1,54 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_table_stats_describes_the_rows_of_a_table(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	// Given a table with rows
+	is.NoErr(writer.Write("app", NewRow(now, Row{"message": "started"})))
+	is.NoErr(writer.Write("app", NewRow(now.Add(time.Hour), Row{"message": "stopped", "status": 1})))
+
+	// When the stats are requested
+	stats, err := writer.TableStats(ctx, "app")
+	is.NoErr(err)
+
+	// Then they describe the rows, an in-memory table has no size on disk
+	is.Equal(stats, TableStats{Rows: 2, Earliest: now, Latest: now.Add(time.Hour), Columns: 3})
+
+	// And a table that doesn't exist is not found
+	_, err = writer.TableStats(ctx, "missing")
+	is.True(errors.Is(err, ErrTableNotFound))
+}
+
+func Test_table_stats_of_a_partitioned_table_on_disk(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	writer, err := NewStorageClient(filepath.Join(t.TempDir(), "app.db"))
+	is.NoErr(err)
+	defer writer.Close()
+	writer.PartitionDaily("logs")
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	is.NoErr(writer.Write("logs", NewRow(now, Row{"message": "first"})))
+	is.NoErr(writer.Write("logs", NewRow(now.Add(24*time.Hour), Row{"level": "info"})))
+	is.NoErr(writer.Checkpoint())
+
+	stats, err := writer.TableStats(ctx, "logs")
+	is.NoErr(err)
+	is.Equal(stats.Rows, int64(2))
+	is.Equal(stats.Earliest, now)
+	is.Equal(stats.Latest, now.Add(24*time.Hour))
+	is.Equal(stats.Columns, 3)
+	is.True(stats.Bytes > 0)
+}
@@ -0,0 +1,72 @@
+package timeline
+
+import (
+	"strings"
+	"time"
+)
+
+// tableTemplate is a table name with a Go time layout in braces, e.g. logs_{2006_01}: the rows of
+// the template are written to the table of the time of their timestamp (UTC), e.g. logs_2025_09.
+// Query, Between and Export read the tables of the time range as one table, like the partitions of
+// PartitionDaily, which are the tables of the template table_{20060102}.
+type tableTemplate struct {
+	prefix string
+	layout string
+	suffix string
+}
+
+// parseTableTemplate parses a table name with one time layout in braces
+func parseTableTemplate(table string) (tableTemplate, bool) {
+	start := strings.IndexByte(table, '{')
+	end := strings.LastIndexByte(table, '}')
+	if start < 0 || end < start+2 || strings.ContainsAny(table[start+1:end], "{}") {
+		return tableTemplate{}, false
+	}
+	template := tableTemplate{prefix: table[:start], layout: table[start+1 : end], suffix: table[end+1:]}
+	// A layout without a year, e.g. {logs}, doesn't change over time
+	if template.name(time.Time{}) == template.name(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		return tableTemplate{}, false
+	}
+	return template, true
+}
+
+// isTableTemplate reports whether a table name is a template, e.g. logs_{2006_01}
+func isTableTemplate(table string) bool {
+	_, ok := parseTableTemplate(table)
+	return ok
+}
+
+// name returns the table of the template of the time
+func (t tableTemplate) name(timestamp time.Time) string {
+	return t.prefix + timestamp.UTC().Format(t.layout) + t.suffix
+}
+
+// period returns the start and end of the times of a table of the template
+func (t tableTemplate) period(table string) (time.Time, time.Time, bool) {
+	value, ok := strings.CutPrefix(table, t.prefix)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	if value, ok = strings.CutSuffix(value, t.suffix); !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	start, err := time.Parse(t.layout, value)
+	if err != nil || t.name(start) != table {
+		return time.Time{}, time.Time{}, false
+	}
+
+	// The period ends at the first unit of time that changes the name, e.g. a month for 2006_01
+	steps := []func(time.Time) time.Time{
+		func(t time.Time) time.Time { return t.Add(time.Second) },
+		func(t time.Time) time.Time { return t.Add(time.Minute) },
+		func(t time.Time) time.Time { return t.Add(time.Hour) },
+		func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+		func(t time.Time) time.Time { return t.AddDate(0, 1, 0) },
+	}
+	for _, step := range steps {
+		if end := step(start); t.name(end) != table {
+			return start, end, true
+		}
+	}
+	return start, start.AddDate(1, 0, 0), true
+}
@@ -0,0 +1,94 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_table_template_period(t *testing.T) {
+	is := is.New(t)
+	template, ok := parseTableTemplate("logs_{2006_01}")
+	is.True(ok)
+
+	start, end, ok := template.period("logs_2025_09")
+	is.True(ok)
+	is.Equal(start, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))
+	is.Equal(end, time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC))
+
+	_, _, ok = template.period("logs_2025_9")
+	is.True(!ok)
+	_, _, ok = template.period("logs_2025_09_extra")
+	is.True(!ok)
+
+	hourly, ok := parseTableTemplate("{2006010215}_requests")
+	is.True(ok)
+	is.Equal(hourly.name(time.Date(2025, 9, 14, 23, 30, 0, 0, time.UTC)), "2025091423_requests")
+	start, end, ok = hourly.period("2025091423_requests")
+	is.True(ok)
+	is.Equal(end.Sub(start), time.Hour)
+}
+
+func Test_table_template_requires_a_time_layout(t *testing.T) {
+	is := is.New(t)
+	for _, table := range []string{"logs", "logs_{}", "logs_{logs}", "logs_{2006}_{01}"} {
+		_, ok := parseTableTemplate(table)
+		is.True(!ok)
+	}
+}
+
+func Test_table_template_writes_a_table_per_month(t *testing.T) {
+	is, writer := setup(t)
+	ctx := context.Background()
+
+	// When rows of three months are written to the template
+	for month := time.July; month <= time.September; month++ {
+		is.NoErr(writer.Write("logs_{2006_01}", NewRow(time.Date(2025, month, 14, 12, 0, 0, 0, time.UTC), Row{"month": int(month)})))
+	}
+
+	// Then they are in the tables of their month
+	tables, err := writer.tableNames(ctx)
+	is.NoErr(err)
+	is.Equal(tables, []string{"logs_2025_07", "logs_2025_08", "logs_2025_09"})
+
+	// And the template queries the tables of the time range
+	rows, err := writer.Query(ctx, "logs_{2006_01}", QueryOptions{From: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)})
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.Equal(rows[0]["month"], 8)
+	is.Equal(rows[1]["month"], 9)
+}
+
+func Test_max_age_drops_the_old_tables_of_a_template(t *testing.T) {
+	is, writer := setup(t)
+	now := time.Now().UTC()
+
+	// Given a row of this month and of three months ago
+	is.NoErr(writer.Write("logs_{2006_01}", NewRow(now, Row{"message": "new"})))
+	is.NoErr(writer.Write("logs_{2006_01}", NewRow(now.AddDate(0, -3, 0), Row{"message": "old"})))
+
+	// When the rows of more than 40 days old are deleted
+	deleted, err := writer.ApplyRetention(context.Background(), MaxAge(40*24*time.Hour, "logs_{2006_01}"))
+
+	// Then the table of the old row is dropped
+	is.NoErr(err)
+	is.Equal(deleted, int64(1))
+	tables, err := writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.Equal(tables, []string{"logs_" + now.Format("2006_01")})
+}
+
+func Test_table_template_can_be_dropped_but_not_renamed(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("logs_{2006_01}", NewRow(time.Now(), Row{"message": "hello"})))
+
+	err := writer.RenameTable(context.Background(), "logs_{2006_01}", "events_{2006_01}")
+	is.True(err != nil)
+
+	is.NoErr(writer.DropTable(context.Background(), "logs_{2006_01}"))
+	tables, err := writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.Equal(len(tables), 0)
+}
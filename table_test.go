@@ -0,0 +1,143 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_drop_table(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "hello"})))
+
+	is.NoErr(writer.DropTable(context.Background(), "logs"))
+
+	_, err := writer.Query(context.Background(), "logs", QueryOptions{})
+	is.True(errors.Is(err, ErrTableNotFound))
+	is.True(errors.Is(writer.DropTable(context.Background(), "logs"), ErrTableNotFound))
+}
+
+func Test_drop_partitioned_table(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("logs")
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "today"})))
+	is.NoErr(writer.Write("logs", NewRow(time.Now().AddDate(0, 0, -1), Row{"message": "yesterday"})))
+
+	is.NoErr(writer.DropTable(context.Background(), "logs"))
+
+	tables, err := writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.Equal(len(tables), 0)
+	is.True(!writer.isPartitioned("logs"))
+}
+
+func Test_truncate_table_keeps_the_columns(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "hello", "status": 200})))
+
+	is.NoErr(writer.TruncateTable(context.Background(), "logs"))
+
+	waitForCount(t, writer, "SELECT count(*) FROM logs", 0)
+	columns, err := writer.getCurrentColumns("logs")
+	is.NoErr(err)
+	is.Equal(len(columns), 3)
+}
+
+func Test_rename_table(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "hello"})))
+
+	is.NoErr(writer.RenameTable(context.Background(), "logs", "app"))
+
+	rows, err := writer.Query(context.Background(), "app", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(len(rows), 1)
+	_, err = writer.Query(context.Background(), "logs", QueryOptions{})
+	is.True(errors.Is(err, ErrTableNotFound))
+}
+
+func Test_rename_partitioned_and_archived_table(t *testing.T) {
+	is, writer := setup(t)
+	dir := t.TempDir()
+	writer.PartitionDaily("logs")
+	writer.ArchiveTo(dir, "logs")
+	now := time.Now().UTC()
+	is.NoErr(writer.Write("logs", NewRow(now.AddDate(0, 0, -3), Row{"message": "archived"})))
+	is.NoErr(writer.Write("logs", NewRow(now, Row{"message": "today"})))
+	_, err := writer.ApplyRetention(context.Background(), ArchiveAfter(24*time.Hour))
+	is.NoErr(err)
+
+	// When the table is renamed
+	is.NoErr(writer.RenameTable(context.Background(), "logs", "app"))
+
+	// Then the partitions, the partitioning and the archive are renamed
+	tables, err := writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.Equal(tables, []string{partitionName("app", now)})
+	files, err := filepath.Glob(filepath.Join(dir, "app", "*.parquet"))
+	is.NoErr(err)
+	is.Equal(len(files), 1)
+	rows, err := writer.Query(context.Background(), "app", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(len(rows), 2)
+	is.NoErr(writer.Write("app", NewRow(now, Row{"message": "renamed"})))
+	tables, err = writer.tableNames(context.Background())
+	is.NoErr(err)
+	is.Equal(len(tables), 1)
+}
+
+func Test_rename_column(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"msg": "hello"})))
+
+	is.NoErr(writer.RenameColumn(context.Background(), "logs", "msg", "message"))
+
+	rows, err := writer.Query(context.Background(), "logs", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(rows[0]["message"], "hello")
+	is.True(writer.RenameColumn(context.Background(), "logs", "timestamp", "time") != nil)
+	is.True(writer.RenameColumn(context.Background(), "logs", "missing", "other") != nil)
+}
+
+func Test_drop_column_of_partitions(t *testing.T) {
+	is, writer := setup(t)
+	writer.PartitionDaily("logs")
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"message": "today", "secret": "a"})))
+	is.NoErr(writer.Write("logs", NewRow(time.Now().AddDate(0, 0, -1), Row{"message": "yesterday", "secret": "b"})))
+	is.NoErr(writer.Write("logs", NewRow(time.Now().AddDate(0, 0, -2), Row{"message": "before"})))
+
+	is.NoErr(writer.DropColumn(context.Background(), "logs", "secret"))
+
+	rows, err := writer.Query(context.Background(), "logs", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(len(rows), 3)
+	for _, row := range rows {
+		_, ok := row["secret"]
+		is.True(!ok)
+	}
+}
+
+func Test_set_column_type_with_cast_expression(t *testing.T) {
+	is, writer := setup(t)
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"status": 200})))
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"status": "n/a"})))
+	columns, err := writer.getCurrentColumns("logs")
+	is.NoErr(err)
+	is.Equal(columns["status"], Varchar)
+
+	// Without a cast expression the values that can't be cast fail
+	is.True(writer.SetColumnType(context.Background(), "logs", "status", Bigint, "") != nil)
+
+	is.NoErr(writer.SetColumnType(context.Background(), "logs", "status", Bigint, "TRY_CAST(status AS BIGINT)"))
+
+	columns, err = writer.getCurrentColumns("logs")
+	is.NoErr(err)
+	is.Equal(columns["status"], Bigint)
+	rows, err := writer.Query(context.Background(), "logs", QueryOptions{})
+	is.NoErr(err)
+	is.Equal(rows[0]["status"], 200)
+	_, ok := rows[1]["status"]
+	is.True(!ok)
+}
@@ -0,0 +1,121 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"time"
+)
+
+// RunTailCommand runs the tail command of the timeline binary: it writes the newest rows of a
+// table of a database file (of all tables without --table) to stdout as JSON lines. With -f it
+// keeps writing the new rows until ctx is done. args are the arguments after the command name,
+// e.g.
+//
+//	timeline tail --db app.db --table logs -n 20 -f
+//
+// The database is opened read-only for every poll, which DuckDB doesn't allow while another process
+// writes to it: the new rows appear when the writer closes the file, e.g. between the runs of a
+// job. Returns flag.ErrHelp for -h.
+func RunTailCommand(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: timeline tail --db <path> [--table <name>] [-n <rows>] [-f]")
+		flags.PrintDefaults()
+	}
+	dbPath := flags.String("db", "", "path of the database file (required)")
+	table := flags.String("table", "", "table of the rows, all tables by default")
+	n := flags.Int("n", 10, "number of rows")
+	follow := flags.Bool("f", false, "keep writing the new rows, requires --table")
+	interval := flags.Duration("interval", time.Second, "time between the polls of -f")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbPath == "" || (*follow && *table == "") {
+		flags.Usage()
+		return errors.New("the --db flag is required, and the --table flag with -f")
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	if !*follow {
+		writer, err := NewReadOnlyClient(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer writer.Close()
+		rows, err := writer.Tail(ctx, *table, *n)
+		if err != nil {
+			return err
+		}
+		return writeJSONLines(stdout, rows)
+	}
+
+	// The newest rows, then the rows after the cursor of the last written row
+	cursor := ""
+	poll := func(opts QueryOptions, keep int) error {
+		writer, err := NewReadOnlyClient(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer writer.Close()
+
+		opts.cursor = true
+		rows, err := writer.Query(ctx, *table, opts)
+		if err != nil {
+			return err
+		}
+		if opts.Descending {
+			slices.Reverse(rows)
+		}
+		if len(rows) > 0 {
+			last := rows[len(rows)-1]
+			timestamp, _ := last["_cursor_timestamp"].(time.Time)
+			rowID, _ := last["_cursor_rowid"].(int)
+			cursor = encodeCursor(timestamp, rowID)
+		}
+		rows = rows[len(rows)-min(keep, len(rows)):]
+		for _, row := range rows {
+			delete(row, "_cursor_timestamp")
+			delete(row, "_cursor_rowid")
+		}
+		return writeJSONLines(stdout, rows)
+	}
+
+	// The first poll writes the newest rows and sets the cursor (also with -n 0), the next polls
+	// write the rows after the cursor
+	started := false
+	reported := ""
+	for {
+		var err error
+		if !started {
+			err = poll(QueryOptions{Descending: true, Limit: max(*n, 1)}, *n)
+		} else {
+			err = poll(QueryOptions{After: cursor}, math.MaxInt)
+		}
+		switch {
+		case err == nil:
+			started = true
+			reported = ""
+		case errors.Is(err, ErrTableNotFound) || ctx.Err() != nil:
+		case err.Error() != reported:
+			// E.g. the database is locked by a writer, the error is reported once and the next
+			// poll is tried
+			fmt.Fprintf(stderr, "timeline: %v\n", err)
+			reported = err.Error()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
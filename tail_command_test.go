@@ -0,0 +1,90 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// syncBuffer is a bytes.Buffer that a command writes to while the test reads it
+type syncBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.String()
+}
+
+func Test_tail_command_writes_the_newest_rows(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	dbPath := commandDatabase(t,
+		NewRow(start, Row{"n": 1}),
+		NewRow(start.Add(time.Minute), Row{"n": 2}),
+		NewRow(start.Add(2*time.Minute), Row{"n": 3}),
+	)
+	var stdout, stderr bytes.Buffer
+
+	err := RunTailCommand(context.Background(), []string{"--db", dbPath, "--table", "logs", "-n", "2"}, nil, &stdout, &stderr)
+
+	is.NoErr(err)
+	is.Equal(stdout.String(), `{"n":2,"timestamp":"2024-06-01T12:01:00Z"}`+"\n"+`{"n":3,"timestamp":"2024-06-01T12:02:00Z"}`+"\n")
+}
+
+func Test_tail_command_follows_new_rows(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	dbPath := commandDatabase(t,
+		NewRow(start, Row{"n": 1}),
+		NewRow(start.Add(time.Minute), Row{"n": 2}),
+	)
+	var stdout, stderr syncBuffer
+
+	// Given a tail -f of the newest row
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- RunTailCommand(ctx, []string{"--db", dbPath, "--table", "logs", "-n", "1", "-f", "--interval", "10ms"}, nil, &stdout, &stderr)
+	}()
+	is.True(waitFor(func() bool { return stdout.String() != "" }))
+
+	// When another process writes a row while the tail waits for the next poll
+	var writer *Writer
+	is.True(waitFor(func() bool {
+		var err error
+		writer, err = NewStorageClient(dbPath)
+		return err == nil
+	}))
+	is.NoErr(writer.Write("logs", NewRow(start.Add(2*time.Minute), Row{"n": 3})))
+	is.NoErr(writer.Close())
+
+	// Then the new row is written after the newest row
+	is.True(waitFor(func() bool { return strings.Count(stdout.String(), "\n") == 2 }))
+	cancel()
+	is.NoErr(<-done)
+	is.Equal(stdout.String(), `{"n":2,"timestamp":"2024-06-01T12:01:00Z"}`+"\n"+`{"n":3,"timestamp":"2024-06-01T12:02:00Z"}`+"\n")
+}
+
+// waitFor polls the condition for a second
+func waitFor(condition func() bool) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,323 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TailerConfig configures a Tailer
+type TailerConfig struct {
+	// Table is the table the rows are written to
+	Table string
+	// Parser parses the lines, defaults to ParseLineToValues
+	Parser Parser
+	// OffsetFile persists the read offset, so a restarted tailer continues where it stopped.
+	// Without an offset file the tailer starts at the end of the file (or the start, see FromStart).
+	OffsetFile string
+	// FromStart reads a file from the beginning when there is no persisted offset
+	FromStart bool
+	// PollInterval is the time between checks for new lines, defaults to 250ms
+	PollInterval time.Duration
+	// MaxLineSize is the maximum number of bytes of a line, defaults to 1 MiB.
+	// Longer lines are skipped and reported with ErrLineTooLong.
+	MaxLineSize int
+	// OnError is called for lines that could not be written and for offset files that could not be saved
+	OnError func(error)
+}
+
+// fingerprintSize is the number of bytes at the start of a file that identify the file in the offset file
+const fingerprintSize = 1024
+
+// Tailer follows a log file like tail -F and writes every new line to a table.
+// Truncated files are read again from the start, rotated files (renamed or removed and
+// recreated) are read till the end before the tailer switches to the new file.
+type Tailer struct {
+	writer  *Writer
+	path    string
+	config  TailerConfig
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+	// skipping is true while the rest of a too long line is read
+	skipping bool
+	saved    int64
+}
+
+// tailerOffset is the content of the offset file. The fingerprint is a hash of the start
+// of the file, so a file that replaced the tailed file is not read from the middle.
+type tailerOffset struct {
+	Path        string `json:"path"`
+	Offset      int64  `json:"offset"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// NewTailer creates a tailer for the file at path, call Run to start following it
+func NewTailer(writer *Writer, path string, config TailerConfig) *Tailer {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 250 * time.Millisecond
+	}
+	if config.Parser == nil {
+		config.Parser = ParserFunc(ParseLineToValues)
+	}
+	if config.MaxLineSize <= 0 {
+		config.MaxLineSize = 1 << 20
+	}
+	return &Tailer{writer: writer, path: path, config: config, saved: -1}
+}
+
+// Offset returns the position in the current file up to where all lines are written
+func (t *Tailer) Offset() int64 {
+	return t.offset
+}
+
+// Run follows the file until ctx is done and returns the error of ctx.
+// The file doesn't need to exist yet, the tailer waits until it is created.
+func (t *Tailer) Run(ctx context.Context) error {
+	defer t.close()
+
+	ticker := time.NewTicker(t.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.poll(); err != nil {
+			t.reportError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll opens the file when needed, reads the new lines and handles truncation and rotation
+func (t *Tailer) poll() error {
+	if t.file == nil {
+		opened, err := t.open()
+		if err != nil || !opened {
+			return err
+		}
+	}
+
+	info, err := t.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", t.path, err)
+	}
+
+	// Truncated (e.g. by logrotate copytruncate): start over
+	if info.Size() < t.offset {
+		t.offset = 0
+		t.partial = nil
+		t.skipping = false
+	}
+
+	if err := t.readLines(); err != nil {
+		return err
+	}
+
+	// Rotated: the path points to another file (or to nothing) now
+	current, err := os.Stat(t.path)
+	if errors.Is(err, os.ErrNotExist) || err == nil && !os.SameFile(t.info, current) {
+		// Lines without a newline at the end of the old file are complete now,
+		// the old file is kept open until the line is written
+		if len(t.partial) > 0 && !t.skipping {
+			if err := t.writeLine(string(t.partial)); err != nil {
+				return err
+			}
+		}
+		t.close()
+		t.offset = 0
+		t.partial = nil
+		t.skipping = false
+		t.saveOffset()
+	}
+	return nil
+}
+
+// open opens the file and moves to the start offset, returns false when the file doesn't exist (yet)
+func (t *Tailer) open() (bool, error) {
+	file, err := os.Open(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", t.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return false, fmt.Errorf("failed to stat %s: %w", t.path, err)
+	}
+
+	stored, ok := t.loadOffset()
+	switch {
+	case ok && stored.Offset <= info.Size() && fingerprintOf(file, stored.Offset) == stored.Fingerprint:
+		t.offset = stored.Offset
+	case ok:
+		// The file is smaller than the stored offset or starts differently, so it is a new file
+		t.offset = 0
+	case t.config.FromStart || t.info != nil:
+		// A file that replaced a rotated file is always read from the start
+		t.offset = 0
+	default:
+		t.offset = info.Size()
+	}
+
+	t.file = file
+	t.info = info
+	return true, nil
+}
+
+// readLines writes all complete lines after the offset. When a line can't be written,
+// the offset stays before the line, so the line is read and written again on the next poll.
+func (t *Tailer) readLines() error {
+	if _, err := t.file.Seek(t.offset+int64(len(t.partial)), io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek in %s: %w", t.path, err)
+	}
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := t.file.Read(buf)
+		if n > 0 {
+			t.partial = append(t.partial, buf[:n]...)
+			for {
+				end := bytes.IndexByte(t.partial, '\n')
+				if end == -1 {
+					break
+				}
+				switch {
+				case t.skipping:
+					t.skipping = false
+				case end > t.config.MaxLineSize:
+					t.reportTooLong()
+				default:
+					if err := t.writeLine(string(t.partial[:end])); err != nil {
+						t.partial = nil
+						t.saveOffset()
+						return err
+					}
+				}
+				t.offset += int64(end + 1)
+				t.partial = t.partial[end+1:]
+			}
+			// Drop the start of a too long line instead of keeping it in memory
+			if len(t.partial) > t.config.MaxLineSize {
+				if !t.skipping {
+					t.reportTooLong()
+				}
+				t.skipping = true
+				t.offset += int64(len(t.partial))
+				t.partial = t.partial[:0]
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", t.path, err)
+		}
+	}
+
+	// Copy the partial line, so the read buffer can be released
+	t.partial = append([]byte(nil), t.partial...)
+	t.saveOffset()
+	return nil
+}
+
+// writeLine parses the line and writes the row
+func (t *Tailer) writeLine(line string) error {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" {
+		return nil
+	}
+	row := t.config.Parser.Parse(line)
+	if len(row) == 0 {
+		return nil
+	}
+	if err := t.writer.Write(t.config.Table, NewRow(time.Now(), row)); err != nil {
+		return fmt.Errorf("failed to write line of %s: %w", t.path, err)
+	}
+	return nil
+}
+
+func (t *Tailer) reportTooLong() {
+	t.reportError(fmt.Errorf("skipped line of %s longer than %d bytes: %w", t.path, t.config.MaxLineSize, ErrLineTooLong))
+}
+
+// loadOffset reads the persisted offset of the file
+func (t *Tailer) loadOffset() (tailerOffset, bool) {
+	if t.config.OffsetFile == "" {
+		return tailerOffset{}, false
+	}
+	content, err := os.ReadFile(t.config.OffsetFile)
+	if err != nil {
+		return tailerOffset{}, false
+	}
+	var stored tailerOffset
+	if err := json.Unmarshal(content, &stored); err != nil || stored.Path != t.path {
+		return tailerOffset{}, false
+	}
+	return stored, true
+}
+
+// fingerprintOf hashes the first bytes of the file, up to the offset
+func fingerprintOf(file *os.File, offset int64) string {
+	start := make([]byte, min(offset, fingerprintSize))
+	n, _ := file.ReadAt(start, 0)
+	hash := sha256.Sum256(start[:n])
+	return hex.EncodeToString(hash[:])
+}
+
+// saveOffset persists the offset; the file is replaced atomically so a crash never leaves half an offset file
+func (t *Tailer) saveOffset() {
+	if t.config.OffsetFile == "" || t.saved == t.offset {
+		return
+	}
+	stored := tailerOffset{Path: t.path, Offset: t.offset}
+	if t.file != nil {
+		stored.Fingerprint = fingerprintOf(t.file, t.offset)
+	}
+	content, err := json.Marshal(stored)
+	if err != nil {
+		t.reportError(fmt.Errorf("failed to encode offset: %w", err))
+		return
+	}
+	tmp := t.config.OffsetFile + ".tmp" + strconv.Itoa(os.Getpid())
+	if err := os.MkdirAll(filepath.Dir(t.config.OffsetFile), 0o755); err != nil {
+		t.reportError(fmt.Errorf("failed to create directory of offset file: %w", err))
+		return
+	}
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		t.reportError(fmt.Errorf("failed to write offset file: %w", err))
+		return
+	}
+	if err := os.Rename(tmp, t.config.OffsetFile); err != nil {
+		t.reportError(fmt.Errorf("failed to replace offset file: %w", err))
+		return
+	}
+	t.saved = t.offset
+}
+
+func (t *Tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+func (t *Tailer) reportError(err error) {
+	handleError(t.config.OnError, err, ErrorContext{Component: "tailer", Table: t.config.Table})
+}
@@ -0,0 +1,212 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func startTailer(t *testing.T, writer *Writer, path string, config TailerConfig) {
+	config.PollInterval = 10 * time.Millisecond
+	if config.OnError == nil {
+		config.OnError = func(err error) { t.Errorf("tailer error: %v", err) }
+	}
+	tailer := NewTailer(writer, path, config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tailer.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func appendToFile(t *testing.T, path string, content string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func waitForCount(t *testing.T, writer *Writer, query string, expected int) {
+	deadline := time.Now().Add(5 * time.Second)
+	var count int
+	for time.Now().Before(deadline) {
+		if err := writer.DB.QueryRow(query).Scan(&count); err == nil && count == expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d rows for %q, got %d", expected, query, count)
+}
+
+func Test_tailer_follows_new_lines(t *testing.T) {
+	_, writer := setup(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendToFile(t, path, "level=info n=1\n")
+
+	// Given a tailer that starts at the end of an existing file
+	startTailer(t, writer, path, TailerConfig{Table: "timeline"})
+	time.Sleep(50 * time.Millisecond)
+
+	// When lines are appended, including a line that is written in two parts
+	appendToFile(t, path, "level=info n=2\nlevel=info ")
+	time.Sleep(50 * time.Millisecond)
+	appendToFile(t, path, "n=3\r\n")
+
+	// Then only the new, complete lines are written
+	waitForCount(t, writer, `SELECT count(*) FROM timeline WHERE n IN (2, 3)`, 2)
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 2)
+}
+
+func Test_tailer_handles_rotation_and_truncation(t *testing.T) {
+	_, writer := setup(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	// Given a tailer that waits for the file to be created
+	startTailer(t, writer, path, TailerConfig{Table: "timeline", FromStart: true})
+	appendToFile(t, path, "level=info n=1\n")
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 1)
+
+	// When the file is rotated: renamed and recreated
+	appendToFile(t, path, "level=info n=2\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	appendToFile(t, path, "level=info n=3\n")
+
+	// Then the rest of the old file and the new file are read
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 3)
+
+	// When the file is truncated
+	waitForCount(t, writer, `SELECT count(*) FROM timeline WHERE n = 3`, 1)
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	appendToFile(t, path, "level=info n=4\n")
+
+	// Then the file is read from the start again
+	waitForCount(t, writer, `SELECT count(*) FROM timeline WHERE n = 4`, 1)
+}
+
+func Test_tailer_continues_at_persisted_offset(t *testing.T) {
+	_, writer := setup(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	offsetFile := filepath.Join(dir, "state", "app.offset")
+	appendToFile(t, path, "level=info n=1\nlevel=info n=2\n")
+
+	// Given a tailer that read the file before
+	ctx, cancel := context.WithCancel(context.Background())
+	first := NewTailer(writer, path, TailerConfig{Table: "timeline", OffsetFile: offsetFile, FromStart: true, PollInterval: 10 * time.Millisecond})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	first.Run(ctx)
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 2)
+
+	// When lines are added while no tailer is running and a new tailer starts
+	appendToFile(t, path, "level=info n=3\n")
+	startTailer(t, writer, path, TailerConfig{Table: "timeline", OffsetFile: offsetFile, FromStart: true})
+
+	// Then only the new line is written
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 3)
+	time.Sleep(50 * time.Millisecond)
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 3)
+}
+
+func Test_tailer_writes_a_line_again_when_writing_failed(t *testing.T) {
+	_, writer := setup(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendToFile(t, path, "level=info n=1\nlevel=info n=2\n")
+
+	// Given a table that can't be written to
+	if _, err := writer.DB.Exec(`CREATE VIEW timeline AS SELECT 1 AS n`); err != nil {
+		t.Fatalf("failed to create view: %v", err)
+	}
+	failed := make(chan error, 100)
+	startTailer(t, writer, path, TailerConfig{Table: "timeline", FromStart: true, OnError: func(err error) { failed <- err }})
+
+	// When writing the lines fails
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a write error")
+	}
+
+	// Then the lines are written when the table can be written to again
+	if _, err := writer.DB.Exec(`DROP VIEW timeline`); err != nil {
+		t.Fatalf("failed to drop view: %v", err)
+	}
+	waitForCount(t, writer, `SELECT count(*) FROM timeline WHERE n IN (1, 2)`, 2)
+	time.Sleep(50 * time.Millisecond)
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 2)
+}
+
+func Test_tailer_skips_too_long_lines(t *testing.T) {
+	_, writer := setup(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	failed := make(chan error, 100)
+
+	// Given a tailer with a maximum line size
+	startTailer(t, writer, path, TailerConfig{Table: "timeline", FromStart: true, MaxLineSize: 20, OnError: func(err error) { failed <- err }})
+
+	// When a line that is longer is written in parts, followed by a short line
+	appendToFile(t, path, "level=info message=aaaaaaaaaaaa")
+	time.Sleep(50 * time.Millisecond)
+	appendToFile(t, path, "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	time.Sleep(50 * time.Millisecond)
+	appendToFile(t, path, "cccccccccc\nlevel=info n=2\n")
+
+	// Then only the short line is written and the long line is reported once
+	waitForCount(t, writer, `SELECT count(*) FROM timeline WHERE n = 2`, 1)
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 1)
+	if err := <-failed; !errors.Is(err, ErrLineTooLong) {
+		t.Fatalf("expected ErrLineTooLong, got %v", err)
+	}
+	if len(failed) != 0 {
+		t.Fatalf("expected one error, got %d more", len(failed))
+	}
+}
+
+func Test_tailer_reads_a_replaced_file_from_the_start(t *testing.T) {
+	_, writer := setup(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	offsetFile := filepath.Join(dir, "app.offset")
+	appendToFile(t, path, "level=info n=1\nlevel=info n=2\n")
+
+	// Given a tailer that read the file before
+	ctx, cancel := context.WithCancel(context.Background())
+	first := NewTailer(writer, path, TailerConfig{Table: "timeline", OffsetFile: offsetFile, FromStart: true, PollInterval: 10 * time.Millisecond})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	first.Run(ctx)
+	waitForCount(t, writer, `SELECT count(*) FROM timeline`, 2)
+
+	// When the file is replaced by a longer file while no tailer is running
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove: %v", err)
+	}
+	appendToFile(t, path, "level=warn n=3\nlevel=warn n=4\nlevel=warn n=5\n")
+	startTailer(t, writer, path, TailerConfig{Table: "timeline", OffsetFile: offsetFile, FromStart: true})
+
+	// Then the new file is read from the start
+	waitForCount(t, writer, `SELECT count(*) FROM timeline WHERE n IN (3, 4, 5)`, 3)
+}
@@ -1,21 +1,153 @@
 package timeline
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // TimelineConnectionManager manages timeline database connections across multiple function calls
 type TimelineConnectionManager struct {
 	connections map[string]*Writer
 	mutex       sync.RWMutex
+	// states tracks the usage of the connections
+	states map[string]*connectionState
+	// maxOpen is the maximum number of open connections, 0 means unlimited
+	maxOpen int
+	// stopReaper stops the goroutine that closes idle connections
+	stopReaper chan struct{}
+	// options are the options of the paths, also of closed connections
+	options map[string]connectionOptions
+	// defaults are the options of the paths without options
+	defaults    connectionOptions
+	idleTimeout time.Duration
+	// lockTimeout is the maximum time to wait for a database that is locked by another process
+	lockTimeout time.Duration
+	lockBackoff time.Duration
+	// readPoolSize is the maximum number of connections of a read pool, see GetReadConnection
+	readPoolSize int
+	retention    RetentionConfig
+	// stopRetention stops the goroutine that applies the retention policies, which closes
+	// retentionDone when it is stopped
+	stopRetention chan struct{}
+	retentionDone chan struct{}
+}
+
+// connectionState is the usage of a managed connection
+type connectionState struct {
+	openedAt time.Time
+	// lastUsed is the time (in Unix nanoseconds) the connection was last returned
+	lastUsed atomic.Int64
+	// leases is the number of leases that are not released
+	leases atomic.Int32
+	// pinned is true when the Writer is returned by GetOrCreateConnection, callers can hold it as
+	// long as they want
+	pinned atomic.Bool
+}
+
+// use marks the connection as used and leases it when lease is true, otherwise it is pinned
+func (s *connectionState) use(lease bool) {
+	s.touch()
+	if lease {
+		s.leases.Add(1)
+	} else {
+		s.pinned.Store(true)
+	}
+}
+
+// inUse reports whether the connection is leased or pinned, so it must not be closed by the manager
+func (s *connectionState) inUse() bool {
+	return s != nil && (s.pinned.Load() || s.leases.Load() > 0)
+}
+
+// idleSince returns the time (in Unix nanoseconds) the connection was last returned or written to
+func (s *connectionState) idleSince(writer *Writer) int64 {
+	var lastUsed int64
+	if s != nil {
+		lastUsed = s.lastUsed.Load()
+	}
+	return max(lastUsed, writer.lastWrite.Load())
+}
+
+// touch marks the connection as used
+func (s *connectionState) touch() {
+	if s != nil {
+		s.lastUsed.Store(time.Now().UnixNano())
+	}
 }
 
 // Global instance of the connection manager
-var timelineConnManager = &TimelineConnectionManager{
-	connections: make(map[string]*Writer),
+var timelineConnManager = NewConnectionManager()
+
+// ManagerOption configures a connection manager of NewConnectionManager
+type ManagerOption func(*TimelineConnectionManager)
+
+// WithMaxOpenConnections limits the number of open connections, see SetMaxOpenConnections
+func WithMaxOpenConnections(maxOpen int) ManagerOption {
+	return func(m *TimelineConnectionManager) {
+		m.maxOpen = maxOpen
+	}
+}
+
+// WithIdleTimeout closes idle connections, see SetIdleTimeout
+func WithIdleTimeout(timeout time.Duration) ManagerOption {
+	return func(m *TimelineConnectionManager) {
+		m.idleTimeout = timeout
+	}
+}
+
+// WithReadPoolSize is the maximum number of connections of the read-only clients of
+// GetReadConnection, the default is 4
+func WithReadPoolSize(size int) ManagerOption {
+	return func(m *TimelineConnectionManager) {
+		m.readPoolSize = size
+	}
+}
+
+// maxLockBackoff is the maximum time between the attempts to open a locked database
+const maxLockBackoff = 2 * time.Second
+
+// WithLockRetry retries to open a database that is locked by another process until the timeout,
+// with a backoff that starts at backoff and doubles up to 2 seconds. Without retries (the default)
+// a locked database fails immediately. The error wraps ErrDatabaseLocked.
+func WithLockRetry(timeout time.Duration, backoff time.Duration) ManagerOption {
+	return func(m *TimelineConnectionManager) {
+		m.lockTimeout = timeout
+		m.lockBackoff = max(backoff, time.Millisecond)
+	}
+}
+
+// WithDefaultConnectionOptions are the options of the paths without options of their own
+func WithDefaultConnectionOptions(options ...ConnectionOption) ManagerOption {
+	return func(m *TimelineConnectionManager) {
+		for _, option := range options {
+			option(&m.defaults)
+		}
+	}
+}
+
+// NewConnectionManager creates a connection manager with its own connections and configuration,
+// e.g. for tests. Use GetTimelineConnectionManager to share the connections of the process. Close
+// the manager when it isn't used anymore.
+func NewConnectionManager(options ...ManagerOption) *TimelineConnectionManager {
+	m := &TimelineConnectionManager{
+		connections: make(map[string]*Writer),
+	}
+	for _, option := range options {
+		option(m)
+	}
+	if m.idleTimeout > 0 {
+		m.SetIdleTimeout(m.idleTimeout)
+	}
+	if len(m.retention.Policies) > 0 {
+		m.SetRetention(m.retention)
+	}
+	return m
 }
 
 // GetTimelineConnectionManager returns the global timeline connection manager instance
@@ -23,22 +155,173 @@ func GetTimelineConnectionManager() *TimelineConnectionManager {
 	return timelineConnManager
 }
 
-// GetOrCreateConnection returns an existing connection or creates a new one for the given dbPath
-func (m *TimelineConnectionManager) GetOrCreateConnection(dbPath string) (*Writer, error) {
-	m.mutex.RLock()
-	if writer, exists := m.connections[dbPath]; exists {
+// connectionOptions configure the connection of a path, see ConnectionOption
+type connectionOptions struct {
+	readOnly bool
+	config   ClientConfig
+	quota    QuotaConfig
+}
+
+// ConnectionOption configures the connection of a path of GetOrCreateConnection
+type ConnectionOption func(*connectionOptions)
+
+// WithReadOnly opens the database read-only, see NewReadOnlyClient
+func WithReadOnly() ConnectionOption {
+	return func(o *connectionOptions) {
+		o.readOnly = true
+	}
+}
+
+// WithMemoryLimit limits the memory DuckDB uses for the database, e.g. 512MB or 2GB
+func WithMemoryLimit(limit string) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.config.MemoryLimit = limit
+	}
+}
+
+// WithThreads limits the number of threads DuckDB uses for the queries of the database
+func WithThreads(threads int) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.config.Threads = threads
+	}
+}
+
+// WithClientConfig limits the resources DuckDB uses for the database, see ClientConfig
+func WithClientConfig(config ClientConfig) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.config = config
+	}
+}
+
+// WithQuota limits the size of the database, see SetQuota
+func WithQuota(config QuotaConfig) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.quota = config
+	}
+}
+
+// GetOrCreateConnection returns an existing connection or creates a new one for the given dbPath.
+// The options are remembered for the path: they are used when the connection is opened, also when
+// it is reopened after it is closed, until other options are passed. Options don't change a
+// connection that is open.
+//
+// The manager can't know when the caller is done with the Writer, so the connection is not evicted
+// (see SetMaxOpenConnections) or closed when idle (see SetIdleTimeout) anymore; only CloseConnection
+// and CloseAllConnections close it. Use Acquire for connections the manager may close.
+func (m *TimelineConnectionManager) GetOrCreateConnection(dbPath string, options ...ConnectionOption) (*Writer, error) {
+	writer, _, err := m.getOrCreateConnection(dbPath, options, false)
+	return writer, err
+}
+
+// GetReadConnection returns a read-only client of dbPath with its own pool of connections (see
+// WithReadPoolSize) to the database of the connection of GetOrCreateConnection, so dashboard
+// queries don't wait for the connections of the ingestion. The client sees all written rows.
+// Write returns ErrReadOnly. The client is closed with the connection.
+func (m *TimelineConnectionManager) GetReadConnection(dbPath string, options ...ConnectionOption) (*Writer, error) {
+	writer, _, err := m.getOrCreateConnection(dbPath, options, false)
+	if err != nil {
+		return nil, err
+	}
+	size := m.readPoolSize
+	if size <= 0 {
+		size = defaultReadPoolSize
+	}
+	return writer.readPool(size), nil
+}
+
+// Lease is a connection that is in use, the manager doesn't close it (because it is idle or least
+// recently used) until it is released and not returned by GetOrCreateConnection. CloseConnection
+// and CloseAllConnections still close it.
+type Lease struct {
+	*Writer
+	state    *connectionState
+	released atomic.Bool
+}
+
+// Acquire returns the connection of dbPath like GetOrCreateConnection and marks it as in use until
+// the lease is released:
+//
+//	lease, err := manager.Acquire("data/app.db")
+//	if err != nil {
+//		return err
+//	}
+//	defer lease.Release()
+//	lease.Write("app", row)
+func (m *TimelineConnectionManager) Acquire(dbPath string, options ...ConnectionOption) (*Lease, error) {
+	writer, state, err := m.getOrCreateConnection(dbPath, options, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Lease{Writer: writer, state: state}, nil
+}
+
+// Release ends the use of the connection, releasing a lease again does nothing
+func (l *Lease) Release() {
+	if l.released.CompareAndSwap(false, true) {
+		l.state.touch()
+		l.state.leases.Add(-1)
+	}
+}
+
+// getOrCreateConnection returns the connection of dbPath and leases it when lease is true
+func (m *TimelineConnectionManager) getOrCreateConnection(dbPath string, options []ConnectionOption, lease bool) (*Writer, *connectionState, error) {
+	if len(options) == 0 {
+		m.mutex.RLock()
+		if writer, exists := m.connections[dbPath]; exists {
+			state := m.states[dbPath]
+			state.use(lease)
+			m.mutex.RUnlock()
+			return writer, state, nil
+		}
 		m.mutex.RUnlock()
-		return writer, nil
 	}
-	m.mutex.RUnlock()
 
 	// Connection doesn't exist, create a new one
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	// Double-check in case another goroutine created it while we were waiting
-	if writer, exists := m.connections[dbPath]; exists {
-		return writer, nil
+	if len(options) > 0 {
+		var opts connectionOptions
+		for _, option := range options {
+			option(&opts)
+		}
+		if m.options == nil {
+			m.options = make(map[string]connectionOptions)
+		}
+		m.options[dbPath] = opts
+	}
+
+	deadline := time.Now().Add(m.lockTimeout)
+	backoff := m.lockBackoff
+	for {
+		// Double-check in case another goroutine created it while we were waiting
+		if writer, exists := m.connections[dbPath]; exists {
+			state := m.states[dbPath]
+			state.use(lease)
+			return writer, state, nil
+		}
+
+		writer, err := m.openConnection(dbPath)
+		if err == nil {
+			return writer, m.register(dbPath, writer, lease), nil
+		}
+		if !errors.Is(err, ErrDatabaseLocked) || time.Now().Add(backoff).After(deadline) {
+			return nil, nil, err
+		}
+
+		// Wait for the other process without blocking the other connections
+		m.mutex.Unlock()
+		time.Sleep(backoff)
+		m.mutex.Lock()
+		backoff = min(backoff*2, maxLockBackoff)
+	}
+}
+
+// openConnection opens the connection of dbPath. The caller must hold the write lock.
+func (m *TimelineConnectionManager) openConnection(dbPath string) (*Writer, error) {
+	// Make room for the new connection
+	if m.maxOpen > 0 {
+		m.evictLeastRecentlyUsed(m.maxOpen - 1)
 	}
 
 	// Ensure the directory exists
@@ -48,15 +331,195 @@ func (m *TimelineConnectionManager) GetOrCreateConnection(dbPath string) (*Write
 	}
 
 	// Create new connection
-	writer, err := NewStorageClient(dbPath)
+	opts, ok := m.options[dbPath]
+	if !ok {
+		opts = m.defaults
+	}
+	writer, err := openConnection(dbPath, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create timeline storage client for %s: %w", dbPath, err)
 	}
+	return writer, nil
+}
 
+// register adds an opened connection. The caller must hold the write lock.
+func (m *TimelineConnectionManager) register(dbPath string, writer *Writer, lease bool) *connectionState {
 	m.connections[dbPath] = writer
+	if m.states == nil {
+		m.states = make(map[string]*connectionState)
+	}
+	state := &connectionState{openedAt: time.Now()}
+	state.use(lease)
+	m.states[dbPath] = state
+	return state
+}
+
+// openConnection opens the database of a path with its options
+func openConnection(dbPath string, options connectionOptions) (*Writer, error) {
+	if options.readOnly {
+		return newReadOnlyClient(dbPath, options.config)
+	}
+	writer, err := NewStorageClientWithConfig(dbPath, options.config)
+	if err != nil {
+		return nil, err
+	}
+	writer.SetQuota(options.quota)
 	return writer, nil
 }
 
+// ConnectionStats is the usage of a connection of the manager
+type ConnectionStats struct {
+	Path     string
+	OpenedAt time.Time
+	// LastUsed is the last time the connection was returned by GetOrCreateConnection
+	LastUsed time.Time
+	// LastWrite is the time of the last written row, zero without writes
+	LastWrite   time.Time
+	RowsWritten int64
+	// BytesWritten is the size of the values of the written rows, see WriterStats
+	BytesWritten int64
+	// WriteErrors is the number of failed writes
+	WriteErrors int64
+	// FileSize is the size of the database file in bytes
+	FileSize int64
+}
+
+// ListConnections returns the paths of the open connections, sorted
+func (m *TimelineConnectionManager) ListConnections() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	paths := make([]string, 0, len(m.connections))
+	for dbPath := range m.connections {
+		paths = append(paths, dbPath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Stats returns the usage of the open connections, sorted by path. The counters start when the
+// connection is opened.
+func (m *TimelineConnectionManager) Stats() []ConnectionStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	stats := make([]ConnectionStats, 0, len(m.connections))
+	for dbPath, writer := range m.connections {
+		writerStats := writer.Stats()
+		s := ConnectionStats{
+			Path:         dbPath,
+			LastWrite:    writerStats.LastWrite,
+			RowsWritten:  writerStats.RowsWritten,
+			BytesWritten: writerStats.BytesWritten,
+			WriteErrors:  writerStats.WriteErrors,
+		}
+		if state := m.states[dbPath]; state != nil {
+			s.OpenedAt = state.openedAt
+			s.LastUsed = time.Unix(0, state.lastUsed.Load())
+		}
+		if info, err := os.Stat(dbPath); err == nil {
+			s.FileSize = info.Size()
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Path < stats[j].Path })
+	return stats
+}
+
+// SetMaxOpenConnections limits the number of open connections, e.g. when there is a database per
+// project. When a new connection would exceed the limit, the least recently used connection is
+// checkpointed and closed; Acquire opens it again when it is needed. Only released connections of
+// Acquire are closed: connections that are leased or returned by GetOrCreateConnection are in use,
+// so the limit can be exceeded while all connections are in use. 0 means no limit.
+func (m *TimelineConnectionManager) SetMaxOpenConnections(maxOpen int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.maxOpen = maxOpen
+	if maxOpen > 0 {
+		m.evictLeastRecentlyUsed(maxOpen)
+	}
+}
+
+// evictLeastRecentlyUsed closes the least recently used connections until at most keep
+// connections are open. The caller must hold the write lock.
+func (m *TimelineConnectionManager) evictLeastRecentlyUsed(keep int) {
+	for len(m.connections) > keep {
+		var oldestPath string
+		oldest := int64(0)
+		for dbPath, writer := range m.connections {
+			if m.states[dbPath].inUse() {
+				continue
+			}
+			lastUsed := m.states[dbPath].idleSince(writer)
+			if oldestPath == "" || lastUsed < oldest {
+				oldestPath, oldest = dbPath, lastUsed
+			}
+		}
+		if oldestPath == "" {
+			// All connections are in use
+			return
+		}
+		m.closeConnection(oldestPath)
+	}
+}
+
+// SetIdleTimeout closes the released connections of Acquire that are not acquired or written to
+// for the timeout, to free the memory of dormant databases. Acquire opens them again when they
+// are needed. Connections returned by GetOrCreateConnection stay open. 0 (the default) keeps
+// connections open.
+func (m *TimelineConnectionManager) SetIdleTimeout(timeout time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.idleTimeout = timeout
+	if m.stopReaper != nil {
+		close(m.stopReaper)
+		m.stopReaper = nil
+	}
+	if timeout > 0 {
+		m.stopReaper = make(chan struct{})
+		go m.reapIdleConnections(timeout, m.stopReaper)
+	}
+}
+
+// reapIdleConnections closes the idle connections until stop is closed
+func (m *TimelineConnectionManager) reapIdleConnections(timeout time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(max(timeout/4, 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			m.closeIdleConnections(timeout)
+		}
+	}
+}
+
+// closeIdleConnections closes the connections that are idle for the timeout
+func (m *TimelineConnectionManager) closeIdleConnections(timeout time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	idleBefore := time.Now().Add(-timeout).UnixNano()
+	for dbPath, writer := range m.connections {
+		if !m.states[dbPath].inUse() && m.states[dbPath].idleSince(writer) < idleBefore {
+			m.closeConnection(dbPath)
+		}
+	}
+}
+
+// closeConnection flushes and closes a connection. The caller must hold the write lock.
+func (m *TimelineConnectionManager) closeConnection(dbPath string) {
+	writer := m.connections[dbPath]
+	// Flush the WAL to the database file, so the file is complete while the connection is closed
+	writer.Checkpoint()
+	writer.Close()
+	delete(m.connections, dbPath)
+	delete(m.states, dbPath)
+}
+
 // CloseAllConnections closes all managed connections
 // This should be called during application shutdown or when connections need to be refreshed
 func (m *TimelineConnectionManager) CloseAllConnections() {
@@ -66,9 +529,17 @@ func (m *TimelineConnectionManager) CloseAllConnections() {
 	for dbPath, writer := range m.connections {
 		writer.Close()
 		delete(m.connections, dbPath)
+		delete(m.states, dbPath)
 	}
 }
 
+// Close stops closing idle connections and applying retention and closes all connections
+func (m *TimelineConnectionManager) Close() {
+	m.SetIdleTimeout(0)
+	m.SetRetention(RetentionConfig{})
+	m.CloseAllConnections()
+}
+
 // CloseConnection closes a specific connection by dbPath
 func (m *TimelineConnectionManager) CloseConnection(dbPath string) {
 	m.mutex.Lock()
@@ -77,5 +548,6 @@ func (m *TimelineConnectionManager) CloseConnection(dbPath string) {
 	if writer, exists := m.connections[dbPath]; exists {
 		writer.Close()
 		delete(m.connections, dbPath)
+		delete(m.states, dbPath)
 	}
 }
@@ -5,13 +5,12 @@ import (
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 )
 
 // newTestManager creates a fresh TimelineConnectionManager instance for testing
 func newTestManager() *TimelineConnectionManager {
-	return &TimelineConnectionManager{
-		connections: make(map[string]*Writer),
-	}
+	return NewConnectionManager()
 }
 
 func Test_get_or_create_connection_valid_path_returns_writer(t *testing.T) {
@@ -465,3 +464,292 @@ func Test_error_handling_edge_cases_does_not_panic(t *testing.T) {
 	// Then
 	t.Log("Error handling test completed - manager handled edge cases gracefully")
 }
+
+func Test_max_open_connections_evicts_least_recently_used(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	manager := newTestManager()
+	defer manager.CloseAllConnections()
+	manager.SetMaxOpenConnections(2)
+	first := filepath.Join(tempDir, "first.db")
+	second := filepath.Join(tempDir, "second.db")
+	third := filepath.Join(tempDir, "third.db")
+
+	acquire := func(dbPath string) *Lease {
+		lease, err := manager.Acquire(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to acquire connection: %v", err)
+		}
+		return lease
+	}
+	lease := acquire(first)
+	if err := lease.Write("app", NewRow(time.Now(), Row{"message": "kept"})); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	lease.Release()
+	acquire(second).Release()
+	// Use the first connection again, so the second is the least recently used
+	acquire(first).Release()
+
+	// When
+	acquire(third).Release()
+
+	// Then
+	manager.mutex.RLock()
+	_, secondOpen := manager.connections[second]
+	count := len(manager.connections)
+	manager.mutex.RUnlock()
+	if count != 2 || secondOpen {
+		t.Fatalf("Expected the second connection to be evicted, got %d connections", count)
+	}
+
+	// And evicted databases are reopened with their rows
+	manager.SetMaxOpenConnections(1)
+	reopened := acquire(first)
+	defer reopened.Release()
+	var n int
+	if err := reopened.DB.QueryRow("SELECT count(*) FROM app").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("Expected 1 row after reopening, got %d (%v)", n, err)
+	}
+}
+
+func Test_idle_timeout_closes_unused_connections(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	manager := newTestManager()
+	defer manager.CloseAllConnections()
+	idle := filepath.Join(tempDir, "idle.db")
+	busy := filepath.Join(tempDir, "busy.db")
+	lease, err := manager.Acquire(idle)
+	if err != nil {
+		t.Fatalf("Failed to acquire connection: %v", err)
+	}
+	lease.Release()
+
+	// When
+	manager.SetIdleTimeout(100 * time.Millisecond)
+	defer manager.SetIdleTimeout(0)
+	for i := 0; i < 10; i++ {
+		lease, err := manager.Acquire(busy)
+		if err != nil {
+			t.Fatalf("Failed to acquire connection: %v", err)
+		}
+		if err := lease.Write("app", NewRow(time.Now(), Row{"n": i})); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		lease.Release()
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	// Then
+	manager.mutex.RLock()
+	_, idleOpen := manager.connections[idle]
+	_, busyOpen := manager.connections[busy]
+	manager.mutex.RUnlock()
+	if idleOpen {
+		t.Fatal("Expected the idle connection to be closed")
+	}
+	if !busyOpen {
+		t.Fatal("Expected the connection that is written to to stay open")
+	}
+}
+
+func Test_stats_returns_the_usage_per_connection(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	manager := newTestManager()
+	defer manager.CloseAllConnections()
+	busy := filepath.Join(tempDir, "busy.db")
+	quiet := filepath.Join(tempDir, "quiet.db")
+	before := time.Now()
+	writer, err := manager.GetOrCreateConnection(busy)
+	if err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	if _, err := manager.GetOrCreateConnection(quiet); err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+
+	// When
+	for i := 0; i < 3; i++ {
+		if err := writer.Write("app", NewRow(time.Now(), Row{"n": i})); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+	if err := writer.Write("invalid table", NewRow(time.Now(), Row{"n": 1})); err == nil {
+		t.Fatal("Expected an error for an invalid table name")
+	}
+	if err := writer.Checkpoint(); err != nil {
+		t.Fatalf("Failed to checkpoint: %v", err)
+	}
+
+	// Then
+	paths := manager.ListConnections()
+	if len(paths) != 2 || paths[0] != busy || paths[1] != quiet {
+		t.Fatalf("Unexpected connections %v", paths)
+	}
+	stats := manager.Stats()
+	if stats[0].RowsWritten != 3 || stats[0].WriteErrors != 1 || stats[0].LastWrite.Before(before) || stats[0].FileSize == 0 {
+		t.Fatalf("Unexpected stats of the busy connection %+v", stats[0])
+	}
+	if stats[1].RowsWritten != 0 || !stats[1].LastWrite.IsZero() || stats[1].OpenedAt.Before(before) {
+		t.Fatalf("Unexpected stats of the quiet connection %+v", stats[1])
+	}
+}
+
+func Test_connection_options_are_remembered_per_path(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	manager := newTestManager()
+	defer manager.CloseAllConnections()
+	dbPath := filepath.Join(tempDir, "limited.db")
+
+	// When
+	writer, err := manager.GetOrCreateConnection(dbPath, WithMemoryLimit("256MB"), WithThreads(2))
+	if err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	if err := writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	manager.CloseConnection(dbPath)
+	reopened, err := manager.GetOrCreateConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen connection: %v", err)
+	}
+
+	// Then
+	var threads int
+	if err := reopened.DB.QueryRow("SELECT current_setting('threads')").Scan(&threads); err != nil || threads != 2 {
+		t.Fatalf("Expected 2 threads, got %d (%v)", threads, err)
+	}
+	var memoryLimit string
+	if err := reopened.DB.QueryRow("SELECT current_setting('memory_limit')").Scan(&memoryLimit); err != nil || memoryLimit != "244.1 MiB" {
+		t.Fatalf("Expected a memory limit of 256MB, got %s (%v)", memoryLimit, err)
+	}
+}
+
+func Test_connection_options_read_only(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	manager := newTestManager()
+	defer manager.CloseAllConnections()
+	dbPath := filepath.Join(tempDir, "archive.db")
+	writer, err := manager.GetOrCreateConnection(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	if err := writer.Write("app", NewRow(time.Now(), Row{"message": "hello"})); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	manager.CloseConnection(dbPath)
+
+	// When
+	reader, err := manager.GetOrCreateConnection(dbPath, WithReadOnly())
+	if err != nil {
+		t.Fatalf("Failed to open read-only connection: %v", err)
+	}
+
+	// Then
+	if err := reader.Write("app", NewRow(time.Now(), Row{"message": "again"})); err != ErrReadOnly {
+		t.Fatalf("Expected ErrReadOnly, got %v", err)
+	}
+}
+
+func Test_new_connection_manager_with_options(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	manager := NewConnectionManager(
+		WithMaxOpenConnections(1),
+		WithIdleTimeout(time.Hour),
+		WithDefaultConnectionOptions(WithThreads(1)),
+	)
+	defer manager.Close()
+
+	// When
+	first, err := manager.Acquire(filepath.Join(tempDir, "first.db"))
+	if err != nil {
+		t.Fatalf("Failed to acquire connection: %v", err)
+	}
+	var threads int
+	if err := first.DB.QueryRow("SELECT current_setting('threads')").Scan(&threads); err != nil || threads != 1 {
+		t.Fatalf("Expected the default options, got %d threads (%v)", threads, err)
+	}
+	first.Release()
+	if _, err := manager.GetOrCreateConnection(filepath.Join(tempDir, "second.db"), WithThreads(2)); err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+
+	// Then
+	if paths := manager.ListConnections(); len(paths) != 1 {
+		t.Fatalf("Expected 1 open connection, got %v", paths)
+	}
+	if len(GetTimelineConnectionManager().ListConnections()) != 0 {
+		t.Fatal("Expected the global manager to be untouched")
+	}
+}
+
+func Test_leased_connections_are_not_evicted(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	manager := NewConnectionManager(WithMaxOpenConnections(1))
+	defer manager.Close()
+	leasedPath := filepath.Join(tempDir, "leased.db")
+	lease, err := manager.Acquire(leasedPath)
+	if err != nil {
+		t.Fatalf("Failed to acquire connection: %v", err)
+	}
+
+	// When
+	if _, err := manager.GetOrCreateConnection(filepath.Join(tempDir, "other.db")); err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+
+	// Then
+	if err := lease.Write("app", NewRow(time.Now(), Row{"message": "still open"})); err != nil {
+		t.Fatalf("Expected the leased connection to be open: %v", err)
+	}
+	if paths := manager.ListConnections(); len(paths) != 2 {
+		t.Fatalf("Expected the limit to be exceeded while the connection is leased, got %v", paths)
+	}
+
+	// And after the release it can be evicted
+	lease.Release()
+	lease.Release()
+	if _, err := manager.GetOrCreateConnection(filepath.Join(tempDir, "third.db")); err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	manager.mutex.RLock()
+	_, leasedOpen := manager.connections[leasedPath]
+	manager.mutex.RUnlock()
+	if leasedOpen {
+		t.Fatal("Expected the released connection to be evicted")
+	}
+}
+
+func Test_connections_of_get_or_create_connection_are_not_closed_by_the_manager(t *testing.T) {
+	// Given
+	tempDir := t.TempDir()
+	manager := NewConnectionManager(WithMaxOpenConnections(1), WithIdleTimeout(20*time.Millisecond))
+	defer manager.Close()
+	writer, err := manager.GetOrCreateConnection(filepath.Join(tempDir, "held.db"))
+	if err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+
+	// When
+	lease, err := manager.Acquire(filepath.Join(tempDir, "other.db"))
+	if err != nil {
+		t.Fatalf("Failed to acquire connection: %v", err)
+	}
+	lease.Release()
+	time.Sleep(100 * time.Millisecond)
+
+	// Then
+	if err := writer.Write("app", NewRow(time.Now(), Row{"message": "still open"})); err != nil {
+		t.Fatalf("Expected the held writer to be open: %v", err)
+	}
+	if paths := manager.ListConnections(); len(paths) != 1 || filepath.Base(paths[0]) != "held.db" {
+		t.Fatalf("Expected only the held connection to stay open, got %v", paths)
+	}
+}
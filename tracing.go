@@ -0,0 +1,70 @@
+package timeline
+
+import (
+	"context"
+)
+
+// Tracer starts the spans of a writer, see SetTracer. Implement it with an OpenTelemetry tracer to
+// add the writes to existing traces, without a dependency of this package on OpenTelemetry:
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, attributes map[string]string) (context.Context, timeline.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		for key, value := range attributes {
+//			span.SetAttributes(attribute.String(key, value))
+//		}
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	// Start starts a span with the attributes (e.g. table and column) as a child of the span of ctx
+	Start(ctx context.Context, name string, attributes map[string]string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	// RecordError marks the span as failed
+	RecordError(err error)
+	// End ends the span
+	End()
+}
+
+// The names of the spans of a writer
+const (
+	SpanWrite       = "timeline.write"
+	SpanIngestBatch = "timeline.ingest_batch"
+	SpanCreateTable = "timeline.create_table"
+	SpanAddColumn   = "timeline.add_column"
+	SpanPromote     = "timeline.promote_column"
+	SpanAlterColumn = "timeline.alter_column"
+)
+
+// tracerRef holds the Tracer of a writer, an interface can't be stored in an atomic.Pointer
+type tracerRef struct {
+	Tracer
+}
+
+// SetTracer creates spans for the writes (Write and the batches of Ingest) and for the changes of
+// the tables: created tables, added and promoted columns and the migrations of columns. nil stops
+// the tracing.
+func (w *Writer) SetTracer(tracer Tracer) {
+	if tracer == nil {
+		w.tracerRef.Store(nil)
+		return
+	}
+	w.tracerRef.Store(&tracerRef{tracer})
+}
+
+// startSpan starts a span when the writer has a tracer, the returned function ends the span with
+// the error of the operation
+func (w *Writer) startSpan(ctx context.Context, name string, attributes map[string]string) (context.Context, func(error)) {
+	ref := w.tracerRef.Load()
+	if ref == nil {
+		return ctx, func(error) {}
+	}
+	ctx, span := ref.Start(ctx, name, attributes)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}
+}
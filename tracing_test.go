@@ -0,0 +1,121 @@
+package timeline
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTracer records the started spans
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name       string
+	parent     *recordedSpan
+	attributes map[string]string
+	err        error
+	ended      bool
+}
+
+type spanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attributes map[string]string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attributes: attributes}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+func (t *recordingTracer) named(name string) []*recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var spans []*recordedSpan
+	for _, span := range t.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func Test_tracer_spans_writes_and_schema_changes(t *testing.T) {
+	is, writer := setup(t)
+	tracer := &recordingTracer{}
+	writer.SetTracer(tracer)
+
+	// Given a parent span of the application
+	ctx, parent := tracer.Start(context.Background(), "handle request", nil)
+
+	// When a row creates the table and a column and another row promotes the column
+	is.NoErr(writer.WriteContext(ctx, "logs", NewRow(time.Now(), Row{"status": 200})))
+	is.NoErr(writer.Write("logs", NewRow(time.Now(), Row{"status": "ok"})))
+
+	// Then the writes are spans, the first in the trace of the application
+	writes := tracer.named(SpanWrite)
+	is.Equal(len(writes), 2)
+	is.Equal(writes[0].parent, parent)
+	is.Equal(writes[0].attributes["table"], "logs")
+	is.True(writes[0].ended)
+	is.Equal(writes[1].parent, nil)
+
+	// And the changes of the table are spans of the writes
+	created := tracer.named(SpanCreateTable)
+	is.Equal(len(created), 1)
+	is.Equal(created[0].parent, writes[0])
+	added := tracer.named(SpanAddColumn)
+	is.Equal(len(added), 1)
+	is.Equal(added[0].attributes, map[string]string{"table": "logs", "column": "status", "type": "UTINYINT"})
+	promoted := tracer.named(SpanPromote)
+	is.Equal(len(promoted), 1)
+	is.Equal(promoted[0].parent, writes[1])
+	is.Equal(promoted[0].attributes, map[string]string{"table": "logs", "column": "status", "from": "UTINYINT", "to": "VARCHAR"})
+}
+
+func Test_tracer_records_the_errors_of_writes(t *testing.T) {
+	is, writer := setup(t)
+	tracer := &recordingTracer{}
+	writer.SetTracer(tracer)
+
+	is.True(writer.Write("logs", NewRow(time.Now(), Row{"bad column": func() {}})) != nil)
+
+	writes := tracer.named(SpanWrite)
+	is.Equal(len(writes), 1)
+	is.True(writes[0].err != nil)
+	is.True(writes[0].ended)
+	is.True(tracer.named(SpanAddColumn)[0].err != nil)
+}
+
+func Test_tracer_spans_ingest_batches_and_column_migrations(t *testing.T) {
+	is, writer := setup(t)
+	tracer := &recordingTracer{}
+	writer.SetTracer(tracer)
+
+	_, err := writer.Ingest(context.Background(), strings.NewReader("level=info status=200\nlevel=warn status=500\n"), "logs")
+	is.NoErr(err)
+	is.NoErr(writer.RenameColumn(context.Background(), "logs", "status", "code"))
+
+	batches := tracer.named(SpanIngestBatch)
+	is.Equal(len(batches), 1)
+	is.Equal(batches[0].attributes, map[string]string{"table": "logs", "lines": "2"})
+	for _, write := range tracer.named(SpanWrite) {
+		is.Equal(write.parent, batches[0])
+	}
+	altered := tracer.named(SpanAlterColumn)
+	is.Equal(len(altered), 1)
+	is.Equal(altered[0].attributes["column"], "status")
+}
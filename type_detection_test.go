@@ -1,6 +1,7 @@
 package timeline
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -582,7 +583,7 @@ func Test_promote_existing_column(t *testing.T) {
 			}
 
 			// When
-			err = w.promoteColumn(name+"_table", "column_to_promote", tc.old, tc.promotion)
+			err = w.promoteColumn(context.Background(), name+"_table", "column_to_promote", tc.old, tc.promotion)
 
 			// Then
 			is.NoErr(err)
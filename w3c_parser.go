@@ -0,0 +1,159 @@
+package timeline
+
+import (
+	"net/url"
+	"strings"
+)
+
+// w3cFieldNames maps W3C field identifiers to the column names used by the other parsers.
+// Unknown identifiers are normalized, e.g. sc-substatus becomes sc_substatus.
+var w3cFieldNames = map[string]string{
+	"c-ip":            "remote_host",
+	"cs-username":     "remote_user",
+	"cs-method":       "method",
+	"cs-uri-stem":     "path",
+	"cs-uri-query":    "query",
+	"cs-version":      "protocol",
+	"cs-host":         "host",
+	"cs(host)":        "host",
+	"cs(user-agent)":  "user_agent",
+	"cs(referer)":     "referer",
+	"cs(referrer)":    "referer",
+	"sc-status":       "status",
+	"sc-bytes":        "response_size",
+	"cs-bytes":        "request_size",
+	"s-ip":            "server_ip",
+	"s-port":          "server_port",
+	"s-sitename":      "site_name",
+	"s-computername":  "server_name",
+	"time-taken":      "time_taken",
+	"x-forwarded-for": "forwarded_for",
+	// CloudFront specific fields
+	"cs(cookie)":                  "cookie",
+	"cs-protocol":                 "scheme",
+	"cs-protocol-version":         "protocol",
+	"x-edge-location":             "edge_location",
+	"x-edge-result-type":          "edge_result_type",
+	"x-edge-response-result-type": "edge_response_result_type",
+	"x-edge-detailed-result-type": "edge_detailed_result_type",
+	"x-edge-request-id":           "edge_request_id",
+	"x-host-header":               "host_header",
+	"sc-content-type":             "content_type",
+	"sc-content-len":              "content_length",
+	"c-port":                      "remote_port",
+}
+
+// cloudFrontFields are the fields of CloudFront standard (access) logs, in order
+var cloudFrontFields = []string{
+	"date", "time", "x-edge-location", "sc-bytes", "c-ip", "cs-method", "cs(Host)", "cs-uri-stem",
+	"sc-status", "cs(Referer)", "cs(User-Agent)", "cs-uri-query", "cs(Cookie)", "x-edge-result-type",
+	"x-edge-request-id", "x-host-header", "cs-protocol", "cs-bytes", "time-taken", "x-forwarded-for",
+	"ssl-protocol", "ssl-cipher", "x-edge-response-result-type", "cs-protocol-version", "fle-status",
+	"fle-encrypted-fields", "c-port", "time-to-first-byte", "x-edge-detailed-result-type",
+	"sc-content-type", "sc-content-len", "sc-range-start", "sc-range-end",
+}
+
+// W3CParser parses the W3C Extended Log File Format used by IIS and several CDNs.
+// Directive lines (starting with #) are not stored; the #Fields directive defines the columns
+// of the lines that follow. Values are separated by spaces or tabs, "-" means no value.
+// Example:
+//
+//	#Fields: date time c-ip cs-method cs-uri-stem sc-status sc-bytes time-taken cs(User-Agent)
+//	2025-09-21 22:35:12 10.0.0.2 GET /index.html 200 5120 15 Mozilla/5.0+(Windows+NT+10.0)
+//
+// Fields: timestamp (date and time merged), method, path, query, status, response_size, remote_host,
+// user_agent, referer, time_taken and the other W3C fields with normalized names
+type W3CParser struct {
+	fields []string
+}
+
+// NewW3CParser creates a W3C parser. The fields are read from the #Fields directive,
+// or can be given up front for logs without directives.
+func NewW3CParser(fields ...string) *W3CParser {
+	return &W3CParser{fields: fields}
+}
+
+// NewCloudFrontParser creates a parser for CloudFront standard logs.
+// CloudFront logs are tab-separated W3C logs; the #Fields directive in the file
+// overrides the default field list when present.
+func NewCloudFrontParser() *W3CParser {
+	return NewW3CParser(cloudFrontFields...)
+}
+
+// Fields returns the W3C field identifiers of the current #Fields directive
+func (p *W3CParser) Fields() []string {
+	return p.fields
+}
+
+// Parse maps the values of a log line to columns, or handles a directive line (returning nil)
+func (p *W3CParser) Parse(l string) Row {
+	l = strings.TrimRight(l, "\r")
+	if l == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(l, "#") {
+		if fields, ok := strings.CutPrefix(l, "#Fields:"); ok {
+			p.fields = strings.Fields(fields)
+		}
+		return nil
+	}
+
+	if len(p.fields) == 0 {
+		return nil
+	}
+
+	var values []string
+	if strings.Contains(l, "\t") {
+		values = strings.Split(l, "\t")
+	} else {
+		values = strings.Fields(l)
+	}
+	if len(values) != len(p.fields) {
+		return nil
+	}
+
+	result := make(Row)
+	var date, timeOfDay string
+	for i, field := range p.fields {
+		value := values[i]
+		if value == "-" || value == "" {
+			continue
+		}
+
+		switch strings.ToLower(field) {
+		case "date":
+			date = value
+			continue
+		case "time":
+			timeOfDay = value
+			continue
+		case "cs(user-agent)":
+			// Spaces are encoded as + (IIS) or %20 (CloudFront)
+			if decoded, err := url.QueryUnescape(value); err == nil {
+				value = decoded
+			}
+		}
+
+		result[w3cColumnName(field)] = convertDelimitedValue(value)
+	}
+
+	switch {
+	case date != "" && timeOfDay != "":
+		result["timestamp"] = date + " " + timeOfDay
+	case date != "":
+		result["date"] = date
+	case timeOfDay != "":
+		result["time"] = timeOfDay
+	}
+
+	return result
+}
+
+// w3cColumnName returns the column name for a W3C field identifier
+func w3cColumnName(field string) string {
+	if name, ok := w3cFieldNames[strings.ToLower(field)]; ok {
+		return name
+	}
+	return normalizeColumnName(field)
+}
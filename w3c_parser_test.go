@@ -0,0 +1,110 @@
+package timeline
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func Test_parse_w3c_reads_fields_from_directive(t *testing.T) {
+	is := is.New(t)
+	parser := NewW3CParser()
+
+	is.Equal(parser.Parse("#Software: Microsoft Internet Information Services 10.0"), nil)
+	is.Equal(parser.Parse("#Version: 1.0"), nil)
+	is.Equal(parser.Parse("#Fields: date time s-ip cs-method cs-uri-stem cs-uri-query s-port cs-username c-ip cs(User-Agent) cs(Referer) sc-status sc-substatus time-taken"), nil)
+	data := parser.Parse("2025-09-21 22:35:12 10.0.0.1 GET /index.html id=5 80 - 10.0.0.2 Mozilla/5.0+(Windows+NT+10.0) - 200 0 15")
+
+	is.Equal(data["timestamp"], "2025-09-21 22:35:12")
+	is.Equal(data["server_ip"], "10.0.0.1")
+	is.Equal(data["method"], "GET")
+	is.Equal(data["path"], "/index.html")
+	is.Equal(data["query"], "id=5")
+	is.Equal(data["server_port"], 80)
+	is.Equal(data["remote_host"], "10.0.0.2")
+	is.Equal(data["user_agent"], "Mozilla/5.0 (Windows NT 10.0)")
+	is.Equal(data["status"], 200)
+	is.Equal(data["sc_substatus"], 0)
+	is.Equal(data["time_taken"], 15)
+	// "-" means no value
+	_, hasUser := data["remote_user"]
+	is.True(!hasUser)
+	_, hasReferer := data["referer"]
+	is.True(!hasReferer)
+}
+
+func Test_parse_w3c_without_fields_directive(t *testing.T) {
+	is := is.New(t)
+	parser := NewW3CParser()
+
+	data := parser.Parse("2025-09-21 22:35:12 GET /index.html")
+
+	is.Equal(data, nil)
+}
+
+func Test_parse_w3c_with_configured_fields(t *testing.T) {
+	is := is.New(t)
+	parser := NewW3CParser("date", "cs-method", "sc-bytes")
+
+	data := parser.Parse("2025-09-21 POST 512")
+
+	is.Equal(data["date"], "2025-09-21")
+	is.Equal(data["method"], "POST")
+	is.Equal(data["response_size"], 512)
+}
+
+func Test_parse_w3c_new_fields_directive_replaces_fields(t *testing.T) {
+	is := is.New(t)
+	parser := NewW3CParser()
+
+	parser.Parse("#Fields: date time cs-method")
+	parser.Parse("#Fields: cs-method sc-status")
+	data := parser.Parse("GET 404")
+
+	is.Equal(parser.Fields(), []string{"cs-method", "sc-status"})
+	is.Equal(data["status"], 404)
+}
+
+func Test_parse_w3c_line_with_wrong_number_of_values(t *testing.T) {
+	is := is.New(t)
+	parser := NewW3CParser("cs-method", "sc-status")
+
+	data := parser.Parse("GET /index.html 200")
+
+	is.Equal(data, nil)
+}
+
+func Test_parse_cloudfront_line_with_default_fields(t *testing.T) {
+	is := is.New(t)
+	parser := NewCloudFrontParser()
+	line := "2019-12-04\t21:02:31\tLAX1\t392\t192.0.2.100\tGET\td111111abcdef8.cloudfront.net\t/index.html\t200\t-\tMozilla/5.0%20(Windows%20NT%2010.0)\t-\t-\tHit\tSOX4xwn4XV6Q4rgb7XiVGOHms_BGlTAC4KyHmureZmBNrjGdRLiNIQ==\td111111abcdef8.cloudfront.net\thttps\t23\t0.001\t-\tTLSv1.2\tECDHE-RSA-AES128-GCM-SHA256\tHit\tHTTP/2.0\t-\t-\t11040\t0.001\tHit\ttext/html\t78\t-\t-"
+
+	data := parser.Parse(line)
+
+	is.Equal(data["timestamp"], "2019-12-04 21:02:31")
+	is.Equal(data["edge_location"], "LAX1")
+	is.Equal(data["response_size"], 392)
+	is.Equal(data["remote_host"], "192.0.2.100")
+	is.Equal(data["method"], "GET")
+	is.Equal(data["host"], "d111111abcdef8.cloudfront.net")
+	is.Equal(data["path"], "/index.html")
+	is.Equal(data["status"], 200)
+	is.Equal(data["user_agent"], "Mozilla/5.0 (Windows NT 10.0)")
+	is.Equal(data["edge_result_type"], "Hit")
+	is.Equal(data["request_size"], 23)
+	is.Equal(data["time_taken"], 0.001)
+	is.Equal(data["protocol"], "HTTP/2.0")
+	is.Equal(data["content_type"], "text/html")
+}
+
+func Test_parse_cloudfront_fields_directive_overrides_default_fields(t *testing.T) {
+	is := is.New(t)
+	parser := NewCloudFrontParser()
+
+	parser.Parse("#Version: 1.0")
+	parser.Parse("#Fields: date time x-edge-location sc-bytes")
+	data := parser.Parse("2019-12-04\t21:02:31\tLAX1\t392")
+
+	is.Equal(data["edge_location"], "LAX1")
+	is.Equal(data["response_size"], 392)
+}
@@ -0,0 +1,184 @@
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxWebhookRequestSize limits the (uncompressed) size of a webhook payload
+const maxWebhookRequestSize = 8 << 20
+
+// WebhookReceiverConfig configures a WebhookReceiver
+type WebhookReceiverConfig struct {
+	// Table is the table the events are written to
+	Table string
+	// Columns maps column names to JSON paths of the payload, e.g.
+	// {"action": "action", "repository": "repository.full_name", "label": "labels[0].name"}.
+	// Without columns the whole payload is written (nested objects are flattened).
+	Columns map[string]string
+	// TimestampPath is the JSON path of the event time, e.g. created_at. RFC 3339 strings and unix
+	// seconds or milliseconds are supported. Without a path (or value) the time of receipt is used.
+	TimestampPath string
+	// Headers maps column names to request headers, e.g. {"event": "X-GitHub-Event"}
+	Headers map[string]string
+}
+
+// WebhookReceiver is an http.Handler that writes the JSON payloads of webhooks (GitHub, Stripe,
+// Sentry, ...) to a table. A payload that is a JSON array is written as one row per element.
+type WebhookReceiver struct {
+	writer *Writer
+	config WebhookReceiverConfig
+}
+
+// NewWebhookReceiver creates a webhook receiver
+func NewWebhookReceiver(writer *Writer, config WebhookReceiverConfig) *WebhookReceiver {
+	if config.Table == "" {
+		config.Table = "webhooks"
+	}
+	return &WebhookReceiver{writer: writer, config: config}
+}
+
+func (h *WebhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := readRequestBody(r, maxWebhookRequestSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var payload any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		http.Error(w, fmt.Sprintf("invalid JSON payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	payload = convertJSONNumbers(payload)
+
+	events, ok := payload.([]any)
+	if !ok {
+		events = []any{payload}
+	}
+
+	rows := make([]Row, 0, len(events))
+	for _, event := range events {
+		object, ok := event.(map[string]any)
+		if !ok {
+			http.Error(w, "the payload must be a JSON object or an array of objects", http.StatusBadRequest)
+			return
+		}
+		rows = append(rows, h.toRow(object, r.Header))
+	}
+
+	for _, row := range rows {
+		if err := h.writer.WriteContext(r.Context(), h.config.Table, row); err != nil {
+			http.Error(w, fmt.Sprintf("failed to write event: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toRow maps an event to a row with the configured columns
+func (h *WebhookReceiver) toRow(event map[string]any, header http.Header) Row {
+	row := make(Row)
+	if len(h.config.Columns) == 0 {
+		for k, v := range event {
+			if column := normalizeColumnName(k); column != "" {
+				row[column] = v
+			}
+		}
+	}
+	for column, path := range h.config.Columns {
+		if value, ok := lookupJSONPath(event, path); ok && value != nil {
+			row[normalizeColumnName(column)] = value
+		}
+	}
+	for column, name := range h.config.Headers {
+		if value := header.Get(name); value != "" {
+			row[normalizeColumnName(column)] = value
+		}
+	}
+
+	timestamp := time.Now()
+	if h.config.TimestampPath != "" {
+		if value, ok := lookupJSONPath(event, h.config.TimestampPath); ok {
+			if t, ok := webhookTime(value); ok {
+				timestamp = t
+				row["timestamp"] = t
+			}
+		}
+	}
+	return NewRow(timestamp, row)
+}
+
+// lookupJSONPath returns the value at a path like data.object.amount or labels[0].name (an optional
+// $. prefix is ignored) of a decoded JSON value
+func lookupJSONPath(value any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	for path != "" {
+		var key string
+		switch {
+		case strings.HasPrefix(path, "["):
+			end := strings.Index(path, "]")
+			if end == -1 {
+				return nil, false
+			}
+			index, err := strconv.Atoi(path[1:end])
+			list, ok := value.([]any)
+			if err != nil || !ok || index < 0 || index >= len(list) {
+				return nil, false
+			}
+			value = list[index]
+			path = strings.TrimPrefix(path[end+1:], ".")
+			continue
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			key, path = path[:end], strings.TrimPrefix(path[end:], ".")
+		}
+
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		value, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// webhookTime converts an RFC 3339 string or unix seconds or milliseconds to a time
+func webhookTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC(), true
+		}
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			return webhookTime(seconds)
+		}
+	case int:
+		return webhookTime(float64(v))
+	case float64:
+		// Unix milliseconds are larger than any unix time in seconds before the year 33658
+		if v > 1e12 {
+			return unixMillisToTime(int64(v)), true
+		}
+		return unixFloatToTime(v), true
+	}
+	return time.Time{}, false
+}
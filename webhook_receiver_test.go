@@ -0,0 +1,87 @@
+package timeline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func Test_lookup_json_path(t *testing.T) {
+	is := is.New(t)
+	payload := map[string]any{
+		"data":   map[string]any{"object": map[string]any{"amount": 2000}},
+		"labels": []any{map[string]any{"name": "bug"}},
+	}
+
+	value, ok := lookupJSONPath(payload, "data.object.amount")
+	is.True(ok)
+	is.Equal(value, 2000)
+
+	value, ok = lookupJSONPath(payload, "$.labels[0].name")
+	is.True(ok)
+	is.Equal(value, "bug")
+
+	_, ok = lookupJSONPath(payload, "labels[1].name")
+	is.True(!ok)
+	_, ok = lookupJSONPath(payload, "data.object.amount.value")
+	is.True(!ok)
+}
+
+func Test_webhook_receiver_maps_paths_to_columns(t *testing.T) {
+	is, writer := setup(t)
+	receiver := NewWebhookReceiver(writer, WebhookReceiverConfig{
+		Table: "github",
+		Columns: map[string]string{
+			"action":     "action",
+			"repository": "repository.full_name",
+			"label":      "issue.labels[0].name",
+		},
+		TimestampPath: "issue.updated_at",
+		Headers:       map[string]string{"event": "X-GitHub-Event"},
+	})
+
+	// Given a GitHub issues webhook
+	payload := `{"action":"labeled","issue":{"number":7,"updated_at":"2024-09-01T12:00:00Z","labels":[{"name":"bug"}]},"repository":{"full_name":"confetti-cms/timeline"}}`
+	request := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(payload))
+	request.Header.Set("X-GitHub-Event", "issues")
+	response := httptest.NewRecorder()
+
+	// When the webhook is received
+	receiver.ServeHTTP(response, request)
+
+	// Then only the mapped columns are written at the time of the event
+	is.Equal(response.Code, http.StatusNoContent)
+	var action, repository, label, event string
+	var timestamp time.Time
+	is.NoErr(writer.DB.QueryRow(`SELECT action, repository, label, event, timestamp FROM github`).Scan(&action, &repository, &label, &event, &timestamp))
+	is.Equal(action, "labeled")
+	is.Equal(repository, "confetti-cms/timeline")
+	is.Equal(label, "bug")
+	is.Equal(event, "issues")
+	is.Equal(timestamp.UTC(), time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC))
+}
+
+func Test_webhook_receiver_writes_whole_payloads_of_arrays(t *testing.T) {
+	is, writer := setup(t)
+	receiver := NewWebhookReceiver(writer, WebhookReceiverConfig{TimestampPath: "created"})
+
+	payload := `[{"type":"charge.succeeded","created":1725192000,"data":{"amount":2000}},{"type":"charge.refunded","created":1725192060,"data":{"amount":500}}]`
+	response := httptest.NewRecorder()
+	receiver.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload)))
+
+	is.Equal(response.Code, http.StatusNoContent)
+	var count, amount int
+	var first time.Time
+	is.NoErr(writer.DB.QueryRow(`SELECT count(*), sum(data_amount), min(timestamp) FROM webhooks`).Scan(&count, &amount, &first))
+	is.Equal(count, 2)
+	is.Equal(amount, 2500)
+	is.Equal(first.UTC(), time.Unix(1725192000, 0).UTC())
+
+	response = httptest.NewRecorder()
+	receiver.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[1, 2]`)))
+	is.Equal(response.Code, http.StatusBadRequest)
+}